@@ -0,0 +1,135 @@
+package gocommonlog
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ErrAlertNotFound is returned when acknowledging an alert with no ack state, because it
+// was never sent with ack_enabled or its state has expired
+var ErrAlertNotFound = errors.New("gocommonlog: alert not found")
+
+// Ack states stored in the cache under ackKey
+const (
+	ackPending    = "pending"
+	ackedPrefix   = "acked:"
+	defaultAckTTL = 24 * time.Hour
+)
+
+// ackKey is the cache key holding an alert's ack state
+func ackKey(alertID string) string {
+	return "commonlog_ack:" + alertID
+}
+
+// ackEnabled reports whether ERROR alerts carry an Acknowledge button
+func (l *Logger) ackEnabled() bool {
+	enabled, _ := l.config.ProviderConfig["ack_enabled"].(bool)
+	return enabled
+}
+
+// ackStore returns the cache holding ack state. Set Config.Cache to a shared cache when
+// several instances send alerts and receive interaction callbacks.
+func (l *Logger) ackStore() cache.Cache {
+	if l.config.Cache != nil {
+		return l.config.Cache
+	}
+	return cache.GetGlobalCache()
+}
+
+// ackTTL is how long ack state is kept, from ack_ttl
+func (l *Logger) ackTTL() time.Duration {
+	if ttl, ok := l.config.ProviderConfig["ack_ttl"].(time.Duration); ok && ttl > 0 {
+		return ttl
+	}
+	return defaultAckTTL
+}
+
+// trackAck records a sent alert as pending acknowledgement and, when ack_remind_after is
+// set, schedules a single reminder to the same channel unless it is acknowledged first
+func (l *Logger) trackAck(alertID string, message string, opts types.SendOptions, channel string) {
+	l.ackStore().Set(ackKey(alertID), ackPending, l.ackTTL())
+	if remindAfter, _ := l.config.ProviderConfig["ack_remind_after"].(time.Duration); remindAfter > 0 {
+		opts.Channel = channel
+		l.followUp(alertID, remindAfter, types.Localize(l.config, types.TextNotAcknowledged)+": "+message, opts)
+	}
+}
+
+// followUp schedules an ERROR alert re-sending message about alertID after d, unless the
+// alert is acknowledged first. Its button acknowledges the original alert.
+func (l *Logger) followUp(alertID string, d time.Duration, message string, opts types.SendOptions) {
+	scheduled, err := l.schedule(d, nil, func(complete func(string, error)) {
+		if acked, _, _ := l.AckStatus(alertID); acked {
+			complete("", ErrScheduleCanceled)
+			return
+		}
+		types.DebugLog(l.config, "Alert %s not acknowledged after %s, sending follow-up to '%s'", alertID, d, opts.Channel)
+		delivery, err := l.send(types.ERROR, message, opts, alertID, nil, "")
+		complete(delivery.ID, err)
+	})
+	if err != nil {
+		return
+	}
+	l.emit(types.Event{Type: types.EventScheduled, AlertID: alertID, Level: types.ERROR, Channel: opts.Channel, Detail: scheduled.At.Format(time.RFC3339)})
+	l.ackMu.Lock()
+	if l.followUps == nil {
+		l.followUps = make(map[string][]*ScheduledAlert)
+	}
+	l.followUps[alertID] = append(l.followUps[alertID], scheduled)
+	l.ackMu.Unlock()
+	go func() {
+		// Forget the follow-up once it has run, so the map only holds pending ones
+		<-scheduled.Done()
+		l.ackMu.Lock()
+		defer l.ackMu.Unlock()
+		pending := l.followUps[alertID][:0]
+		for _, s := range l.followUps[alertID] {
+			if s != scheduled {
+				pending = append(pending, s)
+			}
+		}
+		if len(pending) == 0 {
+			delete(l.followUps, alertID)
+		} else {
+			l.followUps[alertID] = pending
+		}
+	}()
+}
+
+// Acknowledge marks an alert as acknowledged by user and cancels its pending reminder
+// and escalations. Acknowledging twice keeps the first user.
+func (l *Logger) Acknowledge(alertID, user string) error {
+	key := ackKey(alertID)
+	state, found := l.ackStore().Get(key)
+	if !found {
+		return ErrAlertNotFound
+	}
+	if state == ackPending {
+		l.ackStore().Set(key, ackedPrefix+user, l.ackTTL())
+		types.DebugLog(l.config, "Alert %s acknowledged by %s", alertID, user)
+	}
+
+	l.ackMu.Lock()
+	pending := l.followUps[alertID]
+	delete(l.followUps, alertID)
+	l.ackMu.Unlock()
+	for _, scheduled := range pending {
+		scheduled.Cancel()
+	}
+	return nil
+}
+
+// AckStatus reports whether an alert has been acknowledged and by whom
+func (l *Logger) AckStatus(alertID string) (bool, string, error) {
+	state, found := l.ackStore().Get(ackKey(alertID))
+	if !found {
+		return false, "", ErrAlertNotFound
+	}
+	if strings.HasPrefix(state, ackedPrefix) {
+		return true, strings.TrimPrefix(state, ackedPrefix), nil
+	}
+	return false, "", nil
+}
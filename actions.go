@@ -0,0 +1,34 @@
+package gocommonlog
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// withActions appends the configured action buttons to the links of ERROR alerts, after
+// the links given per send. Placeholders in action URLs are replaced by the alert's
+// query-escaped values, so a Silence button can point at the alert's own fingerprint.
+func (l *Logger) withActions(level int, links []types.Link, cfg types.Config) []types.Link {
+	if level != types.ERROR || len(l.config.Actions) == 0 {
+		return links
+	}
+	replacer := strings.NewReplacer(
+		"{alert_id}", url.QueryEscape(cfg.MessageID),
+		"{fingerprint}", url.QueryEscape(cfg.Fingerprint),
+		"{correlation_id}", url.QueryEscape(cfg.CorrelationID),
+		"{service}", url.QueryEscape(cfg.ServiceName),
+		"{environment}", url.QueryEscape(cfg.Environment),
+		"{channel}", url.QueryEscape(cfg.Channel),
+	)
+	merged := make([]types.Link, 0, len(links)+len(l.config.Actions))
+	merged = append(merged, links...)
+	for _, action := range l.config.Actions {
+		if action.Text == "" || action.URL == "" {
+			continue
+		}
+		merged = append(merged, types.Link{Text: action.Text, URL: replacer.Replace(action.URL)})
+	}
+	return merged
+}
@@ -0,0 +1,4 @@
+// Package alertpb contains the protobuf definitions of the gRPC AlertService.
+package alertpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative alert.proto
@@ -0,0 +1,571 @@
+package gocommonlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ErrQueueFull is returned by SendAsync when the queue is full and the async_overflow
+// policy cannot make room for the alert
+var ErrQueueFull = errors.New("gocommonlog: async queue is full")
+
+// Async queue defaults
+const (
+	defaultAsyncQueueSize    = 1000
+	defaultAsyncWorkers      = 1
+	defaultAsyncBlockTimeout = time.Second
+)
+
+// Overflow policies of the async queue, set with async_overflow
+const (
+	OverflowDropLowest = "drop_lowest" // Drop the oldest queued alert of a lower level, or reject the alert; the default
+	OverflowDropOldest = "drop_oldest" // Drop the oldest queued alert of any level
+	OverflowBlock      = "block"       // Wait up to async_block_timeout for room, then reject the alert
+	OverflowSpill      = "spill"       // Write the lowest level alert to async_spill_file, sent once the queue drains
+)
+
+// queuedAlert is an alert accepted by SendAsync, or scheduled by SendAt and SendAfter when
+// it is due or spilled on Close
+type queuedAlert struct {
+	Level   int                 `json:"level"`
+	Message string              `json:"message"`
+	Options types.SendOptions   `json:"options"`
+	Due     *time.Time          `json:"due,omitempty"`    // When a scheduled alert is due
+	Source  string              `json:"source,omitempty"` // Call site of SendAsync, SendAt or SendAfter
+	seq     uint64              // order of arrival, for drop_oldest
+	done    func(string, error) // completes the ScheduledAlert of a due SendAt or SendAfter alert
+}
+
+// complete reports the alert ID and send error to the alert's ScheduledAlert, if any
+func (a queuedAlert) complete(id string, err error) {
+	if a.done != nil {
+		a.done(id, err)
+	}
+}
+
+// alertQueue holds the alerts accepted by SendAsync by level. Workers take the oldest
+// alert of the highest level first, so a fresh ERROR is not delayed by a backlog of WARN
+// alerts. When the queue is full, the async_overflow policy makes room.
+type alertQueue struct {
+	mu           sync.Mutex
+	ready        *sync.Cond                     // signaled when an alert is queued
+	space        *sync.Cond                     // signaled when an alert is taken, for the block policy
+	levels       [types.ERROR + 1][]queuedAlert // FIFO per level, INFO to ERROR
+	size         int
+	seq          uint64
+	capacity     int
+	workers      int
+	start        sync.Once // workers start with the first SendAsync or a non-empty spill file
+	closed       bool
+	policy       string
+	blockTimeout time.Duration
+
+	spillMu    sync.Mutex // serializes access to spillFile; taken before mu
+	spillFile  string
+	spilled    int  // alerts in spillFile
+	unspilling bool // a worker is moving spilled alerts back to the queue
+
+	dropped, rejected, spills, blocked atomic.Int64 // counters reported by QueueStats
+}
+
+// newAlertQueue returns an error for an unknown async_overflow policy or a spill policy
+// without a spill file
+func newAlertQueue(cfg types.Config) (*alertQueue, error) {
+	capacity, _ := cfg.ProviderConfig["async_queue_size"].(int)
+	if capacity <= 0 {
+		capacity = defaultAsyncQueueSize
+	}
+	workers, _ := cfg.ProviderConfig["async_workers"].(int)
+	if workers <= 0 {
+		workers = defaultAsyncWorkers
+	}
+	blockTimeout, _ := cfg.ProviderConfig["async_block_timeout"].(time.Duration)
+	if blockTimeout <= 0 {
+		blockTimeout = defaultAsyncBlockTimeout
+	}
+	q := &alertQueue{capacity: capacity, workers: workers, policy: OverflowDropLowest, blockTimeout: blockTimeout}
+	q.ready = sync.NewCond(&q.mu)
+	q.space = sync.NewCond(&q.mu)
+	if policy, _ := cfg.ProviderConfig["async_overflow"].(string); policy != "" {
+		q.policy = policy
+	}
+	q.spillFile, _ = cfg.ProviderConfig["async_spill_file"].(string)
+	switch q.policy {
+	case OverflowDropLowest, OverflowDropOldest, OverflowBlock:
+	case OverflowSpill:
+		if q.spillFile == "" {
+			return q, fmt.Errorf("async_overflow %q requires async_spill_file", OverflowSpill)
+		}
+	default:
+		err := fmt.Errorf("unknown async_overflow policy %q (expected %s, %s, %s or %s)",
+			q.policy, OverflowDropLowest, OverflowDropOldest, OverflowBlock, OverflowSpill)
+		q.policy = OverflowDropLowest
+		return q, err
+	}
+	if q.spillFile != "" {
+		// Alerts spilled by a previous logger are sent by this one
+		spilled, err := readSpill(q.spillFile)
+		if err != nil {
+			return q, err
+		}
+		q.spilled = len(spilled)
+	}
+	return q, nil
+}
+
+// queueLevel returns the queue index of level, treating unknown levels as the nearest
+// known one
+func queueLevel(level int) int {
+	if level < types.INFO {
+		return types.INFO
+	}
+	if level > types.ERROR {
+		return types.ERROR
+	}
+	return level
+}
+
+// overflow is what push did with a full queue
+type overflow struct {
+	dropped *queuedAlert // queued alert dropped to make room
+	spill   *queuedAlert // alert to write to the spill file instead of the queue
+}
+
+// push adds an alert, applying the overflow policy when the queue is full
+func (q *alertQueue) push(alert queuedAlert) (overflow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var result overflow
+	if q.closed {
+		return result, ErrLoggerClosed
+	}
+	if q.size >= q.capacity {
+		switch q.policy {
+		case OverflowBlock:
+			q.blocked.Add(1)
+			timedOut := false
+			timer := time.AfterFunc(q.blockTimeout, func() {
+				q.mu.Lock()
+				timedOut = true
+				q.space.Broadcast()
+				q.mu.Unlock()
+			})
+			for q.size >= q.capacity && !q.closed && !timedOut {
+				q.space.Wait()
+			}
+			timer.Stop()
+			if q.closed {
+				return result, ErrLoggerClosed
+			}
+			if q.size >= q.capacity {
+				q.rejected.Add(1)
+				return result, ErrQueueFull
+			}
+		case OverflowDropOldest:
+			result.dropped = q.takeOldest()
+		default:
+			result.dropped = q.takeLower(queueLevel(alert.Level))
+			if result.dropped == nil && q.policy == OverflowSpill {
+				result.spill = &alert
+				return result, nil
+			}
+			if result.dropped == nil {
+				q.rejected.Add(1)
+				return result, ErrQueueFull
+			}
+			if q.policy == OverflowSpill {
+				result.spill, result.dropped = result.dropped, nil
+			}
+		}
+		if result.dropped != nil {
+			q.dropped.Add(1)
+		}
+	}
+	q.seq++
+	alert.seq = q.seq
+	q.enqueue(alert)
+	return result, nil
+}
+
+// enqueue adds an alert without checking the capacity
+func (q *alertQueue) enqueue(alert queuedAlert) {
+	level := queueLevel(alert.Level)
+	q.levels[level] = append(q.levels[level], alert)
+	q.size++
+	q.ready.Signal()
+}
+
+// take removes the oldest alert of level
+func (q *alertQueue) take(level int) *queuedAlert {
+	alert := q.levels[level][0]
+	q.levels[level][0] = queuedAlert{}
+	q.levels[level] = q.levels[level][1:]
+	q.size--
+	q.space.Signal()
+	return &alert
+}
+
+// takeLower removes the oldest alert of the lowest level below level, if any
+func (q *alertQueue) takeLower(level int) *queuedAlert {
+	for lower := types.INFO; lower < level; lower++ {
+		if len(q.levels[lower]) > 0 {
+			return q.take(lower)
+		}
+	}
+	return nil
+}
+
+// takeOldest removes the alert queued first, whatever its level
+func (q *alertQueue) takeOldest() *queuedAlert {
+	oldest := -1
+	for level := range q.levels {
+		if len(q.levels[level]) > 0 && (oldest < 0 || q.levels[level][0].seq < q.levels[oldest][0].seq) {
+			oldest = level
+		}
+	}
+	if oldest < 0 {
+		return nil
+	}
+	return q.take(oldest)
+}
+
+// popResult tells a worker what to do next
+type popResult int
+
+const (
+	popSend    popResult = iota // send the alert
+	popUnspill                  // the queue is empty, move spilled alerts back to it
+	popClosed                   // the queue is closed, stop
+)
+
+// pop waits for the next alert
+func (q *alertQueue) pop() (queuedAlert, popResult) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.size == 0 && !q.closed {
+		if q.spilled > 0 && !q.unspilling {
+			q.unspilling = true
+			return queuedAlert{}, popUnspill
+		}
+		q.ready.Wait()
+	}
+	for level := types.ERROR; level >= types.INFO; level-- {
+		if len(q.levels[level]) > 0 {
+			return *q.take(level), popSend
+		}
+	}
+	return queuedAlert{}, popClosed
+}
+
+// close stops the workers and returns the alerts left in the queue, highest level first.
+// Spilled alerts stay in the spill file for the next logger.
+func (q *alertQueue) close() []queuedAlert {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	var left []queuedAlert
+	for level := types.ERROR; level >= types.INFO; level-- {
+		left = append(left, q.levels[level]...)
+		q.levels[level] = nil
+	}
+	q.size = 0
+	q.ready.Broadcast()
+	q.space.Broadcast()
+	return left
+}
+
+// spillOnClose writes alerts Close could not send to the spill file, for the next logger
+// to send, and returns how many were dropped instead: every alert when async_spill_file
+// is not set, otherwise those that could not be written
+func (l *Logger) spillOnClose(kind string, alerts []queuedAlert) int {
+	if l.queue.spillFile == "" {
+		return len(alerts)
+	}
+	dropped := 0
+	for _, alert := range alerts {
+		if err := l.queue.spill(alert); err != nil {
+			log.Printf("[ERROR] Dropped %s %s alert on close: %v", kind, types.LevelName(alert.Level), err)
+			dropped++
+		}
+	}
+	if spilled := len(alerts) - dropped; spilled > 0 {
+		log.Printf("[WARN] Spilled %d %s alerts to %s on close", spilled, kind, l.queue.spillFile)
+	}
+	return dropped
+}
+
+// spill appends an alert to the spill file
+func (q *alertQueue) spill(alert queuedAlert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode spilled alert: %w", err)
+	}
+	q.spillMu.Lock()
+	defer q.spillMu.Unlock()
+	file, err := os.OpenFile(q.spillFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	q.mu.Lock()
+	q.spilled++
+	q.mu.Unlock()
+	q.spills.Add(1)
+	return nil
+}
+
+// readSpill returns the alerts in a spill file, none when it does not exist
+func readSpill(path string) ([]queuedAlert, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	var alerts []queuedAlert
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		var alert queuedAlert
+		if err := json.Unmarshal(scanner.Bytes(), &alert); err != nil {
+			log.Printf("[WARN] Skipping unreadable alert in spill file %s: %v", path, err)
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, scanner.Err()
+}
+
+// writeSpill replaces the spill file with alerts, removing it when there are none
+func writeSpill(path string, alerts []queuedAlert) error {
+	if len(alerts) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, alert := range alerts {
+		if err := encoder.Encode(alert); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// unspill moves spilled alerts back to the queue while it has room
+func (l *Logger) unspill() {
+	q := l.queue
+	q.spillMu.Lock()
+	defer q.spillMu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		q.unspilling = false
+		q.mu.Unlock()
+	}()
+
+	alerts, err := readSpill(q.spillFile)
+	moved, closing := 0, false
+	for err == nil && moved < len(alerts) {
+		if due := alerts[moved].Due; due != nil && due.After(l.now()) {
+			// Scheduled alerts spilled on close are scheduled again
+			alert := alerts[moved]
+			if _, err := l.sendAfter(due.Sub(l.now()), &alert); err != nil {
+				closing = true
+				break
+			}
+			moved++
+			continue
+		}
+		if l.beginSend() != nil {
+			closing = true
+			break
+		}
+		q.mu.Lock()
+		closing = q.closed
+		room := !closing && q.size < q.capacity
+		if room {
+			q.seq++
+			alerts[moved].seq = q.seq
+			q.enqueue(alerts[moved])
+		}
+		q.mu.Unlock()
+		if !room {
+			l.inflight.Done()
+			break
+		}
+		moved++
+	}
+	if err == nil {
+		err = writeSpill(q.spillFile, alerts[moved:])
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err != nil {
+		// Leave the file for the next logger rather than retrying in a loop
+		log.Printf("[ERROR] Failed to requeue spilled alerts from %s: %v", q.spillFile, err)
+		q.spilled = 0
+		return
+	}
+	q.spilled = len(alerts) - moved
+	if closing {
+		// The remaining alerts are sent by the next logger
+		q.spilled = 0
+	}
+	types.DebugLog(l.config, "Requeued %d spilled alerts, %d left in %s", moved, q.spilled, q.spillFile)
+}
+
+// startQueue starts the workers sending queued alerts, once
+func (l *Logger) startQueue() {
+	l.queue.start.Do(func() {
+		for i := 0; i < l.queue.workers; i++ {
+			go l.sendQueued()
+		}
+	})
+}
+
+// SendAsync queues an alert and returns without waiting for its delivery, which is made
+// by async_workers goroutines. ERROR alerts are sent before queued WARN and INFO alerts.
+// When async_queue_size alerts are queued, the async_overflow policy makes room or the
+// alert fails with ErrQueueFull. Delivery failures are logged; Close waits for the queued
+// alerts to be sent. Personal data is scrubbed before the alert is queued, so it does not
+// reach the spill file or Config.OnOverflow either.
+func (l *Logger) SendAsync(level int, message string, opts types.SendOptions) error {
+	if err := l.beginSend(); err != nil {
+		return err
+	}
+	l.startQueue()
+	message, opts = l.scrubAlert(message, opts)
+	opts.Fields = l.scrubFields(opts.Fields)
+	if opts.Time.IsZero() {
+		opts.Time = l.now()
+	}
+	return l.enqueue(queuedAlert{Level: level, Message: message, Options: opts, Source: l.callSite()})
+}
+
+// enqueue queues an alert registered as in flight, applying the async_overflow policy.
+// Alerts that leave the queue without being sent are completed with ErrQueueFull.
+func (l *Logger) enqueue(alert queuedAlert) error {
+	l.startQueue()
+	level := alert.Level
+	result, err := l.queue.push(alert)
+	if err != nil {
+		l.inflight.Done()
+		log.Printf("[WARN] Dropped %s alert: %v", types.LevelName(level), err)
+		if err == ErrQueueFull {
+			l.overflowed(types.OverflowRejected, alert)
+		}
+		alert.complete("", err)
+		return err
+	}
+	if result.dropped != nil {
+		l.inflight.Done()
+		log.Printf("[WARN] Async queue is full, dropped a queued %s alert", types.LevelName(result.dropped.Level))
+		l.overflowed(types.OverflowDropped, *result.dropped)
+		result.dropped.complete("", ErrQueueFull)
+	}
+	if spilled := result.spill; spilled != nil {
+		l.inflight.Done()
+		spilled.complete("", ErrQueueFull)
+		if err := l.queue.spill(*spilled); err != nil {
+			log.Printf("[ERROR] Dropped %s alert: %v", types.LevelName(spilled.Level), err)
+			if spilled.seq == 0 {
+				// The new alert itself could not be spilled
+				l.queue.rejected.Add(1)
+				l.overflowed(types.OverflowRejected, *spilled)
+				return err
+			}
+			l.queue.dropped.Add(1)
+			l.overflowed(types.OverflowDropped, *spilled)
+		} else {
+			l.overflowed(types.OverflowSpilled, *spilled)
+		}
+	}
+	types.DebugLog(l.config, "Queued %s alert, message length: %d", types.LevelName(level), len(alert.Message))
+	if result.spill == nil || result.spill.seq != 0 {
+		l.emit(types.Event{Type: types.EventEnqueued, Level: level})
+	}
+	return nil
+}
+
+// overflowed reports an alert the queue had no room for to Config.OnOverflow, and as an
+// event whose type is the overflow action
+func (l *Logger) overflowed(action string, alert queuedAlert) {
+	event := types.Event{Type: action, Level: alert.Level}
+	if action == types.OverflowSpilled {
+		event.Detail = l.queue.spillFile
+	}
+	l.emit(event)
+	if l.config.OnOverflow == nil {
+		return
+	}
+	l.queue.mu.Lock()
+	queued := l.queue.size
+	l.queue.mu.Unlock()
+	l.config.OnOverflow(types.OverflowEvent{Action: action, Level: alert.Level, Message: alert.Message, Queued: queued})
+}
+
+// sendQueued delivers queued alerts until the queue is closed
+func (l *Logger) sendQueued() {
+	for {
+		alert, result := l.queue.pop()
+		switch result {
+		case popClosed:
+			return
+		case popUnspill:
+			l.unspill()
+			continue
+		}
+		delivery, err := l.dispatch(alert.Level, alert.Message, alert.Options, "", nil, alert.Source)
+		if err != nil {
+			log.Printf("[ERROR] Failed to send queued %s alert %s: %v", types.LevelName(alert.Level), delivery.ID, err)
+		}
+		alert.complete(delivery.ID, err)
+		l.inflight.Done()
+	}
+}
+
+// QueueStats reports the SendAsync queue
+type QueueStats struct {
+	Queued   int   `json:"queued"`   // Alerts waiting to be sent
+	Capacity int   `json:"capacity"` // async_queue_size
+	Spilled  int   `json:"spilled"`  // Alerts waiting in async_spill_file
+	Dropped  int64 `json:"dropped"`  // Queued alerts dropped to make room, since the logger was created
+	Rejected int64 `json:"rejected"` // Alerts SendAsync failed with ErrQueueFull
+	Spills   int64 `json:"spills"`   // Alerts written to async_spill_file
+	Blocked  int64 `json:"blocked"`  // SendAsync calls that waited for room with the block policy
+}
+
+// QueueStats returns the length and overflow counters of the SendAsync queue, for
+// example to export as metrics
+func (l *Logger) QueueStats() QueueStats {
+	q := l.queue
+	q.mu.Lock()
+	stats := QueueStats{Queued: q.size, Capacity: q.capacity, Spilled: q.spilled}
+	q.mu.Unlock()
+	stats.Dropped, stats.Rejected = q.dropped.Load(), q.rejected.Load()
+	stats.Spills, stats.Blocked = q.spills.Load(), q.blocked.Load()
+	return stats
+}
@@ -0,0 +1,210 @@
+package gocommonlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// attachmentLimits is the most inline attachment content, in bytes, each provider's
+// messages can carry alongside the alert text. Providers not listed have no limit.
+var attachmentLimits = map[string]int{
+	"slack":  35000, // 40,000 character message text
+	"lark":   25000, // 30 KB request body
+	"webex":  6000,  // 7,439 byte message
+	"zulip":  9000,  // 10,000 character message
+	"ntfy":   3500,  // 4,096 byte message
+	"matrix": 60000, // 65,536 byte event
+	"github": 60000, // 65,536 character issue body
+}
+
+// offloadTimeout bounds an attachment upload to the object store or paste sink
+const offloadTimeout = 30 * time.Second
+
+// defaultPastePreviewLines is how many lines of a pasted attachment the alert shows
+const defaultPastePreviewLines = 20
+
+// attachmentLimit returns the inline attachment limit for the provider of cfg, overridden
+// by the attachment_limit setting or the provider's entry in attachment_limits; 0 means
+// no limit
+func attachmentLimit(cfg types.Config) int {
+	if limit, ok := cfg.ProviderConfig["attachment_limit"].(int); ok {
+		return limit
+	}
+	if limits, ok := cfg.ProviderConfig["attachment_limits"].(map[string]int); ok {
+		if limit, ok := limits[cfg.Provider]; ok {
+			return limit
+		}
+	}
+	return attachmentLimits[cfg.Provider]
+}
+
+// fitAttachment keeps attachment content within the provider's inline limit. Content over
+// the limit is pasted to the paste sink and shown as its first lines and a link, or
+// uploaded to the object store and replaced by a signed URL; without either, or when both
+// fail, it is truncated with a note of how much was cut.
+func fitAttachment(attachment *types.Attachment, cfg types.Config) *types.Attachment {
+	limit := attachmentLimit(cfg)
+	if attachment == nil || limit <= 0 || len(attachment.Content) <= limit {
+		return attachment
+	}
+	name := attachment.FileName
+	if name == "" {
+		name = "trace.log"
+	}
+
+	if cfg.PasteSink != nil {
+		pasted, err := pasteAttachment(cfg, name, attachment, limit)
+		if err == nil {
+			return pasted
+		}
+		log.Printf("[WARN] Failed to paste attachment %s of %d bytes: %v", name, len(attachment.Content), err)
+	}
+	if cfg.ObjectStore != nil {
+		fileName, url, err := offloadAttachment(cfg, name, attachment.Content)
+		if err == nil {
+			offloaded := &types.Attachment{FileName: attachment.FileName, URL: url}
+			if fileName != path.Base(name) {
+				offloaded.FileName = fileName
+			}
+			if attachment.URL != "" {
+				// Keep the caller's link and show the uploaded content's link in its place
+				offloaded.URL = attachment.URL
+				offloaded.Content = url
+			}
+			return offloaded
+		}
+		log.Printf("[WARN] Failed to upload attachment %s of %d bytes, truncating it: %v", name, len(attachment.Content), err)
+	} else {
+		log.Printf("[WARN] Attachment %s of %d bytes exceeds the %s limit of %d bytes, truncating it", name, len(attachment.Content), cfg.Provider, limit)
+	}
+
+	truncated := *attachment
+	note := fmt.Sprintf("\n... (%d bytes truncated)", len(attachment.Content)-limit)
+	truncated.Content = strings.ToValidUTF8(attachment.Content[:limit], "") + note
+	return &truncated
+}
+
+// pasteAttachment pastes content to the paste sink under the alert's ID and returns the
+// attachment showing its first paste_preview_lines lines, within limit, and linking the
+// paste. A caller's link is kept and the paste is linked in the note of lines left out.
+func pasteAttachment(cfg types.Config, name string, attachment *types.Attachment, limit int) (*types.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), offloadTimeout)
+	defer cancel()
+	key := path.Join("commonlog", cfg.MessageID, path.Base(name))
+	url, err := cfg.PasteSink.Paste(ctx, key, attachment.Content)
+	if err != nil {
+		return nil, err
+	}
+	types.DebugLog(cfg, "Attachment %s of %d bytes pasted to %s", name, len(attachment.Content), url)
+
+	lines, ok := cfg.ProviderConfig["paste_preview_lines"].(int)
+	if !ok || lines <= 0 {
+		lines = defaultPastePreviewLines
+	}
+	pasted := &types.Attachment{FileName: attachment.FileName, URL: url}
+	note := func(more int) string { return fmt.Sprintf("\n... (%d more lines)", more) }
+	if attachment.URL != "" {
+		pasted.URL = attachment.URL
+		note = func(more int) string { return fmt.Sprintf("\n... (%d more lines at %s)", more, url) }
+	}
+	total := strings.Count(strings.TrimSuffix(attachment.Content, "\n"), "\n") + 1
+	preview, shown := previewLines(attachment.Content, lines, limit-len(note(total)))
+	pasted.Content = preview + note(total-shown)
+	return pasted, nil
+}
+
+// previewLines returns up to lines whole lines from the start of content within limit
+// bytes, and how many lines it returned. A first line over the limit is cut to it.
+func previewLines(content string, lines, limit int) (string, int) {
+	if limit <= 0 {
+		return "", 0
+	}
+	end, shown := 0, 0
+	for shown < lines && end < len(content) {
+		next := strings.IndexByte(content[end:], '\n') + 1
+		if next == 0 {
+			next = len(content) - end
+		}
+		if end+next > limit {
+			break
+		}
+		end += next
+		shown++
+	}
+	if shown == 0 {
+		return strings.ToValidUTF8(content[:limit], ""), 1
+	}
+	return strings.TrimSuffix(content[:end], "\n"), shown
+}
+
+// hideTrace applies a layout without the trace section to the attachment of an alert
+// sent to Slack or Lark, the providers rendering Config.Sections. Its inline content is
+// uploaded to the object store and linked among the alert's links instead; without a
+// store, or when the upload fails, it is left out.
+func hideTrace(provider types.Provider, attachment *types.Attachment, cfg types.Config) (*types.Attachment, types.Config) {
+	if attachment == nil || attachment.Content == "" || types.ShowsSection(cfg, types.SectionTrace) {
+		return attachment, cfg
+	}
+	switch provider.(type) {
+	case *providers.SlackProvider, *providers.LarkProvider:
+	default:
+		return attachment, cfg
+	}
+	hidden := *attachment
+	hidden.Content = ""
+	if cfg.ObjectStore == nil {
+		types.DebugLog(cfg, "Trace section is hidden, leaving out %d bytes of attachment content", len(attachment.Content))
+		return &hidden, cfg
+	}
+	name := attachment.FileName
+	if name == "" {
+		name = types.TraceFileName
+	}
+	fileName, url, err := offloadAttachment(cfg, name, attachment.Content)
+	if err != nil {
+		log.Printf("[WARN] Failed to upload hidden attachment %s of %d bytes, leaving it out: %v", name, len(attachment.Content), err)
+		return &hidden, cfg
+	}
+	// Copy the links, which may be the caller's SendOptions.Links
+	cfg.Links = append(append(make([]types.Link, 0, len(cfg.Links)+1), cfg.Links...), types.Link{Text: fileName, URL: url})
+	return &hidden, cfg
+}
+
+// offloadAttachment uploads content to the object store under the alert's ID, gzipped
+// when attachment_gzip is set, and returns the uploaded file name and its URL
+func offloadAttachment(cfg types.Config, name, content string) (string, string, error) {
+	fileName := path.Base(name)
+	data := []byte(content)
+	contentType := "text/plain; charset=utf-8"
+	if compress, _ := cfg.ProviderConfig["attachment_gzip"].(bool); compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Name = fileName
+		zw.Write(data)
+		if err := zw.Close(); err != nil {
+			return "", "", err
+		}
+		fileName += ".gz"
+		data = buf.Bytes()
+		contentType = "application/gzip"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), offloadTimeout)
+	defer cancel()
+	key := path.Join("commonlog", cfg.MessageID, fileName)
+	url, err := cfg.ObjectStore.Upload(ctx, key, data, contentType)
+	if err != nil {
+		return "", "", err
+	}
+	types.DebugLog(cfg, "Attachment %s of %d bytes uploaded to %s as %d bytes", name, len(content), key, len(data))
+	return fileName, url, nil
+}
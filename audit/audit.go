@@ -0,0 +1,105 @@
+// Package audit provides sinks that record the metadata of every alert sent by a
+// Logger, for compliance review. Set one as Config.AuditSink, or pass a callback
+// wrapped in types.AuditFunc.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// FileSink appends audit records to a file as JSON lines
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating it with mode 0600 if needed
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// RecordAlert writes record as a single JSON line
+func (s *FileSink) RecordAlert(record types.AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(line)
+	return err
+}
+
+// Close closes the underlying file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// RedisStreamSink adds audit records to a Redis stream with XADD
+type RedisStreamSink struct {
+	client  *redis.Client
+	stream  string
+	maxLen  int64
+	timeout time.Duration
+}
+
+// NewRedisStreamSink records to stream, trimming it to approximately maxLen entries
+// when maxLen is positive
+func NewRedisStreamSink(client *redis.Client, stream string, maxLen int64) *RedisStreamSink {
+	return &RedisStreamSink{client: client, stream: stream, maxLen: maxLen, timeout: 5 * time.Second}
+}
+
+// RecordAlert adds record to the stream, one field per record attribute
+func (s *RedisStreamSink) RecordAlert(record types.AuditRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: s.maxLen > 0,
+		Values: streamValues(record),
+	}).Err()
+}
+
+// streamValues flattens a record into stream entry fields, omitting empty optional ones
+func streamValues(record types.AuditRecord) map[string]interface{} {
+	values := map[string]interface{}{
+		"id":         record.ID,
+		"time":       record.Time.UTC().Format(time.RFC3339Nano),
+		"level":      record.Level,
+		"provider":   record.Provider,
+		"outcome":    record.Outcome,
+		"latency_ms": strconv.FormatInt(record.LatencyMs, 10),
+	}
+	optional := map[string]string{
+		"correlation_id": record.CorrelationID,
+		"service":        record.Service,
+		"environment":    record.Environment,
+		"channel":        record.Channel,
+		"error":          record.Error,
+	}
+	for key, value := range optional {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sink.RecordAlert(types.AuditRecord{ID: "01A", Time: at, Level: "ERROR", Provider: "slack", Outcome: types.AuditSent, LatencyMs: 12})
+	sink.RecordAlert(types.AuditRecord{ID: "01B", Time: at, Level: "WARN", Provider: "slack", Outcome: types.AuditFailed, Error: "timeout"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []types.AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record types.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 || records[0].ID != "01A" || records[1].Error != "timeout" || !records[0].Time.Equal(at) {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestStreamValuesOmitsEmptyFields(t *testing.T) {
+	values := streamValues(types.AuditRecord{ID: "01A", Level: "ERROR", Provider: "lark", Outcome: types.AuditSent, LatencyMs: 7, Channel: "#ops"})
+	if values["latency_ms"] != "7" || values["channel"] != "#ops" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+	if _, ok := values["error"]; ok {
+		t.Errorf("Expected empty error to be omitted, got %v", values)
+	}
+}
@@ -0,0 +1,204 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Alert budget defaults
+const (
+	defaultBudgetWindow = 24 * time.Hour
+	budgetBuckets       = 24   // alert_budget_window is counted in this many buckets
+	maxBudgetServices   = 1000 // services tracked, so per-send service names cannot grow it without bound
+)
+
+// alertBudget tracks the alerts of each service against its alert budget, set with
+// alert_budget for every service or alert_budgets by service name. A service sending more
+// alerts than its budget within alert_budget_window is warned about once, with a note on
+// the alert that went over, until its rate falls back within the budget.
+type alertBudget struct {
+	mu       sync.Mutex
+	budget   int            // budget of services not in budgets, 0 for none
+	budgets  map[string]int // budgets by service name
+	window   time.Duration
+	bucket   time.Duration
+	services map[string]*serviceBudget
+}
+
+// serviceBudget counts the alerts of one service in buckets covering the window
+type serviceBudget struct {
+	counts   [budgetBuckets]int
+	index    int
+	current  time.Time // start of the current bucket
+	exceeded bool      // the service went over its budget and has not come back within it
+}
+
+// BudgetUsage is a service's alerts within alert_budget_window against its budget
+type BudgetUsage struct {
+	Service  string `json:"service"`
+	Alerts   int    `json:"alerts"`
+	Budget   int    `json:"budget"`
+	Exceeded bool   `json:"exceeded"` // The service is over its budget
+}
+
+// newAlertBudget returns nil when neither alert_budget nor alert_budgets is set
+func newAlertBudget(cfg types.Config) *alertBudget {
+	budget, _ := cfg.ProviderConfig["alert_budget"].(int)
+	budgets, _ := cfg.ProviderConfig["alert_budgets"].(map[string]int)
+	if budget <= 0 && len(budgets) == 0 {
+		return nil
+	}
+	window, _ := cfg.ProviderConfig["alert_budget_window"].(time.Duration)
+	if window <= 0 {
+		window = defaultBudgetWindow
+	}
+	bucket := window / budgetBuckets
+	if bucket <= 0 {
+		bucket = 1
+	}
+	return &alertBudget{budget: budget, budgets: budgets, window: window, bucket: bucket, services: make(map[string]*serviceBudget)}
+}
+
+// limit returns the budget of service, 0 when it has none
+func (b *alertBudget) limit(service string) int {
+	if budget, ok := b.budgets[service]; ok {
+		return budget
+	}
+	return b.budget
+}
+
+// advance moves the current bucket to now, clearing the buckets that left the window
+func (b *alertBudget) advance(state *serviceBudget, now time.Time) {
+	if state.current.IsZero() {
+		state.current = now
+		return
+	}
+	passed := int64(now.Sub(state.current) / b.bucket)
+	if passed <= 0 {
+		return
+	}
+	for i := int64(0); i < passed && i < budgetBuckets; i++ {
+		state.index = (state.index + 1) % budgetBuckets
+		state.counts[state.index] = 0
+	}
+	state.current = state.current.Add(time.Duration(passed) * b.bucket)
+}
+
+// count returns the alerts within the window
+func (s *serviceBudget) count() int {
+	total := 0
+	for _, count := range s.counts {
+		total += count
+	}
+	return total
+}
+
+// spend counts an alert of service and reports whether it took the service over its
+// budget, with the alerts in the window and the budget
+func (b *alertBudget) spend(service string, now time.Time) (bool, int, int) {
+	limit := b.limit(service)
+	if limit <= 0 {
+		return false, 0, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.services[service]
+	if state == nil {
+		if len(b.services) >= maxBudgetServices {
+			return false, 0, limit
+		}
+		state = &serviceBudget{}
+		b.services[service] = state
+	}
+	b.advance(state, now)
+	state.counts[state.index]++
+	count := state.count()
+	if count <= limit {
+		state.exceeded = false
+		return false, count, limit
+	}
+	if state.exceeded {
+		return false, count, limit
+	}
+	state.exceeded = true
+	return true, count, limit
+}
+
+// exceededMessage appends the budget warning to the alert that went over it
+func (b *alertBudget) exceededMessage(message, service string, count, limit int) string {
+	return fmt.Sprintf("%s\n\n(Alert budget exceeded: %s sent %d alerts in %s, over its budget of %d. Consider fixing or tuning its noisiest alerts.)",
+		message, budgetServiceName(service), count, b.window, limit)
+}
+
+// budgetServiceName names alerts without a service in budget messages and reports
+func budgetServiceName(service string) string {
+	if service == "" {
+		return "(none)"
+	}
+	return service
+}
+
+// budgetServiceKey is the service named by budgetServiceName
+func budgetServiceKey(name string) string {
+	if name == "(none)" {
+		return ""
+	}
+	return name
+}
+
+// AlertBudgets returns the alerts each service sent within alert_budget_window against its
+// budget, sorted by service name, for the services that sent alerts with a budget. It is
+// empty when no budget is set.
+func (l *Logger) AlertBudgets() []BudgetUsage {
+	b := l.budget
+	if b == nil {
+		return nil
+	}
+	now := l.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	usage := make([]BudgetUsage, 0, len(b.services))
+	for service, state := range b.services {
+		b.advance(state, now)
+		limit := b.limit(service)
+		count := state.count()
+		usage = append(usage, BudgetUsage{Service: service, Alerts: count, Budget: limit, Exceeded: count > limit})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Service < usage[j].Service })
+	return usage
+}
+
+// consumption reports the budget consumption of the services in a digest report: their
+// alerts across every channel against their budget scaled to the report's period, such as
+// "payments 120 of 350 (34%)". It is empty when no service has a budget; b may be nil.
+func (b *alertBudget) consumption(counts *digestCounts, services map[string]int, period time.Duration) string {
+	if b == nil {
+		return ""
+	}
+	names := make([]string, 0, len(counts.services))
+	for service := range counts.services {
+		if b.limit(budgetServiceKey(service)) > 0 {
+			names = append(names, service)
+		}
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, service := range names {
+		allowed := int(float64(b.limit(budgetServiceKey(service)))*float64(period)/float64(b.window) + 0.5)
+		if allowed < 1 {
+			allowed = 1
+		}
+		count := services[service]
+		line := fmt.Sprintf("%s %d of %d (%d%%", budgetServiceName(service), count, allowed, count*100/allowed)
+		if count > allowed {
+			line += ", over budget"
+		}
+		lines = append(lines, line+")")
+	}
+	return strings.Join(lines, ", ")
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,9 +14,17 @@ type Cache interface {
 	Delete(key string)
 }
 
-// InMemoryCache provides thread-safe in-memory caching with automatic cleanup
+// InMemoryCache provides thread-safe in-memory caching with automatic cleanup. The
+// cleanup goroutine starts with the first Set and runs until Close.
 type InMemoryCache struct {
 	data sync.Map // key -> cacheItem
+	now  func() time.Time
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	stop      chan struct{} // closed by Close to end the cleanup goroutine
+
+	hits, misses, expired, sets, deletes atomic.Int64 // counters reported by Stats
 }
 
 type cacheItem struct {
@@ -25,24 +34,31 @@ type cacheItem struct {
 
 // NewInMemoryCache creates a new in-memory cache instance
 func NewInMemoryCache() *InMemoryCache {
-	cache := &InMemoryCache{}
-	// Start cleanup goroutine
-	go cache.cleanupWorker()
-	return cache
+	return NewInMemoryCacheWithClock(time.Now)
+}
+
+// NewInMemoryCacheWithClock creates an in-memory cache that evaluates expiry with now,
+// so tests can control TTLs without sleeping
+func NewInMemoryCacheWithClock(now func() time.Time) *InMemoryCache {
+	return &InMemoryCache{now: now, stop: make(chan struct{})}
 }
 
 // Get retrieves a value from the cache
 func (c *InMemoryCache) Get(key string) (string, bool) {
 	value, ok := c.data.Load(key)
 	if !ok {
+		c.misses.Add(1)
 		return "", false
 	}
 	item := value.(cacheItem)
-	if time.Now().After(item.expiry) {
+	if c.now().After(item.expiry) {
 		// Expired, remove it
 		c.data.Delete(key)
+		c.expired.Add(1)
+		c.misses.Add(1)
 		return "", false
 	}
+	c.hits.Add(1)
 	return item.value, true
 }
 
@@ -50,26 +66,58 @@ func (c *InMemoryCache) Get(key string) (string, bool) {
 func (c *InMemoryCache) Set(key, value string, duration time.Duration) {
 	item := cacheItem{
 		value:  value,
-		expiry: time.Now().Add(duration),
+		expiry: c.now().Add(duration),
 	}
 	c.data.Store(key, item)
+	c.sets.Add(1)
+	c.startOnce.Do(func() { go c.cleanupWorker() })
+}
+
+// TTL returns how long a value has left before it expires
+func (c *InMemoryCache) TTL(key string) (time.Duration, bool) {
+	value, ok := c.data.Load(key)
+	if !ok {
+		return 0, false
+	}
+	remaining := value.(cacheItem).expiry.Sub(c.now())
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
 }
 
 // Delete removes a value from the cache
 func (c *InMemoryCache) Delete(key string) {
 	c.data.Delete(key)
+	c.deletes.Add(1)
+}
+
+// Close stops the cleanup goroutine. The cache remains usable, with expired entries
+// removed when they are read. Closing twice is a no-op.
+func (c *InMemoryCache) Close() error {
+	c.closeOnce.Do(func() {
+		// Keep a later Set from starting the goroutine
+		c.startOnce.Do(func() {})
+		close(c.stop)
+	})
+	return nil
 }
 
 func (c *InMemoryCache) cleanupWorker() {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
-	for range ticker.C {
-		c.cleanupExpired()
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanupExpired()
+		case <-c.stop:
+			return
+		}
 	}
 }
 
 func (c *InMemoryCache) cleanupExpired() {
-	now := time.Now()
+	now := c.now()
 	expiredKeys := make([]string, 0)
 
 	c.data.Range(func(key, value interface{}) bool {
@@ -83,21 +131,29 @@ func (c *InMemoryCache) cleanupExpired() {
 	for _, key := range expiredKeys {
 		c.data.Delete(key)
 	}
+	c.expired.Add(int64(len(expiredKeys)))
 
 	if len(expiredKeys) > 0 {
 		fmt.Printf("[Cache] Cleaned up %d expired entries from memory cache\n", len(expiredKeys))
 	}
 }
 
-// Global cache instance
-var globalCache Cache = NewInMemoryCache()
+// Global cache instance, guarded so it can be swapped while sends are in flight
+var (
+	globalCache   Cache = NewInMemoryCache()
+	globalCacheMu sync.RWMutex
+)
 
 // GetGlobalCache returns the global cache instance
 func GetGlobalCache() Cache {
+	globalCacheMu.RLock()
+	defer globalCacheMu.RUnlock()
 	return globalCache
 }
 
 // SetGlobalCache allows setting a custom cache implementation (useful for testing or Redis integration)
 func SetGlobalCache(c Cache) {
+	globalCacheMu.Lock()
+	defer globalCacheMu.Unlock()
 	globalCache = c
 }
\ No newline at end of file
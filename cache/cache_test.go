@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 )
@@ -39,3 +41,158 @@ func TestGlobalCache(t *testing.T) {
 		t.Error("Expected global cache to be singleton")
 	}
 }
+
+func TestInMemoryCache_ExpiryWithClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewInMemoryCacheWithClock(func() time.Time { return now })
+
+	cache.Set("token", "abc", time.Minute)
+	now = now.Add(59 * time.Second)
+	if _, found := cache.Get("token"); !found {
+		t.Error("Expected token to be cached before expiry")
+	}
+	now = now.Add(2 * time.Second)
+	if _, found := cache.Get("token"); found {
+		t.Error("Expected token to expire after its TTL")
+	}
+}
+
+func TestEncryptedCache(t *testing.T) {
+	cipher, err := NewCipher("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=") // 32 bytes
+	if err != nil {
+		t.Fatalf("Expected a valid key, got %v", err)
+	}
+	inner := NewInMemoryCache()
+	cache := NewEncryptedCache(inner, cipher)
+
+	cache.Set("token", "t-secret", time.Minute)
+	if value, found := cache.Get("token"); !found || value != "t-secret" {
+		t.Errorf("Expected the decrypted value, got %q, %t", value, found)
+	}
+	stored, _ := inner.Get("token")
+	if !strings.HasPrefix(stored, "enc:v1:") || strings.Contains(stored, "t-secret") {
+		t.Errorf("Expected an encrypted value at rest, got %q", stored)
+	}
+
+	inner.Set("legacy", "plaintext", time.Minute)
+	if value, found := cache.Get("legacy"); !found || value != "plaintext" {
+		t.Errorf("Expected plaintext values written before encryption to be readable, got %q", value)
+	}
+
+	other, _ := NewCipher("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	if _, found := NewEncryptedCache(inner, other).Get("token"); found {
+		t.Error("Expected a value sealed with another key to be a miss")
+	}
+	if _, err := NewCipher("c2hvcnQ="); err == nil {
+		t.Error("Expected an error for a 5 byte key")
+	}
+	if _, err := NewCipher("not base64!"); err == nil {
+		t.Error("Expected an error for a key that isn't base64")
+	}
+}
+
+func TestTypedCache(t *testing.T) {
+	type token struct {
+		Value     string
+		ExpiresAt time.Time
+	}
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	inner := NewInMemoryCacheWithClock(func() time.Time { return now })
+	expected := token{Value: "t-123", ExpiresAt: now.Add(2 * time.Hour)}
+
+	for name, codec := range map[string]Codec{"json": JSON, "gob": Gob} {
+		typed := NewTyped(inner, codec)
+		if err := typed.Set("token:"+name, expected, time.Hour); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		var got token
+		if found, err := typed.Get("token:"+name, &got); !found || err != nil || got.Value != expected.Value || !got.ExpiresAt.Equal(expected.ExpiresAt) {
+			t.Errorf("%s: expected %+v, got %+v, %t, %v", name, expected, got, found, err)
+		}
+	}
+	if raw, _ := inner.Get("token:json"); !strings.HasPrefix(raw, `{"Value":"t-123"`) {
+		t.Errorf("Expected JSON in the cache, got %q", raw)
+	}
+
+	var missing token
+	if found, err := NewTyped(inner, nil).Get("missing", &missing); found || err != nil {
+		t.Errorf("Expected a miss, got %t, %v", found, err)
+	}
+	inner.Set("corrupt", "{", time.Hour)
+	if _, err := NewTyped(inner, JSON).Get("corrupt", &missing); err == nil {
+		t.Error("Expected a decoding error")
+	}
+
+	now = now.Add(15 * time.Minute)
+	if ttl, ok := NewTyped(inner, JSON).TTL("token:json"); !ok || ttl != 45*time.Minute {
+		t.Errorf("Expected 45m left, got %v, %t", ttl, ok)
+	}
+	if _, ok := TTL(inner, "missing"); ok {
+		t.Error("Expected no TTL for a missing key")
+	}
+}
+
+func TestInMemoryCacheStats(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	cache := NewInMemoryCacheWithClock(func() time.Time { return now })
+	cache.Set("token", "t-1", time.Minute)
+	cache.Set("chat", "oc_1", time.Hour)
+	cache.Set("gone", "x", time.Hour)
+	cache.Get("token")
+	cache.Get("token")
+	cache.Get("missing")
+	cache.Delete("gone")
+
+	now = now.Add(2 * time.Minute)
+	cache.Get("token")
+
+	stats, ok := StatsOf(cache)
+	expected := Stats{Hits: 2, Misses: 2, Expired: 1, Sets: 3, Deletes: 1, Size: 1}
+	if !ok || stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if rate := stats.HitRate(); rate != 0.5 {
+		t.Errorf("Expected a hit rate of 0.5, got %v", rate)
+	}
+
+	cache.Set("soon", "x", time.Minute)
+	now = now.Add(2 * time.Minute)
+	cache.cleanupExpired()
+	if stats := cache.Stats(); stats.Expired != 2 || stats.Size != 1 {
+		t.Errorf("Expected cleanup to count expired entries, got %+v", stats)
+	}
+}
+
+func TestInMemoryCacheClose(t *testing.T) {
+	before := runtime.NumGoroutine()
+	caches := make([]*InMemoryCache, 20)
+	for i := range caches {
+		caches[i] = NewInMemoryCache()
+	}
+	if n := runtime.NumGoroutine(); n >= before+len(caches) {
+		t.Errorf("Expected no cleanup goroutines before the first Set, got %d more", n-before)
+	}
+	for _, c := range caches {
+		c.Set("key", "value", time.Minute)
+	}
+	for _, c := range caches {
+		c.Close()
+		c.Close()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("Expected the cleanup goroutines to exit, %d remain", n-before)
+	}
+
+	caches[0].Set("after", "value", time.Minute)
+	if value, found := caches[0].Get("after"); !found || value != "value" {
+		t.Error("Expected the cache to remain usable after Close")
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("Expected Set after Close not to start a goroutine, %d running", n-before)
+	}
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+)
+
+// Codec encodes structured values stored in a Cache
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// CodecFuncs adapts a pair of functions to a Codec, for example msgpack.Marshal and
+// msgpack.Unmarshal
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+// Marshal calls MarshalFunc
+func (c CodecFuncs) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+// Unmarshal calls UnmarshalFunc
+func (c CodecFuncs) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+// Built-in codecs: JSON is readable in Redis, Gob is more compact for Go-only readers
+var (
+	JSON Codec = CodecFuncs{MarshalFunc: json.Marshal, UnmarshalFunc: json.Unmarshal}
+	Gob  Codec = CodecFuncs{MarshalFunc: gobMarshal, UnmarshalFunc: gobUnmarshal}
+)
+
+func gobMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func gobUnmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// TTLCache is implemented by caches that can report how long a value has left
+type TTLCache interface {
+	Cache
+	TTL(key string) (time.Duration, bool)
+}
+
+// TTL returns how long the value of key has left in c, or false when the key is missing
+// or c cannot report it
+func TTL(c Cache, key string) (time.Duration, bool) {
+	if ttlCache, ok := c.(TTLCache); ok {
+		return ttlCache.TTL(key)
+	}
+	return 0, false
+}
+
+// Typed stores structured values in a Cache, encoded with a Codec
+type Typed struct {
+	Cache Cache
+	Codec Codec // Defaults to JSON
+}
+
+// NewTyped returns a Typed cache storing values in c with codec
+func NewTyped(c Cache, codec Codec) Typed {
+	return Typed{Cache: c, Codec: codec}
+}
+
+func (t Typed) codec() Codec {
+	if t.Codec != nil {
+		return t.Codec
+	}
+	return JSON
+}
+
+// Get decodes the value of key into v, reporting false when the key is missing
+func (t Typed) Get(key string, v interface{}) (bool, error) {
+	data, found := t.Cache.Get(key)
+	if !found {
+		return false, nil
+	}
+	if err := t.codec().Unmarshal([]byte(data), v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set encodes v and stores it under key with expiration
+func (t Typed) Set(key string, v interface{}, duration time.Duration) error {
+	data, err := t.codec().Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.Cache.Set(key, string(data), duration)
+	return nil
+}
+
+// Delete removes the value of key
+func (t Typed) Delete(key string) {
+	t.Cache.Delete(key)
+}
+
+// TTL returns how long the value of key has left, when the cache can report it
+func (t Typed) TTL(key string) (time.Duration, bool) {
+	return TTL(t.Cache, key)
+}
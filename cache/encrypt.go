@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// encryptedPrefix marks values sealed by a Cipher, so plaintext values written before
+// encryption was enabled can still be read
+const encryptedPrefix = "enc:v1:"
+
+// ErrDecrypt is returned for sealed values that cannot be opened with the key
+var ErrDecrypt = errors.New("cache: cannot decrypt value")
+
+// Cipher seals cache values with AES-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher from a base64-encoded 16, 24 or 32 byte key, for AES-128,
+// AES-192 or AES-256
+func NewCipher(key string) (*Cipher, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("cache encryption key must be base64: %w", err)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("cache encryption key must be 16, 24 or 32 bytes: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal encrypts value with a random nonce
+func (c *Cipher) Seal(value string) string {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("cache: failed to read random nonce: %v", err))
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+// Open decrypts a value sealed by Seal. Values without the encryption prefix are returned
+// unchanged, so caches populated before encryption was enabled keep working.
+func (c *Cipher) Open(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encryptedPrefix):])
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
+
+// EncryptedCache wraps a Cache, encrypting values before they are stored. Values that
+// cannot be decrypted, for example after the key changed, are treated as missing.
+type EncryptedCache struct {
+	inner  Cache
+	cipher *Cipher
+}
+
+// NewEncryptedCache returns a Cache storing values in inner sealed with c
+func NewEncryptedCache(inner Cache, c *Cipher) *EncryptedCache {
+	return &EncryptedCache{inner: inner, cipher: c}
+}
+
+// Get retrieves and decrypts a value
+func (e *EncryptedCache) Get(key string) (string, bool) {
+	value, found := e.inner.Get(key)
+	if !found {
+		return "", false
+	}
+	plaintext, err := e.cipher.Open(value)
+	if err != nil {
+		fmt.Printf("[Cache] Failed to decrypt cached value for key %s, ignoring it\n", key)
+		return "", false
+	}
+	return plaintext, true
+}
+
+// Set encrypts and stores a value with expiration
+func (e *EncryptedCache) Set(key, value string, duration time.Duration) {
+	e.inner.Set(key, e.cipher.Seal(value), duration)
+}
+
+// TTL returns how long a value has left, when the wrapped cache can report it
+func (e *EncryptedCache) TTL(key string) (time.Duration, bool) {
+	return TTL(e.inner, key)
+}
+
+// Delete removes a value
+func (e *EncryptedCache) Delete(key string) {
+	e.inner.Delete(key)
+}
@@ -0,0 +1,59 @@
+package cache
+
+// Stats counts the lookups and writes of a cache
+type Stats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`  // Lookups of missing or expired keys
+	Expired int64 `json:"expired"` // Entries removed because they expired
+	Sets    int64 `json:"sets"`
+	Deletes int64 `json:"deletes"`
+	Size    int64 `json:"size"` // Entries currently stored, -1 when unknown
+}
+
+// HitRate returns the share of lookups that were hits, or 0 before any lookup
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// StatsCache is implemented by caches that count their lookups
+type StatsCache interface {
+	Cache
+	Stats() Stats
+}
+
+// StatsOf returns the statistics of c, or false when c does not count them
+func StatsOf(c Cache) (Stats, bool) {
+	if statsCache, ok := c.(StatsCache); ok {
+		return statsCache.Stats(), true
+	}
+	return Stats{}, false
+}
+
+// Stats returns the cache's counters and current number of entries, including expired
+// entries not yet cleaned up
+func (c *InMemoryCache) Stats() Stats {
+	var size int64
+	c.data.Range(func(key, value interface{}) bool {
+		size++
+		return true
+	})
+	return Stats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Expired: c.expired.Load(),
+		Sets:    c.sets.Load(),
+		Deletes: c.deletes.Load(),
+		Size:    size,
+	}
+}
+
+// Stats returns the statistics of the wrapped cache, with Size -1 when it does not count them
+func (e *EncryptedCache) Stats() Stats {
+	if stats, ok := StatsOf(e.inner); ok {
+		return stats
+	}
+	return Stats{Size: -1}
+}
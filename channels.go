@@ -0,0 +1,192 @@
+package gocommonlog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ChannelError is the failure of one channel in SendToChannels or SendToGroup
+type ChannelError struct {
+	Channel  string
+	Provider string // Set for group members with their own provider
+	Err      error
+}
+
+// ChannelErrors is returned by SendToChannels and SendToGroup when some channels failed.
+// Failures are listed in the order the channels were given.
+type ChannelErrors struct {
+	Total    int // Channels the alert was sent to
+	Failures []ChannelError
+}
+
+func (e *ChannelErrors) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		label := failure.Channel
+		if failure.Provider != "" {
+			label = failure.Provider + " " + label
+		}
+		parts[i] = fmt.Sprintf("%s: %v", strings.TrimSpace(label), failure.Err)
+	}
+	return fmt.Sprintf("failed to send to %d of %d channels: %s", len(e.Failures), e.Total, strings.Join(parts, "; "))
+}
+
+// Is reports whether any channel failed with target, so errors.Is(err, ErrLoggerClosed)
+// or errors.Is(err, types.ErrChannelNotFound) work on the summary
+func (e *ChannelErrors) Is(target error) bool {
+	for _, failure := range e.Failures {
+		if errors.Is(failure.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendToChannels sends the same alert to each channel, overriding opts.Channel, and
+// returns a *ChannelErrors listing every channel that failed. Duplicate channels are sent
+// to once. Channels are sent to in order unless opts.Parallel is set. The copies share one
+// alert ID and are sampled, rate limited and escalated as a single alert.
+func (l *Logger) SendToChannels(level int, message string, opts types.SendOptions, channels ...string) error {
+	_, err := l.SendToChannelsWithResult(level, message, opts, channels...)
+	return err
+}
+
+// SendToChannelsWithResult is SendToChannels, also returning the delivery to each channel
+func (l *Logger) SendToChannelsWithResult(level int, message string, opts types.SendOptions, channels ...string) (types.SendResult, error) {
+	if len(channels) == 0 {
+		return types.SendResult{}, fmt.Errorf("no channels to send to")
+	}
+	seen := make(map[string]bool, len(channels))
+	unique := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if !seen[channel] {
+			seen[channel] = true
+			unique = append(unique, channel)
+		}
+	}
+
+	dests := make([]destination, len(unique))
+	for i, channel := range unique {
+		dests[i] = destination{channel: channel}
+	}
+	deliveries := l.sendTo(level, message, opts, dests)
+
+	result := &ChannelErrors{Total: len(unique)}
+	for i, delivery := range deliveries {
+		if delivery.Err != nil {
+			result.Failures = append(result.Failures, ChannelError{Channel: unique[i], Err: delivery.Err})
+		}
+	}
+	if len(result.Failures) > 0 {
+		types.DebugLog(l.config, "SendToChannels: %v", result)
+		return types.SendResult{Deliveries: deliveries}, result
+	}
+	return types.SendResult{Deliveries: deliveries}, nil
+}
+
+// SendToGroup sends an alert to every member of the broadcast group named in
+// Config.Groups, see SendGroupWithOptions
+func (l *Logger) SendToGroup(level int, message string, group string) error {
+	_, err := l.SendGroupWithResult(level, message, group, types.SendOptions{})
+	return err
+}
+
+// SendGroupWithOptions sends an alert to every member of the broadcast group named in
+// Config.Groups. Each member is a route: it receives the alert only when its criteria
+// match, with its own channel, provider, send method and settings, and the routing table
+// is not consulted. opts.Channel and opts.Provider are ignored. Failures are returned as a
+// *ChannelErrors, and members are sent to concurrently when opts.Parallel is set. Like
+// SendToChannels, the copies share one alert ID and count as a single alert.
+func (l *Logger) SendGroupWithOptions(level int, message string, group string, opts types.SendOptions) error {
+	_, err := l.SendGroupWithResult(level, message, group, opts)
+	return err
+}
+
+// SendGroupWithResult is SendGroupWithOptions, also returning the delivery to each member
+// the alert matched
+func (l *Logger) SendGroupWithResult(level int, message string, group string, opts types.SendOptions) (types.SendResult, error) {
+	members, ok := l.groups[group]
+	if !ok {
+		return types.SendResult{}, fmt.Errorf("unknown broadcast group %q", group)
+	}
+	service, environment := l.config.ServiceName, l.config.Environment
+	if opts.ServiceName != "" {
+		service = opts.ServiceName
+	}
+	if opts.Environment != "" {
+		environment = opts.Environment
+	}
+	alert := types.AlertContext{
+		Level:         level,
+		Message:       message,
+		Service:       service,
+		Environment:   environment,
+		Fields:        l.alertFields(opts),
+		CorrelationID: opts.CorrelationID,
+	}
+	var matched []*compiledRoute
+	for i := range members {
+		alert.Provider = members[i].providerName
+		if members[i].Matches(alert) {
+			matched = append(matched, &members[i])
+		}
+	}
+	types.DebugLog(l.config, "SendToGroup: %d of %d members of group '%s' match", len(matched), len(members), group)
+
+	opts.Channel, opts.Provider = "", ""
+	dests := make([]destination, len(matched))
+	for i, route := range matched {
+		dests[i] = destination{route: route}
+	}
+	deliveries := l.sendTo(level, message, opts, dests)
+
+	result := &ChannelErrors{Total: len(matched)}
+	for i, delivery := range deliveries {
+		if delivery.Err != nil {
+			result.Failures = append(result.Failures, ChannelError{Channel: matched[i].Channel, Provider: matched[i].providerName, Err: delivery.Err})
+		}
+	}
+	if len(result.Failures) > 0 {
+		types.DebugLog(l.config, "SendToGroup: %v", result)
+		return types.SendResult{Deliveries: deliveries}, result
+	}
+	return types.SendResult{Deliveries: deliveries}, nil
+}
+
+// sendTo sends one alert to every destination, see dispatchTo
+func (l *Logger) sendTo(level int, message string, opts types.SendOptions, dests []destination) []types.Delivery {
+	if len(dests) == 0 {
+		return nil
+	}
+	if err := l.beginSend(); err != nil {
+		return repeatDelivery(types.Delivery{Status: types.AuditFailed, Err: err}, len(dests))
+	}
+	defer l.inflight.Done()
+	return l.dispatchTo(level, message, opts, "", dests, opts.Parallel, "")
+}
+
+// fanOut calls send for 0..n-1, one after another or concurrently, and returns the
+// deliveries by index
+func fanOut(n int, parallel bool, send func(i int) types.Delivery) []types.Delivery {
+	deliveries := make([]types.Delivery, n)
+	if !parallel {
+		for i := 0; i < n; i++ {
+			deliveries[i] = send(i)
+		}
+		return deliveries
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			deliveries[i] = send(i)
+		}(i)
+	}
+	wg.Wait()
+	return deliveries
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// fileConfig is the JSON representation of types.Config read by the CLI
+type fileConfig struct {
+	Provider       string                 `json:"provider"`
+	SendMethod     string                 `json:"send_method"`
+	Token          string                 `json:"token"`
+	SlackToken     string                 `json:"slack_token"`
+	LarkAppID      string                 `json:"lark_app_id"`
+	LarkAppSecret  string                 `json:"lark_app_secret"`
+	Channel        string                 `json:"channel"`
+	ChannelMap     map[string]string      `json:"channel_map"` // level name -> channel
+	ServiceName    string                 `json:"service_name"`
+	Environment    string                 `json:"environment"`
+	Fields         map[string]string      `json:"fields"`
+	ProviderConfig map[string]interface{} `json:"provider_config"`
+	Debug          bool                   `json:"debug"`
+}
+
+// loadConfig reads the optional JSON config file and applies COMMONLOG_* environment overrides
+func loadConfig(path string) (types.Config, error) {
+	var fc fileConfig
+	if path == "" {
+		path = os.Getenv("COMMONLOG_CONFIG")
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return types.Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, &fc); err != nil {
+			return types.Config{}, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	overrides := map[string]*string{
+		"COMMONLOG_PROVIDER":        &fc.Provider,
+		"COMMONLOG_SEND_METHOD":     &fc.SendMethod,
+		"COMMONLOG_TOKEN":           &fc.Token,
+		"COMMONLOG_SLACK_TOKEN":     &fc.SlackToken,
+		"COMMONLOG_LARK_APP_ID":     &fc.LarkAppID,
+		"COMMONLOG_LARK_APP_SECRET": &fc.LarkAppSecret,
+		"COMMONLOG_CHANNEL":         &fc.Channel,
+		"COMMONLOG_SERVICE_NAME":    &fc.ServiceName,
+		"COMMONLOG_ENVIRONMENT":     &fc.Environment,
+	}
+	for env, target := range overrides {
+		if value := os.Getenv(env); value != "" {
+			*target = value
+		}
+	}
+	if value := os.Getenv("COMMONLOG_DEBUG"); value != "" {
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			return types.Config{}, fmt.Errorf("invalid COMMONLOG_DEBUG: %w", err)
+		}
+		fc.Debug = debug
+	}
+
+	cfg := types.Config{
+		Provider:       fc.Provider,
+		SendMethod:     fc.SendMethod,
+		Token:          fc.Token,
+		SlackToken:     fc.SlackToken,
+		LarkToken:      types.LarkTokenConfig{AppID: fc.LarkAppID, AppSecret: fc.LarkAppSecret},
+		Channel:        fc.Channel,
+		ServiceName:    fc.ServiceName,
+		Environment:    fc.Environment,
+		Fields:         fc.Fields,
+		ProviderConfig: normalizeProviderConfig(fc.ProviderConfig),
+		Debug:          fc.Debug,
+	}
+	if cfg.SendMethod == "" {
+		cfg.SendMethod = types.MethodWebhook
+	}
+	if len(fc.ChannelMap) > 0 {
+		resolver := &types.DefaultChannelResolver{ChannelMap: map[int]string{}, DefaultChannel: fc.Channel}
+		for name, channel := range fc.ChannelMap {
+			level, err := types.ParseLevel(name)
+			if err != nil {
+				return types.Config{}, fmt.Errorf("invalid channel_map: %w", err)
+			}
+			resolver.ChannelMap[level] = channel
+		}
+		cfg.ChannelResolver = resolver
+	}
+	return cfg, nil
+}
+
+// normalizeProviderConfig converts JSON numbers that are whole into ints, matching how
+// provider settings are set from Go code
+func normalizeProviderConfig(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	normalized := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if f, ok := value.(float64); ok && f == math.Trunc(f) {
+			normalized[key] = int(f)
+			continue
+		}
+		normalized[key] = value
+	}
+	return normalized
+}
@@ -0,0 +1,107 @@
+// Command gocommonlog sends alerts from the command line or stdin using the same
+// routing and formatting as the library, so shell scripts and cron jobs can reuse it.
+//
+// Usage:
+//
+//	gocommonlog send [-config file.json] [-level error] [-channel name] [-attach path] [-trace path] [message]
+//
+// When no message argument is given, or it is "-", the message is read from stdin.
+// Configuration is read from -config (or COMMONLOG_CONFIG) and overridden by
+// COMMONLOG_* environment variables.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "send" {
+		fmt.Fprintln(stderr, "usage: gocommonlog send [flags] [message]")
+		return 2
+	}
+
+	flags := flag.NewFlagSet("send", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "path to JSON config file (defaults to $COMMONLOG_CONFIG)")
+	levelName := flags.String("level", "error", "alert level: info, warn or error")
+	channel := flags.String("channel", "", "channel override")
+	provider := flags.String("provider", "", "provider override")
+	attachPath := flags.String("attach", "", "file to include as attachment")
+	tracePath := flags.String("trace", "", "file to include as trace log")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	level, err := types.ParseLevel(*levelName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	message := strings.Join(flags.Args(), " ")
+	if message == "" || message == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to read message from stdin: %v\n", err)
+			return 1
+		}
+		message = strings.TrimRight(string(data), "\n")
+	}
+	if message == "" {
+		fmt.Fprintln(stderr, "message is required")
+		return 2
+	}
+
+	var attachment *types.Attachment
+	if *attachPath != "" {
+		data, err := os.ReadFile(*attachPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to read attachment: %v\n", err)
+			return 1
+		}
+		attachment = &types.Attachment{FileName: filepath.Base(*attachPath), Content: string(data)}
+	}
+	trace := ""
+	if *tracePath != "" {
+		data, err := os.ReadFile(*tracePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to read trace: %v\n", err)
+			return 1
+		}
+		trace = string(data)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	logger, err := gocommonlog.NewStrictLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *provider != "" {
+		err = logger.CustomSend(*provider, level, message, attachment, trace, *channel)
+	} else {
+		err = logger.SendToChannel(level, message, attachment, trace, *channel)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to send alert: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestLoadConfigFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commonlog.json")
+	content := `{
+		"provider": "slack",
+		"token": "file-token",
+		"channel": "#alerts",
+		"channel_map": {"error": "#incidents"},
+		"service_name": "billing",
+		"provider_config": {"twilio_max_length": 120, "ntfy_server": "https://ntfy.example.com"}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("COMMONLOG_TOKEN", "env-token")
+	t.Setenv("COMMONLOG_ENVIRONMENT", "staging")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if cfg.Token != "env-token" {
+		t.Errorf("expected env token to override file, got %q", cfg.Token)
+	}
+	if cfg.Environment != "staging" || cfg.ServiceName != "billing" {
+		t.Errorf("unexpected service/environment: %q/%q", cfg.ServiceName, cfg.Environment)
+	}
+	if cfg.SendMethod != types.MethodWebhook {
+		t.Errorf("expected default send method webhook, got %q", cfg.SendMethod)
+	}
+	if got := cfg.ChannelResolver.ResolveChannel(types.ERROR); got != "#incidents" {
+		t.Errorf("expected #incidents for ERROR, got %q", got)
+	}
+	if got := cfg.ChannelResolver.ResolveChannel(types.WARN); got != "#alerts" {
+		t.Errorf("expected default channel for WARN, got %q", got)
+	}
+	if got, ok := cfg.ProviderConfig["twilio_max_length"].(int); !ok || got != 120 {
+		t.Errorf("expected integer provider setting, got %#v", cfg.ProviderConfig["twilio_max_length"])
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run(nil, strings.NewReader(""), &stderr); code != 2 {
+		t.Errorf("expected exit code 2 without subcommand, got %d", code)
+	}
+	stderr.Reset()
+	if code := run([]string{"send", "-level", "fatal", "boom"}, strings.NewReader(""), &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for invalid level, got %d", code)
+	}
+	stderr.Reset()
+	if code := run([]string{"send"}, strings.NewReader(""), &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for empty stdin message, got %d", code)
+	}
+}
@@ -0,0 +1,337 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Digest defaults
+const (
+	defaultDigestName  = "Alert digest"
+	defaultDigestTop   = 5
+	maxDigestAlerts    = 1000 // distinct alerts counted per report, the rest only in the totals
+	digestNoisiestHour = 3    // hours listed as the noisiest
+	digestMessageWidth = 100  // characters of an alert's message shown in the report
+)
+
+// digestJob posts the reports of one Config.Digests entry
+type digestJob struct {
+	types.Digest
+	schedule *cronSchedule
+	location *time.Location
+	route    compiledRoute // where the report goes when Channel is set
+
+	mu       sync.Mutex
+	since    time.Time                   // start of the current report
+	reports  map[digestKey]*digestCounts // alerts counted for each report
+	services map[string]int              // alerts by service across the reports, for alert budgets
+	timer    types.Timer
+}
+
+// digestKey is where a report goes: the channel the alerts went to, with the provider and
+// route they were sent with. Digests with their own Channel count every alert under the
+// zero key.
+type digestKey struct {
+	channel  string
+	provider string
+	route    *compiledRoute // nil for the logger's provider
+}
+
+// digestCounts counts the alerts of one report
+type digestCounts struct {
+	total, failed int
+	levels        map[string]int
+	services      map[string]int
+	channels      map[string]int
+	alerts        map[string]*digestAlert // by fingerprint
+	hours         [24]int
+}
+
+// digestAlert is an alert counted by its fingerprint, with the first message seen
+type digestAlert struct {
+	message string
+	count   int
+}
+
+// newDigests parses the schedule of every digest. Digests with an invalid schedule or
+// time zone are logged and left out, so they cannot stop alerts from being sent.
+func newDigests(cfg types.Config, now time.Time) []*digestJob {
+	var jobs []*digestJob
+	for _, digest := range cfg.Digests {
+		if digest.Name == "" {
+			digest.Name = defaultDigestName
+		}
+		if digest.Top <= 0 {
+			digest.Top = defaultDigestTop
+		}
+		schedule, err := parseCron(digest.Schedule)
+		if err != nil {
+			log.Printf("[WARN] Digest '%s' is not sent: %v", digest.Name, err)
+			continue
+		}
+		location := time.Local
+		if digest.TimeZone != "" {
+			if location, err = time.LoadLocation(digest.TimeZone); err != nil {
+				log.Printf("[WARN] Digest '%s' is not sent: %v", digest.Name, err)
+				continue
+			}
+		}
+		job := &digestJob{Digest: digest, schedule: schedule, location: location, since: now, reports: make(map[digestKey]*digestCounts), services: make(map[string]int)}
+		if digest.Channel != "" {
+			route := types.Route{Name: "digest " + digest.Name, Channel: digest.Channel, Provider: digest.Provider, SendMethod: digest.SendMethod, Token: digest.Token}
+			job.route = compileRouteList(cfg, []types.Route{route})[0]
+			job.route.digest = true
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// recordDigests counts an alert passed to a provider in every digest. route is the route
+// it was sent through, nil for the logger's provider; reports themselves are not counted.
+// first is false for the further copies of an alert sent to several destinations, which
+// only the digests reporting by channel count, once for each channel.
+func (l *Logger) recordDigests(record types.AuditRecord, route *compiledRoute, fingerprint, message string, first bool) {
+	if len(l.digests) == 0 || route != nil && route.digest {
+		return
+	}
+	for _, job := range l.digests {
+		if !first && job.Channel != "" {
+			continue
+		}
+		key := digestKey{}
+		if job.Channel == "" {
+			key = digestKey{channel: record.Channel, provider: record.Provider, route: route}
+		}
+		job.mu.Lock()
+		counts := job.reports[key]
+		if counts == nil {
+			counts = &digestCounts{
+				levels:   make(map[string]int),
+				services: make(map[string]int),
+				channels: make(map[string]int),
+				alerts:   make(map[string]*digestAlert),
+			}
+			job.reports[key] = counts
+		}
+		counts.add(record, fingerprint, message, job.location)
+		if first {
+			job.services[budgetServiceName(record.Service)]++
+		}
+		job.mu.Unlock()
+	}
+}
+
+// add counts an alert
+func (c *digestCounts) add(record types.AuditRecord, fingerprint, message string, location *time.Location) {
+	c.total++
+	if record.Outcome == types.AuditFailed {
+		c.failed++
+	}
+	c.levels[record.Level]++
+	c.services[budgetServiceName(record.Service)]++
+	c.channels[record.Channel]++
+	c.hours[record.Time.In(location).Hour()]++
+	if alert := c.alerts[fingerprint]; alert != nil {
+		alert.count++
+	} else if len(c.alerts) < maxDigestAlerts {
+		c.alerts[fingerprint] = &digestAlert{message: digestMessage(message), count: 1}
+	}
+}
+
+// digestMessage returns the first line of a message, shortened for the report
+func digestMessage(message string) string {
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	if runes := []rune(message); len(runes) > digestMessageWidth {
+		message = string(runes[:digestMessageWidth-1]) + "…"
+	}
+	return message
+}
+
+// startDigests schedules the first report of every digest
+func (l *Logger) startDigests() {
+	for _, job := range l.digests {
+		l.armDigest(job)
+	}
+}
+
+// armDigest schedules the next report of a digest, unless the logger is closed
+func (l *Logger) armDigest(job *digestJob) {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed {
+		return
+	}
+	now := l.now()
+	next := job.schedule.next(now.In(job.location))
+	if next.IsZero() {
+		log.Printf("[WARN] Digest '%s' schedule %q never runs", job.Name, job.Schedule)
+		return
+	}
+	types.DebugLog(l.config, "Digest '%s' scheduled at %s", job.Name, next.Format(time.RFC3339))
+	job.mu.Lock()
+	job.timer = l.afterFunc(next.Sub(now), func() {
+		if err := l.sendDigest(job); err != nil && err != ErrLoggerClosed {
+			log.Printf("[ERROR] Failed to send digest '%s': %v", job.Name, err)
+		}
+		l.armDigest(job)
+	})
+	job.mu.Unlock()
+}
+
+// stopDigests stops the digest timers on Close
+func (l *Logger) stopDigests() {
+	for _, job := range l.digests {
+		job.mu.Lock()
+		if job.timer != nil {
+			job.timer.Stop()
+		}
+		job.mu.Unlock()
+	}
+}
+
+// SendDigest posts the reports of the digest named name now, as its schedule would, and
+// starts counting the next ones. Nothing is sent when no alerts were sent since the
+// previous report.
+func (l *Logger) SendDigest(name string) error {
+	for _, job := range l.digests {
+		if job.Name == name {
+			return l.sendDigest(job)
+		}
+	}
+	return fmt.Errorf("unknown digest %q", name)
+}
+
+// sendDigest posts the reports counted since the previous one, returning the first error
+func (l *Logger) sendDigest(job *digestJob) error {
+	now := l.now()
+	job.mu.Lock()
+	from, reports, services := job.since, job.reports, job.services
+	job.since, job.reports, job.services = now, make(map[digestKey]*digestCounts), make(map[string]int)
+	job.mu.Unlock()
+	if len(reports) == 0 {
+		types.DebugLog(l.config, "Digest '%s' has no alerts to report", job.Name)
+		return nil
+	}
+
+	keys := make([]digestKey, 0, len(reports))
+	for key := range reports {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].channel != keys[j].channel {
+			return keys[i].channel < keys[j].channel
+		}
+		return keys[i].provider < keys[j].provider
+	})
+	var firstErr error
+	for _, key := range keys {
+		budgets := l.budget.consumption(reports[key], services, now.Sub(from))
+		report := job.report(key, reports[key], budgets, from.In(job.location), now.In(job.location))
+		if _, err := l.send(types.WARN, report, types.SendOptions{}, "", l.digestRoute(job, key), ""); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// digestRoute returns where a report goes: the digest's channel, or the channel the
+// alerts went to through the provider and route they were sent with
+func (l *Logger) digestRoute(job *digestJob, key digestKey) *compiledRoute {
+	if job.Channel != "" {
+		return &job.route
+	}
+	route := compiledRoute{provider: l.provider}
+	route.providerName, _ = l.config.ProviderConfig["provider"].(string)
+	if key.route != nil {
+		route = *key.route
+	}
+	if key.provider != route.providerName {
+		route.provider, route.providerName = createProvider(key.provider), key.provider
+	}
+	route.Name = "digest " + job.Name
+	route.Channel = key.channel
+	route.digest = true
+	return &route
+}
+
+// report formats the report of the alerts counted between from and to, with the budget
+// consumption of their services when alert budgets are set
+func (job *digestJob) report(key digestKey, counts *digestCounts, budgets string, from, to time.Time) string {
+	const layout = "2006-01-02 15:04"
+	var b strings.Builder
+	b.WriteString(job.Name)
+	if key.channel != "" {
+		b.WriteString(" for " + key.channel)
+	}
+	fmt.Fprintf(&b, ": %d alerts from %s to %s %s", counts.total, from.Format(layout), to.Format(layout), to.Format("MST"))
+	if counts.failed > 0 {
+		fmt.Fprintf(&b, " (%d failed)", counts.failed)
+	}
+	b.WriteString("\n\nBy level: " + digestRanking(counts.levels, 0))
+	b.WriteString("\nBy service: " + digestRanking(counts.services, 0))
+	if budgets != "" {
+		b.WriteString("\nAlert budgets: " + budgets)
+	}
+	if job.Channel != "" {
+		b.WriteString("\nBy channel: " + digestRanking(counts.channels, 0))
+	}
+
+	alerts := make([]*digestAlert, 0, len(counts.alerts))
+	for _, alert := range counts.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].count != alerts[j].count {
+			return alerts[i].count > alerts[j].count
+		}
+		return alerts[i].message < alerts[j].message
+	})
+	if len(alerts) > job.Top {
+		alerts = alerts[:job.Top]
+	}
+	b.WriteString("\n\nTop alerts:")
+	for i, alert := range alerts {
+		fmt.Fprintf(&b, "\n%d. %d× %s", i+1, alert.count, alert.message)
+	}
+
+	hours := make(map[string]int)
+	for hour, count := range counts.hours {
+		if count > 0 {
+			hours[fmt.Sprintf("%02d:00", hour)] = count
+		}
+	}
+	b.WriteString("\n\nNoisiest hours: " + digestRanking(hours, digestNoisiestHour))
+	return b.String()
+}
+
+// digestRanking lists counts as "name (count)", most frequent first, keeping the first
+// limit when it is positive
+func digestRanking(counts map[string]int, limit int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,106 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// envExpander expands ${VAR} references in configuration values, remembering the
+// variables that are not set
+type envExpander struct {
+	missing map[string]bool
+}
+
+// expand replaces each ${VAR} in value with the environment variable's value; $${ is a
+// literal ${. Other uses of $ are left alone, since tokens and URLs may contain it.
+func (e *envExpander) expand(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+	var out strings.Builder
+	for {
+		i := strings.Index(value, "${")
+		if i < 0 {
+			break
+		}
+		if i > 0 && value[i-1] == '$' {
+			out.WriteString(value[:i-1] + "${")
+			value = value[i+2:]
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			break
+		}
+		name := value[i+2 : i+end]
+		out.WriteString(value[:i])
+		if env, ok := os.LookupEnv(name); ok && name != "" {
+			out.WriteString(env)
+		} else {
+			e.missing[name] = true
+		}
+		value = value[i+end+1:]
+	}
+	out.WriteString(value)
+	return out.String()
+}
+
+// expandSettings expands the string, LarkTokenConfig and SlackRefreshConfig values of
+// settings in place
+func (e *envExpander) expandSettings(settings map[string]interface{}) {
+	for key, value := range settings {
+		switch value := value.(type) {
+		case string:
+			settings[key] = e.expand(value)
+		case types.LarkTokenConfig:
+			settings[key] = types.LarkTokenConfig{AppID: e.expand(value.AppID), AppSecret: e.expand(value.AppSecret)}
+		case types.SlackRefreshConfig:
+			value.ClientID = e.expand(value.ClientID)
+			value.ClientSecret = e.expand(value.ClientSecret)
+			value.RefreshToken = e.expand(value.RefreshToken)
+			settings[key] = value
+		}
+	}
+}
+
+// expandEnv expands ${VAR} references in the tokens and settings of cfg, whose
+// ProviderConfig must already be a copy owned by the logger. It returns an error naming
+// the variables that are not set.
+func expandEnv(cfg *types.Config) error {
+	e := &envExpander{missing: make(map[string]bool)}
+	cfg.Token = e.expand(cfg.Token)
+	cfg.SlackToken = e.expand(cfg.SlackToken)
+	cfg.LarkToken = types.LarkTokenConfig{AppID: e.expand(cfg.LarkToken.AppID), AppSecret: e.expand(cfg.LarkToken.AppSecret)}
+	e.expandSettings(cfg.ProviderConfig)
+
+	if len(cfg.Routes) > 0 {
+		routes := make([]types.Route, len(cfg.Routes))
+		for i, route := range cfg.Routes {
+			route.Token = e.expand(route.Token)
+			if route.ProviderConfig != nil {
+				settings := make(map[string]interface{}, len(route.ProviderConfig))
+				for key, value := range route.ProviderConfig {
+					settings[key] = value
+				}
+				e.expandSettings(settings)
+				route.ProviderConfig = settings
+			}
+			routes[i] = route
+		}
+		cfg.Routes = routes
+	}
+
+	if len(e.missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(e.missing))
+	for name := range e.missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("config references unset environment variables: %s", strings.Join(names, ", "))
+}
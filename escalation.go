@@ -0,0 +1,37 @@
+package gocommonlog
+
+import (
+	"fmt"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// escalationPolicy returns the policy for service, falling back to "*"
+func (l *Logger) escalationPolicy(service string) (types.EscalationPolicy, bool) {
+	if policy, ok := l.config.Escalation[service]; ok {
+		return policy, true
+	}
+	policy, ok := l.config.Escalation["*"]
+	return policy, ok
+}
+
+// escalate schedules every step of the escalation policy for a sent ERROR alert. Steps
+// still pending when the alert is acknowledged are canceled.
+func (l *Logger) escalate(alertID, service, message string, opts types.SendOptions, channel string) {
+	policy, ok := l.escalationPolicy(service)
+	if !ok {
+		return
+	}
+	for i, step := range policy.Steps {
+		stepOpts := opts
+		stepOpts.Channel = step.Channel
+		if stepOpts.Channel == "" {
+			stepOpts.Channel = channel
+		}
+		if step.Provider != "" {
+			stepOpts.Provider = step.Provider
+		}
+		types.DebugLog(l.config, "Scheduling escalation step %d for alert %s after %s to '%s'", i+1, alertID, step.After, stepOpts.Channel)
+		l.followUp(alertID, step.After, fmt.Sprintf(types.Localize(l.config, types.TextEscalation), step.After)+": "+message, stepOpts)
+	}
+}
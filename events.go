@@ -0,0 +1,85 @@
+package gocommonlog
+
+import (
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultEventBuffer is the channel buffer of Subscribe when none is given
+const defaultEventBuffer = 100
+
+// Subscribe returns a channel receiving the logger's lifecycle events, such as alerts
+// queued, sent, failed or suppressed, and alert storms starting. Events are never waited
+// for: when the channel's buffer is full, events are dropped for that subscriber, so a
+// slow reader cannot hold up alerts. Call the returned function to unsubscribe; the
+// channel is closed then, or by Close.
+func (l *Logger) Subscribe(buffer int) (<-chan types.Event, func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	events := make(chan types.Event, buffer)
+	l.eventsMu.Lock()
+	defer l.eventsMu.Unlock()
+	if l.eventsClosed {
+		close(events)
+		return events, func() {}
+	}
+	if l.subscribers == nil {
+		l.subscribers = make(map[chan types.Event]struct{})
+	}
+	l.subscribers[events] = struct{}{}
+	return events, func() {
+		l.eventsMu.Lock()
+		defer l.eventsMu.Unlock()
+		if _, ok := l.subscribers[events]; ok {
+			delete(l.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// emit passes an event to Config.OnEvent and the subscribers
+func (l *Logger) emit(event types.Event) {
+	if event.Time.IsZero() {
+		event.Time = l.now()
+	}
+	if l.config.OnEvent != nil {
+		l.config.OnEvent(event)
+	}
+	l.eventsMu.RLock()
+	defer l.eventsMu.RUnlock()
+	for events := range l.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// deliveryEvent returns the event reporting the outcome of an alert, and false for alerts
+// only written to the local log
+func deliveryEvent(record types.AuditRecord, err error) (types.Event, bool) {
+	level, _ := types.ParseLevel(record.Level)
+	event := types.Event{AlertID: record.ID, Level: level, Provider: record.Provider, Channel: record.Channel}
+	switch record.Outcome {
+	case types.AuditSent:
+		event.Type = types.EventSent
+	case types.AuditFailed:
+		event.Type, event.Err = types.EventFailed, err
+	case types.AuditLogged:
+		return event, false
+	default:
+		event.Type, event.Detail = types.EventSuppressed, record.Outcome
+	}
+	return event, true
+}
+
+// closeSubscribers closes the subscribers' channels on Close
+func (l *Logger) closeSubscribers() {
+	l.eventsMu.Lock()
+	defer l.eventsMu.Unlock()
+	l.eventsClosed = true
+	for events := range l.subscribers {
+		close(events)
+	}
+	l.subscribers = nil
+}
@@ -0,0 +1,38 @@
+package gocommonlog
+
+import "github.com/alvianhanif/gocommonlog/types"
+
+// Fingerprint returns the grouping key the sinks receive for an alert sent with opts,
+// from Config.Fingerprinter or types.DefaultFingerprint
+func (l *Logger) Fingerprint(level int, message string, opts types.SendOptions) string {
+	alert := types.AlertContext{
+		Level:         level,
+		Message:       message,
+		Service:       l.config.ServiceName,
+		Environment:   l.config.Environment,
+		Fields:        l.alertFields(opts),
+		CorrelationID: opts.CorrelationID,
+		Provider:      opts.Provider,
+	}
+	if opts.ServiceName != "" {
+		alert.Service = opts.ServiceName
+	}
+	if opts.Environment != "" {
+		alert.Environment = opts.Environment
+	}
+	if alert.Provider == "" {
+		alert.Provider, _ = l.config.ProviderConfig["provider"].(string)
+	}
+	return l.fingerprint(alert)
+}
+
+// fingerprint applies the configured fingerprint function, falling back to the default
+// when it returns ""
+func (l *Logger) fingerprint(alert types.AlertContext) string {
+	if l.config.Fingerprinter != nil {
+		if fingerprint := l.config.Fingerprinter(alert); fingerprint != "" {
+			return fingerprint
+		}
+	}
+	return types.DefaultFingerprint(alert)
+}
@@ -0,0 +1,268 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Flap detection defaults
+const (
+	defaultFlapWindow        = 10 * time.Minute
+	maxFlapStableBackoff     = 16 // the stable period grows at most to 16 times flap_stable
+	defaultFlapStateTTL      = 24 * time.Hour
+	defaultFlapMaxConditions = 10000
+	flapSweepInterval        = time.Minute // how often expired conditions are looked for
+)
+
+// flapDetector tracks conditions alternating between firing and resolved when
+// flap_threshold is set. After flap_threshold transitions within flap_window a condition
+// is flapping: a single notice is sent and its alerts are suppressed until it has not
+// changed state for the stable period, which doubles each time the condition flaps again.
+type flapDetector struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	stable    time.Duration
+	states    map[string]*flapState
+
+	// A condition is forgotten once it has not fired or resolved for its TTL, and the
+	// least recently seen one is evicted when maxStates are tracked
+	ttl       time.Duration
+	ttls      map[string]time.Duration // flap_state_ttls, TTLs by condition pattern
+	maxStates int
+	lastSweep time.Time
+	expired   int64 // conditions forgotten after their TTL, reported by FlapStats
+	evicted   int64 // conditions evicted by flap_max_conditions
+}
+
+// flapState is the history of one condition
+type flapState struct {
+	firing      bool
+	lastChange  time.Time
+	lastSeen    time.Time     // latest fire or resolve, for expiry
+	ttl         time.Duration // how long the condition is remembered after lastSeen
+	transitions []time.Time   // state changes within the window
+	flapping    bool
+	flaps       int // times the condition started flapping, for the backoff
+	suppressed  int // alerts suppressed while flapping
+}
+
+// flapDecision is the outcome of an alert for a condition
+type flapDecision int
+
+const (
+	flapDeliver  flapDecision = iota // deliver the alert
+	flapNotice                       // the condition started flapping, send the notice instead
+	flapSuppress                     // the condition is flapping, suppress the alert
+)
+
+// newFlapDetector returns nil when flap detection is not configured
+func newFlapDetector(cfg types.Config) *flapDetector {
+	threshold, _ := cfg.ProviderConfig["flap_threshold"].(int)
+	if threshold < 2 {
+		return nil
+	}
+	window, _ := cfg.ProviderConfig["flap_window"].(time.Duration)
+	if window <= 0 {
+		window = defaultFlapWindow
+	}
+	stable, _ := cfg.ProviderConfig["flap_stable"].(time.Duration)
+	if stable <= 0 {
+		stable = window
+	}
+	ttl, _ := cfg.ProviderConfig["flap_state_ttl"].(time.Duration)
+	if ttl <= 0 {
+		ttl = defaultFlapStateTTL
+	}
+	ttls, _ := cfg.ProviderConfig["flap_state_ttls"].(map[string]time.Duration)
+	for pattern := range ttls {
+		if _, err := path.Match(pattern, ""); err != nil {
+			log.Printf("[WARN] Ignoring invalid flap_state_ttls pattern %q: %v", pattern, err)
+		}
+	}
+	maxStates, _ := cfg.ProviderConfig["flap_max_conditions"].(int)
+	if maxStates <= 0 {
+		maxStates = defaultFlapMaxConditions
+	}
+	return &flapDetector{threshold: threshold, window: window, stable: stable, states: make(map[string]*flapState),
+		ttl: ttl, ttls: ttls, maxStates: maxStates}
+}
+
+// stateTTL returns how long condition is remembered: the TTL of the longest
+// flap_state_ttls pattern it matches, or flap_state_ttl
+func (d *flapDetector) stateTTL(condition string) time.Duration {
+	ttl, longest := d.ttl, -1
+	for pattern, patternTTL := range d.ttls {
+		if matched, _ := path.Match(pattern, condition); matched && len(pattern) > longest && patternTTL > 0 {
+			ttl, longest = patternTTL, len(pattern)
+		}
+	}
+	return ttl
+}
+
+// sweep forgets the conditions whose TTL has passed, at most once per flapSweepInterval.
+// Callers hold mu.
+func (d *flapDetector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < flapSweepInterval {
+		return
+	}
+	d.lastSweep = now
+	for condition, state := range d.states {
+		if now.Sub(state.lastSeen) >= state.ttl {
+			delete(d.states, condition)
+			d.expired++
+		}
+	}
+}
+
+// track returns the state of condition, creating it and evicting the least recently seen
+// condition when maxStates are tracked. Callers hold mu.
+func (d *flapDetector) track(condition string, now time.Time) *flapState {
+	d.sweep(now)
+	state, ok := d.states[condition]
+	if !ok {
+		if len(d.states) >= d.maxStates {
+			oldest := ""
+			for name, candidate := range d.states {
+				if oldest == "" || candidate.lastSeen.Before(d.states[oldest].lastSeen) {
+					oldest = name
+				}
+			}
+			delete(d.states, oldest)
+			d.evicted++
+		}
+		state = &flapState{ttl: d.stateTTL(condition)}
+		d.states[condition] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// stablePeriod is how long a flapping condition must not change state to recover
+func (d *flapDetector) stablePeriod(state *flapState) time.Duration {
+	backoff := 1 << uint(state.flaps-1)
+	if backoff > maxFlapStableBackoff {
+		backoff = maxFlapStableBackoff
+	}
+	return d.stable * time.Duration(backoff)
+}
+
+// transition records a state change of condition at now
+func (d *flapDetector) transition(state *flapState, now time.Time) {
+	state.firing = !state.firing
+	state.lastChange = now
+	recent := state.transitions[:0]
+	for _, at := range state.transitions {
+		if now.Sub(at) < d.window {
+			recent = append(recent, at)
+		}
+	}
+	state.transitions = append(recent, now)
+}
+
+// fire records an alert for condition and decides whether it is delivered. When a
+// flapping condition recovers, the number of alerts suppressed meanwhile is returned.
+func (d *flapDetector) fire(condition string, now time.Time) (flapDecision, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state := d.track(condition, now)
+
+	suppressed := 0
+	if state.flapping && now.Sub(state.lastChange) >= d.stablePeriod(state) {
+		suppressed = state.suppressed
+		state.flapping, state.suppressed, state.transitions = false, 0, nil
+	}
+	if !state.firing {
+		d.transition(state, now)
+	}
+	if state.flapping {
+		state.suppressed++
+		return flapSuppress, 0
+	}
+	if len(state.transitions) >= d.threshold {
+		state.flapping = true
+		state.flaps++
+		return flapNotice, 0
+	}
+	return flapDeliver, suppressed
+}
+
+// resolve records that condition is no longer firing
+func (d *flapDetector) resolve(condition string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sweep(now)
+	state, ok := d.states[condition]
+	if !ok || !state.firing {
+		return
+	}
+	state.lastSeen = now
+	d.transition(state, now)
+	if !state.flapping && state.flaps == 0 && len(state.transitions) == 1 {
+		// A single fire and resolve is not worth remembering
+		delete(d.states, condition)
+	}
+}
+
+// flapNoticeMessage replaces the alert that made condition start flapping
+func (d *flapDetector) flapNoticeMessage(condition, message string) string {
+	d.mu.Lock()
+	state := d.states[condition]
+	changes, stable := len(state.transitions), d.stablePeriod(state)
+	d.mu.Unlock()
+	return fmt.Sprintf("Flapping: %s changed state %d times in %s; its alerts are suppressed until it is stable for %s.\n\nLatest alert: %s",
+		condition, changes, d.window, stable, message)
+}
+
+// recoveredMessage appends the number of alerts suppressed while the condition was flapping
+func recoveredMessage(message string, suppressed int) string {
+	if suppressed == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n\n(%d alerts were suppressed while this condition was flapping)", message, suppressed)
+}
+
+// Resolve records that the condition of alerts sent with SendOptions.Condition is no
+// longer firing, for flap detection, and unpins its pinned alerts. It sends nothing.
+func (l *Logger) Resolve(condition string) {
+	if condition == "" {
+		return
+	}
+	if l.flaps != nil {
+		l.flaps.resolve(condition, l.now())
+	}
+	l.unpinCondition(condition)
+}
+
+// FlapStats reports the conditions tracked by flap detection
+type FlapStats struct {
+	Conditions int   `json:"conditions"` // Conditions remembered
+	Flapping   int   `json:"flapping"`   // Conditions whose alerts are suppressed
+	Expired    int64 `json:"expired"`    // Conditions forgotten after their TTL, since the logger was created
+	Evicted    int64 `json:"evicted"`    // Conditions evicted because flap_max_conditions were tracked
+}
+
+// FlapStats returns the number of conditions tracked by flap detection and how many were
+// forgotten, for example to check that flap_max_conditions is large enough. It is zero
+// when flap_threshold is not set.
+func (l *Logger) FlapStats() FlapStats {
+	if l.flaps == nil {
+		return FlapStats{}
+	}
+	d := l.flaps
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sweep(l.now())
+	stats := FlapStats{Conditions: len(d.states), Expired: d.expired, Evicted: d.evicted}
+	for _, state := range d.states {
+		if state.flapping {
+			stats.Flapping++
+		}
+	}
+	return stats
+}
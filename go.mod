@@ -2,10 +2,25 @@ module github.com/alvianhanif/gocommonlog
 
 go 1.19
 
-require github.com/go-redis/redis/v8 v8.11.0
+require (
+	github.com/go-redis/redis/v8 v8.11.0
+	github.com/larksuite/oapi-sdk-go/v3 v3.12.0
+	github.com/segmentio/kafka-go v0.4.47
+	github.com/slack-go/slack v0.16.0
+	golang.org/x/net v0.17.0
+	google.golang.org/grpc v1.58.3
+	google.golang.org/protobuf v1.31.0
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
 	github.com/onsi/gomega v1.27.10 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
 )
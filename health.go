@@ -0,0 +1,320 @@
+package gocommonlog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Component health states reported by HealthCheck
+const (
+	HealthOK      = "ok"
+	HealthError   = "error"
+	HealthSkipped = "skipped" // The component has no way to be checked
+)
+
+// queueHealthLimit is the share of async_queue_size at which the queue turns unhealthy
+const queueHealthLimit = 0.9
+
+// ComponentHealth is the result of checking a single dependency
+type ComponentHealth struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	LatencyMs int64       `json:"latency_ms"`
+	Queue     *QueueStats `json:"queue,omitempty"` // Depth and capacity of the SendAsync queue, for the queue component
+}
+
+// HealthStatus is the structured result of Logger.HealthCheck
+type HealthStatus struct {
+	Healthy    bool              `json:"healthy"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// HealthCheck verifies that the configured provider is reachable with valid credentials
+// (auth.test for Slack, token fetch for Lark) and that Redis answers when configured.
+// Providers that do not implement types.HealthChecker are reported as skipped. The
+// SendAsync queue is reported with its depth and capacity, and is unhealthy once it is
+// 90% full.
+func (l *Logger) HealthCheck(ctx context.Context) HealthStatus {
+	status := HealthStatus{Healthy: true}
+	record := func(name string, check func() error) {
+		start := time.Now()
+		err := ctx.Err()
+		if err == nil {
+			err = check()
+		}
+		component := ComponentHealth{Name: name, Status: HealthOK, LatencyMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			component.Status = HealthError
+			component.Error = err.Error()
+			status.Healthy = false
+		}
+		types.DebugLog(l.config, "HealthCheck: %s status: %s", name, component.Status)
+		status.Components = append(status.Components, component)
+	}
+
+	providerName, _ := l.config.ProviderConfig["provider"].(string)
+	if checker, ok := l.provider.(types.HealthChecker); ok {
+		record("provider:"+providerName, func() error {
+			cfg, err := l.resolveSecrets(l.config)
+			if err != nil {
+				return err
+			}
+			return checker.HealthCheck(ctx, cfg)
+		})
+	} else {
+		status.Components = append(status.Components, ComponentHealth{Name: "provider:" + providerName, Status: HealthSkipped})
+	}
+
+	if host, _ := l.config.ProviderConfig["redis_host"].(string); host != "" {
+		record("redis", func() error { return providers.CheckRedis(ctx, l.config) })
+	}
+
+	queue := l.QueueStats()
+	component := ComponentHealth{Name: "queue", Status: HealthOK, Queue: &queue}
+	if float64(queue.Queued) >= queueHealthLimit*float64(queue.Capacity) {
+		component.Status = HealthError
+		component.Error = fmt.Sprintf("async queue is nearly full: %d of %d alerts queued", queue.Queued, queue.Capacity)
+		status.Healthy = false
+	}
+	types.DebugLog(l.config, "HealthCheck: queue status: %s", component.Status)
+	status.Components = append(status.Components, component)
+	return status
+}
+
+// CacheStats reports how well the token and lookup caches work
+type CacheStats struct {
+	Memory cache.Stats `json:"memory"` // Config.Cache or the global cache; Size is -1 when it does not count
+	Redis  cache.Stats `json:"redis"`  // Tokens and chat IDs cached in Redis when redis_host is set
+}
+
+// CacheStats returns the hit, miss and expiry counters of the caches used by providers,
+// for example to check that Lark tenant tokens are reused between alerts
+func (l *Logger) CacheStats() CacheStats {
+	store := l.config.Cache
+	if store == nil {
+		store = cache.GetGlobalCache()
+	}
+	memory, ok := cache.StatsOf(store)
+	if !ok {
+		memory.Size = -1
+	}
+	return CacheStats{Memory: memory, Redis: providers.RedisCacheStats()}
+}
+
+// verifyMessage is sent by Verify to providers that cannot be checked otherwise
+const verifyMessage = "commonlog verification: alert delivery is configured correctly"
+
+// Verify checks the provider for every channel the logger routes to, plus Redis when
+// configured, and returns an error listing each failed check so applications can fail
+// fast on wrong credentials at startup. Providers that do not implement
+// types.HealthChecker are only verified when verify_send is set, by sending a short
+// WARN test message to each channel. Routes and broadcast group members with their own
+// channel are verified with their own provider and settings.
+func (l *Logger) Verify(ctx context.Context) error {
+	var problems []string
+	if l.configErr != nil {
+		problems = append(problems, l.configErr.Error())
+	}
+	verifySend, _ := l.config.ProviderConfig["verify_send"].(bool)
+	check := func(provider types.Provider, cfg types.Config, label, channel string) {
+		cfg.Channel = channel
+		cfg, err := l.resolveSecrets(cfg)
+		if err == nil {
+			if checker, ok := provider.(types.HealthChecker); ok {
+				err = checker.HealthCheck(ctx, cfg)
+			} else if verifySend {
+				err = provider.SendToChannel(types.WARN, verifyMessage, nil, cfg, channel)
+			} else {
+				return
+			}
+		}
+		if err != nil {
+			types.DebugLog(l.config, "Verify: %s failed: %v", label, err)
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+		}
+	}
+
+	for _, channel := range l.configuredChannels() {
+		check(l.provider, l.config, fmt.Sprintf("channel '%s'", channel), channel)
+	}
+	for i := range l.routes {
+		route := &l.routes[i]
+		if route.Channel != "" {
+			check(route.provider, route.apply(l.config), fmt.Sprintf("route '%s' channel '%s'", route.Name, route.Channel), route.Channel)
+		}
+	}
+	for _, name := range sortedGroupNames(l.groups) {
+		for i := range l.groups[name] {
+			member := &l.groups[name][i]
+			if member.Channel != "" {
+				check(member.provider, member.apply(l.config), fmt.Sprintf("group '%s' channel '%s'", name, member.Channel), member.Channel)
+			}
+		}
+	}
+
+	if host, _ := l.config.ProviderConfig["redis_host"].(string); host != "" {
+		if err := providers.CheckRedis(ctx, l.config); err != nil {
+			problems = append(problems, fmt.Sprintf("redis: %v", err))
+		}
+	}
+
+	if len(problems) > 0 {
+		providerName, _ := l.config.ProviderConfig["provider"].(string)
+		return fmt.Errorf("logger verification failed for provider %s: %s", providerName, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// PrefetchChannels resolves the IDs of every channel the logger routes to and caches them,
+// so the first alert to each channel is not delayed by a lookup. Call it at startup after
+// NewLogger; it is optional, since lookups are cached on first use as well. Providers that
+// do not implement types.ChannelPrefetcher are skipped.
+func (l *Logger) PrefetchChannels(ctx context.Context) error {
+	var problems []string
+	prefetch := func(provider types.Provider, cfg types.Config, label string, channels []string) {
+		prefetcher, ok := provider.(types.ChannelPrefetcher)
+		if !ok {
+			return
+		}
+		cfg, err := l.resolveSecrets(cfg)
+		if err == nil {
+			err = prefetcher.PrefetchChannels(ctx, cfg, channels)
+		}
+		if err != nil {
+			types.DebugLog(l.config, "PrefetchChannels: %s failed: %v", label, err)
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+		}
+	}
+
+	var channels []string
+	for _, channel := range l.configuredChannels() {
+		if channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	providerName, _ := l.config.ProviderConfig["provider"].(string)
+	if len(channels) > 0 {
+		prefetch(l.provider, l.config, "provider "+providerName, channels)
+	}
+	for i := range l.routes {
+		route := &l.routes[i]
+		if route.Channel != "" {
+			prefetch(route.provider, route.apply(l.config), fmt.Sprintf("route '%s'", route.Name), []string{route.Channel})
+		}
+	}
+	for _, name := range sortedGroupNames(l.groups) {
+		for i := range l.groups[name] {
+			member := &l.groups[name][i]
+			if member.Channel != "" {
+				prefetch(member.provider, member.apply(l.config), fmt.Sprintf("group '%s'", name), []string{member.Channel})
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("failed to prefetch channels: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Warmup gets the logger ready for its first alert, so it is not delayed by the setup
+// that is otherwise done on first use: providers implementing types.Warmer fetch their
+// tokens, such as the Lark tenant token, and open keep-alive connections to their API,
+// channel IDs are resolved as by PrefetchChannels, and Redis is pinged when configured.
+// Call it at startup after NewLogger. Idle connections are closed by the HTTP transport
+// after its IdleConnTimeout, 90 seconds by default, so warming up long before the first
+// alert only saves the token and channel lookups.
+func (l *Logger) Warmup(ctx context.Context) error {
+	if l.configErr != nil {
+		return l.configErr
+	}
+	var problems []string
+	warm := func(provider types.Provider, cfg types.Config, label string) {
+		warmer, ok := provider.(types.Warmer)
+		if !ok {
+			return
+		}
+		cfg, err := l.resolveSecrets(cfg)
+		if err == nil {
+			err = warmer.Warmup(ctx, cfg)
+		}
+		if err != nil {
+			types.DebugLog(l.config, "Warmup: %s failed: %v", label, err)
+			problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+		}
+	}
+
+	providerName, _ := l.config.ProviderConfig["provider"].(string)
+	warm(l.provider, l.config, "provider "+providerName)
+	for i := range l.routes {
+		route := &l.routes[i]
+		if route.Channel != "" {
+			warm(route.provider, route.apply(l.config), fmt.Sprintf("route '%s'", route.Name))
+		}
+	}
+	for _, name := range sortedGroupNames(l.groups) {
+		for i := range l.groups[name] {
+			member := &l.groups[name][i]
+			if member.Channel != "" {
+				warm(member.provider, member.apply(l.config), fmt.Sprintf("group '%s'", name))
+			}
+		}
+	}
+
+	if err := l.PrefetchChannels(ctx); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if host, _ := l.config.ProviderConfig["redis_host"].(string); host != "" {
+		if err := providers.CheckRedis(ctx, l.config); err != nil {
+			problems = append(problems, fmt.Sprintf("redis: %v", err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("warmup failed: %s", strings.Join(problems, "; "))
+	}
+	types.DebugLog(l.config, "Warmup: logger is ready for provider %s", providerName)
+	return nil
+}
+
+// sortedGroupNames returns the broadcast group names in order, so checks report them
+// deterministically
+func sortedGroupNames(groups map[string][]compiledRoute) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configuredChannels returns the distinct channels the logger can route to
+func (l *Logger) configuredChannels() []string {
+	candidates := []string{l.config.Channel}
+	if resolver, ok := l.config.ChannelResolver.(*types.DefaultChannelResolver); ok {
+		candidates = append(candidates, resolver.DefaultChannel)
+		for _, level := range []int{types.WARN, types.ERROR} {
+			candidates = append(candidates, resolver.ChannelMap[level])
+		}
+	}
+	seen := make(map[string]bool)
+	channels := make([]string, 0, len(candidates))
+	for _, channel := range candidates {
+		if channel != "" && !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		channels = append(channels, "")
+	}
+	return channels
+}
@@ -0,0 +1,61 @@
+// Package echoalert adapts httpalert to Echo, alerting on 5xx responses and recovering
+// handler panics. It is a separate module so the gocommonlog module does not depend on Echo.
+package echoalert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/httpalert"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns an Echo middleware sending the alerts of a, in place of
+// middleware.Recover:
+//
+//	e := echo.New()
+//	e.Use(echoalert.Middleware(httpalert.New(logger)))
+func Middleware(a *httpalert.Alerter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			start := time.Now()
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				a.Panic(request(c, http.StatusInternalServerError, start), recovered)
+				err = echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprint(recovered))
+			}()
+			err = next(c)
+			status := c.Response().Status
+			if err != nil {
+				// The error handler writes the response after the middlewares return
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			req := request(c, status, start)
+			if err != nil {
+				req.Error = err.Error()
+			}
+			a.Response(req)
+			return err
+		}
+	}
+}
+
+// request describes the request of c
+func request(c echo.Context, status int, start time.Time) httpalert.Request {
+	req := httpalert.FromRequest(c.Request())
+	req.Route, req.RemoteAddr = c.Path(), c.RealIP()
+	req.Status, req.Duration = status, time.Since(start)
+	return req
+}
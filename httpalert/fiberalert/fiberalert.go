@@ -0,0 +1,63 @@
+// Package fiberalert adapts httpalert to Fiber, alerting on 5xx responses and recovering
+// handler panics. It is a separate module so the gocommonlog module does not depend on Fiber.
+package fiberalert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/httpalert"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Middleware returns a Fiber middleware sending the alerts of a, in place of the recover
+// middleware:
+//
+//	app := fiber.New()
+//	app.Use(fiberalert.Middleware(httpalert.New(logger)))
+func Middleware(a *httpalert.Alerter) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		start := time.Now()
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			a.Panic(request(c, fiber.StatusInternalServerError, start), recovered)
+			err = fiber.NewError(fiber.StatusInternalServerError, fmt.Sprint(recovered))
+		}()
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler writes the response after the middlewares return
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		req := request(c, status, start)
+		if err != nil {
+			req.Error = err.Error()
+		}
+		a.Response(req)
+		return err
+	}
+}
+
+// request describes the request of c. Fiber reuses the memory of its strings once the
+// handler returns, so they are copied.
+func request(c *fiber.Ctx, status int, start time.Time) httpalert.Request {
+	return httpalert.Request{
+		Method:     c.Method(),
+		Path:       utils.CopyString(c.Path()),
+		Route:      c.Route().Path,
+		RemoteAddr: utils.CopyString(c.IP()),
+		UserAgent:  utils.CopyString(c.Get(fiber.HeaderUserAgent)),
+		RequestID:  utils.CopyString(c.Get(fiber.HeaderXRequestID)),
+		Status:     status,
+		Duration:   time.Since(start),
+	}
+}
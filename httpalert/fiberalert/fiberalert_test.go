@@ -0,0 +1,59 @@
+package fiberalert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/httpalert"
+	"github.com/alvianhanif/gocommonlog/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+type captureProvider struct {
+	messages []string
+	fields   []map[string]string
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.messages = append(p.messages, message)
+	p.fields = append(p.fields, cfg.Fields)
+	return nil
+}
+
+func newAlerter(name string) (*httpalert.Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return httpalert.New(logger, httpalert.WithSampleWindow(0)), capture
+}
+
+func TestMiddleware(t *testing.T) {
+	alerter, capture := newAlerter("fiber-capture")
+	app := fiber.New()
+	app.Use(Middleware(alerter))
+	app.Get("/orders/:id", func(c *fiber.Ctx) error { return fiber.NewError(fiber.StatusBadGateway, "db down") })
+	app.Get("/panic", func(c *fiber.Ctx) error { panic("nil order") })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected the handler's 502, got %v, %v", resp, err)
+	}
+	if len(capture.messages) != 1 || capture.messages[0] != "HTTP 502 on GET /orders/1: db down" {
+		t.Fatalf("Unexpected alerts %q", capture.messages)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after a panic, got %v, %v", resp, err)
+	}
+	if len(capture.messages) != 2 || !strings.HasPrefix(capture.messages[1], "Panic on GET /panic: nil order") {
+		t.Errorf("Expected a panic alert, got %q", capture.messages)
+	}
+}
@@ -0,0 +1,50 @@
+// Package ginalert adapts httpalert to Gin, alerting on 5xx responses and recovering
+// handler panics. It is a separate module so the gocommonlog module does not depend on Gin.
+package ginalert
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/httpalert"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns a Gin middleware sending the alerts of a. Register it before other
+// middlewares so it also recovers their panics, in place of gin.Recovery:
+//
+//	router := gin.New()
+//	router.Use(ginalert.Middleware(httpalert.New(logger)))
+func Middleware(a *httpalert.Alerter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			a.Panic(request(c, http.StatusInternalServerError, start), recovered)
+			if c.Writer.Written() {
+				c.Abort()
+			} else {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+		}()
+		c.Next()
+		a.Response(request(c, c.Writer.Status(), start))
+	}
+}
+
+// request describes the request of c with the last handler error
+func request(c *gin.Context, status int, start time.Time) httpalert.Request {
+	req := httpalert.FromRequest(c.Request)
+	req.Route, req.RemoteAddr = c.FullPath(), c.ClientIP()
+	req.Status, req.Duration = status, time.Since(start)
+	if err := c.Errors.Last(); err != nil {
+		req.Error = err.Error()
+	}
+	return req
+}
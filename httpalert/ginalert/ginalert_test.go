@@ -0,0 +1,61 @@
+package ginalert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/httpalert"
+	"github.com/alvianhanif/gocommonlog/types"
+	"github.com/gin-gonic/gin"
+)
+
+type captureProvider struct {
+	messages []string
+	fields   []map[string]string
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.messages = append(p.messages, message)
+	p.fields = append(p.fields, cfg.Fields)
+	return nil
+}
+
+func newAlerter(name string) (*httpalert.Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return httpalert.New(logger, httpalert.WithSampleWindow(0)), capture
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alerter, capture := newAlerter("gin-capture")
+	router := gin.New()
+	router.Use(Middleware(alerter))
+	router.GET("/orders/:id", func(c *gin.Context) { c.String(http.StatusBadGateway, "db down") })
+	router.GET("/panic", func(c *gin.Context) { panic("nil order") })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+	if len(capture.messages) != 1 || capture.messages[0] != "HTTP 502 on GET /orders/1" {
+		t.Fatalf("Unexpected alerts %q", capture.messages)
+	}
+	if capture.fields[0]["http.route"] != "/orders/:id" {
+		t.Errorf("Expected the route pattern, got %v", capture.fields[0])
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after a panic, got %d", rec.Code)
+	}
+	if len(capture.messages) != 2 || !strings.HasPrefix(capture.messages[1], "Panic on GET /panic: nil order") {
+		t.Errorf("Expected a panic alert, got %q", capture.messages)
+	}
+}
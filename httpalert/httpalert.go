@@ -0,0 +1,274 @@
+// Package httpalert alerts on HTTP 5xx responses and handler panics with the request's
+// context. Middleware wraps net/http handlers; the ginalert, echoalert and fiberalert
+// modules adapt the same Alerter to Gin, Echo and Fiber. Alerts are sampled per route
+// and status so a failing endpoint sends one alert per window instead of one per request.
+package httpalert
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultWindow is how long alerts for the same route and status are sampled
+const defaultWindow = time.Minute
+
+// maxSampleKeys bounds the routes and statuses tracked for sampling
+const maxSampleKeys = 1000
+
+// Request describes the request an alert is about
+type Request struct {
+	Method     string
+	Path       string        // Request path
+	Route      string        // Route pattern such as /orders/:id, used for sampling; defaults to Path
+	RemoteAddr string        // Client address
+	UserAgent  string        // User-Agent header
+	RequestID  string        // X-Request-ID header, used as the correlation ID
+	Status     int           // Response status
+	Duration   time.Duration // Time spent handling the request
+	Error      string        // Error returned by the handler, if any
+}
+
+// FromRequest returns the Request of r without its status and duration
+func FromRequest(r *http.Request) Request {
+	return Request{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		RequestID:  r.Header.Get("X-Request-ID"),
+	}
+}
+
+// Option customizes an Alerter
+type Option func(*Alerter)
+
+// WithMinStatus alerts on responses with at least status instead of 500
+func WithMinStatus(status int) Option {
+	return func(a *Alerter) { a.minStatus = status }
+}
+
+// WithSampleWindow sends at most one alert per route and status every window instead of
+// every minute; 0 sends an alert for every failing request
+func WithSampleWindow(window time.Duration) Option {
+	return func(a *Alerter) { a.window = window }
+}
+
+// WithSendOptions sets the options of the alerts, such as their channel
+func WithSendOptions(opts types.SendOptions) Option {
+	return func(a *Alerter) { a.send = opts }
+}
+
+// Alerter sends the alerts of the HTTP middlewares through a Logger
+type Alerter struct {
+	logger    *gocommonlog.Logger
+	clock     types.Clock
+	minStatus int
+	window    time.Duration
+	send      types.SendOptions
+
+	mu      sync.Mutex
+	samples map[string]*sample
+}
+
+// sample tracks the alerts of one route and status within a window
+type sample struct {
+	sent       time.Time
+	suppressed int
+}
+
+// New creates an Alerter sending through logger
+func New(logger *gocommonlog.Logger, options ...Option) *Alerter {
+	a := &Alerter{
+		logger:    logger,
+		clock:     logger.Config().Clock,
+		minStatus: http.StatusInternalServerError,
+		window:    defaultWindow,
+		samples:   make(map[string]*sample),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// Response alerts on a response with a status of at least the minimum status, as an
+// ERROR alert for 5xx statuses and a WARN alert otherwise
+func (a *Alerter) Response(req Request) {
+	if req.Status < a.minStatus {
+		return
+	}
+	level := types.ERROR
+	if req.Status < http.StatusInternalServerError {
+		level = types.WARN
+	}
+	message := fmt.Sprintf("HTTP %d on %s %s", req.Status, req.Method, req.Path)
+	if req.Error != "" {
+		message += ": " + req.Error
+	}
+	a.alert(level, strconv.Itoa(req.Status), message, "", req)
+}
+
+// Panic sends an ERROR alert for a panic recovered while handling req, with its stack as
+// the trace; call it from the deferred function recovering the panic
+func (a *Alerter) Panic(req Request, recovered interface{}) {
+	message := fmt.Sprintf("Panic on %s %s: %v", req.Method, req.Path, recovered)
+	a.alert(types.ERROR, "panic", message, a.logger.Stack(1), req)
+}
+
+// Middleware alerts on the 5xx responses of next and recovers its panics, answering 500
+func (a *Alerter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := a.now()
+		req := FromRequest(r)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			req.Status, req.Duration = http.StatusInternalServerError, a.now().Sub(start)
+			a.Panic(req, recovered)
+			if !rec.written {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		req.Status, req.Duration = rec.status, a.now().Sub(start)
+		a.Response(req)
+	})
+}
+
+// alert sends one alert unless its route and outcome was already alerted on within the
+// sampling window, adding the number of alerts suppressed since the last one sent
+func (a *Alerter) alert(level int, outcome, message, trace string, req Request) {
+	route := req.Route
+	if route == "" {
+		route = req.Path
+	}
+	suppressed, ok := a.sample(req.Method + " " + route + " " + outcome)
+	if !ok {
+		types.DebugLog(a.logger.Config(), "httpalert: suppressed alert for %s %s (%s)", req.Method, route, outcome)
+		return
+	}
+	if suppressed > 0 {
+		message += fmt.Sprintf("\n\n(%d more alerts for %s %s were suppressed since the last one sent)", suppressed, req.Method, route)
+	}
+
+	opts := a.send
+	opts.Trace = trace
+	opts.CorrelationID = req.RequestID
+	opts.Fields = make(map[string]string, len(a.send.Fields)+7)
+	for key, value := range a.send.Fields {
+		opts.Fields[key] = value
+	}
+	for key, value := range map[string]string{
+		"http.method":      req.Method,
+		"http.path":        req.Path,
+		"http.route":       route,
+		"http.status":      strconv.Itoa(req.Status),
+		"http.duration":    req.Duration.Round(time.Millisecond).String(),
+		"http.remote_addr": req.RemoteAddr,
+		"http.user_agent":  req.UserAgent,
+	} {
+		if value != "" {
+			opts.Fields[key] = value
+		}
+	}
+	if _, err := a.logger.SendWithOptions(level, message, opts); err != nil {
+		log.Printf("[ERROR] httpalert: failed to send alert for %s %s: %v", req.Method, route, err)
+	}
+}
+
+// sample reports whether an alert for key is sent and, if so, how many alerts for it were
+// suppressed since the last one sent
+func (a *Alerter) sample(key string) (int, bool) {
+	if a.window <= 0 {
+		return 0, true
+	}
+	now := a.now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.samples[key]
+	if ok && now.Sub(s.sent) < a.window {
+		s.suppressed++
+		return 0, false
+	}
+	if !ok {
+		if len(a.samples) >= maxSampleKeys {
+			a.prune(now)
+		}
+		if len(a.samples) >= maxSampleKeys {
+			a.evictOldest()
+		}
+		s = &sample{}
+		a.samples[key] = s
+	}
+	suppressed := s.suppressed
+	s.sent, s.suppressed = now, 0
+	return suppressed, true
+}
+
+// prune forgets the keys whose window ended
+func (a *Alerter) prune(now time.Time) {
+	for key, s := range a.samples {
+		if now.Sub(s.sent) >= a.window {
+			delete(a.samples, key)
+		}
+	}
+}
+
+// evictOldest forgets the key whose alert was sent longest ago, for when every key is
+// still within its window
+func (a *Alerter) evictOldest() {
+	oldest := ""
+	var sent time.Time
+	for key, s := range a.samples {
+		if oldest == "" || s.sent.Before(sent) {
+			oldest, sent = key, s.sent
+		}
+	}
+	delete(a.samples, oldest)
+}
+
+func (a *Alerter) now() time.Time {
+	if a.clock != nil {
+		return a.clock.Now()
+	}
+	return time.Now()
+}
+
+// statusRecorder records the status written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.written {
+		r.status, r.written = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.written = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,141 @@
+package httpalert
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type sentAlert struct {
+	level      int
+	message    string
+	attachment *types.Attachment
+	cfg        types.Config
+}
+
+type captureProvider struct {
+	alerts []sentAlert
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.alerts = append(p.alerts, sentAlert{level, message, attachment, cfg})
+	return nil
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestAlerter(t *testing.T, name string, options ...Option) (*Alerter, *captureProvider, *testClock) {
+	capture := &captureProvider{}
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"}, gocommonlog.WithClock(clock))
+	return New(logger, options...), capture, clock
+}
+
+func TestMiddlewareAlertsOnServerErrorsAndPanics(t *testing.T) {
+	alerter, capture, _ := newTestAlerter(t, "httpalert-capture")
+	handler := alerter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/broken":
+			http.Error(w, "db down", http.StatusBadGateway)
+		case "/panic":
+			panic("nil order")
+		}
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("User-Agent", "curl/8.0")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if serve("/missing").Code != http.StatusNotFound || len(capture.alerts) != 0 {
+		t.Fatalf("Expected no alert for a 404, got %+v", capture.alerts)
+	}
+
+	serve("/broken")
+	if len(capture.alerts) != 1 {
+		t.Fatalf("Expected an alert for a 502, got %d", len(capture.alerts))
+	}
+	alert := capture.alerts[0]
+	if alert.level != types.ERROR || alert.message != "HTTP 502 on GET /broken" {
+		t.Errorf("Unexpected alert %d %q", alert.level, alert.message)
+	}
+	fields := alert.cfg.Fields
+	if fields["http.status"] != "502" || fields["http.route"] != "/broken" || fields["http.user_agent"] != "curl/8.0" || fields["http.remote_addr"] == "" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+	if alert.cfg.CorrelationID != "req-1" {
+		t.Errorf("Expected the request ID as correlation ID, got %q", alert.cfg.CorrelationID)
+	}
+
+	if rec := serve("/panic"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after a panic, got %d", rec.Code)
+	}
+	if len(capture.alerts) != 2 {
+		t.Fatalf("Expected an alert for the panic, got %d", len(capture.alerts))
+	}
+	alert = capture.alerts[1]
+	if alert.level != types.ERROR || alert.message != "Panic on GET /panic: nil order" {
+		t.Errorf("Unexpected panic alert %d %q", alert.level, alert.message)
+	}
+	if alert.attachment == nil || !strings.HasPrefix(alert.attachment.Content, "github.com/alvianhanif/gocommonlog/httpalert.TestMiddlewareAlertsOnServerErrorsAndPanics.func") {
+		t.Errorf("Expected the panic stack as trace, got %+v", alert.attachment)
+	}
+}
+
+func TestAlerterSamplesPerRouteAndStatus(t *testing.T) {
+	alerter, capture, clock := newTestAlerter(t, "httpalert-sampled", WithSampleWindow(time.Minute), WithMinStatus(http.StatusTooManyRequests))
+	failing := Request{Method: http.MethodGet, Path: "/orders/1", Route: "/orders/:id", Status: http.StatusServiceUnavailable}
+
+	alerter.Response(failing)
+	failing.Path = "/orders/2"
+	alerter.Response(failing)
+	alerter.Response(failing)
+	alerter.Response(Request{Method: http.MethodGet, Path: "/orders/3", Route: "/orders/:id", Status: http.StatusTooManyRequests})
+	if len(capture.alerts) != 2 {
+		t.Fatalf("Expected one alert per route and status, got %d", len(capture.alerts))
+	}
+	if capture.alerts[1].level != types.WARN {
+		t.Errorf("Expected a WARN alert for a 429, got %d", capture.alerts[1].level)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	alerter.Response(failing)
+	if len(capture.alerts) != 3 {
+		t.Fatalf("Expected an alert once the window ended, got %d", len(capture.alerts))
+	}
+	if message := capture.alerts[2].message; !strings.Contains(message, "2 more alerts for GET /orders/:id were suppressed") {
+		t.Errorf("Expected the suppressed count, got %q", message)
+	}
+
+	// Keys still within their window are evicted oldest first once the cap is reached
+	for i := 0; i < maxSampleKeys; i++ {
+		clock.now = clock.now.Add(time.Millisecond)
+		alerter.sample(fmt.Sprintf("GET /items/%d 500", i))
+	}
+	if _, ok := alerter.sample("GET /new 500"); !ok || len(alerter.samples) != maxSampleKeys {
+		t.Fatalf("Expected the new key to be tracked within the cap, got %d keys", len(alerter.samples))
+	}
+	if _, ok := alerter.samples["GET /items/0 500"]; ok {
+		t.Error("Expected the oldest key to be evicted")
+	}
+}
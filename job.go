@@ -0,0 +1,86 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// JobOption customizes the alerts RunJob sends
+type JobOption func(*jobOptions)
+
+type jobOptions struct {
+	warnAfter time.Duration
+	onSuccess bool
+	send      types.SendOptions
+}
+
+// WithWarnAfter sends a WARN alert when a successful job runs longer than d
+func WithWarnAfter(d time.Duration) JobOption {
+	return func(o *jobOptions) { o.warnAfter = d }
+}
+
+// WithSuccessAlert sends an INFO alert when the job succeeds, which reaches the chat
+// only when the INFO level policy sends it
+func WithSuccessAlert() JobOption {
+	return func(o *jobOptions) { o.onSuccess = true }
+}
+
+// WithJobSendOptions sets the options of the job's alerts, such as their channel or links
+func WithJobSendOptions(opts types.SendOptions) JobOption {
+	return func(o *jobOptions) { o.send = opts }
+}
+
+// RunJob runs a batch job and alerts on its outcome: an ERROR alert when fn returns an
+// error or panics, with the panic's stack as the trace, a WARN alert when it succeeds
+// but runs longer than WithWarnAfter, and an INFO alert on success with
+// WithSuccessAlert. Failures use the condition "job:<name>", which a later success
+// resolves, so a job failing on every other run is caught by flap detection. It returns
+// fn's error, or the recovered panic as an error.
+func RunJob(l *Logger, name string, fn func() error, options ...JobOption) error {
+	var o jobOptions
+	for _, option := range options {
+		option(&o)
+	}
+
+	start := l.now()
+	trace, err := runRecovered(l, fn)
+	elapsed := l.now().Sub(start).Round(time.Millisecond)
+	condition := "job:" + name
+
+	opts := o.send
+	var level int
+	var message string
+	switch {
+	case err != nil:
+		level, message = types.ERROR, fmt.Sprintf("Job %s failed after %s: %v", name, elapsed, err)
+		opts.Trace, opts.Condition = trace, condition
+	case o.warnAfter > 0 && elapsed > o.warnAfter:
+		l.Resolve(condition)
+		level, message = types.WARN, fmt.Sprintf("Job %s took %s, longer than %s", name, elapsed, o.warnAfter)
+	case o.onSuccess:
+		l.Resolve(condition)
+		level, message = types.INFO, fmt.Sprintf("Job %s succeeded in %s", name, elapsed)
+	default:
+		l.Resolve(condition)
+		types.DebugLog(l.config, "Job %s succeeded in %s", name, elapsed)
+		return nil
+	}
+
+	if _, sendErr := l.SendWithOptions(level, message, opts); sendErr != nil {
+		log.Printf("[ERROR] Failed to send alert for job %s: %v", name, sendErr)
+	}
+	return err
+}
+
+// runRecovered calls fn, turning a panic into an error and returning its stack
+func runRecovered(l *Logger, fn func() error) (trace string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			trace, err = l.Stack(0), fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return "", fn()
+}
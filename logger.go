@@ -1,8 +1,17 @@
 package gocommonlog
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/alvianhanif/gocommonlog/cache"
 	"github.com/alvianhanif/gocommonlog/providers"
 	"github.com/alvianhanif/gocommonlog/types"
 )
@@ -11,30 +20,222 @@ import (
 // Main Logger
 // ====================
 
+// providerRegistry maps provider names to their constructors
+var (
+	providerRegistry = map[string]func() types.Provider{
+		"slack":          func() types.Provider { return &providers.SlackProvider{} },
+		"lark":           func() types.Provider { return &providers.LarkProvider{} },
+		"genericwebhook": func() types.Provider { return &providers.GenericWebhookProvider{} },
+		"kafka":          func() types.Provider { return &providers.KafkaProvider{} },
+		"sentry":         func() types.Provider { return &providers.SentryProvider{} },
+		"webex":          func() types.Provider { return &providers.WebexProvider{} },
+		"twilio":         func() types.Provider { return &providers.TwilioProvider{} },
+		"ntfy":           func() types.Provider { return &providers.NtfyProvider{} },
+		"gotify":         func() types.Provider { return &providers.GotifyProvider{} },
+		"zulip":          func() types.Provider { return &providers.ZulipProvider{} },
+		"matrix":         func() types.Provider { return &providers.MatrixProvider{} },
+		"github":         func() types.Provider { return &providers.GitHubProvider{} },
+		"syslog":         func() types.Provider { return &providers.SyslogProvider{} },
+		"elasticsearch":  func() types.Provider { return &providers.ElasticsearchProvider{} },
+		"cloudwatch":     func() types.Provider { return &providers.CloudWatchProvider{} },
+		"pubsub":         func() types.Provider { return &providers.PubSubProvider{} },
+	}
+	providerRegistryMu sync.RWMutex
+)
+
+// RegisterProvider makes a provider available by name to NewLogger and CustomSend,
+// replacing any provider previously registered under that name
+func RegisterProvider(name string, factory func() types.Provider) {
+	providerRegistryMu.Lock()
+	defer providerRegistryMu.Unlock()
+	providerRegistry[name] = factory
+}
+
+// providerRegistered reports whether a provider is registered under name
+func providerRegistered(name string) bool {
+	providerRegistryMu.RLock()
+	defer providerRegistryMu.RUnlock()
+	_, ok := providerRegistry[name]
+	return ok
+}
+
+// registeredProviders returns the registered provider names in order
+func registeredProviders() []string {
+	providerRegistryMu.RLock()
+	defer providerRegistryMu.RUnlock()
+	names := make([]string, 0, len(providerRegistry))
+	for name := range providerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // createProvider creates a provider instance by name
 func createProvider(providerName string) types.Provider {
-	switch providerName {
-	case "slack":
-		return &providers.SlackProvider{}
-	case "lark":
-		return &providers.LarkProvider{}
-	default:
+	providerRegistryMu.RLock()
+	factory, ok := providerRegistry[providerName]
+	providerRegistryMu.RUnlock()
+	if !ok {
 		return &providers.SlackProvider{}
 	}
+	return factory()
 }
 
+// ErrLoggerClosed is returned by sends made after Close
+var ErrLoggerClosed = errors.New("gocommonlog: logger is closed")
+
 // Logger is the main struct
 type Logger struct {
 	config   types.Config
 	provider types.Provider
+
+	closeMu  sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup // sends in progress, awaited by Close
+
+	scheduleMu sync.Mutex
+	scheduled  map[*ScheduledAlert]struct{} // alerts pending from SendAt/SendAfter
+
+	routes   []compiledRoute            // Config.Routes with their providers created
+	groups   map[string][]compiledRoute // Config.Groups with their providers created
+	sampler  *warnSampler               // nil unless WARN sampling is configured
+	scrubber *scrubber                  // nil unless scrub_pii or scrub_patterns is set
+	flaps    *flapDetector              // nil unless flap detection is configured
+	storm    *stormValve                // nil unless storm_threshold is set
+	monitor  *selfMonitor               // nil unless self_monitor_threshold is set
+	digests  []*digestJob               // scheduled summary reports from Config.Digests
+	budget   *alertBudget               // nil unless alert_budget or alert_budgets is set
+	queue    *alertQueue                // alerts accepted by SendAsync
+	secrets  *secretCache               // resolved secret references
+	counters pipelineCounters           // alerts by outcome, reported by Stats
+
+	eventsMu     sync.RWMutex
+	subscribers  map[chan types.Event]struct{} // channels returned by Subscribe
+	eventsClosed bool                          // set by Close, which closes the subscribers
+
+	clockCache *cache.InMemoryCache // created for Config.Clock when Config.Cache is not set, closed by Close
+
+	configErr error // configuration problem found by NewLogger, returned by every send
+	strict    bool  // created by NewStrictLogger; unknown providers fail sends
+
+	ackMu     sync.Mutex
+	followUps map[string][]*ScheduledAlert // pending ack reminders and escalations by alert ID
+
+	postedMu sync.Mutex
+	posted   map[string][]postedMessage // messages React can add reactions to, by alert ID
+
+	mirrorMu sync.Mutex // serializes writes to Config.MirrorWriter
+
+	kafkaMu   sync.Mutex
+	kafkaKeys map[string]struct{} // shared Kafka writers this logger retains, released by Close
+
+	muteMu      sync.Mutex
+	muteUntil   time.Time   // set by Mute
+	muteReason  string
+	mutedCount  int         // alerts muted since the last summary
+	mutedReason string      // reason of the latest muted alert, for the summary
+	muteTimer   types.Timer // sends the summary when the mute ends
 }
 
-// NewLogger creates a new Logger with the appropriate provider
-func NewLogger(cfg types.Config) *Logger {
-	// Populate ProviderConfig with top-level fields for backward compatibility
-	if cfg.ProviderConfig == nil {
-		cfg.ProviderConfig = make(map[string]interface{})
+// NewLogger creates a new Logger with the appropriate provider. Options inject
+// dependencies such as the provider, cache, HTTP client and clock. Unknown provider names
+// fall back to Slack with a warning; use NewStrictLogger to reject them.
+func NewLogger(cfg types.Config, options ...Option) *Logger {
+	logger, _ := newLogger(cfg, false, options)
+	return logger
+}
+
+// NewStrictLogger creates a Logger like NewLogger, but returns an error instead of a
+// logger when the configuration has a problem: an unknown provider name or send method,
+// in the logger, its routes or its broadcast groups, an unset environment variable or an
+// invalid cache encryption key. Sends through CustomSend or SendOptions.Provider with an
+// unknown provider fail instead of falling back to Slack.
+func NewStrictLogger(cfg types.Config, options ...Option) (*Logger, error) {
+	return newLogger(cfg, true, options)
+}
+
+// newLogger creates a Logger, returning the configuration problems it found. Unless strict,
+// they are logged and the logger is returned anyway.
+func newLogger(cfg types.Config, strict bool, options []Option) (*Logger, error) {
+	var injected loggerOptions
+	for _, option := range options {
+		option(&injected)
+	}
+	injected.apply(&cfg)
+	var clockCache *cache.InMemoryCache
+	if cfg.Clock != nil && cfg.Cache == nil {
+		// TTLs follow the clock, which the shared global cache cannot
+		clockCache = cache.NewInMemoryCacheWithClock(cfg.Clock.Now)
+		cfg.Cache = clockCache
+	}
+
+	// Copy the maps so the logger's configuration is immutable after construction and
+	// safe to read from concurrent sends, even if the caller keeps mutating theirs
+	providerConfig := make(map[string]interface{}, len(cfg.ProviderConfig)+4)
+	for key, value := range cfg.ProviderConfig {
+		providerConfig[key] = value
+	}
+	cfg.ProviderConfig = providerConfig
+	if cfg.Fields != nil {
+		fields := make(map[string]string, len(cfg.Fields))
+		for key, value := range cfg.Fields {
+			fields[key] = value
+		}
+		cfg.Fields = fields
+	}
+	if cfg.LevelPolicies != nil {
+		policies := make(map[int]types.LevelPolicy, len(cfg.LevelPolicies))
+		for level, policy := range cfg.LevelPolicies {
+			switch policy {
+			case types.PolicyLocalOnly, types.PolicySendOnly, types.PolicyBoth, types.PolicyDrop:
+				policies[level] = policy
+			default:
+				log.Printf("[WARN] Unknown level policy %q for %s alerts, using the default", policy, types.LevelName(level))
+			}
+		}
+		cfg.LevelPolicies = policies
+	}
+	if cfg.Sections != nil {
+		sections := make([]string, 0, len(cfg.Sections))
+		for _, section := range cfg.Sections {
+			if types.KnownSection(section) {
+				sections = append(sections, section)
+			} else {
+				log.Printf("[WARN] Unknown message section %q, ignoring it", section)
+			}
+		}
+		cfg.Sections = sections
 	}
+	configErr := expandEnv(&cfg)
+	if configErr != nil {
+		log.Printf("[ERROR] %v; alerts will fail until they are set", configErr)
+	}
+	if err := checkCacheEncryptionKey(cfg); err != nil && configErr == nil {
+		log.Printf("[ERROR] %v; alerts will fail until it is fixed", err)
+		configErr = err
+	}
+	scrubber, err := newScrubber(cfg)
+	if err != nil && configErr == nil {
+		// Alerts could leak personal data, so they fail rather than go out unscrubbed
+		log.Printf("[ERROR] %v; alerts will fail until it is fixed", err)
+		configErr = err
+	}
+	queue, err := newAlertQueue(cfg)
+	if err != nil && configErr == nil {
+		log.Printf("[ERROR] %v; alerts will fail until it is fixed", err)
+		configErr = err
+	}
+	client, err := newHTTPClient(cfg)
+	if err != nil && configErr == nil {
+		log.Printf("[ERROR] %v; alerts will fail until it is fixed", err)
+		configErr = err
+	}
+	if client != nil {
+		cfg.HTTPClient = client
+	}
+
+	// Populate ProviderConfig with top-level fields for backward compatibility
 	if cfg.Provider != "" {
 		cfg.ProviderConfig["provider"] = cfg.Provider
 	}
@@ -48,27 +249,163 @@ func NewLogger(cfg types.Config) *Logger {
 		cfg.ProviderConfig["lark_token"] = cfg.LarkToken
 	}
 
+	cfg.FlightRecorder = newFlightRecorder(cfg)
+	if _, ok := cfg.ProviderConfig["chaos"]; ok {
+		log.Printf("[WARN] Fault injection is enabled, provider requests will fail at random")
+	}
+
 	if _, ok := cfg.ProviderConfig["provider"]; !ok {
 		cfg.ProviderConfig["provider"] = "slack"  // default
 	}
 
+	if err := validateProviders(cfg, injected.provider != nil); err != nil {
+		if strict {
+			return nil, err
+		}
+		log.Printf("[WARN] %v", err)
+	}
+	if strict && configErr != nil {
+		return nil, configErr
+	}
+
 	providerName, ok := cfg.ProviderConfig["provider"].(string)
 	if !ok {
 		providerName = "slack"  // fallback
 	}
 	provider := createProvider(providerName)
-	logger := &Logger{config: cfg, provider: provider}
+	if injected.provider != nil {
+		provider = injected.provider
+	}
+	logger := &Logger{config: cfg, provider: provider, routes: compileRoutes(cfg), groups: compileGroups(cfg), sampler: newWarnSampler(cfg), scrubber: scrubber, flaps: newFlapDetector(cfg), storm: newStormValve(cfg), monitor: newSelfMonitor(cfg), budget: newAlertBudget(cfg), queue: queue, clockCache: clockCache, secrets: newSecretCache(cfg), configErr: configErr, strict: strict}
 
-	types.DebugLog(cfg, "Created new logger with provider: %s, send method: %s, debug: %t",
-		providerName, cfg.SendMethod, cfg.Debug)
+	if configErr != nil {
+		logger.emit(types.Event{Type: types.EventConfigError, Err: configErr})
+	}
+	if queue.spilled > 0 && configErr == nil {
+		logger.startQueue()
+	}
+	logger.digests = newDigests(cfg, logger.now())
+	if injected.provider != nil {
+		logger.useProvider(injected.provider)
+	}
+	logger.startDigests()
 
-	return logger
+	types.DebugLog(cfg, "Created new logger (gocommonlog %s) with provider: %s, send method: %s, debug: %t",
+		types.Version(), providerName, cfg.SendMethod, cfg.Debug)
+
+	return logger, nil
+}
+
+// beginSend registers an in-flight send, failing once the logger is closed
+func (l *Logger) beginSend() error {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed {
+		return ErrLoggerClosed
+	}
+	l.inflight.Add(1)
+	return nil
+}
+
+// Close stops accepting alerts, drops scheduled alerts that are not yet due, waits for
+// in-flight sends and alerts queued by SendAsync until ctx is done, then flushes Kafka writers and releases idle HTTP
+// connections. When async_spill_file is set, scheduled alerts and the alerts still queued
+// when ctx is done are written to it for the next logger instead of being dropped.
+// Closing twice is a no-op.
+func (l *Logger) Close(ctx context.Context) error {
+	l.closeMu.Lock()
+	if l.closed {
+		l.closeMu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.closeMu.Unlock()
+	types.DebugLog(l.config, "Close called, waiting for in-flight sends")
+	followUps, scheduled := l.dropScheduled()
+	if dropped := followUps + l.spillOnClose("scheduled", scheduled); dropped > 0 {
+		log.Printf("[WARN] Dropped %d scheduled alerts on close", dropped)
+	}
+	l.stopMuteTimer()
+	l.stopDigests()
+
+	done := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+		types.DebugLog(l.config, "All in-flight sends completed")
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out waiting for in-flight alerts: %w", ctx.Err())
+		log.Printf("[ERROR] %v", err)
+	}
+	if left := l.queue.close(); len(left) > 0 {
+		if dropped := l.spillOnClose("queued", left); dropped > 0 {
+			log.Printf("[WARN] Dropped %d queued alerts on close", dropped)
+		}
+		for _, alert := range left {
+			alert.complete("", ErrLoggerClosed)
+			l.inflight.Done()
+		}
+	}
+
+	if kafkaErr := l.releaseKafkaWriters(); kafkaErr != nil && err == nil {
+		err = kafkaErr
+	}
+	if l.clockCache != nil {
+		l.clockCache.Close()
+	}
+	if idler, ok := l.config.HTTPClient.(interface{ CloseIdleConnections() }); ok {
+		idler.CloseIdleConnections()
+	} else if l.config.HTTPClient == nil {
+		http.DefaultClient.CloseIdleConnections()
+	}
+	l.closeSubscribers()
+	return err
+}
+
+// retainKafkaWriter retains the shared Kafka writer for cfg and channel the
+// first time this logger sends to it, so other loggers closing leave it open
+func (l *Logger) retainKafkaWriter(cfg types.Config, channel string) {
+	l.kafkaMu.Lock()
+	defer l.kafkaMu.Unlock()
+	if l.kafkaKeys == nil {
+		l.kafkaKeys = make(map[string]struct{})
+	}
+	key := providers.KafkaWriterKey(cfg, channel)
+	if _, ok := l.kafkaKeys[key]; !ok && key != "" {
+		l.kafkaKeys[key] = struct{}{}
+		providers.RetainKafkaWriter(key)
+	}
+}
+
+// releaseKafkaWriters releases the Kafka writers this logger retained,
+// closing those no other logger uses
+func (l *Logger) releaseKafkaWriters() error {
+	l.kafkaMu.Lock()
+	defer l.kafkaMu.Unlock()
+	var firstErr error
+	for key := range l.kafkaKeys {
+		if err := providers.ReleaseKafkaWriter(key); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(l.kafkaKeys, key)
+	}
+	return firstErr
 }
 
 // resolveChannel resolves the channel for the given alert level
 func (l *Logger) resolveChannel(level int) string {
+	return l.resolveAlertChannel(types.AlertContext{Level: level})
+}
+
+// resolveAlertChannel resolves the channel for an alert, passing the full context to
+// resolvers implementing types.ContextChannelResolver
+func (l *Logger) resolveAlertChannel(alert types.AlertContext) string {
 	if l.config.ChannelResolver != nil {
-		return l.config.ChannelResolver.ResolveChannel(level)
+		return types.AsContextResolver(l.config.ChannelResolver).ResolveChannelContext(alert)
 	}
 	return l.config.Channel
 }
@@ -80,110 +417,509 @@ func (l *Logger) Send(level int, message string, attachment *types.Attachment, t
 
 // SendToChannel sends a message to a specific channel, overriding the default/channel resolver
 func (l *Logger) SendToChannel(level int, message string, attachment *types.Attachment, trace string, channel string) error {
-	types.DebugLog(l.config, "SendToChannel called with level: %d, message length: %d, channel: %s, has attachment: %t, has trace: %t",
-		level, len(message), channel, attachment != nil, trace != "")
+	_, err := l.SendWithOptions(level, message, types.SendOptions{Attachment: attachment, Trace: trace, Channel: channel})
+	return err
+}
 
-	if level == types.INFO {
-		log.Printf("[INFO] %s", message)
-		types.DebugLog(l.config, "INFO level message logged locally, skipping provider send")
-		return nil
+// SendWithOptions sends an alert and returns its unique ID (a ULID), which providers
+// include in the rendered message and structured payloads alongside opts.CorrelationID
+func (l *Logger) SendWithOptions(level int, message string, opts types.SendOptions) (string, error) {
+	delivery, err := l.send(level, message, opts, "", nil, "")
+	return delivery.ID, err
+}
+
+// SendWithResult sends an alert like SendWithOptions and describes its delivery: the
+// alert ID, provider and channel, whether it was sent or suppressed, and how long it took
+func (l *Logger) SendWithResult(level int, message string, opts types.SendOptions) (types.SendResult, error) {
+	delivery, err := l.send(level, message, opts, "", nil, "")
+	return types.SendResult{Deliveries: []types.Delivery{delivery}}, err
+}
+
+// delivery describes the outcome of a send from its audit record
+func (l *Logger) delivery(record types.AuditRecord, attempts int, err error) types.Delivery {
+	l.counters.record(record, err, l.now())
+	if event, ok := deliveryEvent(record, err); ok {
+		l.emit(event)
+	}
+	return types.Delivery{
+		ID:       record.ID,
+		Channel:  record.Channel,
+		Provider: record.Provider,
+		Status:   record.Outcome,
+		Attempts: attempts,
+		Latency:  l.now().Sub(record.Time),
+		Err:      err,
 	}
+}
 
-	resolvedChannel := channel
-	if resolvedChannel == "" {
-		resolvedChannel = l.resolveChannel(level)
-		types.DebugLog(l.config, "Resolved channel using resolver: %s", resolvedChannel)
-	} else {
-		types.DebugLog(l.config, "Using provided channel: %s", resolvedChannel)
+// send delivers an alert. followUpFor is set to the original alert ID when sending an ack
+// reminder or escalation, so its button acknowledges the original alert and no further
+// follow-ups are scheduled. route is set when sending to a broadcast group member and is
+// used instead of the routing table. source is the call site captured when the alert was
+// queued or scheduled, "" to find it on the current stack.
+func (l *Logger) send(level int, message string, opts types.SendOptions, followUpFor string, route *compiledRoute, source string) (types.Delivery, error) {
+	types.DebugLog(l.config, "SendWithOptions called with level: %d, message length: %d, channel: %s, provider: %s, has attachment: %t, has trace: %t",
+		level, len(message), opts.Channel, opts.Provider, opts.Attachment != nil, opts.Trace != "")
+	if err := l.beginSend(); err != nil {
+		return types.Delivery{Status: types.AuditFailed, Err: err}, err
 	}
+	defer l.inflight.Done()
+	return l.dispatch(level, message, opts, followUpFor, route, source)
+}
 
-	sendConfig := l.config
-	sendConfig.Channel = resolvedChannel
+// dispatch delivers an alert registered as in flight, see send
+func (l *Logger) dispatch(level int, message string, opts types.SendOptions, followUpFor string, route *compiledRoute, source string) (types.Delivery, error) {
+	delivery := l.dispatchTo(level, message, opts, followUpFor, []destination{{channel: opts.Channel, route: route}}, false, source)[0]
+	return delivery, delivery.Err
+}
 
-	if trace != "" {
-		types.DebugLog(l.config, "Processing trace attachment, trace length: %d", len(trace))
-		traceAttachment := &types.Attachment{
-			FileName: "trace.log",
-			Content:  trace,
+// destination is where one copy of an alert goes: route when set, otherwise channel, or
+// the channel routing or the resolver picks when empty
+type destination struct {
+	channel string
+	route   *compiledRoute
+}
+
+// dispatchTo delivers one alert registered as in flight to every destination, returning
+// the delivery to each. The alert passes level policies, mutes, flap detection, sampling,
+// the storm valve and the alert budget once, then each destination gets a copy under the
+// same alert ID, concurrently when parallel is set. A sent ERROR alert starts a single ack
+// reminder and escalation, from the first destination it reached. The call site is taken
+// from the stack here, while the application's frames are still on it, unless source was
+// captured when the alert was queued or scheduled.
+func (l *Logger) dispatchTo(level int, message string, opts types.SendOptions, followUpFor string, dests []destination, parallel bool, source string) []types.Delivery {
+	message, opts = l.scrubAlert(message, opts)
+	if source == "" {
+		source = l.callSite()
+	}
+
+	start := l.now()
+	messageID := types.NewULID(start)
+	// Flap and sampling notes added to the message do not change its fingerprint
+	fingerprintMessage := message
+	provider, providerName, err := l.alertProvider(opts)
+	if err != nil {
+		return repeatDelivery(types.Delivery{Provider: opts.Provider, Status: types.AuditFailed, Err: err}, len(dests))
+	}
+	service, environment := l.config.ServiceName, l.config.Environment
+	if opts.ServiceName != "" {
+		service = opts.ServiceName
+	}
+	if opts.Environment != "" {
+		environment = opts.Environment
+	}
+	record := types.AuditRecord{
+		ID:            messageID,
+		CorrelationID: opts.CorrelationID,
+		Time:          start,
+		Level:         types.LevelName(level),
+		Service:       service,
+		Environment:   environment,
+		Provider:      providerName,
+		Tenant:        opts.Tenant,
+	}
+
+	message, suppressed, ok := l.gate(level, message, opts, followUpFor, l.metaRoute(dests[0].route), record)
+	if !ok {
+		return repeatDelivery(suppressed, len(dests))
+	}
+
+	deliveries := fanOut(len(dests), parallel, func(i int) types.Delivery {
+		destOpts := opts
+		destOpts.Channel = dests[i].channel
+		return l.deliver(level, message, fingerprintMessage, destOpts, followUpFor, dests[i].route, record, provider, source, i == 0)
+	})
+	if level != types.ERROR || followUpFor != "" {
+		return deliveries
+	}
+	for _, delivery := range deliveries {
+		if delivery.Err != nil {
+			continue
 		}
-		if attachment != nil {
-			if attachment.Content != "" {
-				attachment.Content += "\n\n--- Trace Log ---\n" + trace
-				types.DebugLog(l.config, "Appended trace to existing attachment content")
-			} else {
-				attachment.Content = trace
-				attachment.FileName = "trace.log"
-				types.DebugLog(l.config, "Set trace as attachment content")
-			}
+		l.pinSent(messageID)
+		if l.ackEnabled() {
+			l.trackAck(messageID, message, opts, delivery.Channel)
+		}
+		l.escalate(messageID, service, message, opts, delivery.Channel)
+		break
+	}
+	return deliveries
+}
+
+// repeatDelivery returns delivery for each of n destinations
+func repeatDelivery(delivery types.Delivery, n int) []types.Delivery {
+	deliveries := make([]types.Delivery, n)
+	for i := range deliveries {
+		deliveries[i] = delivery
+	}
+	return deliveries
+}
+
+// gate applies the level policy, mutes, flap detection, sampling, the storm valve and the
+// alert budget to an alert, returning its message with their notes added. When the alert
+// is not sent, it returns false with the delivery describing why. meta is set for the
+// logger's own alerts, digest reports and self-monitoring alerts, which only mutes hold
+// back: they are not counted by the storm valve or alert budgets either.
+func (l *Logger) gate(level int, message string, opts types.SendOptions, followUpFor string, meta bool, record types.AuditRecord) (string, types.Delivery, bool) {
+	start := record.Time
+	policy := l.levelPolicy(level)
+	if meta {
+		policy = types.PolicySendOnly
+	}
+	if policy == types.PolicyDrop {
+		types.DebugLog(l.config, "%s alert dropped by level policy", types.LevelName(level))
+		record.Outcome = types.AuditDropped
+		l.audit(record, message)
+		return message, l.delivery(record, 0, nil), false
+	}
+	if policy == types.PolicyLocalOnly || policy == types.PolicyBoth {
+		log.Printf("[%s] %s", types.LevelName(level), message)
+	}
+	if policy == types.PolicyLocalOnly {
+		types.DebugLog(l.config, "%s alert logged locally, skipping provider send", types.LevelName(level))
+		record.Outcome = types.AuditLogged
+		l.audit(record, message)
+		return message, l.delivery(record, 0, nil), false
+	}
+	if l.suppressMuted(start) {
+		types.DebugLog(l.config, "%s alert muted for maintenance", types.LevelName(level))
+		record.Outcome = types.AuditMuted
+		if auditMuted, _ := l.config.ProviderConfig["audit_muted"].(bool); auditMuted {
+			l.audit(record, message)
 		} else {
-			attachment = traceAttachment
-			types.DebugLog(l.config, "Created new trace attachment")
+			l.mirror(record, message)
+		}
+		return message, l.delivery(record, 0, nil), false
+	}
+	if l.flaps != nil && opts.Condition != "" && followUpFor == "" && !meta {
+		decision, suppressed := l.flaps.fire(opts.Condition, start)
+		switch decision {
+		case flapSuppress:
+			types.DebugLog(l.config, "Alert for flapping condition %s suppressed", opts.Condition)
+			record.Outcome = types.AuditFlapping
+			l.audit(record, message)
+			return message, l.delivery(record, 0, nil), false
+		case flapNotice:
+			log.Printf("[WARN] Condition %s is flapping, suppressing its alerts", opts.Condition)
+			l.emit(types.Event{Type: types.EventFlapping, Time: start, Level: level, Detail: opts.Condition})
+			message = l.flaps.flapNoticeMessage(opts.Condition, message)
+		default:
+			message = recoveredMessage(message, suppressed)
+		}
+	}
+	if level == types.WARN && l.sampler != nil && followUpFor == "" && !meta {
+		send, dropped := l.sampler.sample()
+		if !send {
+			types.DebugLog(l.config, "WARN alert dropped by sampling")
+			record.Outcome = types.AuditSampled
+			l.audit(record, message)
+			return message, l.delivery(record, 0, nil), false
+		}
+		message = sampledMessage(message, dropped)
+	}
+	if l.storm != nil && !meta {
+		decision, suppressed := l.storm.fire(start, level)
+		switch decision {
+		case stormSuppress:
+			types.DebugLog(l.config, "%s alert suppressed by the alert storm safety valve", types.LevelName(level))
+			record.Outcome = types.AuditStorm
+			l.audit(record, message)
+			return message, l.delivery(record, 0, nil), false
+		case stormNotice:
+			log.Printf("[WARN] Alert storm detected: more than %d alerts in %s, suppressing alerts", l.storm.threshold, l.storm.window)
+			l.emit(types.Event{Type: types.EventStormStart, Time: start, Level: level})
+			message = l.storm.stormNoticeMessage(message)
+		default:
+			if suppressed > 0 {
+				l.emit(types.Event{Type: types.EventStormEnd, Time: start, Level: level, Detail: strconv.Itoa(suppressed)})
+			}
+			message = stormEndedMessage(message, suppressed)
 		}
 	}
 
-	types.DebugLog(l.config, "Calling provider.SendToChannel with resolved channel: %s", resolvedChannel)
-	err := l.provider.SendToChannel(level, message, attachment, sendConfig, resolvedChannel)
-	if err != nil {
-		types.DebugLog(l.config, "Provider.SendToChannel failed: %v", err)
-	} else {
-		types.DebugLog(l.config, "Provider.SendToChannel completed successfully")
+	if l.budget != nil && followUpFor == "" && !meta {
+		if exceeded, count, limit := l.budget.spend(record.Service, start); exceeded {
+			log.Printf("[WARN] Service %s exceeded its alert budget: %d alerts in %s, budget %d", budgetServiceName(record.Service), count, l.budget.window, limit)
+			l.emit(types.Event{Type: types.EventBudgetExceeded, Time: start, Level: level, Detail: record.Service})
+			message = l.budget.exceededMessage(message, record.Service, count, limit)
+		}
 	}
-	return err
+	return message, types.Delivery{}, true
 }
 
-// CustomSend sends a message with a custom provider, allowing override of the default provider
-func (l *Logger) CustomSend(provider string, level int, message string, attachment *types.Attachment, trace string, channel string) error {
-	types.DebugLog(l.config, "CustomSend called with custom provider: %s, level: %d, message length: %d",
-		provider, level, len(message))
+// deliver sends one copy of an alert that passed the gates through route, or the routing
+// table when nil. record holds the alert's ID, time, service, environment and provider
+// before routing. first is false for the further copies of an alert sent to several
+// destinations, which digests do not count again.
+func (l *Logger) deliver(level int, message, fingerprintMessage string, opts types.SendOptions, followUpFor string, route *compiledRoute, record types.AuditRecord, provider types.Provider, source string, first bool) types.Delivery {
+	out := l.prepare(level, message, fingerprintMessage, opts, followUpFor, route, record, provider, source)
+	alert, provider, sendConfig, attachment := out.alert, out.provider, out.config, out.attachment
+	resolvedChannel := out.channel
+	providerName := alert.Provider
+	record.Provider = providerName
 
-	customProvider := createProvider(provider)
-	if customProvider == nil {
-		log.Printf("[ERROR] Unknown provider: %s, defaulting to slack", provider)
-		customProvider = createProvider("slack")
-		types.DebugLog(l.config, "Unknown provider '%s', defaulted to slack", provider)
-	} else {
-		types.DebugLog(l.config, "Created custom provider: %s", provider)
+	attempts := 0
+	skipped := false
+	err := l.configErr
+	if err == nil {
+		sendConfig, err = l.resolveSecrets(sendConfig)
+	}
+	if err == nil {
+		sendConfig, err = tenantConfig(sendConfig, opts.Tenant, providerName)
+	}
+	if err == nil {
+		attachment, sendConfig = hideTrace(provider, attachment, sendConfig)
+		attachment = fitAttachment(attachment, sendConfig)
+		types.DebugLog(l.config, "Calling provider.SendToChannel with resolved channel: %s, message ID: %s", resolvedChannel, record.ID)
+		attempts++
+		sendConfig.Response = &types.ProviderResponse{}
+		if _, ok := provider.(*providers.KafkaProvider); ok {
+			l.retainKafkaWriter(sendConfig, resolvedChannel)
+		}
+		err = provider.SendToChannel(level, message, attachment, sendConfig, resolvedChannel)
+		if errors.Is(err, types.ErrSkipped) {
+			types.DebugLog(l.config, "Provider.SendToChannel skipped the alert: %v", err)
+			skipped, err = true, nil
+		} else if err != nil {
+			types.DebugLog(l.config, "Provider.SendToChannel failed: %v", err)
+		} else {
+			types.DebugLog(l.config, "Provider.SendToChannel completed successfully")
+		}
+		l.forwardToSentry(provider, level, message, attachment, sendConfig, resolvedChannel)
 	}
 
-	if level == types.INFO {
-		log.Printf("[INFO] %s", message)
-		types.DebugLog(l.config, "INFO level message logged locally for custom provider, skipping send")
-		return nil
+	record.Channel = resolvedChannel
+	record.Outcome = types.AuditSent
+	if skipped {
+		record.Outcome = types.AuditSkipped
+	} else if err != nil {
+		record.Outcome = types.AuditFailed
+		record.Error = err.Error()
+		l.dumpOnFailure(record.ID)
+		l.secrets.authFailed(err)
+	}
+	record.LatencyMs = l.now().Sub(record.Time).Milliseconds()
+	l.audit(record, message)
+	if attempts > 0 && !skipped {
+		l.monitorDelivery(providerName, route, err)
+		l.recordDigests(record, out.route, sendConfig.Fingerprint, fingerprintMessage, first)
+	}
+	if err == nil && !skipped {
+		l.rememberPosted(record.ID, provider, sendConfig, opts.Condition)
+	}
+	delivery := l.delivery(record, attempts, err)
+	delivery.Response = sendConfig.Response
+	if l.config.AfterSend != nil && attempts > 0 {
+		l.config.AfterSend(alert, delivery)
 	}
+	return delivery
+}
 
-	resolvedChannel := channel
-	if resolvedChannel == "" {
-		resolvedChannel = l.resolveChannel(level)
-		types.DebugLog(l.config, "Resolved channel for custom send: %s", resolvedChannel)
+// alertProvider returns the provider an alert sent with opts goes to before routing, and
+// its name
+func (l *Logger) alertProvider(opts types.SendOptions) (types.Provider, string, error) {
+	providerName, _ := l.config.ProviderConfig["provider"].(string)
+	if opts.Provider == "" {
+		return l.provider, providerName, nil
+	}
+	if l.strict && !providerRegistered(opts.Provider) {
+		return nil, "", fmt.Errorf("unknown provider %q", opts.Provider)
 	}
+	types.DebugLog(l.config, "Created custom provider: %s", opts.Provider)
+	return createProvider(opts.Provider), opts.Provider, nil
+}
+
+// outgoing is an alert ready for its provider: where it goes and the config it is sent with
+type outgoing struct {
+	alert      types.AlertContext
+	provider   types.Provider
+	channel    string
+	config     types.Config
+	attachment *types.Attachment
+	ackEnabled bool
+	route      *compiledRoute // route the alert was sent through, nil for the logger's provider
+}
 
+// prepare routes an alert and builds its per-send config. record holds the alert's ID,
+// time, service, environment and provider before routing; source is its call site.
+func (l *Logger) prepare(level int, message, fingerprintMessage string, opts types.SendOptions, followUpFor string, route *compiledRoute, record types.AuditRecord, provider types.Provider, source string) outgoing {
+	alert := types.AlertContext{
+		Level:         level,
+		Message:       message,
+		Service:       record.Service,
+		Environment:   record.Environment,
+		Fields:        l.alertFields(opts),
+		CorrelationID: opts.CorrelationID,
+		Provider:      record.Provider,
+	}
 	sendConfig := l.config
+	resolvedChannel := opts.Channel
+	if route == nil && opts.Channel == "" && opts.Provider == "" {
+		route = l.matchRoute(alert)
+	}
+	if route != nil {
+		provider = route.provider
+		alert.Provider = route.providerName
+		sendConfig = route.apply(sendConfig)
+		resolvedChannel = route.Channel
+	}
+	if resolvedChannel == "" {
+		resolvedChannel = l.resolveAlertChannel(alert)
+		types.DebugLog(l.config, "Resolved channel using resolver: %s", resolvedChannel)
+	} else {
+		types.DebugLog(l.config, "Using provided channel: %s", resolvedChannel)
+	}
+
+	sendConfig.Provider = alert.Provider
 	sendConfig.Channel = resolvedChannel
+	sendConfig.Fields = alert.Fields
+	sendConfig.ServiceName = record.Service
+	sendConfig.Environment = record.Environment
+	sendConfig.MessageID = record.ID
+	sendConfig.CorrelationID = opts.CorrelationID
+	sendConfig.Links = opts.Links
+	sendConfig.Snippets = opts.Snippets
+	sendConfig.Image = opts.Image
+	if len(opts.Sections) > 0 {
+		sendConfig.Sections = opts.Sections
+	}
+	sendConfig.Source = source
+	fingerprintAlert := alert
+	fingerprintAlert.Message = fingerprintMessage
+	sendConfig.Fingerprint = l.fingerprint(fingerprintAlert)
+	sendConfig.Links = l.withActions(level, sendConfig.Links, sendConfig)
+	sendConfig.EventTime = record.Time
+	if !opts.Time.IsZero() {
+		sendConfig.EventTime = opts.Time
+	}
+	ackEnabled := level == types.ERROR && l.ackEnabled()
+	if ackEnabled {
+		sendConfig.AckID = record.ID
+		if followUpFor != "" {
+			sendConfig.AckID = followUpFor
+		}
+	}
+
+	attachment := opts.Attachment
+	if opts.Trace != "" {
+		types.DebugLog(l.config, "Processing trace attachment, trace length: %d", len(opts.Trace))
+		attachment = l.mergeTrace(attachment, opts.Trace)
+	}
+
+	return outgoing{
+		alert:      alert,
+		provider:   provider,
+		channel:    resolvedChannel,
+		config:     sendConfig,
+		attachment: attachment,
+		ackEnabled: ackEnabled,
+		route:      route,
+	}
+}
+
+// alertFields returns the configured fields with the alert's own fields added, scrubbed
+// of personal data
+func (l *Logger) alertFields(opts types.SendOptions) map[string]string {
+	if len(opts.Fields) == 0 && l.scrubber == nil {
+		return l.config.Fields
+	}
+	fields := make(map[string]string, len(l.config.Fields)+len(opts.Fields))
+	for key, value := range l.config.Fields {
+		fields[key] = value
+	}
+	for key, value := range opts.Fields {
+		fields[key] = value
+	}
+	for key, value := range fields {
+		fields[key] = l.scrubber.scrub(value)
+	}
+	return fields
+}
+
+// audit writes record to the configured audit sink and mirrors the alert to the local
+// log. Sink failures are logged but never fail the send, so an unavailable audit store
+// cannot suppress alerts.
+func (l *Logger) audit(record types.AuditRecord, message string) {
+	l.mirror(record, message)
+	if l.config.AuditSink == nil {
+		return
+	}
+	if err := l.config.AuditSink.RecordAlert(record); err != nil {
+		log.Printf("[ERROR] Failed to write audit record for alert %s: %v", record.ID, err)
+	}
+}
 
-	if trace != "" {
-		types.DebugLog(l.config, "Processing trace for custom send, trace length: %d", len(trace))
-		traceAttachment := &types.Attachment{
-			FileName: "trace.log",
+// levelPolicy returns the configured policy for level, or its default
+func (l *Logger) levelPolicy(level int) types.LevelPolicy {
+	if policy, ok := l.config.LevelPolicies[level]; ok {
+		return policy
+	}
+	return types.DefaultLevelPolicy(level)
+}
+
+// now returns the current time from the configured clock
+func (l *Logger) now() time.Time {
+	if l.config.Clock != nil {
+		return l.config.Clock.Now()
+	}
+	return time.Now()
+}
+
+// afterFunc runs f once d has elapsed on the configured clock, with a system timer unless
+// the clock implements types.TimerClock
+func (l *Logger) afterFunc(d time.Duration, f func()) types.Timer {
+	if clock, ok := l.config.Clock.(types.TimerClock); ok {
+		return clock.AfterFunc(d, f)
+	}
+	return time.AfterFunc(d, f)
+}
+
+// mergeTrace returns the attachment with the trace log added. The caller's attachment is
+// copied rather than modified, so the same attachment can be reused across concurrent sends.
+func (l *Logger) mergeTrace(attachment *types.Attachment, trace string) *types.Attachment {
+	if attachment == nil {
+		types.DebugLog(l.config, "Created new trace attachment")
+		return &types.Attachment{
+			FileName: types.TraceFileName,
 			Content:  trace,
 		}
-		if attachment != nil {
-			if attachment.Content != "" {
-				attachment.Content += "\n\n--- Trace Log ---\n" + trace
-			} else {
-				attachment.Content = trace
-				attachment.FileName = "trace.log"
-			}
-		} else {
-			attachment = traceAttachment
-		}
 	}
-
-	types.DebugLog(l.config, "Calling custom provider.SendToChannel with provider: %s, channel: %s", provider, resolvedChannel)
-	err := customProvider.SendToChannel(level, message, attachment, sendConfig, resolvedChannel)
-	if err != nil {
-		types.DebugLog(l.config, "Custom provider.SendToChannel failed: %v", err)
+	merged := *attachment
+	if merged.Content != "" {
+		merged.Content += types.TraceSeparator + trace
+		types.DebugLog(l.config, "Appended trace to existing attachment content")
 	} else {
-		types.DebugLog(l.config, "Custom provider.SendToChannel completed successfully")
+		merged.Content = trace
+		merged.FileName = types.TraceFileName
+		types.DebugLog(l.config, "Set trace as attachment content")
+	}
+	return &merged
+}
+
+// forwardToSentry additionally reports ERROR alerts to Sentry when sentry_dsn is configured,
+// so WARN alerts keep going to chat only. A Sentry failure is logged and does not change
+// the outcome of the alert, which is that of its own provider.
+func (l *Logger) forwardToSentry(sent types.Provider, level int, message string, attachment *types.Attachment, cfg types.Config, channel string) {
+	if level != types.ERROR {
+		return
+	}
+	if dsn, ok := cfg.ProviderConfig["sentry_dsn"].(string); !ok || dsn == "" {
+		return
+	}
+	if _, isSentry := sent.(*providers.SentryProvider); isSentry {
+		return
+	}
+	types.DebugLog(l.config, "Forwarding ERROR alert to Sentry")
+	cfg.Response = nil // Delivery.Response describes the alert's own provider
+	if err := (&providers.SentryProvider{}).SendToChannel(level, message, attachment, cfg, channel); err != nil {
+		log.Printf("[ERROR] Failed to forward alert to Sentry: %v", providers.RedactURLs(err.Error()))
 	}
+}
+
+// CustomSend sends a message with a custom provider, allowing override of the default provider
+func (l *Logger) CustomSend(provider string, level int, message string, attachment *types.Attachment, trace string, channel string) error {
+	types.DebugLog(l.config, "CustomSend called with custom provider: %s, level: %d, message length: %d",
+		provider, level, len(message))
+	_, err := l.SendWithOptions(level, message, types.SendOptions{Attachment: attachment, Trace: trace, Channel: channel, Provider: provider})
 	return err
 }
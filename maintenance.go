@@ -0,0 +1,112 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Mute suppresses alerts until the given time, for example during a deployment. Muted
+// alerts are counted and a summary is sent when the mute ends. Calling Mute again
+// replaces the previous mute.
+func (l *Logger) Mute(until time.Time, reason string) {
+	l.muteMu.Lock()
+	l.muteUntil, l.muteReason = until, reason
+	l.muteMu.Unlock()
+	log.Printf("[WARN] Alerts muted until %s: %s", until.Format(time.RFC3339), reason)
+	l.emit(types.Event{Type: types.EventMuted, Detail: reason})
+}
+
+// Unmute ends a mute started with Mute early. Maintenance windows in Config.Maintenance
+// still apply.
+func (l *Logger) Unmute() {
+	l.muteMu.Lock()
+	l.muteUntil, l.muteReason = time.Time{}, ""
+	l.muteMu.Unlock()
+	l.endMute()
+}
+
+// Muted reports whether alerts are currently suppressed, and why
+func (l *Logger) Muted() (bool, string) {
+	l.muteMu.Lock()
+	defer l.muteMu.Unlock()
+	muted, reason, _ := l.mutedAt(l.now())
+	return muted, reason
+}
+
+// mutedAt reports whether a mute or maintenance window covers now, its reason and when
+// it ends. Callers hold muteMu.
+func (l *Logger) mutedAt(now time.Time) (bool, string, time.Time) {
+	if now.Before(l.muteUntil) {
+		return true, l.muteReason, l.muteUntil
+	}
+	for _, window := range l.config.Maintenance {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			return true, window.Reason, window.End
+		}
+	}
+	return false, "", time.Time{}
+}
+
+// suppressMuted counts the alert and reports whether it is muted. The first alert muted
+// arms a timer sending the summary when the mute ends.
+func (l *Logger) suppressMuted(now time.Time) bool {
+	l.muteMu.Lock()
+	defer l.muteMu.Unlock()
+	muted, reason, end := l.mutedAt(now)
+	if !muted {
+		return false
+	}
+	l.mutedCount++
+	l.mutedReason = reason
+	if l.muteTimer == nil {
+		l.muteTimer = l.afterFunc(end.Sub(now), l.endMute)
+	}
+	return true
+}
+
+// endMute sends the summary of muted alerts once no mute or maintenance window applies,
+// rearming the timer when another one follows
+func (l *Logger) endMute() {
+	l.muteMu.Lock()
+	if l.muteTimer != nil {
+		l.muteTimer.Stop()
+		l.muteTimer = nil
+	}
+	now := l.now()
+	if muted, _, end := l.mutedAt(now); muted {
+		if l.mutedCount > 0 {
+			l.muteTimer = l.afterFunc(end.Sub(now), l.endMute)
+		}
+		l.muteMu.Unlock()
+		return
+	}
+	count, reason := l.mutedCount, l.mutedReason
+	l.mutedCount, l.mutedReason = 0, ""
+	l.muteMu.Unlock()
+	l.emit(types.Event{Type: types.EventUnmuted, Detail: strconv.Itoa(count)})
+	if count == 0 {
+		return
+	}
+
+	summary := fmt.Sprintf("Muted %d alerts during maintenance", count)
+	if reason != "" {
+		summary += " (" + reason + ")"
+	}
+	if _, err := l.send(types.WARN, summary, types.SendOptions{}, "", nil, ""); err != nil && err != ErrLoggerClosed {
+		log.Printf("[ERROR] Failed to send maintenance summary: %v", err)
+	}
+}
+
+// stopMuteTimer cancels a pending maintenance summary on Close
+func (l *Logger) stopMuteTimer() {
+	l.muteMu.Lock()
+	defer l.muteMu.Unlock()
+	if l.muteTimer != nil {
+		l.muteTimer.Stop()
+		l.muteTimer = nil
+	}
+}
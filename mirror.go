@@ -0,0 +1,69 @@
+package gocommonlog
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// mirrorEnabled reports whether alerts are mirrored to Config.MirrorWriter or, with
+// mirror_log, to the standard logger
+func (l *Logger) mirrorEnabled() bool {
+	if l.config.MirrorWriter != nil {
+		return true
+	}
+	enabled, _ := l.config.ProviderConfig["mirror_log"].(bool)
+	return enabled
+}
+
+// mirror writes a single-line logfmt record of an alert and its outcome
+func (l *Logger) mirror(record types.AuditRecord, message string) {
+	if !l.mirrorEnabled() {
+		return
+	}
+	line := mirrorLine(record, message)
+	if l.config.MirrorWriter == nil {
+		log.Print(line)
+		return
+	}
+	l.mirrorMu.Lock()
+	defer l.mirrorMu.Unlock()
+	if _, err := l.config.MirrorWriter.Write([]byte(line + "\n")); err != nil {
+		log.Printf("[ERROR] Failed to mirror alert %s: %v", record.ID, err)
+	}
+}
+
+// mirrorLine renders record as logfmt, omitting empty fields
+func mirrorLine(record types.AuditRecord, message string) string {
+	var line strings.Builder
+	line.WriteString("gocommonlog alert")
+	field := func(key, value string) {
+		if value == "" {
+			return
+		}
+		line.WriteString(" " + key + "=")
+		if strings.ContainsAny(value, " \"=\n\t") {
+			value = strconv.Quote(value)
+		}
+		line.WriteString(value)
+	}
+	field("time", record.Time.UTC().Format(time.RFC3339Nano))
+	field("id", record.ID)
+	field("correlation_id", record.CorrelationID)
+	field("level", record.Level)
+	field("service", record.Service)
+	field("environment", record.Environment)
+	field("channel", record.Channel)
+	field("provider", record.Provider)
+	field("tenant", record.Tenant)
+	field("outcome", record.Outcome)
+	if record.Outcome == types.AuditSent || record.Outcome == types.AuditFailed {
+		field("latency_ms", strconv.FormatInt(record.LatencyMs, 10))
+	}
+	field("error", record.Error)
+	field("message", message)
+	return line.String()
+}
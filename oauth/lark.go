@@ -0,0 +1,184 @@
+package oauth
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// larkAuthURL is the base of the Lark authentication API; a variable so tests can point it elsewhere
+var larkAuthURL = "https://open.larksuite.com/open-apis/auth/v3"
+
+// Lark handles installation of a Lark store app. Lark pushes an app ticket to the event
+// subscription URL, served by EventHandler, every hour, and an app_open event when a
+// tenant installs the app; Token exchanges them for the tenant's access token. Encrypted
+// events are not supported, so leave the event encrypt key unset.
+type Lark struct {
+	AppID             string
+	AppSecret         string
+	VerificationToken string         // Event verification token; events are rejected when empty
+	Cache             cache.Cache    // Where installations and tokens are stored, defaults to the global cache
+	HTTPClient        types.HTTPDoer // Defaults to http.DefaultClient
+
+	// OnInstall is called after a tenant's installation is stored
+	OnInstall func(inst Installation)
+
+	mu sync.Mutex // Serializes token fetches
+}
+
+// larkEvent is a v1 event callback, or the URL verification request
+type larkEvent struct {
+	Type      string `json:"type"`
+	Token     string `json:"token"`
+	Challenge string `json:"challenge"`
+	Event     struct {
+		Type      string `json:"type"`
+		AppID     string `json:"app_id"`
+		AppTicket string `json:"app_ticket"`
+		TenantKey string `json:"tenant_key"`
+	} `json:"event"`
+}
+
+// larkAuthResponse is the response of the app and tenant access token endpoints
+type larkAuthResponse struct {
+	Code              int    `json:"code"`
+	Msg               string `json:"msg"`
+	AppAccessToken    string `json:"app_access_token"`
+	TenantAccessToken string `json:"tenant_access_token"`
+	Expire            int    `json:"expire"`
+}
+
+func (l *Lark) doer() types.HTTPDoer {
+	if l.HTTPClient != nil {
+		return l.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (l *Lark) ticketKey() string {
+	return "commonlog_lark_app_ticket:" + l.AppID
+}
+
+// EventHandler answers URL verification and records app tickets and installations.
+// Every request is rejected when VerificationToken is not set, since events could not be
+// told apart from forged ones.
+func (l *Lark) EventHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.VerificationToken == "" {
+			log.Printf("[ERROR] Rejected Lark event: the verification token is not configured")
+			http.Error(w, "verification token not configured", http.StatusUnauthorized)
+			return
+		}
+		var event larkEvent
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&event); err != nil {
+			http.Error(w, "invalid event", http.StatusBadRequest)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(event.Token), []byte(l.VerificationToken)) != 1 {
+			http.Error(w, "invalid verification token", http.StatusUnauthorized)
+			return
+		}
+		if event.Type == "url_verification" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"challenge": event.Challenge})
+			return
+		}
+
+		switch event.Event.Type {
+		case "app_ticket":
+			// Tickets are pushed every hour and stay valid for a while longer
+			storeOf(l.Cache).Set(l.ticketKey(), event.Event.AppTicket, 12*time.Hour)
+		case "app_open":
+			inst := Installation{Provider: "lark", TenantID: event.Event.TenantKey, InstalledAt: time.Now()}
+			if err := SaveInstallation(l.Cache, inst); err != nil {
+				log.Printf("[ERROR] Failed to store Lark installation: %v", err)
+				http.Error(w, "installation failed", http.StatusInternalServerError)
+				return
+			}
+			if l.OnInstall != nil {
+				l.OnInstall(inst)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// post calls a Lark authentication endpoint
+func (l *Lark) post(path string, payload interface{}) (larkAuthResponse, error) {
+	data, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", larkAuthURL+path, bytes.NewReader(data))
+	if err != nil {
+		return larkAuthResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := l.doer().Do(req)
+	if err != nil {
+		return larkAuthResponse{}, err
+	}
+	defer resp.Body.Close()
+	var result larkAuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return larkAuthResponse{}, fmt.Errorf("invalid lark %s response: %w", path, err)
+	}
+	if result.Code != 0 {
+		return larkAuthResponse{}, fmt.Errorf("lark %s error: %s", path, result.Msg)
+	}
+	return result, nil
+}
+
+// cacheFor returns how long a token that expires in expire seconds is reused
+func cacheFor(expire int) time.Duration {
+	if expire -= 600; expire <= 0 {
+		expire = 60
+	}
+	return time.Duration(expire) * time.Second
+}
+
+// Token returns the tenant access token of an installed tenant, cached until 10 minutes
+// before it expires. Without an app ticket yet, Lark is asked to resend one and an error
+// is returned.
+func (l *Lark) Token(tenantKey string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := LoadInstallation(l.Cache, "lark", tenantKey); err != nil {
+		return "", err
+	}
+	store := storeOf(l.Cache)
+	tokenKey := "commonlog_lark_tenant_token:" + l.AppID + ":" + tenantKey
+	if token, ok := store.Get(tokenKey); ok {
+		return token, nil
+	}
+
+	appKey := "commonlog_lark_app_token:" + l.AppID
+	appToken, ok := store.Get(appKey)
+	if !ok {
+		ticket, ok := store.Get(l.ticketKey())
+		if !ok {
+			if _, err := l.post("/app_ticket/resend", map[string]string{"app_id": l.AppID, "app_secret": l.AppSecret}); err != nil {
+				return "", fmt.Errorf("no Lark app ticket received and resending failed: %w", err)
+			}
+			return "", fmt.Errorf("no Lark app ticket received yet, a resend was requested")
+		}
+		result, err := l.post("/app_access_token", map[string]string{"app_id": l.AppID, "app_secret": l.AppSecret, "app_ticket": ticket})
+		if err != nil {
+			return "", err
+		}
+		appToken = result.AppAccessToken
+		store.Set(appKey, appToken, cacheFor(result.Expire))
+	}
+
+	result, err := l.post("/tenant_access_token", map[string]string{"app_access_token": appToken, "tenant_key": tenantKey})
+	if err != nil {
+		return "", err
+	}
+	store.Set(tokenKey, result.TenantAccessToken, cacheFor(result.Expire))
+	return result.TenantAccessToken, nil
+}
@@ -0,0 +1,176 @@
+// Package oauth implements the Slack OAuth redirect and Lark app installation flows,
+// storing the token of every installed workspace or tenant in a cache.Cache so one
+// service can send alerts on behalf of many customers.
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+)
+
+// ErrNotInstalled is returned when no installation is stored for a workspace or tenant
+var ErrNotInstalled = errors.New("oauth: app is not installed")
+
+// noExpiry keeps installations in caches that require an expiry
+const noExpiry = 100 * 365 * 24 * time.Hour
+
+// stateTTL bounds how long an authorization request may take before its state is rejected
+const stateTTL = 10 * time.Minute
+
+// Installation is the token of one Slack workspace or Lark tenant that installed the app
+type Installation struct {
+	Provider       string    `json:"provider"`                  // "slack" or "lark"
+	TenantID       string    `json:"tenant_id"`                 // Slack team ID or Lark tenant key
+	TenantName     string    `json:"tenant_name,omitempty"`     // Slack workspace name
+	AccessToken    string    `json:"access_token,omitempty"`    // Slack bot token
+	RefreshToken   string    `json:"refresh_token,omitempty"`   // Slack refresh token when token rotation is enabled
+	ExpiresAt      time.Time `json:"expires_at,omitempty"`      // When AccessToken expires, zero if it doesn't
+	BotUserID      string    `json:"bot_user_id,omitempty"`     // Slack bot user
+	WebhookURL     string    `json:"webhook_url,omitempty"`     // Slack incoming webhook, when requested
+	WebhookChannel string    `json:"webhook_channel,omitempty"` // Channel of the incoming webhook
+	InstalledAt    time.Time `json:"installed_at"`
+}
+
+// installationKey returns the cache key of an installation
+func installationKey(provider, tenantID string) string {
+	return "commonlog_installation:" + provider + ":" + tenantID
+}
+
+// storeOf returns c, defaulting to the global cache
+func storeOf(c cache.Cache) cache.Cache {
+	if c != nil {
+		return c
+	}
+	return cache.GetGlobalCache()
+}
+
+// SaveInstallation stores an installation in c, replacing any earlier one for the tenant
+func SaveInstallation(c cache.Cache, inst Installation) error {
+	if inst.Provider == "" || inst.TenantID == "" {
+		return fmt.Errorf("oauth: installation requires a provider and tenant ID")
+	}
+	return cache.NewTyped(storeOf(c), cache.JSON).Set(installationKey(inst.Provider, inst.TenantID), inst, noExpiry)
+}
+
+// LoadInstallation returns the installation stored in c for a provider and tenant, or
+// ErrNotInstalled
+func LoadInstallation(c cache.Cache, provider, tenantID string) (Installation, error) {
+	var inst Installation
+	found, err := cache.NewTyped(storeOf(c), cache.JSON).Get(installationKey(provider, tenantID), &inst)
+	if err != nil {
+		return Installation{}, fmt.Errorf("oauth: invalid stored installation for %s %s: %w", provider, tenantID, err)
+	}
+	if !found {
+		return Installation{}, ErrNotInstalled
+	}
+	return inst, nil
+}
+
+// DeleteInstallation removes the installation of a tenant, for example when the app is uninstalled
+func DeleteInstallation(c cache.Cache, provider, tenantID string) {
+	storeOf(c).Delete(installationKey(provider, tenantID))
+}
+
+// newState creates a random authorization state and remembers it in c
+func newState(c cache.Cache) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(b)
+	storeOf(c).Set("commonlog_oauth_state:"+state, "1", stateTTL)
+	return state, nil
+}
+
+// stateCookie holds the state in the browser that started the installation, so a
+// callback is only accepted from that browser (login CSRF)
+const stateCookie = "commonlog_oauth_state"
+
+// setStateCookie binds state to the browser. The cookie is SameSite=Lax, since the
+// callback is a top-level navigation from the provider, and Secure when the redirect
+// URL is HTTPS.
+func setStateCookie(w http.ResponseWriter, state, redirectURL string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(stateTTL / time.Second),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(redirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// browserState reports whether state matches the cookie set by setStateCookie, and
+// clears the cookie
+func browserState(w http.ResponseWriter, r *http.Request, state, redirectURL string) bool {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || state == "" {
+		return false
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(redirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) == 1
+}
+
+// consumeState reports whether state was issued by newState and not used yet
+func consumeState(c cache.Cache, state string) bool {
+	if state == "" {
+		return false
+	}
+	key := "commonlog_oauth_state:" + state
+	if _, ok := storeOf(c).Get(key); !ok {
+		return false
+	}
+	storeOf(c).Delete(key)
+	return true
+}
+
+// TokenStore serves the tokens of installed workspaces and tenants to a Logger as a
+// types.TokenStore, so SendOptions.Tenant picks the Slack team ID or Lark tenant key an
+// alert is sent to. Leave Slack or Lark nil when the app isn't installed there.
+type TokenStore struct {
+	Slack *Slack
+	Lark  *Lark
+}
+
+// Get returns the bot token of a Slack workspace or the tenant access token of a Lark tenant
+func (s TokenStore) Get(tenant, provider string) (string, error) {
+	switch {
+	case provider == "slack" && s.Slack != nil:
+		return s.Slack.Token(tenant)
+	case provider == "lark" && s.Lark != nil:
+		return s.Lark.Token(tenant)
+	}
+	return "", fmt.Errorf("oauth: no %s app configured", provider)
+}
+
+// Set stores a Slack bot token obtained outside the OAuth flow as the workspace's
+// installation. Lark tenant tokens are always fetched, so they cannot be set.
+func (s TokenStore) Set(tenant, provider, token string) error {
+	if provider != "slack" || s.Slack == nil {
+		return fmt.Errorf("oauth: cannot set %s tokens", provider)
+	}
+	inst, err := LoadInstallation(s.Slack.Cache, "slack", tenant)
+	if err != nil {
+		inst = Installation{Provider: "slack", TenantID: tenant, InstalledAt: time.Now()}
+	}
+	inst.AccessToken = token
+	inst.RefreshToken = ""
+	inst.ExpiresAt = time.Time{}
+	return SaveInstallation(s.Slack.Cache, inst)
+}
@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+)
+
+// doerFunc adapts a function to types.HTTPDoer
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(body string) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestSlackInstallation(t *testing.T) {
+	var grants []url.Values
+	slack := &Slack{
+		ClientID:     "123.456",
+		ClientSecret: "secret",
+		RedirectURL:  "https://alerts.example.com/slack/callback",
+		Cache:        cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			form, _ := url.ParseQuery(string(body))
+			grants = append(grants, form)
+			if form.Get("grant_type") == "refresh_token" {
+				return respond(`{"ok":true,"access_token":"xoxe.xoxb-2","refresh_token":"xoxe-1-b","expires_in":43200}`)
+			}
+			// An already expired token so Token refreshes it
+			return respond(`{"ok":true,"access_token":"xoxe.xoxb-1","refresh_token":"xoxe-1-a","expires_in":60,` +
+				`"bot_user_id":"U0BOT","team":{"id":"T123","name":"Acme"}}`)
+		}),
+	}
+
+	rec := httptest.NewRecorder()
+	slack.InstallHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/slack/install", nil))
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if rec.Code != http.StatusFound || location.Query().Get("scope") != "chat:write" || location.Query().Get("client_id") != "123.456" {
+		t.Fatalf("Expected a redirect to Slack, got %d %s", rec.Code, location)
+	}
+	state := location.Query().Get("state")
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != state || !cookies[0].HttpOnly || !cookies[0].Secure || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("Expected the state in an HttpOnly, Secure, SameSite cookie, got %+v", cookies)
+	}
+	callback := func(state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/slack/callback?code=abc&state="+state, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		slack.CallbackHandler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := callback("forged", cookies[0]); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a forged state to be rejected, got %d", rec.Code)
+	}
+	// A state issued to another browser, such as an attacker's, is rejected (login CSRF)
+	if rec := callback(state, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a state without its cookie to be rejected, got %d", rec.Code)
+	}
+
+	rec = callback(state, cookies[0])
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Acme") {
+		t.Fatalf("Expected the installation to succeed, got %d %s", rec.Code, rec.Body.String())
+	}
+	if grants[0].Get("code") != "abc" || grants[0].Get("client_secret") != "secret" {
+		t.Errorf("Unexpected code exchange: %v", grants[0])
+	}
+	inst, err := LoadInstallation(slack.Cache, "slack", "T123")
+	if err != nil || inst.BotUserID != "U0BOT" || inst.TenantName != "Acme" {
+		t.Fatalf("Expected the installation to be stored, got %+v, %v", inst, err)
+	}
+
+	if rec := callback(state, cookies[0]); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a reused state to be rejected, got %d", rec.Code)
+	}
+
+	token, err := slack.Token("T123")
+	if err != nil || token != "xoxe.xoxb-2" || grants[1].Get("refresh_token") != "xoxe-1-a" {
+		t.Fatalf("Expected the expiring token to be refreshed, got %q, %v", token, err)
+	}
+	if token, _ := slack.Token("T123"); token != "xoxe.xoxb-2" || len(grants) != 2 {
+		t.Errorf("Expected the refreshed token to be reused, got %q after %d exchanges", token, len(grants))
+	}
+	if _, err := slack.Token("T999"); err != ErrNotInstalled {
+		t.Errorf("Expected ErrNotInstalled, got %v", err)
+	}
+
+	store := TokenStore{Slack: slack}
+	if token, err := store.Get("T123", "slack"); err != nil || token != "xoxe.xoxb-2" {
+		t.Errorf("Expected the TokenStore to serve the installed token, got %q, %v", token, err)
+	}
+	if err := store.Set("T777", "slack", "xoxb-manual"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token, _ := store.Get("T777", "slack"); token != "xoxb-manual" {
+		t.Errorf("Expected the set token, got %q", token)
+	}
+	if _, err := store.Get("T123", "lark"); err == nil {
+		t.Error("Expected an error without a Lark app")
+	}
+}
+
+func TestLarkInstallation(t *testing.T) {
+	var paths []string
+	lark := &Lark{
+		AppID:             "cli_123",
+		AppSecret:         "secret",
+		VerificationToken: "verify",
+		Cache:             cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, strings.TrimPrefix(req.URL.Path, "/open-apis/auth/v3"))
+			switch {
+			case strings.HasSuffix(req.URL.Path, "/app_access_token"):
+				return respond(`{"code":0,"app_access_token":"a-123","expire":7200}`)
+			case strings.HasSuffix(req.URL.Path, "/tenant_access_token"):
+				return respond(`{"code":0,"tenant_access_token":"t-456","expire":7200}`)
+			}
+			return respond(`{"code":0}`)
+		}),
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		lark.EventHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/lark/events", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(`{"type":"url_verification","token":"verify","challenge":"c1"}`); !strings.Contains(rec.Body.String(), `"c1"`) {
+		t.Errorf("Expected the challenge to be echoed, got %s", rec.Body.String())
+	}
+	if rec := post(`{"type":"url_verification","token":"wrong","challenge":"c1"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong verification token to be rejected, got %d", rec.Code)
+	}
+	unverified := &Lark{AppID: "cli_123", Cache: cache.NewInMemoryCache()}
+	rec := httptest.NewRecorder()
+	unverified.EventHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/lark/events", strings.NewReader(`{"type":"url_verification","challenge":"c1"}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected events to be rejected without a verification token, got %d", rec.Code)
+	}
+
+	var installed Installation
+	lark.OnInstall = func(inst Installation) { installed = inst }
+	post(`{"type":"event_callback","token":"verify","event":{"type":"app_open","app_id":"cli_123","tenant_key":"tk1"}}`)
+	if installed.TenantID != "tk1" {
+		t.Fatalf("Expected tenant tk1 to be installed, got %+v", installed)
+	}
+
+	if _, err := lark.Token("tk1"); err == nil || len(paths) != 1 || paths[0] != "/app_ticket/resend" {
+		t.Fatalf("Expected a ticket resend without an app ticket, got %v, %v", err, paths)
+	}
+	post(`{"type":"event_callback","token":"verify","event":{"type":"app_ticket","app_id":"cli_123","app_ticket":"ticket"}}`)
+	token, err := lark.Token("tk1")
+	if err != nil || token != "t-456" {
+		t.Fatalf("Expected the tenant token, got %q, %v", token, err)
+	}
+	if token, _ := lark.Token("tk1"); token != "t-456" || len(paths) != 3 {
+		t.Errorf("Expected the tenant token to be cached, got %q after %v", token, paths)
+	}
+	if _, err := lark.Token("tk2"); err != ErrNotInstalled {
+		t.Errorf("Expected ErrNotInstalled, got %v", err)
+	}
+}
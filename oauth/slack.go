@@ -0,0 +1,197 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Slack OAuth endpoints; variables so tests can point them elsewhere
+var (
+	slackAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+	slackAccessURL    = "https://slack.com/api/oauth.v2.access"
+)
+
+// Slack runs the Slack OAuth v2 flow: InstallHandler sends the user to Slack to approve
+// the app, and CallbackHandler, served at RedirectURL, exchanges the returned code for
+// the workspace's bot token and stores it
+type Slack struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string         // Callback URL registered with the Slack app
+	Scopes       []string       // Bot scopes, defaults to chat:write
+	Cache        cache.Cache    // Where installations are stored, defaults to the global cache
+	HTTPClient   types.HTTPDoer // Defaults to http.DefaultClient
+
+	// OnInstall is called after an installation is stored and writes the response to the
+	// user; by default a short confirmation page is written
+	OnInstall func(w http.ResponseWriter, r *http.Request, inst Installation)
+
+	mu sync.Mutex // Serializes token refreshes
+}
+
+// slackAccessResponse is the oauth.v2.access response
+type slackAccessResponse struct {
+	OK           bool   `json:"ok"`
+	Error        string `json:"error,omitempty"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	BotUserID    string `json:"bot_user_id"`
+	Team         struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"team"`
+	IncomingWebhook struct {
+		Channel string `json:"channel"`
+		URL     string `json:"url"`
+	} `json:"incoming_webhook"`
+}
+
+func (s *Slack) doer() types.HTTPDoer {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// InstallHandler redirects to Slack's authorization page with a fresh state, which is
+// also set in a cookie for CallbackHandler to check
+func (s *Slack) InstallHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := newState(s.Cache)
+		if err != nil {
+			http.Error(w, "failed to start installation", http.StatusInternalServerError)
+			return
+		}
+		setStateCookie(w, state, s.RedirectURL)
+		scopes := s.Scopes
+		if len(scopes) == 0 {
+			scopes = []string{"chat:write"}
+		}
+		query := url.Values{
+			"client_id":    {s.ClientID},
+			"scope":        {strings.Join(scopes, ",")},
+			"redirect_uri": {s.RedirectURL},
+			"state":        {state},
+		}
+		http.Redirect(w, r, slackAuthorizeURL+"?"+query.Encode(), http.StatusFound)
+	})
+}
+
+// CallbackHandler completes an installation started by InstallHandler in the same browser
+func (s *Slack) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if reason := query.Get("error"); reason != "" {
+			http.Error(w, "installation was not approved: "+reason, http.StatusForbidden)
+			return
+		}
+		state := query.Get("state")
+		if !browserState(w, r, state, s.RedirectURL) || !consumeState(s.Cache, state) {
+			http.Error(w, "invalid or expired installation state", http.StatusBadRequest)
+			return
+		}
+		code := query.Get("code")
+		if code == "" {
+			http.Error(w, "missing authorization code", http.StatusBadRequest)
+			return
+		}
+
+		result, err := s.exchange(url.Values{"code": {code}, "redirect_uri": {s.RedirectURL}})
+		if err != nil {
+			log.Printf("[ERROR] Slack installation failed: %v", err)
+			http.Error(w, "installation failed", http.StatusBadGateway)
+			return
+		}
+		inst := Installation{
+			Provider:       "slack",
+			TenantID:       result.Team.ID,
+			TenantName:     result.Team.Name,
+			AccessToken:    result.AccessToken,
+			RefreshToken:   result.RefreshToken,
+			BotUserID:      result.BotUserID,
+			WebhookURL:     result.IncomingWebhook.URL,
+			WebhookChannel: result.IncomingWebhook.Channel,
+			InstalledAt:    time.Now(),
+		}
+		if result.ExpiresIn > 0 {
+			inst.ExpiresAt = inst.InstalledAt.Add(time.Duration(result.ExpiresIn) * time.Second)
+		}
+		if err := SaveInstallation(s.Cache, inst); err != nil {
+			log.Printf("[ERROR] Failed to store Slack installation: %v", err)
+			http.Error(w, "installation failed", http.StatusInternalServerError)
+			return
+		}
+		if s.OnInstall != nil {
+			s.OnInstall(w, r, inst)
+			return
+		}
+		fmt.Fprintf(w, "Installed to %s. You can close this page.\n", inst.TenantName)
+	})
+}
+
+// exchange calls oauth.v2.access with the app credentials and the given grant parameters
+func (s *Slack) exchange(params url.Values) (slackAccessResponse, error) {
+	params.Set("client_id", s.ClientID)
+	params.Set("client_secret", s.ClientSecret)
+	req, err := http.NewRequest("POST", slackAccessURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return slackAccessResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.doer().Do(req)
+	if err != nil {
+		return slackAccessResponse{}, err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return slackAccessResponse{}, fmt.Errorf("slack oauth.v2.access response: %d", resp.StatusCode)
+	}
+	var result slackAccessResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return slackAccessResponse{}, fmt.Errorf("invalid slack oauth.v2.access response: %w", err)
+	}
+	if !result.OK {
+		return slackAccessResponse{}, fmt.Errorf("slack oauth error: %s", result.Error)
+	}
+	return result, nil
+}
+
+// Token returns the bot token of an installed workspace, refreshing it with the stored
+// refresh token when token rotation is enabled and it expires within 10 minutes
+func (s *Slack) Token(teamID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inst, err := LoadInstallation(s.Cache, "slack", teamID)
+	if err != nil {
+		return "", err
+	}
+	if inst.ExpiresAt.IsZero() || inst.RefreshToken == "" || time.Until(inst.ExpiresAt) > 10*time.Minute {
+		return inst.AccessToken, nil
+	}
+
+	result, err := s.exchange(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {inst.RefreshToken}})
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh Slack token for %s: %w", teamID, err)
+	}
+	inst.AccessToken = result.AccessToken
+	if result.RefreshToken != "" {
+		inst.RefreshToken = result.RefreshToken
+	}
+	inst.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	if err := SaveInstallation(s.Cache, inst); err != nil {
+		return "", err
+	}
+	return inst.AccessToken, nil
+}
@@ -0,0 +1,160 @@
+// Package oncall provides types.OnCallResolver implementations and a ChannelResolver
+// that routes alerts to whoever is on call instead of a static channel.
+package oncall
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ErrNoOnCall is returned when nobody is on call at the requested time
+var ErrNoOnCall = errors.New("oncall: nobody is on call")
+
+// Rotation is a fixed rotation: Targets take turns for Shift each, starting at Start
+type Rotation struct {
+	Start   time.Time
+	Shift   time.Duration
+	Targets []string
+}
+
+// OnCall returns the target whose shift covers at
+func (r Rotation) OnCall(at time.Time) (string, error) {
+	if len(r.Targets) == 0 || r.Shift <= 0 || at.Before(r.Start) {
+		return "", ErrNoOnCall
+	}
+	shift := int64(at.Sub(r.Start) / r.Shift)
+	return r.Targets[shift%int64(len(r.Targets))], nil
+}
+
+// HTTPResolver asks an HTTP endpoint who is on call, caching the answer for TTL. The
+// endpoint answers GET requests with {"on_call": "<target>"} or the target as plain text.
+type HTTPResolver struct {
+	URL        string
+	Token      string         // Optional bearer token
+	TTL        time.Duration  // How long an answer is reused, defaults to one minute
+	HTTPClient types.HTTPDoer // Defaults to http.DefaultClient
+
+	mu      sync.Mutex
+	target  string
+	fetched time.Time
+}
+
+// onCallResponse is the JSON answer of an on-call endpoint
+type onCallResponse struct {
+	OnCall string `json:"on_call"`
+}
+
+// OnCall returns the cached target, refreshing it from the endpoint once TTL has passed
+func (r *HTTPResolver) OnCall(at time.Time) (string, error) {
+	ttl := r.TTL
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.target != "" && at.Sub(r.fetched) < ttl && !at.Before(r.fetched) {
+		return r.target, nil
+	}
+
+	target, err := r.fetch()
+	if err != nil {
+		return "", err
+	}
+	r.target, r.fetched = target, at
+	return target, nil
+}
+
+func (r *HTTPResolver) fetch() (string, error) {
+	req, err := http.NewRequest("GET", r.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	req.Header.Set("User-Agent", types.UserAgent())
+	var client types.HTTPDoer = http.DefaultClient
+	if r.HTTPClient != nil {
+		client = r.HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("on-call endpoint response: %d", resp.StatusCode)
+	}
+
+	target := strings.TrimSpace(string(body))
+	if strings.HasPrefix(target, "{") {
+		var result onCallResponse
+		if err := json.Unmarshal(body, &result); err != nil {
+			return "", fmt.Errorf("invalid on-call response: %w", err)
+		}
+		target = strings.TrimSpace(result.OnCall)
+	}
+	if target == "" {
+		return "", ErrNoOnCall
+	}
+	return target, nil
+}
+
+// ChannelResolver routes alerts of the given Levels to whoever OnCall returns, and every
+// other alert, or any alert when the on-call lookup fails, to Fallback
+type ChannelResolver struct {
+	OnCall   types.OnCallResolver
+	Levels   []int                 // Levels routed to the on-call target, defaults to ERROR only
+	Fallback types.ChannelResolver // Used for other levels and lookup failures
+	Clock    types.Clock           // Defaults to the system clock
+}
+
+// ResolveChannel implements types.ChannelResolver
+func (r *ChannelResolver) ResolveChannel(level int) string {
+	return r.ResolveChannelContext(types.AlertContext{Level: level})
+}
+
+// ResolveChannelContext implements types.ContextChannelResolver, passing the alert
+// context on to Fallback
+func (r *ChannelResolver) ResolveChannelContext(alert types.AlertContext) string {
+	if r.routesLevel(alert.Level) {
+		now := time.Now()
+		if r.Clock != nil {
+			now = r.Clock.Now()
+		}
+		target, err := r.OnCall.OnCall(now)
+		if err == nil {
+			return target
+		}
+		log.Printf("[WARN] On-call lookup failed, using fallback channel: %v", err)
+	}
+	if r.Fallback != nil {
+		return types.AsContextResolver(r.Fallback).ResolveChannelContext(alert)
+	}
+	return ""
+}
+
+func (r *ChannelResolver) routesLevel(level int) bool {
+	if len(r.Levels) == 0 {
+		return level == types.ERROR
+	}
+	for _, l := range r.Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package oncall
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestRotation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	rotation := Rotation{Start: start, Shift: 7 * 24 * time.Hour, Targets: []string{"U_ALICE", "U_BOB"}}
+	cases := map[time.Time]string{
+		start:                                  "U_ALICE",
+		start.Add(6 * 24 * time.Hour):          "U_ALICE",
+		start.Add(7 * 24 * time.Hour):          "U_BOB",
+		start.Add(14*24*time.Hour + time.Hour): "U_ALICE",
+	}
+	for at, expected := range cases {
+		if target, err := rotation.OnCall(at); err != nil || target != expected {
+			t.Errorf("At %s expected %s, got %s, %v", at, expected, target, err)
+		}
+	}
+	if _, err := rotation.OnCall(start.Add(-time.Hour)); err != ErrNoOnCall {
+		t.Errorf("Expected ErrNoOnCall before the rotation starts, got %v", err)
+	}
+}
+
+func TestHTTPResolverCachesAnswer(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer schedule-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"on_call":"U_CAROL"}`))
+	}))
+	defer server.Close()
+
+	resolver := &HTTPResolver{URL: server.URL, Token: "schedule-token", TTL: time.Minute}
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(30 * time.Second), now.Add(2 * time.Minute)} {
+		if target, err := resolver.OnCall(at); err != nil || target != "U_CAROL" {
+			t.Fatalf("Expected U_CAROL, got %q, %v", target, err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected the answer to be cached for the TTL, got %d requests", requests)
+	}
+}
+
+type staticOnCall struct {
+	target string
+	err    error
+}
+
+func (s staticOnCall) OnCall(time.Time) (string, error) { return s.target, s.err }
+
+func TestChannelResolver(t *testing.T) {
+	fallback := &types.DefaultChannelResolver{DefaultChannel: "#alerts"}
+	resolver := &ChannelResolver{OnCall: staticOnCall{target: "U_ALICE"}, Fallback: fallback}
+	if channel := resolver.ResolveChannel(types.ERROR); channel != "U_ALICE" {
+		t.Errorf("Expected ERROR to page the on-call user, got %s", channel)
+	}
+	if channel := resolver.ResolveChannel(types.WARN); channel != "#alerts" {
+		t.Errorf("Expected WARN to use the fallback, got %s", channel)
+	}
+
+	resolver.OnCall = staticOnCall{err: errors.New("schedule unavailable")}
+	if channel := resolver.ResolveChannel(types.ERROR); channel != "#alerts" {
+		t.Errorf("Expected lookup failures to use the fallback, got %s", channel)
+	}
+}
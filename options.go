@@ -0,0 +1,98 @@
+package gocommonlog
+
+import (
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Option customizes a Logger created by NewLogger, injecting dependencies that would
+// otherwise be created from Config
+type Option func(*loggerOptions)
+
+type loggerOptions struct {
+	provider   types.Provider
+	cache      cache.Cache
+	httpClient types.HTTPDoer
+	clock      types.Clock
+	store      types.ObjectStore
+	paste      types.PasteSink
+}
+
+// WithProvider makes the logger send with provider instead of creating one from
+// Config.Provider, for example a fake in tests or a wrapper adding behavior. Routes and
+// broadcast group members without their own provider use it too. Config.Provider still
+// names the provider in audit records and debug logs.
+func WithProvider(provider types.Provider) Option {
+	return func(o *loggerOptions) { o.provider = provider }
+}
+
+// WithCache sets Config.Cache, the cache holding tokens, lookups and ack state
+func WithCache(c cache.Cache) Option {
+	return func(o *loggerOptions) { o.cache = c }
+}
+
+// WithHTTPClient sets Config.HTTPClient, the client providers send requests with
+func WithHTTPClient(client types.HTTPDoer) Option {
+	return func(o *loggerOptions) { o.httpClient = client }
+}
+
+// WithClock sets Config.Clock, the time source of timestamps, schedules and TTLs. A clock
+// implementing types.TimerClock, such as types.ManualClock, also fires scheduled alerts,
+// reminders, escalations and the end of mutes.
+func WithClock(clock types.Clock) Option {
+	return func(o *loggerOptions) { o.clock = clock }
+}
+
+// WithObjectStore sets Config.ObjectStore, where attachments too large for the provider
+// are uploaded
+func WithObjectStore(store types.ObjectStore) Option {
+	return func(o *loggerOptions) { o.store = store }
+}
+
+// WithPasteSink sets Config.PasteSink, where attachments too large for the provider are
+// pasted and previewed by their first lines
+func WithPasteSink(sink types.PasteSink) Option {
+	return func(o *loggerOptions) { o.paste = sink }
+}
+
+// apply sets the injected dependencies on cfg
+func (o loggerOptions) apply(cfg *types.Config) {
+	if o.cache != nil {
+		cfg.Cache = o.cache
+	}
+	if o.httpClient != nil {
+		cfg.HTTPClient = o.httpClient
+	}
+	if o.clock != nil {
+		cfg.Clock = o.clock
+	}
+	if o.store != nil {
+		cfg.ObjectStore = o.store
+	}
+	if o.paste != nil {
+		cfg.PasteSink = o.paste
+	}
+}
+
+// Provider returns the provider the logger sends with when no route or option overrides it
+func (l *Logger) Provider() types.Provider {
+	return l.provider
+}
+
+// Config returns the logger's configuration, with ProviderConfig populated from the
+// top-level fields as NewLogger resolved it. The returned maps are copies, so changing
+// them does not affect the logger.
+func (l *Logger) Config() types.Config {
+	cfg := l.config
+	cfg.ProviderConfig = make(map[string]interface{}, len(l.config.ProviderConfig))
+	for key, value := range l.config.ProviderConfig {
+		cfg.ProviderConfig[key] = value
+	}
+	if l.config.Fields != nil {
+		cfg.Fields = make(map[string]string, len(l.config.Fields))
+		for key, value := range l.config.Fields {
+			cfg.Fields[key] = value
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,121 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// pinErrors reports whether ERROR alerts are pinned as they are sent, from pin_errors
+func (l *Logger) pinErrors() bool {
+	pin, _ := l.config.ProviderConfig["pin_errors"].(bool)
+	return pin
+}
+
+// Pin pins the message posted for an alert to the top of its channel, so an active
+// incident stays visible while newer alerts arrive. It returns ErrAlertNotFound when the
+// alert's message was not posted by a provider supporting pins, such as Slack or Lark
+// with the webclient method, or has expired (ack_ttl). A pinned alert does not expire
+// until it is unpinned.
+func (l *Logger) Pin(alertID string) error {
+	return l.setPinned(alertID, true)
+}
+
+// Unpin removes the pin of an alert's message, once its incident is resolved
+func (l *Logger) Unpin(alertID string) error {
+	return l.setPinned(alertID, false)
+}
+
+// setPinned pins or unpins the messages of an alert and records their state for Resolve
+func (l *Logger) setPinned(alertID string, pin bool) error {
+	found := false
+	for _, posted := range l.postedFor(alertID) {
+		pinner, ok := posted.provider.(types.Pinner)
+		if !ok {
+			continue
+		}
+		found = true
+		var err error
+		if pin {
+			err = pinner.Pin(posted.config, posted.channel, posted.messageID)
+		} else {
+			err = pinner.Unpin(posted.config, posted.channel, posted.messageID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update the pin of alert %s: %w", alertID, err)
+		}
+		l.postedMu.Lock()
+		for i, current := range l.posted[alertID] {
+			if current.messageID == posted.messageID && current.channel == posted.channel {
+				l.posted[alertID][i].pinned = pin
+			}
+		}
+		l.postedMu.Unlock()
+	}
+	if !found {
+		return ErrAlertNotFound
+	}
+	types.DebugLog(l.config, "Alert %s pinned: %t", alertID, pin)
+	return nil
+}
+
+// pinSent pins an ERROR alert just sent when pin_errors is set, unless an alert of the
+// same incident is already pinned. Failures are logged, as the alert itself was delivered.
+func (l *Logger) pinSent(alertID string) {
+	if !l.pinErrors() {
+		return
+	}
+	if pinned := l.pinnedIncident(alertID); pinned != "" {
+		types.DebugLog(l.config, "Alert %s belongs to the incident of pinned alert %s, not pinning it", alertID, pinned)
+		return
+	}
+	if err := l.Pin(alertID); err == ErrAlertNotFound {
+		types.DebugLog(l.config, "Alert %s was not posted by a provider supporting pins, not pinning it", alertID)
+	} else if err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+}
+
+// pinnedIncident returns the ID of another pinned alert of the same incident as alertID:
+// with the same condition, or the same fingerprint for alerts sent without one. Only the
+// first alert of an incident is pinned, until its condition is resolved or it is unpinned.
+func (l *Logger) pinnedIncident(alertID string) string {
+	l.postedMu.Lock()
+	defer l.postedMu.Unlock()
+	posts := l.posted[alertID]
+	if len(posts) == 0 {
+		return ""
+	}
+	condition, fingerprint := posts[0].condition, posts[0].fingerprint
+	for id, other := range l.posted {
+		if id == alertID || !pinnedPost(other) {
+			continue
+		}
+		if condition != "" && other[0].condition == condition ||
+			condition == "" && fingerprint != "" && other[0].condition == "" && other[0].fingerprint == fingerprint {
+			return id
+		}
+	}
+	return ""
+}
+
+// unpinCondition unpins the pinned alerts sent with condition, when Resolve is called
+func (l *Logger) unpinCondition(condition string) {
+	var pinned []string
+	l.postedMu.Lock()
+	for id, posts := range l.posted {
+		for _, posted := range posts {
+			if posted.pinned && posted.condition == condition {
+				pinned = append(pinned, id)
+				break
+			}
+		}
+	}
+	l.postedMu.Unlock()
+	for _, id := range pinned {
+		if err := l.Unpin(id); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package providers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// awsCredentials holds the static credentials used for Signature Version 4 signing
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// awsConfig reads credentials and region from provider config, falling back to the
+// standard AWS_* environment variables
+func awsConfig(cfg types.Config) (awsCredentials, string, error) {
+	lookup := func(key, env string) string {
+		if value, ok := cfg.ProviderConfig[key].(string); ok && value != "" {
+			return value
+		}
+		return os.Getenv(env)
+	}
+	creds := awsCredentials{
+		AccessKeyID:     lookup("aws_access_key_id", "AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: lookup("aws_secret_access_key", "AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    lookup("aws_session_token", "AWS_SESSION_TOKEN"),
+	}
+	region := lookup("aws_region", "AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, region, fmt.Errorf("AWS credentials must be set in provider_config or environment")
+	}
+	if region == "" {
+		return creds, region, fmt.Errorf("aws_region must be set in provider_config or environment")
+	}
+	return creds, region, nil
+}
+
+// signAWSRequest signs req in place with AWS Signature Version 4
+func signAWSRequest(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	payloadHash := sha256Hex(body)
+
+	// Sign host, content-type and every x-amz-* header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(creds, date, region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// presignAWSURL returns u with a Signature Version 4 query string, so anyone holding the
+// URL can GET it until expires has passed
+func presignAWSURL(u url.URL, creds awsCredentials, region, service string, now time.Time, expires time.Duration) string {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprint(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	canonicalQuery := awsCanonicalQuery(query)
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{"GET", path, canonicalQuery, "host:" + u.Host + "\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(awsSigningKey(creds, date, region, service), stringToSign))
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+// awsSigningKey derives the Signature Version 4 signing key for date, region and service
+func awsSigningKey(creds awsCredentials, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// awsCanonicalQuery encodes query parameters sorted by key using RFC 3986 escaping
+func awsCanonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, awsURIEncode(k)+"="+awsURIEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode escapes everything except unreserved characters, as SigV4 requires
+func awsURIEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
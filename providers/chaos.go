@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// chaosTimeout is the error returned for a simulated timeout; like net errors it
+// reports Timeout() so retry logic treats it as one
+type chaosTimeout struct{}
+
+func (chaosTimeout) Error() string   { return "chaos: simulated timeout" }
+func (chaosTimeout) Timeout() bool   { return true }
+func (chaosTimeout) Temporary() bool { return true }
+
+// faultInjection returns the fault injection settings for the provider sending cfg, from
+// the chaos setting given as a types.FaultInjection for every provider or as a
+// map[string]types.FaultInjection by provider name
+func faultInjection(cfg types.Config) (types.FaultInjection, bool) {
+	switch chaos := cfg.ProviderConfig["chaos"].(type) {
+	case types.FaultInjection:
+		return chaos, true
+	case map[string]types.FaultInjection:
+		provider := cfg.Provider
+		if provider == "" {
+			provider = settingsOf(cfg).String("provider", "")
+		}
+		faults, ok := chaos[provider]
+		return faults, ok
+	}
+	return types.FaultInjection{}, false
+}
+
+// errChaosConnectionRefused is the cause of a simulated connection error
+var errChaosConnectionRefused = errors.New("chaos: simulated connection refused")
+
+// chaosDoer fails requests at random with simulated timeouts, connection errors, 429s and
+// 5xxs, and delays the others by the configured latency
+type chaosDoer struct {
+	next   types.HTTPDoer
+	faults types.FaultInjection
+	cfg    types.Config
+}
+
+func (d chaosDoer) Do(req *http.Request) (*http.Response, error) {
+	roll := rand.Float64()
+	if roll < d.faults.Timeout {
+		types.DebugLog(d.cfg, "chaos: simulating timeout for %s %s", req.Method, req.URL.Host)
+		return nil, &url.Error{Op: req.Method, URL: req.URL.Redacted(), Err: chaosTimeout{}}
+	}
+	roll -= d.faults.Timeout
+	if roll < d.faults.TooManyRequests {
+		types.DebugLog(d.cfg, "chaos: simulating 429 for %s %s", req.Method, req.URL.Host)
+		resp := chaosResponse(req, http.StatusTooManyRequests)
+		resp.Header.Set("Retry-After", "1")
+		return resp, nil
+	}
+	roll -= d.faults.TooManyRequests
+	if roll < d.faults.ServerError {
+		types.DebugLog(d.cfg, "chaos: simulating 503 for %s %s", req.Method, req.URL.Host)
+		return chaosResponse(req, http.StatusServiceUnavailable), nil
+	}
+	roll -= d.faults.ServerError
+	if roll < d.faults.ConnectionError {
+		types.DebugLog(d.cfg, "chaos: simulating connection error for %s %s", req.Method, req.URL.Host)
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &url.Error{Op: req.Method, URL: req.URL.Redacted(), Err: &net.OpError{Op: "dial", Net: "tcp", Err: errChaosConnectionRefused}}
+	}
+	if d.faults.Latency > 0 {
+		types.DebugLog(d.cfg, "chaos: delaying %s %s by %s", req.Method, req.URL.Host, d.faults.Latency)
+		timer := time.NewTimer(d.faults.Latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, &url.Error{Op: req.Method, URL: req.URL.Redacted(), Err: req.Context().Err()}
+		}
+	}
+	return d.next.Do(req)
+}
+
+// chaosResponse builds a simulated error response without contacting the provider
+func chaosResponse(req *http.Request, status int) *http.Response {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	body := fmt.Sprintf("chaos: simulated %d %s", status, http.StatusText(status))
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode: status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
@@ -0,0 +1,187 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// CloudWatchProvider implements Provider by writing alert events into a CloudWatch Logs group/stream
+type CloudWatchProvider struct{}
+
+// cloudWatchEndpoint returns the Logs endpoint for a region; a variable so tests can point it elsewhere
+var cloudWatchEndpoint = func(region string) string {
+	return fmt.Sprintf("https://logs.%s.amazonaws.com/", region)
+}
+
+// cloudWatchTokens remembers the next sequence token per group/stream
+var (
+	cloudWatchTokens   = map[string]string{}
+	cloudWatchTokensMu sync.Mutex
+)
+
+type cloudWatchError struct {
+	Type                  string `json:"__type"`
+	Message               string `json:"message"`
+	ExpectedSequenceToken string `json:"expectedSequenceToken"`
+}
+
+func (p *CloudWatchProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *CloudWatchProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "CloudWatchProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	creds, region, err := awsConfig(cfg)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	group, _ := cfg.ProviderConfig["cloudwatch_log_group"].(string)
+	if group == "" {
+		group = channel
+	}
+	if group == "" {
+		err := fmt.Errorf("cloudwatch_log_group must be set in provider_config or provided as channel")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	stream, _ := cfg.ProviderConfig["cloudwatch_log_stream"].(string)
+	if stream == "" {
+		stream = cfg.ServiceName
+	}
+	if stream == "" {
+		stream, _ = os.Hostname()
+	}
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	logEvent := map[string]interface{}{
+		"timestamp": currentTime(cfg).UnixNano() / int64(time.Millisecond),
+		"message":   string(data),
+	}
+
+	key := group + "|" + stream
+	// Retry once after creating a missing stream, and once after a sequence token mismatch
+	for attempt := 0; attempt < 3; attempt++ {
+		payload := map[string]interface{}{
+			"logGroupName":  group,
+			"logStreamName": stream,
+			"logEvents":     []interface{}{logEvent},
+		}
+		cloudWatchTokensMu.Lock()
+		if token := cloudWatchTokens[key]; token != "" {
+			payload["sequenceToken"] = token
+		}
+		cloudWatchTokensMu.Unlock()
+
+		types.DebugLog(cfg, "sendCloudWatch: PutLogEvents to %s/%s (attempt %d)", group, stream, attempt+1)
+		respBody, cwErr, err := cloudWatchCall(cfg, creds, region, "PutLogEvents", payload)
+		if err != nil {
+			return err
+		}
+		if cwErr == nil {
+			var result struct {
+				NextSequenceToken string `json:"nextSequenceToken"`
+			}
+			json.Unmarshal(respBody, &result)
+			cloudWatchTokensMu.Lock()
+			cloudWatchTokens[key] = result.NextSequenceToken
+			cloudWatchTokensMu.Unlock()
+			types.DebugLog(cfg, "sendCloudWatch: log event written successfully")
+			return nil
+		}
+
+		switch {
+		case strings.HasSuffix(cwErr.Type, "ResourceNotFoundException"):
+			types.DebugLog(cfg, "sendCloudWatch: log stream %s not found, creating it", stream)
+			if err := p.createStream(cfg, creds, region, group, stream); err != nil {
+				return err
+			}
+		case strings.HasSuffix(cwErr.Type, "InvalidSequenceTokenException"), strings.HasSuffix(cwErr.Type, "DataAlreadyAcceptedException"):
+			types.DebugLog(cfg, "sendCloudWatch: refreshing sequence token after %s", cwErr.Type)
+			cloudWatchTokensMu.Lock()
+			cloudWatchTokens[key] = cwErr.ExpectedSequenceToken
+			cloudWatchTokensMu.Unlock()
+			if strings.HasSuffix(cwErr.Type, "DataAlreadyAcceptedException") {
+				return nil
+			}
+		default:
+			return fmt.Errorf("cloudwatch PutLogEvents error: %s: %s", cwErr.Type, cwErr.Message)
+		}
+	}
+	return fmt.Errorf("cloudwatch PutLogEvents failed after retries")
+}
+
+// createStream creates the log stream, and the log group first when cloudwatch_create_group is set
+func (p *CloudWatchProvider) createStream(cfg types.Config, creds awsCredentials, region, group, stream string) error {
+	_, cwErr, err := cloudWatchCall(cfg, creds, region, "CreateLogStream", map[string]string{
+		"logGroupName":  group,
+		"logStreamName": stream,
+	})
+	if err != nil {
+		return err
+	}
+	if cwErr == nil || strings.HasSuffix(cwErr.Type, "ResourceAlreadyExistsException") {
+		return nil
+	}
+	createGroup, _ := cfg.ProviderConfig["cloudwatch_create_group"].(bool)
+	if !createGroup || !strings.HasSuffix(cwErr.Type, "ResourceNotFoundException") {
+		return fmt.Errorf("cloudwatch CreateLogStream error: %s: %s", cwErr.Type, cwErr.Message)
+	}
+
+	types.DebugLog(cfg, "sendCloudWatch: log group %s not found, creating it", group)
+	_, cwErr, err = cloudWatchCall(cfg, creds, region, "CreateLogGroup", map[string]string{"logGroupName": group})
+	if err != nil {
+		return err
+	}
+	if cwErr != nil && !strings.HasSuffix(cwErr.Type, "ResourceAlreadyExistsException") {
+		return fmt.Errorf("cloudwatch CreateLogGroup error: %s: %s", cwErr.Type, cwErr.Message)
+	}
+	return p.createStream(cfg, creds, region, group, stream)
+}
+
+// cloudWatchCall performs a signed Logs API action. Service errors are returned as
+// *cloudWatchError; transport failures as error.
+func cloudWatchCall(cfg types.Config, creds awsCredentials, region, action string, payload interface{}) ([]byte, *cloudWatchError, error) {
+	data, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", cloudWatchEndpoint(region), bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	signAWSRequest(req, data, creds, region, "logs", currentTime(cfg))
+
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "cloudWatchCall: %s failed: %v", action, err)
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "cloudWatchCall: %s response status: %d, body: %s", action, resp.StatusCode, respData.String())
+
+	if resp.StatusCode == 200 {
+		return respData.Bytes(), nil, nil
+	}
+	var cwErr cloudWatchError
+	if err := json.Unmarshal(respData.Bytes(), &cwErr); err != nil || cwErr.Type == "" {
+		return nil, nil, fmt.Errorf("cloudwatch %s response: %d", action, resp.StatusCode)
+	}
+	return nil, &cwErr, nil
+}
@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestCloudWatchCreatesMissingStream(t *testing.T) {
+	var actions []string
+	streamExists := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+		actions = append(actions, action)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch action {
+		case "PutLogEvents":
+			if !streamExists {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"The specified log stream does not exist."}`))
+				return
+			}
+			var payload map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&payload)
+			if payload["logGroupName"] != "/alerts" || payload["logStreamName"] != "billing" {
+				t.Errorf("Unexpected target: %v", payload)
+			}
+			w.Write([]byte(`{"nextSequenceToken":"token-2"}`))
+		case "CreateLogStream":
+			streamExists = true
+		}
+	}))
+	defer server.Close()
+	original := cloudWatchEndpoint
+	cloudWatchEndpoint = func(region string) string { return server.URL + "/" }
+	defer func() { cloudWatchEndpoint = original }()
+
+	cfg := types.Config{
+		ServiceName: "billing",
+		ProviderConfig: map[string]interface{}{
+			"aws_access_key_id":     "AKID",
+			"aws_secret_access_key": "secret",
+			"aws_region":            "eu-west-1",
+			"cloudwatch_log_group":  "/alerts",
+		},
+	}
+	if err := (&CloudWatchProvider{}).Send(types.ERROR, "boom", nil, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "PutLogEvents,CreateLogStream,PutLogEvents"
+	if got := strings.Join(actions, ","); got != expected {
+		t.Errorf("Expected actions %s, got %s", expected, got)
+	}
+	if cloudWatchTokens["/alerts|billing"] != "token-2" {
+		t.Errorf("Expected sequence token to be remembered, got %q", cloudWatchTokens["/alerts|billing"])
+	}
+}
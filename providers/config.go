@@ -0,0 +1,373 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// configStringSlice reads a list from provider config given as []string or a comma separated string
+func configStringSlice(v interface{}) []string {
+	switch values := v.(type) {
+	case []string:
+		return values
+	case string:
+		var result []string
+		for _, item := range strings.Split(values, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// configInt reads an int from provider config given as int or string, returning 0 when unset
+func configInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case string:
+		if parsed, err := strconv.Atoi(n); err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
+
+// configDuration reads a duration from provider config given as time.Duration or string
+func configDuration(v interface{}) time.Duration {
+	switch d := v.(type) {
+	case time.Duration:
+		return d
+	case string:
+		if parsed, err := time.ParseDuration(d); err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
+
+// providerSettings gives typed access to ProviderConfig, so a missing or mistyped
+// setting becomes a default or a descriptive error instead of a panic
+type providerSettings map[string]interface{}
+
+func settingsOf(cfg types.Config) providerSettings {
+	return providerSettings(cfg.ProviderConfig)
+}
+
+// String returns the string setting key, or def when it is unset, empty or not a string
+func (s providerSettings) String(key, def string) string {
+	if value, ok := s[key].(string); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// RequireString returns the string setting key, or an error describing what is missing
+func (s providerSettings) RequireString(key, description string) (string, error) {
+	value, exists := s[key]
+	if !exists || value == nil || value == "" {
+		return "", fmt.Errorf("%s is required (provider_config %q)", description, key)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string (provider_config %q is %T)", description, key, value)
+	}
+	return str, nil
+}
+
+// Bool returns the bool setting key, accepting "true"/"false" strings, or def when unset
+func (s providerSettings) Bool(key string, def bool) bool {
+	switch value := s[key].(type) {
+	case bool:
+		return value
+	case string:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// Int returns the int setting key given as int or string, or def when unset or invalid
+func (s providerSettings) Int(key string, def int) int {
+	if _, exists := s[key]; !exists {
+		return def
+	}
+	if value, ok := s[key].(int); ok {
+		return value
+	}
+	if value, ok := s[key].(string); ok {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// Duration returns the duration setting key, or def when unset or invalid
+func (s providerSettings) Duration(key string, def time.Duration) time.Duration {
+	if value := configDuration(s[key]); value != 0 {
+		return value
+	}
+	return def
+}
+
+// StringSlice returns the list setting key given as []string or a comma separated string
+func (s providerSettings) StringSlice(key string) []string {
+	return configStringSlice(s[key])
+}
+
+// channelNotFoundError keeps a provider's own error message while matching
+// types.ErrChannelNotFound with errors.Is
+type channelNotFoundError struct{ err error }
+
+func (e channelNotFoundError) Error() string { return e.err.Error() }
+
+func (e channelNotFoundError) Is(target error) bool { return target == types.ErrChannelNotFound }
+
+func (e channelNotFoundError) Unwrap() error { return e.err }
+
+// Operation types of provider requests, each limited by its own timeout setting
+const (
+	opAuth   = "auth"   // Token and secret fetches, limited by auth_timeout
+	opLookup = "lookup" // Channel and chat lookups, limited by lookup_timeout
+	opSend   = "send"   // Messages, uploads and every other request, limited by send_timeout
+)
+
+// httpDoer returns the HTTP client configured on cfg for sending alerts, defaulting to
+// http.DefaultClient
+func httpDoer(cfg types.Config) types.HTTPDoer {
+	return operationDoer(cfg, opSend)
+}
+
+// operationDoer returns the HTTP client configured on cfg for a type of operation, with
+// requests limited by the operation's timeout setting when it is set
+func operationDoer(cfg types.Config, operation string) types.HTTPDoer {
+	doer := baseDoer(cfg)
+	if timeout := settingsOf(cfg).Duration(operation+"_timeout", 0); timeout > 0 {
+		doer = timeoutDoer{next: doer, operation: operation, timeout: timeout}
+	}
+	return doer
+}
+
+// baseDoer returns the HTTP client configured on cfg with the library's wrappers
+func baseDoer(cfg types.Config) types.HTTPDoer {
+	var doer types.HTTPDoer = http.DefaultClient
+	if cfg.HTTPClient != nil {
+		doer = cfg.HTTPClient
+	}
+	if faults, ok := faultInjection(cfg); ok {
+		doer = chaosDoer{next: doer, faults: faults, cfg: cfg}
+	}
+	if cfg.FlightRecorder != nil {
+		doer = recordingDoer{next: doer, recorder: cfg.FlightRecorder, alertID: cfg.MessageID}
+	}
+	if cfg.Response != nil {
+		doer = responseDoer{next: doer, response: cfg.Response}
+	}
+	return userAgentDoer{doer}
+}
+
+// responseDoer records the status and rate-limit headers of each response in the
+// ProviderResponse of the send
+type responseDoer struct {
+	next     types.HTTPDoer
+	response *types.ProviderResponse
+}
+
+func (d responseDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.Do(req)
+	if err == nil {
+		d.response.RecordHTTP(resp)
+	}
+	return resp, err
+}
+
+// timeoutDoer limits each request, including reading its response body, to timeout
+type timeoutDoer struct {
+	next      types.HTTPDoer
+	operation string
+	timeout   time.Duration
+}
+
+func (d timeoutDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), d.timeout)
+	resp, err := d.next.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s request to %s timed out after %s: %w", d.operation, req.URL.Host, d.timeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// userAgentDoer sets the library User-Agent on requests that do not set their own
+type userAgentDoer struct {
+	next types.HTTPDoer
+}
+
+func (d userAgentDoer) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", types.UserAgent())
+	}
+	return d.next.Do(req)
+}
+
+// currentTime returns the time from the clock configured on cfg, defaulting to time.Now
+func currentTime(cfg types.Config) time.Time {
+	if cfg.Clock != nil {
+		return cfg.Clock.Now()
+	}
+	return time.Now()
+}
+
+// cacheStore returns the cache configured on cfg, defaulting to the global cache, with
+// values encrypted when cache_encryption_key is set
+func cacheStore(cfg types.Config) cache.Cache {
+	store := cfg.Cache
+	if store == nil {
+		store = cache.GetGlobalCache()
+	}
+	if _, ok := cfg.ProviderConfig["cache_encryption_key"]; !ok {
+		return store
+	}
+	cipher, err := cacheCipher(cfg)
+	if err != nil {
+		log.Printf("[ERROR] Not caching: %v", err)
+		return discardCache{}
+	}
+	return cache.NewEncryptedCache(store, cipher)
+}
+
+// redisStats counts the lookups and writes of tokens and chat IDs cached in Redis
+var redisStats struct {
+	hits, misses, sets, deletes atomic.Int64
+}
+
+// RedisCacheStats returns the counters of tokens and chat IDs cached in Redis since the
+// process started. Size is -1 since the Redis keyspace may be shared.
+func RedisCacheStats() cache.Stats {
+	return cache.Stats{
+		Hits:    redisStats.hits.Load(),
+		Misses:  redisStats.misses.Load(),
+		Sets:    redisStats.sets.Load(),
+		Deletes: redisStats.deletes.Load(),
+		Size:    -1,
+	}
+}
+
+// countRedisLookup records a Redis lookup as a hit or miss
+func countRedisLookup(value string) string {
+	if value == "" {
+		redisStats.misses.Add(1)
+	} else {
+		redisStats.hits.Add(1)
+	}
+	return value
+}
+
+// cacheCiphers holds the Cipher of each cache_encryption_key, so the AES key schedule is
+// computed once
+var cacheCiphers sync.Map // key -> *cache.Cipher
+
+// cacheCipher returns the Cipher for cache_encryption_key, or nil when it is not set
+func cacheCipher(cfg types.Config) (*cache.Cipher, error) {
+	key := settingsOf(cfg).String("cache_encryption_key", "")
+	if key == "" {
+		if _, ok := cfg.ProviderConfig["cache_encryption_key"]; ok {
+			return nil, fmt.Errorf("cache_encryption_key must be a non-empty string")
+		}
+		return nil, nil
+	}
+	if cipher, ok := cacheCiphers.Load(key); ok {
+		return cipher.(*cache.Cipher), nil
+	}
+	cipher, err := cache.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	cacheCiphers.Store(key, cipher)
+	return cipher, nil
+}
+
+// sealCacheValue encrypts a value written to Redis when cache_encryption_key is set
+func sealCacheValue(cfg types.Config, value string) (string, error) {
+	cipher, err := cacheCipher(cfg)
+	if err != nil || cipher == nil {
+		return value, err
+	}
+	return cipher.Seal(value), nil
+}
+
+// openCacheValue decrypts a value read from Redis. Values that cannot be decrypted are
+// reported as missing, so they are fetched again and overwritten.
+func openCacheValue(cfg types.Config, key, value string) string {
+	cipher, err := cacheCipher(cfg)
+	if err != nil || cipher == nil {
+		return value
+	}
+	plaintext, err := cipher.Open(value)
+	if err != nil {
+		log.Printf("[WARN] Failed to decrypt cached value for key %s, ignoring it", key)
+		return ""
+	}
+	return plaintext
+}
+
+// discardCache stores nothing, used when values cannot be cached safely
+type discardCache struct{}
+
+func (discardCache) Get(key string) (string, bool)                 { return "", false }
+func (discardCache) Set(key, value string, duration time.Duration) {}
+func (discardCache) Delete(key string)                             {}
+
+// logResponse debug-logs the response status and body, or just drains the body so the
+// connection can be reused when debug logging is off
+func logResponse(cfg types.Config, operation string, resp *http.Response) {
+	if !cfg.Debug {
+		io.Copy(io.Discard, resp.Body)
+		return
+	}
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "%s: response status: %d, body length: %d, body: %s", operation, resp.StatusCode, respData.Len(), respData.String())
+}
+
+// readResponse reads the response body and debug-logs it, for APIs whose body reports the result
+func readResponse(cfg types.Config, operation string, resp *http.Response) []byte {
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	if cfg.Debug {
+		types.DebugLog(cfg, "%s: response status: %d, body length: %d, body: %s", operation, resp.StatusCode, respData.Len(), respData.String())
+	}
+	return respData.Bytes()
+}
@@ -0,0 +1,247 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestProviderSettings(t *testing.T) {
+	settings := providerSettings{
+		"token":   "xoxb-token",
+		"number":  42,
+		"numeric": "7",
+		"flag":    "true",
+		"wait":    "250ms",
+		"list":    "a, b,,c",
+		"wrong":   123,
+	}
+	if got := settings.String("token", "default"); got != "xoxb-token" {
+		t.Errorf("String: got %q", got)
+	}
+	if got := settings.String("missing", "default"); got != "default" {
+		t.Errorf("String default: got %q", got)
+	}
+	if got := settings.String("wrong", "default"); got != "default" {
+		t.Errorf("String with wrong type: got %q", got)
+	}
+	if got := settings.Int("number", 0) + settings.Int("numeric", 0) + settings.Int("missing", 1); got != 50 {
+		t.Errorf("Int: got %d", got)
+	}
+	if !settings.Bool("flag", false) || settings.Bool("missing", false) {
+		t.Error("Bool: unexpected value")
+	}
+	if got := settings.Duration("wait", time.Second); got != 250*time.Millisecond {
+		t.Errorf("Duration: got %v", got)
+	}
+	if got := settings.StringSlice("list"); len(got) != 3 || got[2] != "c" {
+		t.Errorf("StringSlice: got %v", got)
+	}
+
+	if _, err := settings.RequireString("missing", "webhook URL"); err == nil || err.Error() != `webhook URL is required (provider_config "missing")` {
+		t.Errorf("RequireString missing: got %v", err)
+	}
+	if _, err := settings.RequireString("wrong", "webhook URL"); err == nil || err.Error() != `webhook URL must be a string (provider_config "wrong" is int)` {
+		t.Errorf("RequireString wrong type: got %v", err)
+	}
+}
+
+func TestSlackMissingTokenReturnsError(t *testing.T) {
+	p := &SlackProvider{}
+	for _, method := range []string{types.MethodWebhook, types.MethodWebClient} {
+		cfg := types.Config{SendMethod: method, ProviderConfig: map[string]interface{}{}}
+		if err := p.Send(types.ERROR, "boom", nil, cfg); err == nil {
+			t.Errorf("Expected error for missing token with %s method", method)
+		}
+		cfg.ProviderConfig["token"] = 12345
+		if err := p.Send(types.ERROR, "boom", nil, cfg); err == nil {
+			t.Errorf("Expected error for non-string token with %s method", method)
+		}
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	var calls int
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Header: make(http.Header)}, nil
+	})
+	cfg := types.Config{
+		Provider:       "slack",
+		HTTPClient:     doer,
+		ProviderConfig: map[string]interface{}{"chaos": types.FaultInjection{TooManyRequests: 1}},
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://hooks.example.com/x", strings.NewReader("{}"))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("Expected simulated 429, got %v, %v", resp, err)
+	}
+
+	cfg.ProviderConfig["chaos"] = types.FaultInjection{Timeout: 1}
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	var timeout interface{ Timeout() bool }
+	if _, err := httpDoer(cfg).Do(req); !errors.As(err, &timeout) || !timeout.Timeout() {
+		t.Fatalf("Expected simulated timeout, got %v", err)
+	}
+
+	cfg.ProviderConfig["chaos"] = map[string]types.FaultInjection{"lark": {ServerError: 1}}
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	if resp, err := httpDoer(cfg).Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected slack to be unaffected, got %v, %v", resp, err)
+	}
+	cfg.Provider = "lark"
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	if resp, err := httpDoer(cfg).Do(req); err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected simulated 503 for lark, got %v, %v", resp, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected only the unaffected request to reach the client, got %d", calls)
+	}
+
+	cfg.ProviderConfig["chaos"] = types.FaultInjection{ConnectionError: 1}
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	var opErr *net.OpError
+	if _, err := httpDoer(cfg).Do(req); !errors.As(err, &opErr) || opErr.Op != "dial" {
+		t.Fatalf("Expected simulated connection error, got %v", err)
+	}
+
+	cfg.ProviderConfig["chaos"] = types.FaultInjection{Latency: time.Hour}
+	cfg.ProviderConfig["send_timeout"] = 10 * time.Millisecond
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	if _, err := httpDoer(cfg).Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the latency to exceed send_timeout, got %v", err)
+	}
+	cfg.ProviderConfig["chaos"] = types.FaultInjection{Latency: time.Millisecond}
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	if resp, err := httpDoer(cfg).Do(req); err != nil || resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Fatalf("Expected the delayed request to reach the client, got %v, %v", resp, err)
+	}
+}
+
+func TestCacheEncryption(t *testing.T) {
+	inner := cache.NewInMemoryCache()
+	cfg := types.Config{
+		Cache:          inner,
+		ProviderConfig: map[string]interface{}{"cache_encryption_key": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="},
+	}
+	cacheStore(cfg).Set("commonlog_lark_token:app", "t-secret", time.Minute)
+	if stored, _ := inner.Get("commonlog_lark_token:app"); !strings.HasPrefix(stored, "enc:v1:") {
+		t.Errorf("Expected the token to be encrypted in the cache, got %q", stored)
+	}
+	if value, found := cacheStore(cfg).Get("commonlog_lark_token:app"); !found || value != "t-secret" {
+		t.Errorf("Expected the token to be decrypted, got %q", value)
+	}
+
+	sealed, err := sealCacheValue(cfg, "oc_123")
+	if err != nil || sealed == "oc_123" || openCacheValue(cfg, "key", sealed) != "oc_123" {
+		t.Errorf("Expected Redis values to round-trip encrypted, got %q, %v", sealed, err)
+	}
+	if openCacheValue(cfg, "key", "enc:v1:garbage") != "" {
+		t.Error("Expected an undecryptable value to read as missing")
+	}
+
+	cfg.ProviderConfig["cache_encryption_key"] = "short"
+	cacheStore(cfg).Set("commonlog_lark_token:app2", "t-secret", time.Minute)
+	if _, found := inner.Get("commonlog_lark_token:app2"); found {
+		t.Error("Expected nothing to be cached with an invalid key")
+	}
+	if _, err := sealCacheValue(cfg, "t-secret"); err == nil {
+		t.Error("Expected an error sealing with an invalid key")
+	}
+}
+
+func TestBucket(t *testing.T) {
+	inner := cache.NewInMemoryCache()
+	cfg := types.Config{Cache: inner}
+	state := NewBucket(cfg, "test_state")
+
+	if _, found, err := state.Get("a"); found || err != nil {
+		t.Fatalf("Expected a missing value, got %v, %v", found, err)
+	}
+	if err := state.Set("a", "1", 0); err != nil {
+		t.Fatalf("Expected Set to succeed, got %v", err)
+	}
+	if stored, found := inner.Get("commonlog_test_state:a"); !found || stored != "1" {
+		t.Errorf("Expected the value under its namespaced key, got %q", stored)
+	}
+	if value, found, _ := NewBucket(cfg, "other_state").Get("a"); found {
+		t.Errorf("Expected namespaces to be separate, got %q", value)
+	}
+	if value, found, _ := state.Get("a"); !found || value != "1" {
+		t.Errorf("Expected the stored value, got %q", value)
+	}
+	if err := state.Delete("a"); err != nil {
+		t.Fatalf("Expected Delete to succeed, got %v", err)
+	}
+	if _, found, _ := state.Get("a"); found {
+		t.Error("Expected the value to be deleted")
+	}
+
+	state.Set("b", "2", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, found, _ := state.Get("b"); found {
+		t.Error("Expected the value to expire")
+	}
+
+	cfg.ProviderConfig = map[string]interface{}{"cache_encryption_key": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}
+	NewBucket(cfg, "test_state").Set("c", "secret", time.Minute)
+	if stored, _ := inner.Get("commonlog_test_state:c"); !strings.HasPrefix(stored, "enc:v1:") {
+		t.Errorf("Expected the value to be encrypted, got %q", stored)
+	}
+}
+
+func TestOperationTimeouts(t *testing.T) {
+	var deadlines []time.Duration
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, ok := req.Context().Deadline()
+		if !ok {
+			deadlines = append(deadlines, 0)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"code":0}`))}, nil
+		}
+		deadlines = append(deadlines, time.Until(deadline).Round(time.Second))
+		if strings.Contains(req.URL.Path, "tenant_access_token") {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"code":0}`))}, nil
+	})
+	cfg := types.Config{
+		HTTPClient: doer,
+		Cache:      cache.NewInMemoryCache(),
+		ProviderConfig: map[string]interface{}{
+			"auth_timeout":   10 * time.Millisecond,
+			"lookup_timeout": "30s",
+			"send_timeout":   time.Minute,
+		},
+	}
+
+	_, err := getTenantAccessToken(cfg, "cli_app", "secret")
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "auth request to open.larksuite.com timed out after 10ms") {
+		t.Errorf("Expected the token fetch to time out, got %v", err)
+	}
+	fetchChatList(cfg, "t-token")
+	req, _ := http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	resp.Body.Close()
+	if len(deadlines) != 3 || deadlines[1] != 30*time.Second || deadlines[2] != time.Minute {
+		t.Errorf("Expected the lookup and send timeouts, got %v", deadlines)
+	}
+
+	delete(cfg.ProviderConfig, "send_timeout")
+	req, _ = http.NewRequest(http.MethodPost, "https://hooks.example.com/x", nil)
+	httpDoer(cfg).Do(req)
+	if deadlines[3] != 0 {
+		t.Errorf("Expected no timeout by default, got %v", deadlines[3])
+	}
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultElasticsearchIndex creates one index per day so retention can be managed by deleting indices
+const defaultElasticsearchIndex = "commonlog-alerts-{date}"
+
+// ElasticsearchProvider implements Provider by indexing alert documents into Elasticsearch or OpenSearch
+type ElasticsearchProvider struct{}
+
+func (p *ElasticsearchProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *ElasticsearchProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "ElasticsearchProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	baseURL, _ := cfg.ProviderConfig["es_url"].(string)
+	if baseURL == "" {
+		err := fmt.Errorf("es_url must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	data, err := elasticsearchDocument(event)
+	if err != nil {
+		return err
+	}
+
+	index := elasticsearchIndex(cfg, currentTime(cfg).UTC())
+	endpoint := fmt.Sprintf("%s/%s/_doc", strings.TrimRight(baseURL, "/"), index)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey, ok := cfg.ProviderConfig["es_api_key"].(string); ok && apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+	} else if username, ok := cfg.ProviderConfig["es_username"].(string); ok && username != "" {
+		password, _ := cfg.ProviderConfig["es_password"].(string)
+		req.SetBasicAuth(username, password)
+	}
+
+	types.DebugLog(cfg, "sendElasticsearch: indexing document into %s, size: %d bytes", index, len(data))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendElasticsearch: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendElasticsearch: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		err := fmt.Errorf("elasticsearch index response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendElasticsearch: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendElasticsearch: document indexed successfully")
+	return nil
+}
+
+// elasticsearchIndex expands the {date} placeholder of es_index with the event's UTC date
+func elasticsearchIndex(cfg types.Config, now time.Time) string {
+	index, _ := cfg.ProviderConfig["es_index"].(string)
+	if index == "" {
+		index = defaultElasticsearchIndex
+	}
+	return strings.ReplaceAll(index, "{date}", now.Format("2006.01.02"))
+}
+
+// elasticsearchDocument encodes event with the @timestamp field Kibana and OpenSearch
+// Dashboards expect
+func elasticsearchDocument(event types.AlertEvent) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc["@timestamp"] = event.Timestamp()
+	return json.Marshal(doc)
+}
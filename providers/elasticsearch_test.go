@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestElasticsearchIndexName(t *testing.T) {
+	now := time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC)
+	cfg := types.Config{ProviderConfig: map[string]interface{}{}}
+	if index := elasticsearchIndex(cfg, now); index != "commonlog-alerts-2024.03.09" {
+		t.Errorf("Unexpected default index: %s", index)
+	}
+	cfg.ProviderConfig["es_index"] = "alerts"
+	if index := elasticsearchIndex(cfg, now); index != "alerts" {
+		t.Errorf("Unexpected static index: %s", index)
+	}
+}
+
+func TestElasticsearchIndexesDocument(t *testing.T) {
+	var path, auth string
+	var doc map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&doc)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := types.Config{
+		ServiceName: "billing",
+		ProviderConfig: map[string]interface{}{
+			"es_url":     server.URL,
+			"es_index":   "alerts-{date}",
+			"es_api_key": "abc",
+		},
+	}
+	if err := (&ElasticsearchProvider{}).Send(types.WARN, "slow query", nil, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path != "/alerts-"+time.Now().UTC().Format("2006.01.02")+"/_doc" {
+		t.Errorf("Unexpected index path: %s", path)
+	}
+	if auth != "ApiKey abc" {
+		t.Errorf("Expected API key auth, got %s", auth)
+	}
+	if doc["@timestamp"] == nil || doc["level"] != "WARN" || doc["service"] != "billing" {
+		t.Errorf("Unexpected document: %v", doc)
+	}
+}
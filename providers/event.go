@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// alertIDLine renders the alert and correlation IDs as a plain footer line, or ""
+// when the alert was not sent through a Logger and has no ID
+func alertIDLine(cfg types.Config) string {
+	if cfg.MessageID == "" {
+		return ""
+	}
+	if cfg.CorrelationID == "" {
+		return types.Localize(cfg, types.TextAlertID) + ": " + cfg.MessageID
+	}
+	return types.Localize(cfg, types.TextAlertID) + ": " + cfg.MessageID + " | " + types.Localize(cfg, types.TextCorrelationID) + ": " + cfg.CorrelationID
+}
+
+// imageLink renders the image URL as a labeled link for providers that cannot show it
+// inline, or "" when there is no image URL
+func imageLink(cfg types.Config, bold string) string {
+	if cfg.Image == nil || cfg.Image.URL == "" {
+		return ""
+	}
+	return "\n\n" + bold + types.Localize(cfg, types.TextImage) + ":" + bold + " " + cfg.Image.URL
+}
+
+// markdownSnippet renders a snippet as a fenced code block under its title, emphasized
+// with bold ("*" for Slack mrkdwn, "**" for markdown)
+func markdownSnippet(snippet types.Snippet, bold string) string {
+	code := "```" + snippet.Language + "\n" + snippet.Code + "\n```"
+	if snippet.Title == "" {
+		return code
+	}
+	return bold + snippet.Title + ":" + bold + "\n" + code
+}
+
+// markdownExtras renders the alert's snippets as code blocks and its links as markdown
+// links, and its image URL as an inline image, for providers without buttons or uploads,
+// or "" when there are none
+func markdownExtras(cfg types.Config) string {
+	var formatted strings.Builder
+	if cfg.Image != nil && cfg.Image.URL != "" {
+		formatted.WriteString("\n\n![" + cfg.Image.Alt() + "](" + cfg.Image.URL + ")")
+	}
+	for _, snippet := range cfg.Snippets {
+		formatted.WriteString("\n\n" + markdownSnippet(snippet, "**"))
+	}
+	for i, link := range cfg.Links {
+		if i == 0 {
+			formatted.WriteString("\n\n")
+		} else {
+			formatted.WriteString(" | ")
+		}
+		formatted.WriteString("[" + link.Text + "](" + link.URL + ")")
+	}
+	return formatted.String()
+}
+
+// renderSections joins the sections of an alert in the order of Config.Sections,
+// skipping those render returns "" for. Sections are separated by a blank line, except
+// that the header and the footer are on the line next to their neighbor.
+func renderSections(cfg types.Config, render func(section string) string) string {
+	var formatted strings.Builder
+	previous := ""
+	for _, section := range types.SectionsOf(cfg) {
+		text := render(section)
+		if text == "" {
+			continue
+		}
+		if previous == types.SectionHeader || (previous != "" && section == types.SectionFooter) {
+			formatted.WriteString("\n")
+		} else if previous != "" {
+			formatted.WriteString("\n\n")
+		}
+		formatted.WriteString(text)
+		previous = section
+	}
+	return formatted.String()
+}
+
+// fieldLines renders the alert's fields as key: value lines sorted by key, with the keys
+// emphasized with bold, or "" when there are none
+func fieldLines(cfg types.Config, bold string) string {
+	keys := make([]string, 0, len(cfg.Fields))
+	for key := range cfg.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = bold + key + ":" + bold + " " + cfg.Fields[key]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// alertFooter renders the library version, provider and send method that delivered the
+// alert when include_footer is set, to help trace misrouted messages, or "" otherwise
+func alertFooter(cfg types.Config) string {
+	settings := settingsOf(cfg)
+	if !settings.Bool("include_footer", false) {
+		return ""
+	}
+	via := cfg.Provider
+	if via == "" {
+		via = settings.String("provider", "")
+	}
+	if cfg.SendMethod != "" {
+		via += "-" + cfg.SendMethod
+	}
+	return "sent by gocommonlog " + types.Version() + " via " + via
+}
+
+var (
+	hostnameOnce sync.Once
+	hostname     string
+	locations    sync.Map // timezone name -> *time.Location
+)
+
+// eventTime returns when the alerted event occurred, falling back to now for alerts not
+// sent through a Logger
+func eventTime(cfg types.Config) time.Time {
+	if !cfg.EventTime.IsZero() {
+		return cfg.EventTime
+	}
+	return currentTime(cfg)
+}
+
+// alertHostname returns the hostname setting, or the POD_NAME environment variable, or
+// the machine hostname
+func alertHostname(cfg types.Config) string {
+	if name := settingsOf(cfg).String("hostname", ""); name != "" {
+		return name
+	}
+	hostnameOnce.Do(func() {
+		hostname = os.Getenv("POD_NAME")
+		if hostname == "" {
+			hostname, _ = os.Hostname()
+		}
+	})
+	return hostname
+}
+
+// alertLocation returns the timezone setting, defaulting to UTC
+func alertLocation(cfg types.Config) *time.Location {
+	name := settingsOf(cfg).String("timezone", "")
+	if name == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		types.DebugLog(cfg, "Unknown timezone '%s', using UTC: %v", name, err)
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// alertMetaLine renders the event timestamp, hostname and call site for the message
+// header when include_timestamp, include_hostname or include_source is set, or ""
+// otherwise
+func alertMetaLine(cfg types.Config) string {
+	settings := settingsOf(cfg)
+	var parts []string
+	if settings.Bool("include_timestamp", false) {
+		parts = append(parts, eventTime(cfg).In(alertLocation(cfg)).Format(time.RFC3339))
+	}
+	if settings.Bool("include_hostname", false) {
+		if name := alertHostname(cfg); name != "" {
+			parts = append(parts, name)
+		}
+	}
+	if cfg.Source != "" {
+		parts = append(parts, cfg.Source)
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,211 @@
+package providers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// gcpMetadataTokenURL is the GCE/GKE metadata server token endpoint; a variable so tests can point it elsewhere
+var gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// gcpDefaultTokenURI is the token endpoint of service account keys without token_uri
+const gcpDefaultTokenURI = "https://oauth2.googleapis.com/token"
+
+// gcpServiceAccount is the subset of a service account JSON key needed for the JWT bearer flow
+type gcpServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// gcpAccessToken returns an OAuth2 access token for Google Cloud APIs. It uses, in order:
+// gcp_access_token, a service account key (gcp_credentials_json or GOOGLE_APPLICATION_CREDENTIALS),
+// or the metadata server. Exchanged tokens are cached until shortly before they expire.
+func gcpAccessToken(cfg types.Config) (string, error) {
+	if token, ok := cfg.ProviderConfig["gcp_access_token"].(string); ok && token != "" {
+		return token, nil
+	}
+
+	keyJSON, err := gcpKeyJSON(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	var account gcpServiceAccount
+	tokens := NewBucket(cfg, "gcp_token")
+	cacheKey := "metadata"
+	if keyJSON != "" {
+		if err := json.Unmarshal([]byte(keyJSON), &account); err != nil {
+			return "", fmt.Errorf("invalid GCP service account key: %w", err)
+		}
+		if account.TokenURI == "" {
+			account.TokenURI = gcpDefaultTokenURI
+		}
+		cacheKey = account.ClientEmail
+	}
+	if token, found, _ := tokens.Get(cacheKey); found {
+		types.DebugLog(cfg, "GCP access token retrieved from cache")
+		return token, nil
+	}
+
+	var req *http.Request
+	if keyJSON != "" {
+		assertion, err := gcpSignJWT(account, currentTime(cfg))
+		if err != nil {
+			return "", err
+		}
+		form := url.Values{}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+		req, err = http.NewRequest("POST", account.TokenURI, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, err = http.NewRequest("GET", gcpMetadataTokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata-Flavor", "Google")
+	}
+
+	types.DebugLog(cfg, "Fetching GCP access token from %s", req.URL.Host)
+	resp, err := operationDoer(cfg, opAuth).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch GCP access token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("GCP token response: %d", resp.StatusCode)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	// Cache for (expires_in - 5 minutes)
+	expiry := time.Duration(result.ExpiresIn)*time.Second - 5*time.Minute
+	if expiry > 0 {
+		tokens.Set(cacheKey, result.AccessToken, expiry)
+	}
+	return result.AccessToken, nil
+}
+
+// gcpKeyJSON returns the service account key from gcp_credentials_json or the file named
+// by GOOGLE_APPLICATION_CREDENTIALS, or "" when neither is set
+func gcpKeyJSON(cfg types.Config) (string, error) {
+	if keyJSON, ok := cfg.ProviderConfig["gcp_credentials_json"].(string); ok && keyJSON != "" {
+		return keyJSON, nil
+	}
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+	}
+	return string(data), nil
+}
+
+// gcpPrivateKey parses the RSA private key of a service account key
+func gcpPrivateKey(account gcpServiceAccount) (*rsa.PrivateKey, error) {
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("GCP service account key must contain client_email and private_key")
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid GCP service account private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCP service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GCP service account private key is not RSA")
+	}
+	return key, nil
+}
+
+// gcpSignJWT builds the RS256-signed assertion for the service account JWT bearer flow
+func gcpSignJWT(account gcpServiceAccount, now time.Time) (string, error) {
+	key, err := gcpPrivateKey(account)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   account.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/cloud-platform",
+		"aud":   account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gcpRequest performs an authenticated call to a Google Cloud REST API and returns the
+// response body. operation selects the timeout setting, such as opAuth for secret fetches.
+func gcpRequest(cfg types.Config, operation, method, endpoint string, payload interface{}) ([]byte, error) {
+	token, err := gcpAccessToken(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		body.Write(data)
+	}
+	req, err := http.NewRequest(method, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := operationDoer(cfg, operation).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "gcpRequest: %s %s failed: %v", method, endpoint, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "gcpRequest: %s %s response status: %d, body length: %d", method, endpoint, resp.StatusCode, respData.Len())
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("GCP API response: %d: %s", resp.StatusCode, respData.String())
+	}
+	return respData.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// GenericWebhookProvider implements Provider for arbitrary HTTP endpoints,
+// posting a structured JSON document instead of a chat-formatted message
+type GenericWebhookProvider struct{}
+
+func (p *GenericWebhookProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *GenericWebhookProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "GenericWebhookProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	// The token field contains the target URL, as with the other webhook methods
+	webhookURL, _ := cfg.ProviderConfig["token"].(string)
+	if webhookURL == "" {
+		err := fmt.Errorf("webhook URL is required for generic webhook provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	types.DebugLog(cfg, "sendGenericWebhook: payload prepared, size: %d bytes", len(data))
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if headers, ok := cfg.ProviderConfig["webhook_headers"].(map[string]string); ok {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+	if secret, ok := cfg.ProviderConfig["webhook_secret"].(string); ok && secret != "" {
+		timestamp := strconv.FormatInt(currentTime(cfg).Unix(), 10)
+		req.Header.Set("X-Commonlog-Timestamp", timestamp)
+		req.Header.Set("X-Commonlog-Signature", "sha256="+signWebhookPayload(secret, timestamp, data))
+		types.DebugLog(cfg, "sendGenericWebhook: payload signed with HMAC-SHA256")
+	}
+
+	types.DebugLog(cfg, "sendGenericWebhook: sending HTTP request to webhook URL (length: %d)", len(webhookURL))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendGenericWebhook: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendGenericWebhook: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("generic webhook response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendGenericWebhook: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendGenericWebhook: webhook sent successfully")
+	return nil
+}
+
+// signWebhookPayload computes the hex HMAC-SHA256 of "timestamp.body"
+func signWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestGenericWebhookPayloadAndSignature(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cfg := types.Config{
+		ServiceName: "billing",
+		Environment: "staging",
+		Fields:      map[string]string{"region": "eu-west-1"},
+		ProviderConfig: map[string]interface{}{
+			"token":           server.URL,
+			"webhook_secret":  "s3cret",
+			"webhook_headers": map[string]string{"X-Team": "payments"},
+		},
+	}
+	attachment := &types.Attachment{FileName: "dump.txt", Content: "payload" + types.TraceSeparator + "stack"}
+
+	p := &GenericWebhookProvider{}
+	if err := p.SendToChannel(types.ERROR, "boom", attachment, cfg, "ops"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var event types.AlertEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("Expected JSON body, got %v", err)
+	}
+	if event.Level != types.ERROR || event.Message != "boom" || event.Service != "billing" || event.Channel != "ops" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if event.Fields["region"] != "eu-west-1" {
+		t.Errorf("Expected region field, got %v", event.Fields)
+	}
+	if event.Trace != "stack" || event.Attachment == nil || event.Attachment.Content != "payload" {
+		t.Errorf("Expected trace to be split from attachment, got trace %q attachment %+v", event.Trace, event.Attachment)
+	}
+	if header.Get("X-Team") != "payments" {
+		t.Errorf("Expected custom header, got %q", header.Get("X-Team"))
+	}
+	expected := "sha256=" + signWebhookPayload("s3cret", header.Get("X-Commonlog-Timestamp"), body)
+	if header.Get("X-Commonlog-Signature") != expected {
+		t.Errorf("Expected signature %s, got %s", expected, header.Get("X-Commonlog-Signature"))
+	}
+}
+
+func TestGenericWebhookRequiresURL(t *testing.T) {
+	p := &GenericWebhookProvider{}
+	cfg := types.Config{ProviderConfig: map[string]interface{}{}}
+	if err := p.Send(types.ERROR, "boom", nil, cfg); err == nil {
+		t.Error("Expected error when webhook URL is missing")
+	}
+}
+
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestGenericWebhookWithInjectedClientAndClock(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	cfg := types.Config{
+		Clock: fixedClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			received = req
+			body, _ = io.ReadAll(req.Body)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}),
+		ProviderConfig: map[string]interface{}{
+			"token":          "https://alerts.invalid/ingest",
+			"webhook_secret": "s3cret",
+		},
+	}
+
+	if err := (&GenericWebhookProvider{}).Send(types.ERROR, "boom", nil, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received == nil || received.URL.Host != "alerts.invalid" {
+		t.Fatalf("Expected request through the injected client, got %v", received)
+	}
+	if got := received.Header.Get("X-Commonlog-Timestamp"); got != "1704164645" {
+		t.Errorf("Expected timestamp from injected clock, got %s", got)
+	}
+	var event types.AlertEvent
+	json.Unmarshal(body, &event)
+	if event.Timestamp() != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expected event timestamp from injected clock, got %s", event.Timestamp())
+	}
+}
@@ -0,0 +1,210 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// GitHubProvider implements Provider by opening GitHub issues for ERROR alerts,
+// commenting on the existing open issue when the same fingerprint fires again
+type GitHubProvider struct{}
+
+type githubIssue struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+}
+
+func (p *GitHubProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *GitHubProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "GitHubProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	if level != types.ERROR {
+		types.DebugLog(cfg, "GitHub issues are only opened for ERROR alerts, skipping level: %d", level)
+		return fmt.Errorf("github issues are only opened for ERROR alerts: %w", types.ErrSkipped)
+	}
+
+	// Use github_token if available, otherwise fall back to token
+	token, _ := cfg.ProviderConfig["token"].(string)
+	if githubToken, ok := cfg.ProviderConfig["github_token"].(string); ok && githubToken != "" {
+		token = githubToken
+	}
+	if token == "" {
+		err := fmt.Errorf("token is required for GitHub provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	repo, _ := cfg.ProviderConfig["github_repo"].(string)
+	if repo == "" {
+		repo = channel
+	}
+	if strings.Count(repo, "/") != 1 {
+		err := fmt.Errorf("github_repo must be set to owner/name, got '%s'", repo)
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	apiURL, _ := cfg.ProviderConfig["github_api_url"].(string)
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	number, err := p.findOpenIssue(cfg, apiURL, token, repo, event.Fingerprint)
+	if err != nil {
+		types.DebugLog(cfg, "sendGitHub: failed to look up existing issue: %v", err)
+		return err
+	}
+
+	if number > 0 {
+		types.DebugLog(cfg, "sendGitHub: commenting on open issue #%d for fingerprint %s", number, event.Fingerprint)
+		payload := map[string]string{"body": p.formatComment(event)}
+		_, err := githubRequest(cfg, "POST", fmt.Sprintf("%s/repos/%s/issues/%d/comments", apiURL, repo, number), token, payload)
+		return err
+	}
+
+	types.DebugLog(cfg, "sendGitHub: opening new issue for fingerprint %s", event.Fingerprint)
+	payload := map[string]interface{}{
+		"title": p.formatTitle(message, cfg),
+		"body":  p.formatBody(event),
+	}
+	if labels := configStringSlice(cfg.ProviderConfig["github_labels"]); len(labels) > 0 {
+		payload["labels"] = labels
+	}
+	data, err := githubRequest(cfg, "POST", fmt.Sprintf("%s/repos/%s/issues", apiURL, repo), token, payload)
+	if err != nil {
+		return err
+	}
+	var created githubIssue
+	if err := json.Unmarshal(data, &created); err == nil && created.Number > 0 {
+		NewBucket(cfg, "github_issue").Set(githubIssueKey(apiURL, repo, event.Fingerprint), strconv.Itoa(created.Number), 30*24*time.Hour)
+	}
+	return nil
+}
+
+// githubIssueKey is the cache key of the issue number for a fingerprint. It
+// includes the API URL, since the same repo name may exist on several hosts.
+func githubIssueKey(apiURL, repo, fingerprint string) string {
+	return apiURL + "|" + repo + ":" + fingerprint
+}
+
+// findOpenIssue returns the number of the open issue for the fingerprint, or 0 if none exists.
+// Known issue numbers are cached; otherwise the issue search API is consulted.
+func (p *GitHubProvider) findOpenIssue(cfg types.Config, apiURL, token, repo, fingerprint string) (int, error) {
+	issues := NewBucket(cfg, "github_issue")
+	key := githubIssueKey(apiURL, repo, fingerprint)
+	if cached, found, _ := issues.Get(key); found {
+		if number, err := strconv.Atoi(cached); err == nil {
+			data, err := githubRequest(cfg, "GET", fmt.Sprintf("%s/repos/%s/issues/%d", apiURL, repo, number), token, nil)
+			if err != nil {
+				return 0, err
+			}
+			var issue githubIssue
+			if err := json.Unmarshal(data, &issue); err == nil && issue.State == "open" {
+				return number, nil
+			}
+		}
+		issues.Delete(key)
+	}
+
+	query := fmt.Sprintf("repo:%s is:issue is:open in:body %s", repo, fingerprint)
+	data, err := githubRequest(cfg, "GET", apiURL+"/search/issues?q="+url.QueryEscape(query), token, nil)
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		Items []githubIssue `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return 0, err
+	}
+	if len(result.Items) == 0 {
+		return 0, nil
+	}
+	number := result.Items[0].Number
+	issues.Set(key, strconv.Itoa(number), 30*24*time.Hour)
+	return number, nil
+}
+
+func (p *GitHubProvider) formatTitle(message string, cfg types.Config) string {
+	title := strings.SplitN(strings.TrimSpace(message), "\n", 2)[0]
+	if runes := []rune(title); len(runes) > 100 {
+		title = string(runes[:97]) + "..."
+	}
+	if cfg.ServiceName != "" {
+		title = fmt.Sprintf("[%s] %s", cfg.ServiceName, title)
+	}
+	return title
+}
+
+func (p *GitHubProvider) formatBody(event types.AlertEvent) string {
+	body := event.Message + "\n\n"
+	if event.Service != "" {
+		body += fmt.Sprintf("- **Service:** %s\n", event.Service)
+	}
+	if event.Environment != "" {
+		body += fmt.Sprintf("- **Environment:** %s\n", event.Environment)
+	}
+	body += fmt.Sprintf("- **First seen:** %s\n", event.Timestamp())
+	body += fmt.Sprintf("- **Fingerprint:** %s\n", event.Fingerprint)
+	if event.Trace != "" {
+		body += fmt.Sprintf("\n**Trace:**\n```\n%s\n```\n", event.Trace)
+	}
+	if event.Attachment != nil && event.Attachment.URL != "" {
+		body += fmt.Sprintf("\n**Attachment:** %s\n", event.Attachment.URL)
+	}
+	return body
+}
+
+func (p *GitHubProvider) formatComment(event types.AlertEvent) string {
+	comment := fmt.Sprintf("Fired again at %s", event.Timestamp())
+	if event.Trace != "" {
+		comment += fmt.Sprintf("\n\n**Trace:**\n```\n%s\n```", event.Trace)
+	}
+	return comment
+}
+
+// githubRequest performs an authenticated GitHub API call and returns the response body
+func githubRequest(cfg types.Config, method, endpoint, token string, payload interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		body.Write(data)
+	}
+	req, err := http.NewRequest(method, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "githubRequest: %s %s failed: %v", method, endpoint, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "githubRequest: %s %s response status: %d, body length: %d", method, endpoint, resp.StatusCode, respData.Len())
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("github API response: %d", resp.StatusCode)
+	}
+	return respData.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestGitHubOpensIssueThenComments(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/search/issues":
+			w.Write([]byte(`{"items":[]}`))
+		case r.Method == "POST" && r.URL.Path == "/repos/acme/api/issues":
+			var payload map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&payload)
+			if payload["title"] != "[api] db timeout" {
+				t.Errorf("Unexpected title: %v", payload["title"])
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number":12,"state":"open"}`))
+		case r.Method == "GET" && r.URL.Path == "/repos/acme/api/issues/12":
+			w.Write([]byte(`{"number":12,"state":"open"}`))
+		case r.Method == "POST" && r.URL.Path == "/repos/acme/api/issues/12/comments":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := types.Config{
+		ServiceName: "api",
+		Cache:       cache.NewInMemoryCache(),
+		ProviderConfig: map[string]interface{}{
+			"github_token":   "ghp_test",
+			"github_repo":    "acme/api",
+			"github_api_url": server.URL,
+			"github_labels":  []string{"alert"},
+		},
+	}
+	p := &GitHubProvider{}
+	if err := p.Send(types.WARN, "db slow", nil, cfg); !errors.Is(err, types.ErrSkipped) || len(requests) != 0 {
+		t.Fatalf("Expected WARN to be skipped without requests, got %v, %v", err, requests)
+	}
+	if err := p.Send(types.ERROR, "db timeout", nil, cfg); err != nil {
+		t.Fatalf("Expected no error opening issue, got %v", err)
+	}
+	if err := p.Send(types.ERROR, "db timeout", nil, cfg); err != nil {
+		t.Fatalf("Expected no error commenting, got %v", err)
+	}
+
+	expected := []string{
+		"GET /search/issues",
+		"POST /repos/acme/api/issues",
+		"GET /repos/acme/api/issues/12",
+		"POST /repos/acme/api/issues/12/comments",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("Expected requests %v, got %v", expected, requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("Request %d: expected %s, got %s", i, expected[i], requests[i])
+		}
+	}
+
+	// The cached issue number belongs to the first server, so another
+	// GitHub host with the same repo name is searched again
+	var searched bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/search/issues" {
+			searched = true
+			w.Write([]byte(`{"items":[{"number":3,"state":"open"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer other.Close()
+	cfg.ProviderConfig["github_api_url"] = other.URL
+	if err := p.Send(types.ERROR, "db timeout", nil, cfg); err != nil {
+		t.Fatalf("Expected no error on the other host, got %v", err)
+	}
+	if !searched {
+		t.Error("Expected the issue number cached for another API URL to be ignored")
+	}
+}
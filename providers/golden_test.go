@@ -0,0 +1,327 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden payloads in testdata/golden")
+
+// payloadCase is an alert whose posted payload is checked against
+// testdata/golden/<name>.json and testdata/schemas/<schema>.json
+type payloadCase struct {
+	name       string
+	schema     string
+	provider   types.Provider
+	level      int
+	message    string
+	attachment *types.Attachment
+	cfg        types.Config
+}
+
+// goldenConfig returns a config whose rendering does not depend on the host or the time
+func goldenConfig(method, token string, cfg types.Config) types.Config {
+	cfg.SendMethod = method
+	cfg.ServiceName = "billing"
+	cfg.Environment = "production"
+	cfg.MessageID = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	cfg.EventTime = time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	settings := map[string]interface{}{"token": token, "hostname": "billing-7f9c-x2"}
+	for key, value := range cfg.ProviderConfig {
+		settings[key] = value
+	}
+	cfg.ProviderConfig = settings
+	return cfg
+}
+
+func payloadCases() []payloadCase {
+	trace := &types.Attachment{FileName: "trace.log", Content: "panic: runtime error: index out of range [3] with length 3\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:12 +0x1d"}
+	blocks := types.Config{
+		CorrelationID: "req-42",
+		AckID:         "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		Links:         []types.Link{{Text: "Grafana dashboard", URL: "https://grafana.example.com/d/billing"}},
+		Snippets:      []types.Snippet{{Title: "Slow query", Language: "sql", Code: "SELECT * FROM invoices WHERE due < now()"}},
+	}
+	timestamped := types.Config{ProviderConfig: map[string]interface{}{"include_timestamp": true, "include_hostname": true}}
+	return []payloadCase{
+		{name: "slack_webclient_error", schema: "slack_message", provider: &SlackProvider{}, level: types.ERROR, message: "Payment failed for invoice 1234",
+			attachment: trace, cfg: goldenConfig(types.MethodWebClient, "xoxb-token", timestamped)},
+		{name: "slack_webclient_blocks", schema: "slack_message", provider: &SlackProvider{}, level: types.ERROR, message: "Invoice generation is slow",
+			cfg: goldenConfig(types.MethodWebClient, "xoxb-token", types.Config{
+				CorrelationID: blocks.CorrelationID, AckID: blocks.AckID, Links: blocks.Links, Snippets: blocks.Snippets,
+				Image: &types.Image{URL: "https://charts.example.com/p99.png", AltText: "p99 latency"},
+			})},
+		{name: "slack_webhook_warn", schema: "slack_message", provider: &SlackProvider{}, level: types.WARN, message: "Disk usage at 85%",
+			cfg: goldenConfig(types.MethodWebhook, "https://hooks.slack.com/services/T000/B000/XXX", types.Config{})},
+		{name: "lark_webhook_post", schema: "lark_message", provider: &LarkProvider{}, level: types.ERROR, message: "Payment failed for invoice 1234",
+			attachment: trace, cfg: goldenConfig(types.MethodWebhook, "https://open.larksuite.com/open-apis/bot/v2/hook/x", timestamped)},
+		{name: "lark_webhook_card", schema: "lark_message", provider: &LarkProvider{}, level: types.ERROR, message: "Invoice generation is slow",
+			cfg: goldenConfig(types.MethodWebhook, "https://open.larksuite.com/open-apis/bot/v2/hook/x", blocks)},
+	}
+}
+
+// postedPayload sends the alert and returns the body of the last request the provider made
+func postedPayload(t *testing.T, c payloadCase) []byte {
+	t.Helper()
+	var body []byte
+	cfg := c.cfg
+	if cfg.SendMethod == types.MethodWebhook {
+		cfg.Token = settingsOf(cfg).String("token", "")
+	}
+	cfg.HTTPClient = doerFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ = io.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"ok":true,"code":0}`))}, nil
+	})
+	if err := c.provider.SendToChannel(c.level, c.message, c.attachment, cfg, "#billing-alerts"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return body
+}
+
+func TestPayloadGoldenFiles(t *testing.T) {
+	for _, c := range payloadCases() {
+		t.Run(c.name, func(t *testing.T) {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, postedPayload(t, c), "", "  "); err != nil {
+				t.Fatalf("Payload is not JSON: %v", err)
+			}
+			indented.WriteByte('\n')
+
+			path := filepath.Join("testdata", "golden", c.name+".json")
+			if *updateGolden {
+				if err := os.WriteFile(path, indented.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Missing golden file, run go test ./providers -run TestPayloadGoldenFiles -update: %v", err)
+			}
+			if !bytes.Equal(indented.Bytes(), golden) {
+				t.Errorf("Payload differs from %s, rerun with -update if the change is intended:\n got: %s\nwant: %s", path, indented.Bytes(), golden)
+			}
+		})
+	}
+}
+
+func TestPayloadSchemas(t *testing.T) {
+	cases := payloadCases()
+	// Limits are only reached by alerts too large for a golden file
+	long := strings.Repeat("Payment failed. ", 400)
+	var links []types.Link
+	for i := 0; i < 30; i++ {
+		links = append(links, types.Link{Text: fmt.Sprintf("Runbook %d: %s", i, strings.Repeat("step ", 20)), URL: fmt.Sprintf("https://runbooks.example.com/%d", i)})
+	}
+	cases = append(cases,
+		payloadCase{name: "slack_many_actions", schema: "slack_message", provider: &SlackProvider{}, level: types.ERROR, message: "Payment failed",
+			cfg: goldenConfig(types.MethodWebClient, "xoxb-token", types.Config{AckID: "01ARZ3NDEKTSV4RRFFQ69G5FAV", Links: links})},
+		payloadCase{name: "slack_long_message", schema: "slack_message", provider: &SlackProvider{}, level: types.ERROR, message: long,
+			cfg: goldenConfig(types.MethodWebClient, "xoxb-token", types.Config{AckID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"})},
+		payloadCase{name: "lark_long_message", schema: "lark_message", provider: &LarkProvider{}, level: types.ERROR, message: long,
+			cfg: goldenConfig(types.MethodWebhook, "https://open.larksuite.com/open-apis/bot/v2/hook/x", types.Config{AckID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"})},
+	)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			schema := loadSchema(t, c.schema)
+			var payload interface{}
+			if err := json.Unmarshal(postedPayload(t, c), &payload); err != nil {
+				t.Fatalf("Payload is not JSON: %v", err)
+			}
+			for _, violation := range schema.validate(payload, "$") {
+				t.Errorf("%s: %s", c.schema, violation)
+			}
+		})
+	}
+}
+
+func TestSchemaValidatorReportsViolations(t *testing.T) {
+	schema := loadSchema(t, "slack_message")
+	var payload interface{}
+	json.Unmarshal([]byte(`{"channel":"#alerts","blocks":[{"type":"section","text":{"type":"mrkdwn","text":""}},{"type":"divider"}],"extra":1}`), &payload)
+	violations := schema.validate(payload, "$")
+	expected := []string{
+		`$: missing required property "text"`,
+		`$.blocks[0]: matches 0 of the oneOf schemas`,
+		`$.blocks[1]: matches 0 of the oneOf schemas`,
+		`$: unexpected property "extra"`,
+	}
+	if strings.Join(violations, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected violations:\n got: %q\nwant: %q", violations, expected)
+	}
+}
+
+// jsonSchema is the subset of JSON Schema used by testdata/schemas: type, enum, required,
+// properties, additionalProperties, items, oneOf, $ref to definitions and length limits
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Ref                  string                 `json:"$ref"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinProperties        *int                   `json:"minProperties"`
+
+	root *jsonSchema
+}
+
+func loadSchema(t *testing.T, name string) *jsonSchema {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "schemas", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Invalid schema %s: %v", name, err)
+	}
+	return &schema
+}
+
+// resolve follows $ref and returns the schema with the root it was loaded from
+func (s *jsonSchema) resolve(root *jsonSchema) *jsonSchema {
+	if s.Ref != "" {
+		s = root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	s.root = root
+	return s
+}
+
+// validate returns a violation per line for value at path, or none when it is valid
+func (s *jsonSchema) validate(value interface{}, path string) []string {
+	root := s.root
+	if root == nil {
+		root = s
+	}
+	s = s.resolve(root)
+	var violations []string
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, option := range s.OneOf {
+			option.root = root
+			if len(option.validate(value, path)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("matches %d of the oneOf schemas", matched)
+		}
+		return violations
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", value, s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.Type != "" && s.Type != "object" {
+			fail("expected %s, got object", s.Type)
+			return violations
+		}
+		for _, key := range s.Required {
+			if _, ok := v[key]; !ok {
+				fail("missing required property %q", key)
+			}
+		}
+		if s.MinProperties != nil && len(v) < *s.MinProperties {
+			fail("expected at least %d properties, got %d", *s.MinProperties, len(v))
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var additional *jsonSchema
+		if len(s.AdditionalProperties) > 0 && string(s.AdditionalProperties) != "false" && string(s.AdditionalProperties) != "true" {
+			json.Unmarshal(s.AdditionalProperties, &additional)
+		}
+		for _, key := range keys {
+			property, ok := s.Properties[key]
+			switch {
+			case ok:
+			case additional != nil:
+				property = additional
+			case string(s.AdditionalProperties) == "false":
+				fail("unexpected property %q", key)
+				continue
+			default:
+				continue
+			}
+			property.root = root
+			violations = append(violations, property.validate(v[key], path+"."+key)...)
+		}
+	case []interface{}:
+		if s.Type != "" && s.Type != "array" {
+			fail("expected %s, got array", s.Type)
+			return violations
+		}
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("expected at least %d items, got %d", *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("expected at most %d items, got %d", *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.root = root
+				violations = append(violations, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case string:
+		if s.Type != "" && s.Type != "string" {
+			fail("expected %s, got string", s.Type)
+			return violations
+		}
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("expected at least %d characters, got %d", *s.MinLength, length)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("expected at most %d characters, got %d", *s.MaxLength, length)
+		}
+	case bool:
+		if s.Type != "" && s.Type != "boolean" {
+			fail("expected %s, got boolean", s.Type)
+		}
+	case float64:
+		if s.Type != "" && s.Type != "number" && s.Type != "integer" {
+			fail("expected %s, got number", s.Type)
+		}
+	case nil:
+		if s.Type != "" {
+			fail("expected %s, got null", s.Type)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,89 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// GotifyProvider implements Provider for Gotify push notifications
+type GotifyProvider struct{}
+
+// gotifyPriorities maps alert levels to Gotify priorities (0-10)
+var gotifyPriorities = map[int]int{
+	types.INFO:  2,
+	types.WARN:  5,
+	types.ERROR: 8,
+}
+
+func (p *GotifyProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *GotifyProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "GotifyProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	server, _ := cfg.ProviderConfig["gotify_server"].(string)
+	if server == "" {
+		err := fmt.Errorf("gotify_server must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	// Use gotify_token if available, otherwise fall back to token
+	appToken, _ := cfg.ProviderConfig["token"].(string)
+	if gotifyToken, ok := cfg.ProviderConfig["gotify_token"].(string); ok && gotifyToken != "" {
+		appToken = gotifyToken
+	}
+	if appToken == "" {
+		err := fmt.Errorf("application token is required for Gotify provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	title, body := pushMessage(level, message, attachment, cfg)
+	payload := map[string]interface{}{
+		"title":    title,
+		"message":  body,
+		"priority": pushPriority(cfg, "gotify_priorities", gotifyPriorities, level),
+	}
+	if clickURL, ok := cfg.ProviderConfig["gotify_click_url"].(string); ok && clickURL != "" {
+		payload["extras"] = map[string]interface{}{
+			"client::notification": map[string]interface{}{
+				"click": map[string]string{"url": clickURL},
+			},
+		}
+	}
+	data, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", strings.TrimRight(server, "/")+"/message", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", appToken)
+
+	types.DebugLog(cfg, "sendGotify: sending notification, payload size: %d bytes", len(data))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendGotify: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendGotify: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("gotify response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendGotify: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendGotify: notification sent successfully")
+	return nil
+}
@@ -0,0 +1,211 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alvianhanif/gocommonlog/types"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// KafkaProvider implements Provider by writing alert events as JSON to a Kafka topic
+type KafkaProvider struct{}
+
+// kafkaWriters holds one long-lived writer per brokers/topic pair so batching
+// survives across provider instances
+var (
+	kafkaWriters   = map[string]*sharedKafkaWriter{}
+	kafkaWritersMu sync.Mutex
+)
+
+// sharedKafkaWriter is a writer with the number of loggers retaining it. The
+// writer is created by the first send, so it is nil while only retained.
+type sharedKafkaWriter struct {
+	writer *kafka.Writer
+	users  int
+}
+
+func (p *KafkaProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *KafkaProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "KafkaProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	writer, err := getKafkaWriter(cfg, channel)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	msg, err := kafkaMessage(level, message, attachment, cfg, channel)
+	if err != nil {
+		return err
+	}
+	types.DebugLog(cfg, "sendKafka: writing event to topic %s, key: %s, size: %d bytes", writer.Topic, msg.Key, len(msg.Value))
+
+	if err := writer.WriteMessages(context.Background(), msg); err != nil {
+		types.DebugLog(cfg, "sendKafka: write failed: %v", err)
+		return fmt.Errorf("kafka write failed: %w", err)
+	}
+	types.DebugLog(cfg, "sendKafka: event written successfully")
+	return nil
+}
+
+// kafkaMessage builds the message for an alert: its JSON event, keyed by the
+// fingerprint so related alerts land on the same partition
+func kafkaMessage(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) (kafka.Message, error) {
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{
+		Key:   []byte(event.Fingerprint),
+		Value: data,
+		Time:  currentTime(cfg),
+	}, nil
+}
+
+// kafkaWriterKey returns the brokers and topic for cfg and channel, and the
+// key of their shared writer. The topic comes from kafka_topic, falling back
+// to the resolved channel.
+func kafkaWriterKey(cfg types.Config, channel string) ([]string, string, string, error) {
+	brokers := configStringSlice(cfg.ProviderConfig["kafka_brokers"])
+	if len(brokers) == 0 {
+		return nil, "", "", fmt.Errorf("kafka_brokers must be set in provider_config")
+	}
+	topic, _ := cfg.ProviderConfig["kafka_topic"].(string)
+	if topic == "" {
+		topic = channel
+	}
+	if topic == "" {
+		return nil, "", "", fmt.Errorf("kafka_topic must be set in provider_config or provided as channel")
+	}
+	return brokers, topic, strings.Join(brokers, ",") + "|" + topic, nil
+}
+
+// getKafkaWriter returns the shared writer for the configured brokers and topic
+func getKafkaWriter(cfg types.Config, channel string) (*kafka.Writer, error) {
+	brokers, topic, key, err := kafkaWriterKey(cfg, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaWritersMu.Lock()
+	defer kafkaWritersMu.Unlock()
+	shared := kafkaWriters[key]
+	if shared != nil && shared.writer != nil {
+		return shared.writer, nil
+	}
+
+	writer, err := newKafkaWriter(cfg, brokers, topic)
+	if err != nil {
+		return nil, err
+	}
+	types.DebugLog(cfg, "Created Kafka writer for brokers: %v, topic: %s, acks: %d, batch size: %d, async: %t",
+		brokers, topic, writer.RequiredAcks, writer.BatchSize, writer.Async)
+	if shared == nil {
+		shared = &sharedKafkaWriter{}
+		kafkaWriters[key] = shared
+	}
+	shared.writer = writer
+	return writer, nil
+}
+
+// newKafkaWriter creates a writer for brokers and topic with the configured
+// acks, batching and async settings
+func newKafkaWriter(cfg types.Config, brokers []string, topic string) (*kafka.Writer, error) {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	if acks, ok := cfg.ProviderConfig["kafka_required_acks"].(string); ok && acks != "" {
+		switch acks {
+		case "none":
+			writer.RequiredAcks = kafka.RequireNone
+		case "one":
+			writer.RequiredAcks = kafka.RequireOne
+		case "all":
+			writer.RequiredAcks = kafka.RequireAll
+		default:
+			return nil, fmt.Errorf("unknown kafka_required_acks: %s", acks)
+		}
+	}
+	if batchSize := configInt(cfg.ProviderConfig["kafka_batch_size"]); batchSize > 0 {
+		writer.BatchSize = batchSize
+	}
+	if batchTimeout := configDuration(cfg.ProviderConfig["kafka_batch_timeout"]); batchTimeout > 0 {
+		writer.BatchTimeout = batchTimeout
+	}
+	if async, ok := cfg.ProviderConfig["kafka_async"].(bool); ok {
+		writer.Async = async
+	}
+
+	return writer, nil
+}
+
+// KafkaWriterKey returns the key of the shared writer for cfg and channel, or
+// "" when the brokers or topic are missing
+func KafkaWriterKey(cfg types.Config, channel string) string {
+	_, _, key, err := kafkaWriterKey(cfg, channel)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// RetainKafkaWriter marks the shared writer with key as used by one more
+// logger, so it stays open until each of them calls ReleaseKafkaWriter
+func RetainKafkaWriter(key string) {
+	kafkaWritersMu.Lock()
+	defer kafkaWritersMu.Unlock()
+	shared := kafkaWriters[key]
+	if shared == nil {
+		shared = &sharedKafkaWriter{}
+		kafkaWriters[key] = shared
+	}
+	shared.users++
+}
+
+// ReleaseKafkaWriter drops a use taken by RetainKafkaWriter. The writer is
+// flushed and closed once no logger retains it.
+func ReleaseKafkaWriter(key string) error {
+	kafkaWritersMu.Lock()
+	defer kafkaWritersMu.Unlock()
+	shared := kafkaWriters[key]
+	if shared == nil {
+		return nil
+	}
+	if shared.users--; shared.users > 0 {
+		return nil
+	}
+	delete(kafkaWriters, key)
+	if shared.writer == nil {
+		return nil
+	}
+	return shared.writer.Close()
+}
+
+// CloseKafkaWriters flushes and closes all shared Kafka writers, including
+// those other loggers still retain
+func CloseKafkaWriters() error {
+	kafkaWritersMu.Lock()
+	defer kafkaWritersMu.Unlock()
+	var firstErr error
+	for key, shared := range kafkaWriters {
+		if shared.writer != nil {
+			if err := shared.writer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		delete(kafkaWriters, key)
+	}
+	return firstErr
+}
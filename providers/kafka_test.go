@@ -0,0 +1,125 @@
+package providers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+func TestKafkaMessage(t *testing.T) {
+	cfg := types.Config{ServiceName: "billing", Environment: "production"}
+	msg, err := kafkaMessage(types.ERROR, "Order 1234 failed", &types.Attachment{Content: "boom"}, cfg, "alerts")
+	if err != nil {
+		t.Fatalf("kafkaMessage failed: %v", err)
+	}
+
+	var event types.AlertEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		t.Fatalf("Expected a JSON event, got %s: %v", msg.Value, err)
+	}
+	if event.Level != types.ERROR || event.Message != "Order 1234 failed" || event.Service != "billing" ||
+		event.Environment != "production" || event.Channel != "alerts" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	fingerprint := types.Fingerprint(types.ERROR, "Order 1234 failed")
+	if string(msg.Key) != fingerprint || event.Fingerprint != fingerprint {
+		t.Errorf("Expected the fingerprint %s as the key, got %s", fingerprint, msg.Key)
+	}
+}
+
+func TestKafkaWriterConfig(t *testing.T) {
+	brokers := []string{"kafka-1:9092", "kafka-2:9092"}
+	writer, err := newKafkaWriter(types.Config{ProviderConfig: map[string]interface{}{}}, brokers, "alerts")
+	if err != nil {
+		t.Fatalf("newKafkaWriter failed: %v", err)
+	}
+	if writer.Topic != "alerts" || writer.Addr.String() != "kafka-1:9092,kafka-2:9092" {
+		t.Errorf("Unexpected writer target: %s %s", writer.Addr, writer.Topic)
+	}
+	if writer.RequiredAcks != kafka.RequireAll || writer.BatchSize != 0 || writer.BatchTimeout != 0 || writer.Async {
+		t.Errorf("Unexpected defaults: %+v", writer)
+	}
+
+	for acks, want := range map[string]kafka.RequiredAcks{"none": kafka.RequireNone, "one": kafka.RequireOne, "all": kafka.RequireAll} {
+		writer, err := newKafkaWriter(types.Config{ProviderConfig: map[string]interface{}{"kafka_required_acks": acks}}, brokers, "alerts")
+		if err != nil || writer.RequiredAcks != want {
+			t.Errorf("kafka_required_acks %q: got %v, %v", acks, writer, err)
+		}
+	}
+	if _, err := newKafkaWriter(types.Config{ProviderConfig: map[string]interface{}{"kafka_required_acks": "most"}}, brokers, "alerts"); err == nil ||
+		!strings.Contains(err.Error(), "unknown kafka_required_acks: most") {
+		t.Errorf("Expected an error for unknown acks, got %v", err)
+	}
+
+	writer, err = newKafkaWriter(types.Config{ProviderConfig: map[string]interface{}{
+		"kafka_batch_size":    100,
+		"kafka_batch_timeout": "500ms",
+		"kafka_async":         true,
+	}}, brokers, "alerts")
+	if err != nil {
+		t.Fatalf("newKafkaWriter failed: %v", err)
+	}
+	if writer.BatchSize != 100 || writer.BatchTimeout != 500*time.Millisecond || !writer.Async {
+		t.Errorf("Unexpected batching: size %d, timeout %s, async %t", writer.BatchSize, writer.BatchTimeout, writer.Async)
+	}
+}
+
+func TestKafkaTopicFallsBackToChannel(t *testing.T) {
+	cfg := types.Config{ProviderConfig: map[string]interface{}{"kafka_brokers": []string{"kafka-1:9092"}}}
+	if _, topic, _, err := kafkaWriterKey(cfg, "alerts"); err != nil || topic != "alerts" {
+		t.Errorf("Expected the channel as topic, got %q, %v", topic, err)
+	}
+	if _, _, _, err := kafkaWriterKey(cfg, ""); err == nil {
+		t.Error("Expected an error without topic or channel")
+	}
+
+	cfg.ProviderConfig["kafka_topic"] = "audit"
+	if _, topic, _, err := kafkaWriterKey(cfg, "alerts"); err != nil || topic != "audit" {
+		t.Errorf("Expected kafka_topic to win over the channel, got %q, %v", topic, err)
+	}
+	if _, _, _, err := kafkaWriterKey(types.Config{ProviderConfig: map[string]interface{}{}}, "alerts"); err == nil {
+		t.Error("Expected an error without brokers")
+	}
+}
+
+func TestKafkaWriterStaysOpenWhileRetained(t *testing.T) {
+	cfg := types.Config{ProviderConfig: map[string]interface{}{
+		"kafka_brokers": "127.0.0.1:1",
+		"kafka_topic":   "retained",
+	}}
+	key := KafkaWriterKey(cfg, "")
+	if key != "127.0.0.1:1|retained" {
+		t.Fatalf("Unexpected writer key: %q", key)
+	}
+	RetainKafkaWriter(key)
+	RetainKafkaWriter(key)
+	writer, err := getKafkaWriter(cfg, "")
+	if err != nil {
+		t.Fatalf("getKafkaWriter failed: %v", err)
+	}
+
+	if err := ReleaseKafkaWriter(key); err != nil {
+		t.Fatalf("ReleaseKafkaWriter failed: %v", err)
+	}
+	if again, _ := getKafkaWriter(cfg, ""); again != writer {
+		t.Error("Expected the writer to stay open while another logger retains it")
+	}
+	if err := ReleaseKafkaWriter(key); err != nil {
+		t.Fatalf("ReleaseKafkaWriter failed: %v", err)
+	}
+	kafkaWritersMu.Lock()
+	_, open := kafkaWriters[key]
+	kafkaWritersMu.Unlock()
+	if open {
+		t.Error("Expected the writer to be closed once no logger retains it")
+	}
+
+	if key := KafkaWriterKey(types.Config{}, "alerts"); key != "" {
+		t.Errorf("Expected no key without brokers, got %q", key)
+	}
+}
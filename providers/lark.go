@@ -6,43 +6,51 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/alvianhanif/gocommonlog/cache"
 	"github.com/alvianhanif/gocommonlog/types"
 
 	redis "github.com/go-redis/redis/v8"
 )
 
-// getRedisClient returns a Redis client using host/port from cfg, env, or default
+// redisClients holds one client per Redis server and database, shared by every Bucket,
+// so state lookups on the send path do not open a connection each time
+var redisClients = struct {
+	sync.Mutex
+	clients map[string]*redis.Client
+}{clients: make(map[string]*redis.Client)}
+
+// getRedisClient returns the Redis client for the host/port in cfg, creating it and
+// checking it with a ping on first use. The ping is not done under the lock, so an
+// unreachable server does not hold up lookups of other servers.
 func getRedisClient(cfg types.Config) (*redis.Client, error) {
-	host, ok := cfg.ProviderConfig["redis_host"].(string)
-	if !ok || host == "" {
-		return nil, fmt.Errorf("redis_host must be set in provider_config")
-	}
-	port, ok := cfg.ProviderConfig["redis_port"].(string)
-	if !ok || port == "" {
-		return nil, fmt.Errorf("redis_port must be set in provider_config")
+	settings := settingsOf(cfg)
+	host, err := settings.RequireString("redis_host", "redis host")
+	if err != nil {
+		return nil, err
 	}
-
-	// Optional configuration for ElastiCache support
-	password, _ := cfg.ProviderConfig["redis_password"].(string)
-	ssl, _ := cfg.ProviderConfig["redis_ssl"].(bool)
-	clusterMode, _ := cfg.ProviderConfig["redis_cluster_mode"].(bool)
-	db := 0
-	if dbVal, ok := cfg.ProviderConfig["redis_db"]; ok {
-		if dbInt, ok := dbVal.(int); ok {
-			db = dbInt
-		} else if dbStr, ok := dbVal.(string); ok {
-			if parsed, err := strconv.Atoi(dbStr); err == nil {
-				db = parsed
-			}
+	port := settings.String("redis_port", "")
+	if port == "" {
+		if n := settings.Int("redis_port", 0); n > 0 {
+			port = strconv.Itoa(n)
 		}
 	}
+	if port == "" {
+		return nil, fmt.Errorf("redis port is required (provider_config \"redis_port\")")
+	}
 
-	fmt.Printf("[Lark] Initializing Redis client with host: '%s', port: '%s'\n", host, port)
+	// Optional configuration for ElastiCache support
+	password := settings.String("redis_password", "")
+	ssl := settings.Bool("redis_ssl", false)
+	clusterMode := settings.Bool("redis_cluster_mode", false)
+	db := settings.Int("redis_db", 0)
 
 	if clusterMode {
 		// For cluster mode, we need to use RedisCluster
@@ -51,7 +59,14 @@ func getRedisClient(cfg types.Config) (*redis.Client, error) {
 	}
 
 	addr := host + ":" + port
-	fmt.Printf("[Lark] Connecting to Redis at address: %s\n", addr)
+	key := fmt.Sprintf("%s/%d/%t/%s", addr, db, ssl, password)
+	redisClients.Lock()
+	client, ok := redisClients.clients[key]
+	redisClients.Unlock()
+	if ok {
+		return client, nil
+	}
+	types.DebugLog(cfg, "Connecting to Redis at address: %s", addr)
 
 	options := &redis.Options{
 		Addr:     addr,
@@ -66,125 +81,159 @@ func getRedisClient(cfg types.Config) (*redis.Client, error) {
 		}
 	}
 
-	client := redis.NewClient(options)
+	client = redis.NewClient(options)
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
-		fmt.Printf("[Lark] Failed to ping Redis at %s: %v\n", addr, err)
+		client.Close()
+		types.DebugLog(cfg, "Failed to ping Redis at %s: %v", addr, err)
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
-	fmt.Printf("[Lark] Successfully connected to Redis at %s\n", addr)
+	types.DebugLog(cfg, "Connected to Redis at %s", addr)
+
+	redisClients.Lock()
+	defer redisClients.Unlock()
+	if existing, ok := redisClients.clients[key]; ok {
+		// Another lookup connected first
+		client.Close()
+		return existing, nil
+	}
+	redisClients.clients[key] = client
 	return client, nil
 }
 
-func cacheLarkToken(cfg types.Config, appID, appSecret, token string) error {
-	key := "commonlog_lark_token:" + appID + ":" + appSecret
-	client, err := getRedisClient(cfg)
-	if err != nil {
-		// Fallback to in-memory cache
-		cache.GetGlobalCache().Set(key, token, 90*time.Minute)
-		types.DebugLog(cfg, "Lark token cached in memory")
-		return nil
+// forgetRedisClient drops a client whose command failed, so the next lookup pings Redis
+// again and falls back to memory while it is down
+func forgetRedisClient(client *redis.Client) {
+	redisClients.Lock()
+	defer redisClients.Unlock()
+	for key, cached := range redisClients.clients {
+		if cached == client {
+			delete(redisClients.clients, key)
+			client.Close()
+		}
 	}
-	return client.Set(context.Background(), key, token, 90*time.Minute).Err()
 }
 
-func cacheChatID(cfg types.Config, channelName, chatID string) error {
-	key := "commonlog_lark_chat_id:" + cfg.Environment + ":" + channelName
-	client, err := getRedisClient(cfg)
-	if err != nil {
-		// Fallback to in-memory cache (30 days expiry)
-		cache.GetGlobalCache().Set(key, chatID, 30*24*time.Hour)
-		types.DebugLog(cfg, "Lark chat ID cached in memory")
-		return nil
+// CheckRedis verifies that the Redis server configured by redis_host/redis_port answers a ping
+func CheckRedis(ctx context.Context, cfg types.Config) error {
+	result := make(chan error, 1)
+	go func() {
+		client, err := getRedisClient(cfg)
+		if err == nil {
+			if err = client.Ping(ctx).Err(); err != nil {
+				forgetRedisClient(client)
+			}
+		}
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// larkChatKey returns the state key of a channel's chat ID, scoped to the tenant when
+// sending with a tenant's token since chat names are only unique within a tenant
+func larkChatKey(cfg types.Config, channelName string) string {
+	if cfg.Tenant != "" {
+		return cfg.Tenant + ":" + cfg.Environment + ":" + channelName
 	}
-	return client.Set(context.Background(), key, chatID, 0).Err() // No expiry
+	return cfg.Environment + ":" + channelName
+}
+
+func cacheChatID(cfg types.Config, channelName, chatID string) error {
+	// Chat IDs never change, so they are kept until replaced
+	return NewBucket(cfg, "lark_chat_id").Set(larkChatKey(cfg, channelName), chatID, 0)
 }
 
 func getCachedLarkToken(cfg types.Config, appID, appSecret string) (string, error) {
-	key := "commonlog_lark_token:" + appID + ":" + appSecret
-	client, err := getRedisClient(cfg)
+	token, _, err := NewBucket(cfg, "lark_token").Get(appID + ":" + appSecret)
 	if err != nil {
-		// Fallback to in-memory cache
-		if token, found := cache.GetGlobalCache().Get(key); found {
-			types.DebugLog(cfg, "Lark token retrieved from memory")
-			return token, nil
-		}
-		return "", nil // No cached token
-	}
-	result, err := client.Get(context.Background(), key).Result()
-	if err == redis.Nil {
-		fmt.Printf("[Lark] No cached token found for key: %s\n", key)
-		return "", nil // No cached token
-	} else if err != nil {
-		fmt.Printf("[Lark] Error retrieving cached token for key %s: %v\n", key, err)
-		return "", err
+		fmt.Printf("[Lark] Error retrieving cached token for app %s: %v\n", appID, err)
 	}
-	fmt.Printf("[Lark] Retrieved cached token for key: %s\n", key)
-	return result, nil
+	return token, err
 }
 
 func getCachedChatID(cfg types.Config, channelName string) (string, error) {
-	key := "commonlog_lark_chat_id:" + cfg.Environment + ":" + channelName
-	client, err := getRedisClient(cfg)
+	chatID, found, err := NewBucket(cfg, "lark_chat_id").Get(larkChatKey(cfg, channelName))
 	if err != nil {
-		// Fallback to in-memory cache
-		if chatID, found := cache.GetGlobalCache().Get(key); found {
-			types.DebugLog(cfg, "Lark chat ID retrieved from memory")
-			return chatID, nil
-		}
-		return "", nil // No cached chat ID
-	}
-	result, err := client.Get(context.Background(), key).Result()
-	if err == redis.Nil {
-		fmt.Printf("[Lark] No cached chat_id found for channel: %s in environment: %s\n", channelName, cfg.Environment)
-		return "", nil // No cached chat_id
-	} else if err != nil {
 		fmt.Printf("[Lark] Error retrieving cached chat_id for channel %s in environment %s: %v\n", channelName, cfg.Environment, err)
 		return "", err
 	}
-	fmt.Printf("[Lark] Retrieved cached chat_id for channel: %s in environment: %s\n", channelName, cfg.Environment)
-	return result, nil
+	if found {
+		fmt.Printf("[Lark] Retrieved cached chat_id for channel: %s in environment: %s\n", channelName, cfg.Environment)
+	}
+	return chatID, nil
 }
 
-// getChatIDFromChannelName fetches the chat_id for a given channel name using pagination
-func getChatIDFromChannelName(cfg types.Config, token, channelName string) (string, error) {
-	// Try Redis cache first
-	cached, err := getCachedChatID(cfg, channelName)
-	if err != nil {
-		return "", fmt.Errorf("failed to get Redis client: %w", err)
+// larkChatPageSize is the largest page the chats API returns
+const larkChatPageSize = 100
+
+// larkChatListTTL is how long the name to chat_id map of a tenant is cached by default
+const larkChatListTTL = time.Hour
+
+// larkChatListKey returns the state key of the chat list the bot belongs to, scoped like
+// larkChatKey
+func larkChatListKey(cfg types.Config) string {
+	if cfg.Tenant != "" {
+		return cfg.Tenant + ":" + cfg.Environment
 	}
-	if cached != "" {
-		return cached, nil
+	return cfg.Environment
+}
+
+// getCachedChatList returns the cached name to chat_id map, or nil when none is cached
+func getCachedChatList(cfg types.Config) map[string]string {
+	data, found, err := NewBucket(cfg, "lark_chat_list").Get(larkChatListKey(cfg))
+	if err != nil || !found {
+		return nil
+	}
+	var chats map[string]string
+	if err := json.Unmarshal([]byte(data), &chats); err != nil {
+		return nil
 	}
+	return chats
+}
 
-	baseURL := "https://open.larksuite.com/open-apis/im/v1/chats"
-	headers := map[string]string{"Authorization": "Bearer " + token}
+// cacheChatList stores the name to chat_id map for lark_chat_list_ttl, one hour by default
+func cacheChatList(cfg types.Config, chats map[string]string) error {
+	data, err := json.Marshal(chats)
+	if err != nil {
+		return err
+	}
+	ttl := settingsOf(cfg).Duration("lark_chat_list_ttl", larkChatListTTL)
+	return NewBucket(cfg, "lark_chat_list").Set(larkChatListKey(cfg), string(data), ttl)
+}
 
+// fetchChatList pages through every chat the bot belongs to and returns their chat IDs
+// keyed by name
+func fetchChatList(cfg types.Config, token string) (map[string]string, error) {
+	baseURL := "https://open.larksuite.com/open-apis/im/v1/chats"
+	chats := make(map[string]string)
 	pageToken := ""
 	hasMore := true
 
 	for hasMore {
-		url := baseURL + "?page_size=10"
+		url := fmt.Sprintf("%s?page_size=%d", baseURL, larkChatPageSize)
 		if pageToken != "" {
 			url += "&page_token=" + pageToken
 		}
 
 		req, err := http.NewRequest("GET", url, nil)
 		if err != nil {
-			return "", err
-		}
-		for k, v := range headers {
-			req.Header.Set(k, v)
+			return nil, err
 		}
+		req.Header.Set("Authorization", "Bearer "+token)
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := operationDoer(cfg, opLookup).Do(req)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
-			return "", fmt.Errorf("lark chats API response: %d", resp.StatusCode)
+			return nil, fmt.Errorf("lark chats API response: %d", resp.StatusCode)
 		}
 
 		var result struct {
@@ -201,21 +250,17 @@ func getChatIDFromChannelName(cfg types.Config, token, channelName string) (stri
 		}
 
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			return "", err
+			return nil, err
 		}
 
 		if result.Code != 0 {
-			return "", fmt.Errorf("lark API error: %s", result.Msg)
+			return nil, fmt.Errorf("lark API error: %s", result.Msg)
 		}
 
-		// Search for the channel name in the current page
 		for _, item := range result.Data.Items {
-			if item.Name == channelName {
-				// Cache the chat_id without expiry
-				if err := cacheChatID(cfg, channelName, item.ChatID); err != nil {
-					fmt.Printf("[Lark] Warning: failed to cache chat_id for channel %s: %v\n", channelName, err)
-				}
-				return item.ChatID, nil
+			// Keep the first chat when several share a name, as lookups always have
+			if _, exists := chats[item.Name]; !exists {
+				chats[item.Name] = item.ChatID
 			}
 		}
 
@@ -224,12 +269,339 @@ func getChatIDFromChannelName(cfg types.Config, token, channelName string) (stri
 		hasMore = result.Data.HasMore
 	}
 
-	return "", fmt.Errorf("channel '%s' not found", channelName)
+	types.DebugLog(cfg, "fetchChatList: fetched %d chats", len(chats))
+	return chats, nil
+}
+
+// larkMissingChatTTL is how long a channel that was not found is remembered by default
+const larkMissingChatTTL = 5 * time.Minute
+
+// larkChatFetches coalesces concurrent chat list fetches for the same tenant, so a burst of
+// alerts to an uncached channel pages through the chats once
+var larkChatFetches = struct {
+	sync.Mutex
+	calls map[string]*larkChatFetch
+}{calls: make(map[string]*larkChatFetch)}
+
+type larkChatFetch struct {
+	done  chan struct{}
+	chats map[string]string
+	err   error
+}
+
+// fetchChatListOnce fetches the chat list with list and caches it, waiting for a fetch
+// already in progress for the same tenant and environment instead of starting another
+func fetchChatListOnce(cfg types.Config, list func() (map[string]string, error)) (map[string]string, error) {
+	key := larkChatListKey(cfg)
+	larkChatFetches.Lock()
+	if call, ok := larkChatFetches.calls[key]; ok {
+		larkChatFetches.Unlock()
+		<-call.done
+		return call.chats, call.err
+	}
+	call := &larkChatFetch{done: make(chan struct{})}
+	larkChatFetches.calls[key] = call
+	larkChatFetches.Unlock()
+
+	call.chats, call.err = list()
+	if call.err == nil {
+		if err := cacheChatList(cfg, call.chats); err != nil {
+			fmt.Printf("[Lark] Warning: failed to cache chat list: %v\n", err)
+		}
+	}
+	larkChatFetches.Lock()
+	delete(larkChatFetches.calls, key)
+	larkChatFetches.Unlock()
+	close(call.done)
+	return call.chats, call.err
+}
+
+// getChatIDFromChannelName returns the chat_id for a given channel name, from the chat ID
+// cache, then the cached chat list, then a fresh chat list. Channels that are not found
+// are remembered for lark_missing_chat_ttl, so sends to them fail with
+// types.ErrChannelNotFound without paging through the chats again.
+func getChatIDFromChannelName(cfg types.Config, token, channelName string) (string, error) {
+	return resolveChatID(cfg, channelName, func() (map[string]string, error) {
+		return fetchChatList(cfg, token)
+	})
+}
+
+// resolveChatID looks up the chat_id of a channel like getChatIDFromChannelName, fetching
+// the chat list with list when it is not cached
+func resolveChatID(cfg types.Config, channelName string, list func() (map[string]string, error)) (string, error) {
+	// Try Redis cache first
+	cached, err := getCachedChatID(cfg, channelName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Redis client: %w", err)
+	}
+	if cached != "" {
+		return cached, nil
+	}
+
+	missing := NewBucket(cfg, "lark_chat_missing")
+	chatID, found := getCachedChatList(cfg)[channelName]
+	if !found {
+		if _, known, _ := missing.Get(larkChatKey(cfg, channelName)); known {
+			types.DebugLog(cfg, "getChatIDFromChannelName: channel '%s' recently not found", channelName)
+			return "", fmt.Errorf("channel '%s': %w", channelName, types.ErrChannelNotFound)
+		}
+		// The cached list may predate the channel, so fetch it again
+		chats, err := fetchChatListOnce(cfg, list)
+		if err != nil {
+			return "", err
+		}
+		chatID, found = chats[channelName]
+	}
+	if !found {
+		ttl := settingsOf(cfg).Duration("lark_missing_chat_ttl", larkMissingChatTTL)
+		if err := missing.Set(larkChatKey(cfg, channelName), "1", ttl); err != nil {
+			fmt.Printf("[Lark] Warning: failed to cache missing channel %s: %v\n", channelName, err)
+		}
+		return "", fmt.Errorf("channel '%s': %w", channelName, types.ErrChannelNotFound)
+	}
+	// Cache the chat_id without expiry
+	if err := cacheChatID(cfg, channelName, chatID); err != nil {
+		fmt.Printf("[Lark] Warning: failed to cache chat_id for channel %s: %v\n", channelName, err)
+	}
+	return chatID, nil
 }
 
 // LarkProvider implements Provider for Lark
 type LarkProvider struct{}
 
+// larkMessage is the im/v1/messages and bot webhook payload for a rich text post,
+// or for an interactive card when the alert can be acknowledged
+type larkMessage struct {
+	ReceiveID string       `json:"receive_id,omitempty"`
+	MsgType   string       `json:"msg_type"`
+	Content   *larkContent `json:"content,omitempty"`
+	Card      *larkCard    `json:"card,omitempty"`
+}
+
+type larkContent struct {
+	Post map[string]larkPost `json:"post"` // locale -> post
+}
+
+type larkPost struct {
+	Title   string              `json:"title"`
+	Content [][]larkPostElement `json:"content"`
+}
+
+type larkPostElement struct {
+	Tag      string `json:"tag"`
+	Text     string `json:"text,omitempty"`
+	ImageKey string `json:"image_key,omitempty"`
+}
+
+// larkTokenRequest is the tenant_access_token/internal request body
+type larkTokenRequest struct {
+	AppID     string `json:"app_id"`
+	AppSecret string `json:"app_secret"`
+}
+
+// larkResponse is the envelope returned by Lark APIs and bot webhooks; a non-zero code is a failure
+type larkResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		MessageID string `json:"message_id"`
+		ChatID    string `json:"chat_id"`
+	} `json:"data"`
+}
+
+// checkLarkResponse returns an error for a response body reporting a non-zero code, and
+// records the ID of a posted message in response
+func checkLarkResponse(body []byte, response *types.ProviderResponse) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var result larkResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("invalid lark response: %w", err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("lark API error %d: %s", result.Code, result.Msg)
+	}
+	if result.Data.MessageID != "" {
+		response.SetMessage(result.Data.MessageID, result.Data.ChatID)
+	}
+	return nil
+}
+
+// newLarkPost builds post content with a single text paragraph
+func newLarkPost(title, text string) *larkContent {
+	return &larkContent{Post: map[string]larkPost{
+		larkDefaultLocale: {Title: title, Content: [][]larkPostElement{{{Tag: "text", Text: text}}}},
+	}}
+}
+
+// larkCard is an interactive message card with link and Acknowledge buttons
+type larkCard struct {
+	Config   larkCardConfig    `json:"config"`
+	Header   larkCardHeader    `json:"header"`
+	Elements []larkCardElement `json:"elements"`
+}
+
+type larkCardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+type larkCardHeader struct {
+	Title larkCardText `json:"title"`
+}
+
+type larkCardText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+type larkCardElement struct {
+	Tag     string           `json:"tag"`
+	Text    *larkCardText    `json:"text,omitempty"`
+	Content string           `json:"content,omitempty"`
+	ImgKey  string           `json:"img_key,omitempty"`
+	Alt     *larkCardText    `json:"alt,omitempty"`
+	Actions []larkCardButton `json:"actions,omitempty"`
+}
+
+type larkCardButton struct {
+	Tag   string            `json:"tag"`
+	Text  larkCardText      `json:"text"`
+	Type  string            `json:"type"`
+	URL   string            `json:"url,omitempty"`
+	Value map[string]string `json:"value,omitempty"`
+}
+
+// larkUsesCard reports whether the alert needs an interactive card rather than a post,
+// for an Acknowledge button, link buttons or highlighted snippets
+func larkUsesCard(cfg types.Config) bool {
+	return cfg.AckID != "" || len(cfg.Links) > 0 || len(cfg.Snippets) > 0
+}
+
+// newLarkMessage builds a post, or an interactive card when larkUsesCard. Snippets are
+// rendered as markdown code blocks and links as buttons.
+func newLarkMessage(receiveID, title, text string, cfg types.Config) larkMessage {
+	if !larkUsesCard(cfg) {
+		return larkMessage{ReceiveID: receiveID, MsgType: "post", Content: newLarkPost(title, text)}
+	}
+	card := &larkCard{
+		Config:   larkCardConfig{WideScreenMode: true},
+		Header:   larkCardHeader{Title: larkCardText{Tag: "plain_text", Content: title}},
+		Elements: []larkCardElement{{Tag: "div", Text: &larkCardText{Tag: "lark_md", Content: text}}},
+	}
+	for _, snippet := range cfg.Snippets {
+		card.Elements = append(card.Elements, larkCardElement{Tag: "markdown", Content: markdownSnippet(snippet, "**")})
+	}
+
+	var buttons []larkCardButton
+	for _, link := range cfg.Links {
+		buttons = append(buttons, larkCardButton{
+			Tag:  "button",
+			Text: larkCardText{Tag: "plain_text", Content: link.Text},
+			Type: "default",
+			URL:  link.URL,
+		})
+	}
+	if cfg.AckID != "" {
+		buttons = append(buttons, larkCardButton{
+			Tag:   "button",
+			Text:  larkCardText{Tag: "plain_text", Content: types.Localize(cfg, types.TextAcknowledge)},
+			Type:  "primary",
+			Value: map[string]string{"action": types.AckActionID, "alert_id": cfg.AckID},
+		})
+	}
+	if len(buttons) > 0 {
+		card.Elements = append(card.Elements, larkCardElement{Tag: "action", Actions: buttons})
+	}
+	return larkMessage{ReceiveID: receiveID, MsgType: "interactive", Card: card}
+}
+
+// addImage shows an uploaded image after the message text, in every locale of a post
+func (m *larkMessage) addImage(imageKey, altText string) {
+	if m.Card != nil {
+		image := larkCardElement{Tag: "img", ImgKey: imageKey, Alt: &larkCardText{Tag: "plain_text", Content: altText}}
+		m.Card.Elements = append(m.Card.Elements[:1], append([]larkCardElement{image}, m.Card.Elements[1:]...)...)
+		return
+	}
+	for locale, post := range m.Content.Post {
+		post.Content = append(post.Content, []larkPostElement{{Tag: "img", ImageKey: imageKey}})
+		m.Content.Post[locale] = post
+	}
+}
+
+// larkImageLimit is the maximum size of an image uploaded to Lark
+const larkImageLimit = 10 << 20
+
+// larkImageResponse is the im/v1/images response
+type larkImageResponse struct {
+	larkResponse
+	Data struct {
+		ImageKey string `json:"image_key"`
+	} `json:"data"`
+}
+
+// uploadLarkImage uploads image bytes, downloading them from the image URL when only a URL
+// is set, and returns the image key
+func uploadLarkImage(cfg types.Config, token string, image *types.Image) (string, error) {
+	data := image.Data
+	if len(data) == 0 {
+		if image.URL == "" {
+			return "", fmt.Errorf("image %s has no data or URL", image.Name())
+		}
+		req, err := http.NewRequest("GET", image.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := httpDoer(cfg).Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			return "", fmt.Errorf("image download response: %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, larkImageLimit+1)); err != nil {
+			return "", err
+		}
+	}
+	if len(data) > larkImageLimit {
+		return "", fmt.Errorf("image %s exceeds the Lark limit of %d bytes", image.Name(), larkImageLimit)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("image_type", "message")
+	part, err := writer.CreateFormFile("image", image.Name())
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	req, _ := http.NewRequest("POST", "https://open.larksuite.com/open-apis/im/v1/images", &body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respData := readResponse(cfg, "uploadLarkImage", resp)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("lark image upload response: %d", resp.StatusCode)
+	}
+	var result larkImageResponse
+	if err := json.Unmarshal(respData, &result); err != nil {
+		return "", fmt.Errorf("invalid lark response: %w", err)
+	}
+	if result.Code != 0 {
+		return "", fmt.Errorf("lark API error %d: %s", result.Code, result.Msg)
+	}
+	types.DebugLog(cfg, "uploadLarkImage: uploaded %s (%d bytes)", image.Name(), len(data))
+	return result.Data.ImageKey, nil
+}
+
 func getTenantAccessToken(cfg types.Config, appID, appSecret string) (string, error) {
 	// Try Redis cache first
 	cached, err := getCachedLarkToken(cfg, appID, appSecret)
@@ -240,14 +612,14 @@ func getTenantAccessToken(cfg types.Config, appID, appSecret string) (string, er
 		return cached, nil
 	}
 	url := "https://open.larksuite.com/open-apis/auth/v3/tenant_access_token/internal"
-	payload := map[string]string{"app_id": appID, "app_secret": appSecret}
+	payload := larkTokenRequest{AppID: appID, AppSecret: appSecret}
 	data, _ := json.Marshal(payload)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := operationDoer(cfg, opAuth).Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -269,17 +641,9 @@ func getTenantAccessToken(cfg types.Config, appID, appSecret string) (string, er
 	if expireSeconds <= 0 {
 		expireSeconds = 60 // fallback to 1 minute if API returns too low
 	}
-	key := "commonlog_lark_token:" + appID + ":" + appSecret
-	client, err := getRedisClient(cfg)
-	if err != nil {
-		// Redis not configured, skip caching but continue with token
-		types.DebugLog(cfg, "Lark token caching disabled - Redis not configured")
-	} else {
-		err = client.Set(context.Background(), key, result.Token, time.Duration(expireSeconds)*time.Second).Err()
-		if err != nil {
-			fmt.Printf("[Lark] Warning: failed to cache token: %v\n", err)
-			// Don't return error, just log warning and continue
-		}
+	if err := NewBucket(cfg, "lark_token").Set(appID+":"+appSecret, result.Token, time.Duration(expireSeconds)*time.Second); err != nil {
+		// Don't return error, just log warning and continue
+		fmt.Printf("[Lark] Warning: failed to cache token: %v\n", err)
 	}
 	return result.Token, nil
 }
@@ -288,6 +652,143 @@ func (p *LarkProvider) Send(level int, message string, attachment *types.Attachm
 	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
 }
 
+// HealthCheck fetches a tenant access token for the webclient method and, when cfg.Channel
+// is set, resolves its chat ID; webhooks cannot be verified without posting, so only their
+// URL is checked
+func (p *LarkProvider) HealthCheck(ctx context.Context, cfg types.Config) error {
+	if cfg.SendMethod != types.MethodWebClient && cfg.SendMethod != types.MethodSDK {
+		if cfg.Token == "" {
+			return fmt.Errorf("webhook URL is required for Lark webhook method")
+		}
+		return nil
+	}
+	larkToken, ok := cfg.ProviderConfig["lark_token"].(types.LarkTokenConfig)
+	if !ok || larkToken.AppID == "" || larkToken.AppSecret == "" {
+		if cfg.Token == "" {
+			return fmt.Errorf("lark_token or token is required for Lark webclient method")
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	token, err := getTenantAccessToken(cfg, larkToken.AppID, larkToken.AppSecret)
+	if err != nil || cfg.Channel == "" {
+		return err
+	}
+	// Resolving the chat ID confirms the bot has joined the channel
+	if _, err := getChatIDFromChannelName(cfg, token, cfg.Channel); err != nil {
+		return fmt.Errorf("failed to get chat_id for channel '%s': %w", cfg.Channel, err)
+	}
+	return nil
+}
+
+// larkTenantToken returns the tenant access token for lark_token, or Token when the app
+// credentials are not configured
+func larkTenantToken(cfg types.Config) (string, error) {
+	if larkToken, ok := cfg.ProviderConfig["lark_token"].(types.LarkTokenConfig); ok && larkToken.AppID != "" && larkToken.AppSecret != "" {
+		return getTenantAccessToken(cfg, larkToken.AppID, larkToken.AppSecret)
+	}
+	return cfg.Token, nil
+}
+
+// larkChatLister returns the function fetching the chat list: the SDK client's for the api
+// send method when it lists channels, otherwise the chats API
+func larkChatLister(ctx context.Context, cfg types.Config, token string) func() (map[string]string, error) {
+	if cfg.SendMethod == types.MethodSDK {
+		if client, err := sdkClient("lark"); err == nil {
+			if lister, ok := client.(ChannelLister); ok {
+				return func() (map[string]string, error) { return lister.ListChannels(ctx, cfg, token) }
+			}
+		}
+	}
+	return func() (map[string]string, error) { return fetchChatList(cfg, token) }
+}
+
+// sendLarkSDK posts the message the webclient method would post through the Lark SDK
+// client registered by the sdk package
+func (p *LarkProvider) sendLarkSDK(message string, attachment *types.Attachment, cfg types.Config) error {
+	client, err := sdkClient("lark")
+	if err != nil {
+		return err
+	}
+	token, err := larkTenantToken(cfg)
+	if err != nil {
+		types.DebugLog(cfg, "sendLarkSDK: error fetching tenant access token: %v", err)
+		return err
+	}
+	ctx := context.Background()
+	chatID, err := resolveChatID(cfg, cfg.Channel, larkChatLister(ctx, cfg, token))
+	if err != nil {
+		return fmt.Errorf("failed to get chat_id for channel '%s': %w", cfg.Channel, err)
+	}
+
+	// Images are uploaded and shown inline, falling back to a link to the image URL
+	image := cfg.Image
+	var imageKey string
+	if image != nil {
+		imageKey, err = uploadLarkImage(cfg, token, image)
+		if err != nil {
+			log.Printf("[WARN] Failed to upload Lark image %s, falling back to its URL: %v", image.Name(), err)
+		} else {
+			cfg.Image = nil
+		}
+	}
+	payload := p.buildMessage(chatID, message, attachment, cfg)
+	if imageKey != "" {
+		payload.addImage(imageKey, image.Alt())
+	}
+	var content []byte
+	if payload.Card != nil {
+		content, _ = json.Marshal(payload.Card)
+	} else {
+		content, _ = json.Marshal(payload.Content)
+	}
+
+	types.DebugLog(cfg, "sendLarkSDK: sending %s message to channel '%s'", payload.MsgType, cfg.Channel)
+	if err := client.PostMessage(ctx, cfg, SDKMessage{Token: token, ChannelID: chatID, MsgType: payload.MsgType, Content: string(content)}); err != nil {
+		types.DebugLog(cfg, "sendLarkSDK: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendLarkSDK: message sent successfully to channel '%s'", cfg.Channel)
+	return nil
+}
+
+// PrefetchChannels fetches the chats the bot belongs to and caches the chat ID of each
+// channel, so the first alert to a channel does not page through the chat list. Only the
+// webclient method looks up chats; webhooks have nothing to prefetch.
+func (p *LarkProvider) PrefetchChannels(ctx context.Context, cfg types.Config, channels []string) error {
+	if cfg.SendMethod != types.MethodWebClient && cfg.SendMethod != types.MethodSDK {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	token, err := larkTenantToken(cfg)
+	if err != nil {
+		return err
+	}
+	chats, err := fetchChatListOnce(cfg, larkChatLister(ctx, cfg, token))
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, channel := range channels {
+		chatID, found := chats[channel]
+		if !found {
+			missing = append(missing, channel)
+			continue
+		}
+		if err := cacheChatID(cfg, channel, chatID); err != nil {
+			fmt.Printf("[Lark] Warning: failed to cache chat_id for channel %s: %v\n", channel, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", types.ErrChannelNotFound, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func (p *LarkProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
 	types.DebugLog(cfg, "LarkProvider.SendToChannel called with level: %d, send method: %s, channel: %s",
 		level, cfg.SendMethod, channel)
@@ -301,6 +802,9 @@ func (p *LarkProvider) SendToChannel(level int, message string, attachment *type
 	case types.MethodWebhook:
 		types.DebugLog(cfg, "Using Lark webhook method")
 		return p.sendLarkWebhook(message, attachment, cfgCopy)
+	case types.MethodSDK:
+		types.DebugLog(cfg, "Using Lark SDK method")
+		return p.sendLarkSDK(message, attachment, cfgCopy)
 	default:
 		err := fmt.Errorf("unknown send method for Lark: %s", cfgCopy.SendMethod)
 		types.DebugLog(cfg, "Error: %v", err)
@@ -308,10 +812,42 @@ func (p *LarkProvider) SendToChannel(level int, message string, attachment *type
 	}
 }
 
+// larkDefaultLocale keys the post when no locale is configured; its text stays in English
+const larkDefaultLocale = "zh_cn"
+
+// buildMessage renders the alert once per locale in lark_locales, each a post body keyed by
+// its locale, so Lark shows the one matching the reader's language. Without lark_locales
+// a single post is rendered in Config.Locale. Cards use the first locale only.
+func (p *LarkProvider) buildMessage(receiveID, message string, attachment *types.Attachment, cfg types.Config) larkMessage {
+	locales := settingsOf(cfg).StringSlice("lark_locales")
+	if len(locales) == 0 {
+		title, text := p.formatMessage(message, attachment, cfg)
+		msg := newLarkMessage(receiveID, title, text, cfg)
+		if cfg.Locale != "" && msg.Content != nil {
+			msg.Content.Post = map[string]larkPost{cfg.Locale: msg.Content.Post[larkDefaultLocale]}
+		}
+		return msg
+	}
+
+	localized := cfg
+	localized.Locale = locales[0]
+	if larkUsesCard(cfg) {
+		title, text := p.formatMessage(message, attachment, localized)
+		return newLarkMessage(receiveID, title, text, localized)
+	}
+	content := &larkContent{Post: make(map[string]larkPost, len(locales))}
+	for _, locale := range locales {
+		localized.Locale = locale
+		title, text := p.formatMessage(message, attachment, localized)
+		content.Post[locale] = larkPost{Title: title, Content: [][]larkPostElement{{{Tag: "text", Text: text}}}}
+	}
+	return larkMessage{ReceiveID: receiveID, MsgType: "post", Content: content}
+}
+
 // formatMessage formats the alert message with optional attachment and returns title and content separately
 func (p *LarkProvider) formatMessage(message string, attachment *types.Attachment, cfg types.Config) (string, string) {
 	// Extract title from service and environment
-	title := "Alert"
+	title := types.Localize(cfg, types.TextAlert)
 	if cfg.ServiceName != "" && cfg.Environment != "" {
 		title = fmt.Sprintf("%s - %s", cfg.ServiceName, cfg.Environment)
 	} else if cfg.ServiceName != "" {
@@ -320,30 +856,48 @@ func (p *LarkProvider) formatMessage(message string, attachment *types.Attachmen
 		title = cfg.Environment
 	}
 
-	// Format message content without the header
-	formatted := message
-
-	if attachment != nil {
-		if attachment.Content != "" {
+	// Format message content without the service and environment, shown as the title
+	return title, renderSections(cfg, func(section string) string {
+		switch section {
+		case types.SectionHeader:
+			return alertMetaLine(cfg)
+		case types.SectionMessage:
+			return message + imageLink(cfg, "**")
+		case types.SectionFields:
+			return fieldLines(cfg, "**")
+		case types.SectionTrace:
+			if attachment == nil || attachment.Content == "" {
+				return ""
+			}
 			// Inline content - show as expandable code block
 			filename := attachment.FileName
 			if filename == "" {
-				filename = "Trace Logs"
+				filename = types.Localize(cfg, types.TextTraceLogs)
+			}
+			return "**" + filename + ":**\n```\n" + attachment.Content + "\n```"
+		case types.SectionAttachment:
+			if attachment == nil || attachment.URL == "" {
+				return ""
 			}
-			formatted += fmt.Sprintf("\n\n**%s:**\n```\n%s\n```", filename, attachment.Content)
-		}
-		if attachment.URL != "" {
 			// External URL attachment
-			formatted += fmt.Sprintf("\n\n**Attachment:** %s", attachment.URL)
+			return "**" + types.Localize(cfg, types.TextAttachment) + ":** " + attachment.URL
+		case types.SectionFooter:
+			// The ID line and footer trail the message
+			trailer := alertIDLine(cfg)
+			if footer := alertFooter(cfg); footer != "" {
+				if trailer != "" {
+					trailer += "\n"
+				}
+				trailer += footer
+			}
+			return trailer
 		}
-	}
-
-	return title, formatted
+		return ""
+	})
 }
 
 func (p *LarkProvider) sendLarkWebClient(message string, attachment *types.Attachment, cfg types.Config) error {
 	types.DebugLog(cfg, "sendLarkWebClient: formatting message and preparing API request")
-	title, formattedMessage := p.formatMessage(message, attachment, cfg)
 	token := cfg.Token
 
 	types.DebugLog(cfg, "sendLarkWebClient: sending to channel '%s'", cfg.Channel)
@@ -368,41 +922,38 @@ func (p *LarkProvider) sendLarkWebClient(message string, attachment *types.Attac
 	chatID, err := getChatIDFromChannelName(cfg, token, cfg.Channel)
 	if err != nil {
 		types.DebugLog(cfg, "sendLarkWebClient: failed to get chat_id for channel '%s': %v", cfg.Channel, err)
-		return fmt.Errorf("failed to get chat_id for channel '%s': %v", cfg.Channel, err)
+		return fmt.Errorf("failed to get chat_id for channel '%s': %w", cfg.Channel, err)
 	}
 	types.DebugLog(cfg, "sendLarkWebClient: resolved chat_id (length: %d)", len(chatID))
 
 	url := "https://open.larksuite.com/open-apis/im/v1/messages?receive_id_type=chat_id"
-	headers := map[string]string{"Authorization": "Bearer " + token, "Content-Type": "application/json"}
-
-	payload := map[string]interface{}{
-		"receive_id": chatID,
-		"msg_type":   "post",
-		"content": map[string]interface{}{
-			"post": map[string]interface{}{
-				"zh_cn": map[string]interface{}{
-					"title": title,
-					"content": []interface{}{
-						[]interface{}{
-							map[string]interface{}{
-								"tag":  "text",
-								"text": formattedMessage,
-							},
-						},
-					},
-				},
-			},
-		},
+
+	// Images are uploaded and shown inline, falling back to a link to the image URL
+	image := cfg.Image
+	var imageKey string
+	if image != nil {
+		imageKey, err = uploadLarkImage(cfg, token, image)
+		if err != nil {
+			log.Printf("[WARN] Failed to upload Lark image %s, falling back to its URL: %v", image.Name(), err)
+		} else {
+			cfg.Image = nil
+		}
+	}
+
+	payload := p.buildMessage(chatID, message, attachment, cfg)
+	if imageKey != "" {
+		payload.addImage(imageKey, image.Alt())
 	}
 	data, _ := json.Marshal(payload)
 
-	types.DebugLog(cfg, "sendLarkWebClient: sending HTTP request to Lark API, payload size: %d bytes, payload: %s", len(data), string(data))
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	if cfg.Debug {
+		types.DebugLog(cfg, "sendLarkWebClient: sending HTTP request to Lark API, payload size: %d bytes, payload: %s", len(data), string(data))
 	}
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpDoer(cfg).Do(req)
 	if err != nil {
 		types.DebugLog(cfg, "sendLarkWebClient: HTTP request failed: %v", err)
 		return err
@@ -410,26 +961,23 @@ func (p *LarkProvider) sendLarkWebClient(message string, attachment *types.Attac
 	defer resp.Body.Close()
 
 	// Log response data
-	respBody := new(bytes.Buffer)
-	_, copyErr := respBody.ReadFrom(resp.Body)
-	if copyErr != nil {
-		types.DebugLog(cfg, "sendLarkWebClient: error reading response body: %v", copyErr)
-	} else {
-		types.DebugLog(cfg, "sendLarkWebClient: response status: %d, body length: %d, body: %s", resp.StatusCode, respBody.Len(), respBody.String())
-	}
+	respData := readResponse(cfg, "sendLarkWebClient", resp)
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("lark WebClient response: %d", resp.StatusCode)
 		types.DebugLog(cfg, "sendLarkWebClient: error response: %v", err)
 		return err
 	}
+	if err := checkLarkResponse(respData, cfg.Response); err != nil {
+		types.DebugLog(cfg, "sendLarkWebClient: error response: %v", err)
+		return err
+	}
 	types.DebugLog(cfg, "sendLarkWebClient: message sent successfully to channel '%s'", cfg.Channel)
 	return nil
 }
 
 func (p *LarkProvider) sendLarkWebhook(message string, attachment *types.Attachment, cfg types.Config) error {
 	types.DebugLog(cfg, "sendLarkWebhook: formatting message and preparing webhook request")
-	title, formattedMessage := p.formatMessage(message, attachment, cfg)
 
 	// For webhook, the token field contains the webhook URL
 	webhookURL := cfg.Token
@@ -440,33 +988,18 @@ func (p *LarkProvider) sendLarkWebhook(message string, attachment *types.Attachm
 	}
 	types.DebugLog(cfg, "sendLarkWebhook: using webhook URL (length: %d)", len(webhookURL))
 
-	payload := map[string]interface{}{
-		"msg_type": "post",
-		"content": map[string]interface{}{
-			"post": map[string]interface{}{
-				"zh_cn": map[string]interface{}{
-					"title": title,
-					"content": []interface{}{
-						[]interface{}{
-							map[string]interface{}{
-								"tag":  "text",
-								"text": formattedMessage,
-							},
-						},
-					},
-				},
-			},
-		},
-	}
+	payload := p.buildMessage("", message, attachment, cfg)
 
 	data, _ := json.Marshal(payload)
-	types.DebugLog(cfg, "sendLarkWebhook: payload prepared, size: %d bytes, payload: %s", len(data), string(data))
+	if cfg.Debug {
+		types.DebugLog(cfg, "sendLarkWebhook: payload prepared, size: %d bytes, payload: %s", len(data), string(data))
+	}
 
-	req, _ := http.NewRequest("POST", webhookURL, bytes.NewBuffer(data))
+	req, _ := http.NewRequest("POST", webhookURL, bytes.NewReader(data))
 	req.Header.Set("Content-Type", "application/json")
 
 	types.DebugLog(cfg, "sendLarkWebhook: sending HTTP request to webhook URL")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpDoer(cfg).Do(req)
 	if err != nil {
 		types.DebugLog(cfg, "sendLarkWebhook: HTTP request failed: %v", err)
 		return err
@@ -474,19 +1007,17 @@ func (p *LarkProvider) sendLarkWebhook(message string, attachment *types.Attachm
 	defer resp.Body.Close()
 
 	// Log response data
-	respBody := new(bytes.Buffer)
-	_, copyErr := respBody.ReadFrom(resp.Body)
-	if copyErr != nil {
-		types.DebugLog(cfg, "sendLarkWebhook: error reading response body: %v", copyErr)
-	} else {
-		types.DebugLog(cfg, "sendLarkWebhook: response status: %d, body length: %d, body: %s", resp.StatusCode, respBody.Len(), respBody.String())
-	}
+	respData := readResponse(cfg, "sendLarkWebhook", resp)
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("lark webhook response: %d", resp.StatusCode)
 		types.DebugLog(cfg, "sendLarkWebhook: error response: %v", err)
 		return err
 	}
+	if err := checkLarkResponse(respData, cfg.Response); err != nil {
+		types.DebugLog(cfg, "sendLarkWebhook: error response: %v", err)
+		return err
+	}
 	types.DebugLog(cfg, "sendLarkWebhook: webhook sent successfully")
 	return nil
 }
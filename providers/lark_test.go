@@ -0,0 +1,318 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestLarkPayloadSchema(t *testing.T) {
+	payload := larkMessage{ReceiveID: "oc_123", MsgType: "post", Content: newLarkPost("billing - production", "boom")}
+	data, _ := json.Marshal(payload)
+	expected := `{"receive_id":"oc_123","msg_type":"post","content":{"post":{"zh_cn":{"title":"billing - production","content":[[{"tag":"text","text":"boom"}]]}}}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected Lark payload:\n got: %s\nwant: %s", data, expected)
+	}
+
+	data, _ = json.Marshal(larkMessage{MsgType: "post", Content: newLarkPost("Alert", "boom")})
+	if strings.Contains(string(data), "receive_id") {
+		t.Errorf("Expected receive_id to be omitted for webhooks, got %s", data)
+	}
+}
+
+func TestLarkWebhookReportsAPIError(t *testing.T) {
+	cfg := types.Config{
+		SendMethod:     types.MethodWebhook,
+		Token:          "https://open.larksuite.invalid/open-apis/bot/v2/hook/x",
+		ProviderConfig: map[string]interface{}{},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"code":19001,"msg":"param invalid: incoming webhook access token invalid"}`))}, nil
+		}),
+	}
+	err := (&LarkProvider{}).Send(types.ERROR, "boom", nil, cfg)
+	if err == nil || !strings.Contains(err.Error(), "lark API error 19001") {
+		t.Errorf("Expected Lark API error, got %v", err)
+	}
+}
+
+func TestLarkAckCard(t *testing.T) {
+	msg := newLarkMessage("", "billing", "boom", types.Config{AckID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"})
+	data, _ := json.Marshal(msg)
+	expected := `{"msg_type":"interactive","card":{"config":{"wide_screen_mode":true},"header":{"title":{"tag":"plain_text","content":"billing"}},` +
+		`"elements":[{"tag":"div","text":{"tag":"lark_md","content":"boom"}},{"tag":"action","actions":[{"tag":"button","text":{"tag":"plain_text","content":"Acknowledge"},"type":"primary","value":{"action":"commonlog_ack","alert_id":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}}]}]}}`
+	if string(data) != expected {
+		t.Errorf("Unexpected Lark card:\n got: %s\nwant: %s", data, expected)
+	}
+}
+
+func TestLarkLinksAndSnippetsUseCard(t *testing.T) {
+	cfg := types.Config{
+		Links:    []types.Link{{Text: "Kibana query", URL: "https://kibana.example.com/q"}},
+		Snippets: []types.Snippet{{Language: "go", Code: "panic(err)"}},
+	}
+	msg := newLarkMessage("oc_1", "billing", "boom", cfg)
+	if msg.MsgType != "interactive" || msg.Card == nil || len(msg.Card.Elements) != 3 {
+		t.Fatalf("Expected a card with text, snippet and actions, got %+v", msg)
+	}
+	if snippet := msg.Card.Elements[1]; snippet.Tag != "markdown" || snippet.Content != "```go\npanic(err)\n```" {
+		t.Errorf("Unexpected snippet element: %+v", snippet)
+	}
+	button := msg.Card.Elements[2].Actions[0]
+	if button.URL != "https://kibana.example.com/q" || button.Text.Content != "Kibana query" || button.Value != nil {
+		t.Errorf("Unexpected link button: %+v", button)
+	}
+}
+
+func TestLarkSections(t *testing.T) {
+	cfg := types.Config{
+		ServiceName: "billing",
+		MessageID:   "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		Sections:    []string{types.SectionMessage, types.SectionAttachment, types.SectionFooter},
+	}
+	attachment := &types.Attachment{Content: "panic: nil map", URL: "https://logs.example.com/1"}
+	title, text := (&LarkProvider{}).formatMessage("boom", attachment, cfg)
+	if title != "billing" || text != "boom\n\n**Attachment:** https://logs.example.com/1\nAlert ID: 01ARZ3NDEKTSV4RRFFQ69G5FAV" {
+		t.Errorf("Expected the trace to be left out, got %q: %q", title, text)
+	}
+}
+
+func TestLarkImage(t *testing.T) {
+	// Webhooks cannot upload, so the image URL is linked
+	cfg := types.Config{ProviderConfig: map[string]interface{}{}, Image: &types.Image{URL: "https://charts.example.com/1.png"}}
+	msg := (&LarkProvider{}).buildMessage("", "boom", nil, cfg)
+	if text := msg.Content.Post["zh_cn"].Content[0][0].Text; text != "boom\n\n**Image:** https://charts.example.com/1.png" {
+		t.Errorf("Unexpected webhook image fallback: %q", text)
+	}
+
+	// Uploaded images are added after the text
+	msg = (&LarkProvider{}).buildMessage("oc_1", "boom", nil, types.Config{ProviderConfig: map[string]interface{}{}})
+	msg.addImage("img_v2_123", "chart")
+	data, _ := json.Marshal(msg.Content)
+	if string(data) != `{"post":{"zh_cn":{"title":"Alert","content":[[{"tag":"text","text":"boom"}],[{"tag":"img","image_key":"img_v2_123"}]]}}}` {
+		t.Errorf("Unexpected post with image: %s", data)
+	}
+}
+
+func TestLarkLocalizedPosts(t *testing.T) {
+	cfg := types.Config{
+		ServiceName:    "billing",
+		ProviderConfig: map[string]interface{}{"lark_locales": []string{"en_us", "zh_cn"}},
+	}
+	attachment := &types.Attachment{URL: "https://logs.example/1"}
+	msg := (&LarkProvider{}).buildMessage("oc_1", "boom", attachment, cfg)
+	if len(msg.Content.Post) != 2 {
+		t.Fatalf("Expected en_us and zh_cn posts, got %+v", msg.Content.Post)
+	}
+	if text := msg.Content.Post["en_us"].Content[0][0].Text; text != "boom\n\n**Attachment:** https://logs.example/1" {
+		t.Errorf("Unexpected en_us text: %q", text)
+	}
+	if text := msg.Content.Post["zh_cn"].Content[0][0].Text; text != "boom\n\n**附件:** https://logs.example/1" {
+		t.Errorf("Unexpected zh_cn text: %q", text)
+	}
+
+	// Without configuration the single post keeps English text under zh_cn
+	msg = (&LarkProvider{}).buildMessage("oc_1", "boom", nil, types.Config{ProviderConfig: map[string]interface{}{}})
+	if post, ok := msg.Content.Post["zh_cn"]; !ok || post.Title != "Alert" {
+		t.Errorf("Expected default English post under zh_cn, got %+v", msg.Content.Post)
+	}
+
+	translator := types.Translations{"en_us": {types.TextAlert: "Incident"}}
+	msg = (&LarkProvider{}).buildMessage("oc_1", "boom", nil, types.Config{Locale: "en_us", Translator: translator, ProviderConfig: map[string]interface{}{}})
+	if post, ok := msg.Content.Post["en_us"]; !ok || post.Title != "Incident" {
+		t.Errorf("Expected custom translation under en_us, got %+v", msg.Content.Post)
+	}
+}
+
+func TestLarkChatListCache(t *testing.T) {
+	pages := 0
+	cfg := types.Config{
+		SendMethod:  types.MethodWebClient,
+		Token:       "t-token",
+		Environment: "chatlist",
+		Cache:       cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			pages++
+			if req.URL.Query().Get("page_size") != "100" {
+				t.Errorf("Expected page_size=100, got %s", req.URL.RawQuery)
+			}
+			body := `{"code":0,"data":{"items":[{"chat_id":"oc_1","name":"alerts"}],"page_token":"p2","has_more":true}}`
+			if req.URL.Query().Get("page_token") == "p2" {
+				body = `{"code":0,"data":{"items":[{"chat_id":"oc_2","name":"billing"}],"has_more":false}}`
+			}
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}),
+	}
+
+	provider := &LarkProvider{}
+	if err := provider.PrefetchChannels(context.Background(), cfg, []string{"alerts", "missing"}); !errors.Is(err, types.ErrChannelNotFound) || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected the missing channel to be reported, got %v", err)
+	}
+	if pages != 2 {
+		t.Fatalf("Expected both pages to be fetched, got %d requests", pages)
+	}
+	if chatID, err := getChatIDFromChannelName(cfg, "t-token", "alerts"); err != nil || chatID != "oc_1" {
+		t.Errorf("Expected the prefetched chat ID, got %q, %v", chatID, err)
+	}
+	if chatID, err := getChatIDFromChannelName(cfg, "t-token", "billing"); err != nil || chatID != "oc_2" {
+		t.Errorf("Expected the chat ID from the cached list, got %q, %v", chatID, err)
+	}
+	if pages != 2 {
+		t.Errorf("Expected lookups to use the cache, got %d requests", pages)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := getChatIDFromChannelName(cfg, "t-token", "missing"); !errors.Is(err, types.ErrChannelNotFound) {
+			t.Errorf("Expected ErrChannelNotFound for an unknown channel, got %v", err)
+		}
+	}
+	if pages != 4 {
+		t.Errorf("Expected an unknown channel to refetch the list once, got %d requests", pages)
+	}
+}
+
+func TestLarkChatLookupCoalesces(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	cfg := types.Config{
+		Environment: "coalesce",
+		Cache:       cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			body := `{"code":0,"data":{"items":[{"chat_id":"oc_1","name":"alerts"}],"has_more":false}}`
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if chatID, err := getChatIDFromChannelName(cfg, "t-token", "alerts"); err != nil || chatID != "oc_1" {
+				t.Errorf("Expected the chat ID, got %q, %v", chatID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if requests != 1 {
+		t.Errorf("Expected concurrent lookups to share one fetch, got %d requests", requests)
+	}
+}
+
+func TestLarkWebhookRecordsMessageID(t *testing.T) {
+	response := &types.ProviderResponse{}
+	cfg := types.Config{
+		SendMethod:     types.MethodWebhook,
+		Token:          "https://open.larksuite.invalid/open-apis/bot/v2/hook/x",
+		ProviderConfig: map[string]interface{}{},
+		Response:       response,
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"code":0,"msg":"success","data":{"message_id":"om_123","chat_id":"oc_456"}}`))}, nil
+		}),
+	}
+	if err := (&LarkProvider{}).Send(types.ERROR, "boom", nil, cfg); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if response.MessageID != "om_123" || response.Channel != "oc_456" || response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the Lark message_id and chat_id, got %+v", response)
+	}
+}
+
+// fakeRedis answers PING, GET (always missing), SET and DEL over RESP, counting
+// connections and pings
+type fakeRedis struct {
+	listener    net.Listener
+	connections atomic.Int32
+	pings       atomic.Int32
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.connections.Add(1)
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		var args []string
+		for i := 0; i < n; i++ {
+			reader.ReadString('\n') // bulk string length
+			arg, _ := reader.ReadString('\n')
+			args = append(args, strings.TrimSpace(arg))
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			s.pings.Add(1)
+			conn.Write([]byte("+PONG\r\n"))
+		case "GET":
+			conn.Write([]byte("$-1\r\n"))
+		case "DEL":
+			conn.Write([]byte(":1\r\n"))
+		default:
+			conn.Write([]byte("+OK\r\n"))
+		}
+	}
+}
+
+func TestRedisClientIsShared(t *testing.T) {
+	server := newFakeRedis(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	cfg := types.Config{ProviderConfig: map[string]interface{}{"redis_host": host, "redis_port": port}}
+	bucket := NewBucket(cfg, "lark_token")
+	for i := 0; i < 3; i++ {
+		if _, _, err := bucket.Get("app"); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if err := bucket.Set("app", "token", time.Minute); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if pings, connections := server.pings.Load(), server.connections.Load(); pings != 1 || connections != 1 {
+		t.Errorf("Expected one client pinged once, got %d pings over %d connections", pings, connections)
+	}
+	if err := CheckRedis(context.Background(), cfg); err != nil || server.pings.Load() != 2 {
+		t.Errorf("Expected CheckRedis to ping the server, got %v and %d pings", err, server.pings.Load())
+	}
+
+	client, _ := getRedisClient(cfg)
+	forgetRedisClient(client)
+	if other, _ := getRedisClient(cfg); other == client {
+		t.Error("Expected a forgotten client to be replaced")
+	}
+}
@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// MatrixProvider implements Provider for Matrix rooms using a homeserver access token
+type MatrixProvider struct{}
+
+func (p *MatrixProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *MatrixProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "MatrixProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	homeserver, _ := cfg.ProviderConfig["matrix_homeserver"].(string)
+	if homeserver == "" {
+		err := fmt.Errorf("matrix_homeserver must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	// Use matrix_token if available, otherwise fall back to token
+	accessToken, _ := cfg.ProviderConfig["token"].(string)
+	if matrixToken, ok := cfg.ProviderConfig["matrix_token"].(string); ok && matrixToken != "" {
+		accessToken = matrixToken
+	}
+	if accessToken == "" {
+		err := fmt.Errorf("access token is required for Matrix provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	// The channel is the room ID (e.g. !abc123:example.org)
+	roomID := channel
+	if roomID == "" {
+		roomID, _ = cfg.ProviderConfig["matrix_room_id"].(string)
+	}
+	if roomID == "" {
+		err := fmt.Errorf("room ID is required for Matrix provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	body := markdownMessage(level, message, attachment, cfg)
+	payload := map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": matrixHTML(level, message, attachment, cfg),
+	}
+	data, _ := json.Marshal(payload)
+
+	// Transaction IDs make retried requests idempotent on the homeserver
+	txnID := make([]byte, 12)
+	rand.Read(txnID)
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(homeserver, "/"), url.PathEscape(roomID), hex.EncodeToString(txnID))
+
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	types.DebugLog(cfg, "sendMatrix: sending to room: %s, payload size: %d bytes", roomID, len(data))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendMatrix: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendMatrix: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("matrix send response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendMatrix: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendMatrix: message sent successfully")
+	return nil
+}
+
+// matrixHTML renders the HTML formatted_body shown by Matrix clients
+func matrixHTML(level int, message string, attachment *types.Attachment, cfg types.Config) string {
+	header := types.LevelName(level)
+	if cfg.ServiceName != "" && cfg.Environment != "" {
+		header = fmt.Sprintf("[%s] %s - %s", header, cfg.ServiceName, cfg.Environment)
+	} else if cfg.ServiceName != "" {
+		header = fmt.Sprintf("[%s] %s", header, cfg.ServiceName)
+	} else if cfg.Environment != "" {
+		header = fmt.Sprintf("[%s] %s", header, cfg.Environment)
+	} else {
+		header = fmt.Sprintf("[%s]", header)
+	}
+
+	formatted := fmt.Sprintf("<strong>%s</strong><br/>", html.EscapeString(header))
+	if meta := alertMetaLine(cfg); meta != "" {
+		formatted += fmt.Sprintf("<em>%s</em><br/>", html.EscapeString(meta))
+	}
+	formatted += strings.ReplaceAll(html.EscapeString(message), "\n", "<br/>")
+
+	if attachment != nil {
+		if attachment.Content != "" {
+			filename := attachment.FileName
+			if filename == "" {
+				filename = types.Localize(cfg, types.TextTraceLogs)
+			}
+			formatted += fmt.Sprintf("<br/><br/><strong>%s:</strong><pre><code>%s</code></pre>",
+				html.EscapeString(filename), html.EscapeString(attachment.Content))
+		}
+		if attachment.URL != "" {
+			formatted += fmt.Sprintf("<br/><br/><strong>%s:</strong> <a href=\"%s\">%s</a>", html.EscapeString(types.Localize(cfg, types.TextAttachment)),
+				html.EscapeString(attachment.URL), html.EscapeString(attachment.URL))
+		}
+	}
+	return formatted
+}
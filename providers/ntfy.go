@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// NtfyProvider implements Provider for ntfy push notifications
+type NtfyProvider struct{}
+
+// ntfyPriorities maps alert levels to ntfy priorities (1 = min, 5 = urgent)
+var ntfyPriorities = map[int]int{
+	types.INFO:  3,
+	types.WARN:  4,
+	types.ERROR: 5,
+}
+
+func (p *NtfyProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *NtfyProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "NtfyProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	server, _ := cfg.ProviderConfig["ntfy_server"].(string)
+	if server == "" {
+		server = "https://ntfy.sh"
+	}
+	// The channel is the ntfy topic
+	topic := channel
+	if topic == "" {
+		topic, _ = cfg.ProviderConfig["ntfy_topic"].(string)
+	}
+	if topic == "" {
+		err := fmt.Errorf("topic is required for ntfy provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	title, body := pushMessage(level, message, attachment, cfg)
+	req, err := http.NewRequest("POST", strings.TrimRight(server, "/")+"/"+topic, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Priority", strconv.Itoa(pushPriority(cfg, "ntfy_priorities", ntfyPriorities, level)))
+	req.Header.Set("Tags", strings.ToLower(types.LevelName(level)))
+	if clickURL, ok := cfg.ProviderConfig["ntfy_click_url"].(string); ok && clickURL != "" {
+		req.Header.Set("Click", clickURL)
+	}
+	if token, ok := cfg.ProviderConfig["ntfy_token"].(string); ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	types.DebugLog(cfg, "sendNtfy: sending to topic: %s, body size: %d bytes", topic, len(body))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendNtfy: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendNtfy: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("ntfy response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendNtfy: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendNtfy: notification sent successfully")
+	return nil
+}
+
+// pushMessage formats a title and plaintext body for push notification providers
+func pushMessage(level int, message string, attachment *types.Attachment, cfg types.Config) (string, string) {
+	title := types.LevelName(level)
+	if cfg.ServiceName != "" && cfg.Environment != "" {
+		title += fmt.Sprintf(" - %s - %s", cfg.ServiceName, cfg.Environment)
+	} else if cfg.ServiceName != "" {
+		title += " - " + cfg.ServiceName
+	} else if cfg.Environment != "" {
+		title += " - " + cfg.Environment
+	}
+
+	body := message
+	if meta := alertMetaLine(cfg); meta != "" {
+		body = meta + "\n" + body
+	}
+	if attachment != nil {
+		if attachment.Content != "" {
+			filename := attachment.FileName
+			if filename == "" {
+				filename = types.Localize(cfg, types.TextTraceLogs)
+			}
+			body += fmt.Sprintf("\n\n%s:\n%s", filename, attachment.Content)
+		}
+		if attachment.URL != "" {
+			body += fmt.Sprintf("\n\n%s: %s", types.Localize(cfg, types.TextAttachment), attachment.URL)
+		}
+	}
+	if idLine := alertIDLine(cfg); idLine != "" {
+		body += "\n" + idLine
+	}
+	if footer := alertFooter(cfg); footer != "" {
+		body += "\n" + footer
+	}
+	return title, body
+}
+
+// pushPriority maps an alert level to a provider priority, honoring a map[int]int override under key
+func pushPriority(cfg types.Config, key string, defaults map[int]int, level int) int {
+	if overrides, ok := cfg.ProviderConfig[key].(map[int]int); ok {
+		if priority, ok := overrides[level]; ok {
+			return priority
+		}
+	}
+	return defaults[level]
+}
@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestNtfyPriorityAndHeaders(t *testing.T) {
+	var path, priority, title, click, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, body = r.URL.Path, string(data)
+		priority, title, click = r.Header.Get("Priority"), r.Header.Get("Title"), r.Header.Get("Click")
+	}))
+	defer server.Close()
+
+	cfg := types.Config{
+		ServiceName: "nas",
+		ProviderConfig: map[string]interface{}{
+			"ntfy_server":    server.URL,
+			"ntfy_click_url": "https://grafana.local/d/disk",
+		},
+	}
+	p := &NtfyProvider{}
+	if err := p.SendToChannel(types.ERROR, "disk full", nil, cfg, "homelab"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path != "/homelab" || body != "disk full" {
+		t.Errorf("Expected POST to /homelab with message body, got %s %q", path, body)
+	}
+	if priority != "5" || title != "ERROR - nas" || click != "https://grafana.local/d/disk" {
+		t.Errorf("Unexpected headers: priority %s, title %s, click %s", priority, title, click)
+	}
+
+	cfg.ProviderConfig["ntfy_priorities"] = map[int]int{types.WARN: 2}
+	if err := p.SendToChannel(types.WARN, "disk at 80%", nil, cfg, "homelab"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if priority != "2" {
+		t.Errorf("Expected overridden priority 2, got %s", priority)
+	}
+}
@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// maxObjectLinkTTL is the longest S3 and GCS accept for a signed URL
+const maxObjectLinkTTL = 7 * 24 * time.Hour
+
+// gcsEndpoint is the Cloud Storage XML API endpoint; a variable so tests can point it elsewhere
+var gcsEndpoint = "https://storage.googleapis.com"
+
+// S3Store is a types.ObjectStore keeping attachments in an S3 bucket and linking them with
+// presigned URLs. Credentials and region come from the aws_* settings of Config or the
+// AWS_* environment variables.
+type S3Store struct {
+	Bucket   string
+	TTL      time.Duration // How long links stay valid, at most and by default 7 days
+	Endpoint string        // Optional S3-compatible endpoint, addressed path-style; defaults to AWS
+	Config   types.Config  // Credentials, HTTPClient and Clock
+}
+
+// NewS3Store returns a store uploading to bucket with links valid for ttl
+func NewS3Store(bucket string, ttl time.Duration, cfg types.Config) *S3Store {
+	return &S3Store{Bucket: bucket, TTL: ttl, Config: cfg}
+}
+
+// Upload puts data under key, tagged commonlog-ttl-days for lifecycle rules, and returns
+// a presigned GET URL
+func (s *S3Store) Upload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	creds, region, err := awsConfig(s.Config)
+	if err != nil {
+		return "", err
+	}
+	ttl := objectLinkTTL(s.TTL)
+	object := s.objectURL(region, key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, object.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", sha256Hex(data))
+	req.Header.Set("X-Amz-Tagging", fmt.Sprintf("commonlog-ttl-days=%d", ttlDays(ttl)))
+	now := currentTime(s.Config)
+	signAWSRequest(req, data, creds, region, "s3", now)
+
+	if err := doUpload(s.Config, req, "S3"); err != nil {
+		return "", err
+	}
+	types.DebugLog(s.Config, "S3Store: uploaded %d bytes to s3://%s/%s", len(data), s.Bucket, key)
+	return presignAWSURL(object, creds, region, "s3", now, ttl), nil
+}
+
+// objectURL addresses key virtual-hosted style on AWS, or path-style on a custom endpoint
+func (s *S3Store) objectURL(region, key string) url.URL {
+	path := "/" + key
+	if s.Endpoint != "" {
+		path = "/" + s.Bucket + path
+		u, _ := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+		return objectPathURL(*u, path)
+	}
+	return objectPathURL(url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.Bucket, region)}, path)
+}
+
+// GCSStore is a types.ObjectStore keeping attachments in a Cloud Storage bucket and
+// linking them with V4 signed URLs. Signing needs a service account key, from the
+// gcp_credentials_json setting of Config or GOOGLE_APPLICATION_CREDENTIALS.
+type GCSStore struct {
+	Bucket string
+	TTL    time.Duration // How long links stay valid, at most and by default 7 days
+	Config types.Config  // Credentials, HTTPClient and Clock
+}
+
+// NewGCSStore returns a store uploading to bucket with links valid for ttl
+func NewGCSStore(bucket string, ttl time.Duration, cfg types.Config) *GCSStore {
+	return &GCSStore{Bucket: bucket, TTL: ttl, Config: cfg}
+}
+
+// Upload puts data under key with its custom time set to when the link expires, for
+// lifecycle rules on daysSinceCustomTime, and returns a signed GET URL
+func (s *GCSStore) Upload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	keyJSON, err := gcpKeyJSON(s.Config)
+	if err != nil {
+		return "", err
+	}
+	if keyJSON == "" {
+		return "", fmt.Errorf("GCS signed URLs require a service account key in gcp_credentials_json or GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	var account gcpServiceAccount
+	if err := json.Unmarshal([]byte(keyJSON), &account); err != nil {
+		return "", fmt.Errorf("invalid GCP service account key: %w", err)
+	}
+	privateKey, err := gcpPrivateKey(account)
+	if err != nil {
+		return "", err
+	}
+	token, err := gcpAccessToken(s.Config)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := objectLinkTTL(s.TTL)
+	now := currentTime(s.Config)
+	base, err := url.Parse(gcsEndpoint)
+	if err != nil {
+		return "", err
+	}
+	object := objectPathURL(*base, "/"+s.Bucket+"/"+key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, object.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Goog-Custom-Time", now.Add(ttl).UTC().Format(time.RFC3339))
+
+	if err := doUpload(s.Config, req, "GCS"); err != nil {
+		return "", err
+	}
+	types.DebugLog(s.Config, "GCSStore: uploaded %d bytes to gs://%s/%s", len(data), s.Bucket, key)
+	return signGCSURL(object, account.ClientEmail, privateKey, now, ttl)
+}
+
+// signGCSURL returns u with a V4 signed query string, so anyone holding the URL can GET
+// it until expires has passed
+func signGCSURL(u url.URL, email string, key *rsa.PrivateKey, now time.Time, expires time.Duration) (string, error) {
+	timestamp := now.UTC().Format("20060102T150405Z")
+	scope := timestamp[:8] + "/auto/storage/goog4_request"
+
+	query := u.Query()
+	query.Set("X-Goog-Algorithm", "GOOG4-RSA-SHA256")
+	query.Set("X-Goog-Credential", email+"/"+scope)
+	query.Set("X-Goog-Date", timestamp)
+	query.Set("X-Goog-Expires", fmt.Sprint(int(expires.Seconds())))
+	query.Set("X-Goog-SignedHeaders", "host")
+	canonicalQuery := awsCanonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{"GET", u.EscapedPath(), canonicalQuery, "host:" + u.Host + "\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	stringToSign := strings.Join([]string{"GOOG4-RSA-SHA256", timestamp, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	u.RawQuery = canonicalQuery + "&X-Goog-Signature=" + hex.EncodeToString(signature)
+	return u.String(), nil
+}
+
+// objectPathURL sets path on base, escaping each segment as the signing schemes expect
+func objectPathURL(base url.URL, path string) url.URL {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	base.Path = path
+	base.RawPath = strings.Join(segments, "/")
+	return base
+}
+
+// objectLinkTTL defaults and caps the validity of a signed link
+func objectLinkTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > maxObjectLinkTTL {
+		return maxObjectLinkTTL
+	}
+	return ttl
+}
+
+// ttlDays rounds ttl up to whole days for lifecycle rules
+func ttlDays(ttl time.Duration) int {
+	return int((ttl + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+// doUpload sends an object upload request, failing on a non-2xx response
+func doUpload(cfg types.Config, req *http.Request, service string) error {
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return fmt.Errorf("%s upload failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return fmt.Errorf("%s upload response: %d: %s", service, resp.StatusCode, body)
+	}
+	return nil
+}
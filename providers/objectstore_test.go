@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestS3StoreUpload(t *testing.T) {
+	var uploaded *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		uploaded, body = r, string(data)
+	}))
+	defer server.Close()
+
+	store := NewS3Store("alerts", 48*time.Hour, types.Config{
+		Clock: fixedClock{time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		ProviderConfig: map[string]interface{}{
+			"aws_access_key_id":     "AKIDEXAMPLE",
+			"aws_secret_access_key": "secret",
+			"aws_region":            "eu-west-1",
+		},
+	})
+	store.Endpoint = server.URL
+	link, err := store.Upload(context.Background(), "commonlog/01HX/trace.log", []byte("panic: boom"), "text/plain")
+	if err != nil {
+		t.Fatalf("Expected the upload to succeed, got %v", err)
+	}
+	if uploaded.Method != http.MethodPut || uploaded.URL.Path != "/alerts/commonlog/01HX/trace.log" || body != "panic: boom" {
+		t.Errorf("Unexpected upload: %s %s %q", uploaded.Method, uploaded.URL.Path, body)
+	}
+	if uploaded.Header.Get("X-Amz-Tagging") != "commonlog-ttl-days=2" || !strings.Contains(uploaded.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request") {
+		t.Errorf("Unexpected upload headers: %v", uploaded.Header)
+	}
+
+	parsed, _ := url.Parse(link)
+	query := parsed.Query()
+	if parsed.Path != "/alerts/commonlog/01HX/trace.log" || query.Get("X-Amz-Expires") != "172800" || query.Get("X-Amz-Signature") == "" {
+		t.Errorf("Unexpected presigned URL: %s", link)
+	}
+}
+
+func TestGCSStoreUpload(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	account, _ := json.Marshal(gcpServiceAccount{
+		ClientEmail: "uploader@test-project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+
+	var uploaded *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploaded = r
+	}))
+	defer server.Close()
+	original := gcsEndpoint
+	gcsEndpoint = server.URL
+	defer func() { gcsEndpoint = original }()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewGCSStore("alerts", 0, types.Config{
+		Clock: fixedClock{now},
+		Cache: cache.NewInMemoryCache(),
+		ProviderConfig: map[string]interface{}{
+			"gcp_credentials_json": string(account),
+			"gcp_access_token":     "ya29.test",
+		},
+	})
+	link, err := store.Upload(context.Background(), "commonlog/01HX/trace.log", []byte("panic: boom"), "text/plain")
+	if err != nil {
+		t.Fatalf("Expected the upload to succeed, got %v", err)
+	}
+	if uploaded.URL.Path != "/alerts/commonlog/01HX/trace.log" || uploaded.Header.Get("Authorization") != "Bearer ya29.test" {
+		t.Errorf("Unexpected upload: %s with %s", uploaded.URL.Path, uploaded.Header.Get("Authorization"))
+	}
+	if got := uploaded.Header.Get("X-Goog-Custom-Time"); got != "2024-05-08T12:00:00Z" {
+		t.Errorf("Expected the custom time set to the link expiry, got %s", got)
+	}
+
+	// Verify the signature over the string to sign of the V4 signing scheme
+	parsed, _ := url.Parse(link)
+	query := parsed.Query()
+	signature, _ := hex.DecodeString(query.Get("X-Goog-Signature"))
+	query.Del("X-Goog-Signature")
+	if query.Get("X-Goog-Expires") != "604800" || query.Get("X-Goog-Credential") != "uploader@test-project.iam.gserviceaccount.com/20240501/auto/storage/goog4_request" {
+		t.Errorf("Unexpected signed URL: %s", link)
+	}
+	canonicalRequest := "GET\n/alerts/commonlog/01HX/trace.log\n" + awsCanonicalQuery(query) + "\nhost:" + parsed.Host + "\n\nhost\nUNSIGNED-PAYLOAD"
+	stringToSign := "GOOG4-RSA-SHA256\n20240501T120000Z\n20240501/auto/storage/goog4_request\n" + sha256Hex([]byte(canonicalRequest))
+	digest := sha256.Sum256([]byte(stringToSign))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+}
+
+func TestGCSStoreRequiresServiceAccountKey(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	store := NewGCSStore("alerts", time.Hour, types.Config{ProviderConfig: map[string]interface{}{"gcp_access_token": "ya29.test"}})
+	if _, err := store.Upload(context.Background(), "trace.log", nil, "text/plain"); err == nil || !strings.Contains(err.Error(), "service account key") {
+		t.Errorf("Expected an error asking for a service account key, got %v", err)
+	}
+}
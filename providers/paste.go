@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// PasteService is a types.PasteSink posting content to a pastebin-style service, such as
+// an internal paste server or a hastebin-compatible endpoint. The content is the request
+// body and the key is sent in the X-Paste-Title header; the response is the paste's URL,
+// either as plain text or as the url field of a JSON object.
+type PasteService struct {
+	URL    string       // Endpoint the content is posted to
+	Token  string       // Optional bearer token
+	Config types.Config // HTTPClient and timeouts
+}
+
+// NewPasteService returns a sink posting to url, authorized by the paste_token setting of
+// cfg when set
+func NewPasteService(url string, cfg types.Config) *PasteService {
+	token, _ := cfg.ProviderConfig["paste_token"].(string)
+	return &PasteService{URL: url, Token: token, Config: cfg}
+}
+
+// Paste posts content and returns the URL of the paste
+func (s *PasteService) Paste(ctx context.Context, key, content string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader([]byte(content)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("X-Paste-Title", key)
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := httpDoer(s.Config).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("paste failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("paste response: %d: %s", resp.StatusCode, body)
+	}
+	link := strings.TrimSpace(string(body))
+	if strings.HasPrefix(link, "{") {
+		var result struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return "", fmt.Errorf("paste response: %w", err)
+		}
+		link = result.URL
+	}
+	if link == "" {
+		return "", fmt.Errorf("paste response has no URL: %s", body)
+	}
+	types.DebugLog(s.Config, "PasteService: pasted %d bytes as %s", len(content), link)
+	return link, nil
+}
+
+// StorePaste is a types.PasteSink keeping pastes in an object store, such as S3Store or
+// GCSStore, and linking them with the store's signed URL
+type StorePaste struct {
+	Store types.ObjectStore
+}
+
+// NewStorePaste returns a sink uploading to store
+func NewStorePaste(store types.ObjectStore) *StorePaste {
+	return &StorePaste{Store: store}
+}
+
+// Paste uploads content as plain text under key
+func (s *StorePaste) Paste(ctx context.Context, key, content string) (string, error) {
+	return s.Store.Upload(ctx, key, []byte(content), "text/plain; charset=utf-8")
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestPasteService(t *testing.T) {
+	var pasted *http.Request
+	var body string
+	response := "https://paste.example.com/abc\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		pasted, body = r, string(data)
+		io.WriteString(w, response)
+	}))
+	defer server.Close()
+
+	sink := NewPasteService(server.URL, types.Config{ProviderConfig: map[string]interface{}{"paste_token": "secret"}})
+	link, err := sink.Paste(context.Background(), "commonlog/01HX/trace.log", "panic: boom")
+	if err != nil {
+		t.Fatalf("Expected the paste to succeed, got %v", err)
+	}
+	if link != "https://paste.example.com/abc" || body != "panic: boom" {
+		t.Errorf("Unexpected paste %q of %q", link, body)
+	}
+	if pasted.Method != http.MethodPost || pasted.Header.Get("X-Paste-Title") != "commonlog/01HX/trace.log" || pasted.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("Unexpected paste request: %s %v", pasted.Method, pasted.Header)
+	}
+
+	response = `{"key": "def", "url": "https://paste.example.com/def"}`
+	if link, err := sink.Paste(context.Background(), "key", "content"); err != nil || link != "https://paste.example.com/def" {
+		t.Errorf("Expected the URL read from JSON, got %q, %v", link, err)
+	}
+	response = `{"key": "def"}`
+	if _, err := sink.Paste(context.Background(), "key", "content"); err == nil || !strings.Contains(err.Error(), "no URL") {
+		t.Errorf("Expected a response without a URL to fail, got %v", err)
+	}
+}
+
+type pasteObjectStore struct {
+	key, data, contentType string
+}
+
+func (s *pasteObjectStore) Upload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	s.key, s.data, s.contentType = key, string(data), contentType
+	return "https://objects.example.com/" + key, nil
+}
+
+func TestStorePaste(t *testing.T) {
+	store := &pasteObjectStore{}
+	link, err := NewStorePaste(store).Paste(context.Background(), "commonlog/01HX/trace.log", "panic: boom")
+	if err != nil || link != "https://objects.example.com/commonlog/01HX/trace.log" {
+		t.Fatalf("Expected the store's URL, got %q, %v", link, err)
+	}
+	if store.data != "panic: boom" || !strings.HasPrefix(store.contentType, "text/plain") {
+		t.Errorf("Unexpected upload %q as %s", store.data, store.contentType)
+	}
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Pin pins a posted message to the top of its channel with pins.add. A message that is
+// already pinned is not an error.
+func (p *SlackProvider) Pin(cfg types.Config, channel, messageID string) error {
+	return slackPinAPI(cfg, "pins.add", channel, messageID, "already_pinned")
+}
+
+// Unpin removes a message's pin with pins.remove. A message that is not pinned is not an
+// error.
+func (p *SlackProvider) Unpin(cfg types.Config, channel, messageID string) error {
+	return slackPinAPI(cfg, "pins.remove", channel, messageID, "no_pin")
+}
+
+// slackPinAPI calls a pins method for a message, ignoring the error reporting that the
+// message already is in the requested state
+func slackPinAPI(cfg types.Config, method, channel, messageID, unchanged string) error {
+	token, err := slackToken(cfg)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]string{"channel": channel, "timestamp": messageID})
+	req, _ := http.NewRequest("POST", "https://slack.com/api/"+method, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	var result slackResponse
+	if err := slackAPI(cfg, token, req, &result); err != nil {
+		return err
+	}
+	if !result.OK && result.Error != unchanged {
+		return fmt.Errorf("slack API error: %s", result.Error)
+	}
+	types.DebugLog(cfg, "slackPinAPI: %s for message %s in %s", method, messageID, channel)
+	return nil
+}
+
+// Pin pins a posted message in its chat with the pins API
+func (p *LarkProvider) Pin(cfg types.Config, channel, messageID string) error {
+	data, _ := json.Marshal(map[string]string{"message_id": messageID})
+	req, _ := http.NewRequest("POST", "https://open.larksuite.com/open-apis/im/v1/pins", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return larkPinAPI(cfg, req, "Pin")
+}
+
+// Unpin removes a message's pin from its chat
+func (p *LarkProvider) Unpin(cfg types.Config, channel, messageID string) error {
+	req, _ := http.NewRequest("DELETE", "https://open.larksuite.com/open-apis/im/v1/pins/"+messageID, nil)
+	return larkPinAPI(cfg, req, "Unpin")
+}
+
+// larkPinAPI sends a pins API request with the tenant access token
+func larkPinAPI(cfg types.Config, req *http.Request, operation string) error {
+	token, err := larkTenantToken(cfg)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data := readResponse(cfg, "larkPinAPI", resp)
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("lark pins response: %d", resp.StatusCode)
+	}
+	if err := checkLarkResponse(data, nil); err != nil {
+		return err
+	}
+	types.DebugLog(cfg, "larkPinAPI: %s succeeded", operation)
+	return nil
+}
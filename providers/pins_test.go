@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestSlackPins(t *testing.T) {
+	var calls []string
+	response := `{"ok":true}`
+	cfg := types.Config{
+		SendMethod:     types.MethodWebClient,
+		ProviderConfig: map[string]interface{}{"token": "xoxb-token"},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			var body map[string]string
+			json.NewDecoder(req.Body).Decode(&body)
+			calls = append(calls, req.URL.Path+" "+body["channel"]+" "+body["timestamp"])
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(response))}, nil
+		}),
+	}
+	p := &SlackProvider{}
+	if err := p.Pin(cfg, "C0123ABC", "1700000000.000100"); err != nil {
+		t.Fatalf("Unexpected error pinning: %v", err)
+	}
+	if err := p.Unpin(cfg, "C0123ABC", "1700000000.000100"); err != nil {
+		t.Fatalf("Unexpected error unpinning: %v", err)
+	}
+	want := "/api/pins.add C0123ABC 1700000000.000100,/api/pins.remove C0123ABC 1700000000.000100"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	response = `{"ok":false,"error":"already_pinned"}`
+	if err := p.Pin(cfg, "C0123ABC", "1700000000.000100"); err != nil {
+		t.Errorf("Expected a pinned message to stay pinned without error, got %v", err)
+	}
+	response = `{"ok":false,"error":"no_pin"}`
+	if err := p.Unpin(cfg, "C0123ABC", "1700000000.000100"); err != nil {
+		t.Errorf("Expected an unpinned message to be unpinned without error, got %v", err)
+	}
+	if err := p.Pin(cfg, "C0123ABC", "1700000000.000100"); err == nil || err.Error() != "slack API error: no_pin" {
+		t.Errorf("Expected other errors to fail, got %v", err)
+	}
+}
+
+func TestLarkPins(t *testing.T) {
+	var calls []string
+	response := `{"code":0,"msg":"success"}`
+	cfg := types.Config{
+		SendMethod: types.MethodWebClient,
+		Token:      "t-token",
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			body := ""
+			if req.Body != nil {
+				data, _ := io.ReadAll(req.Body)
+				body = string(data)
+			}
+			if req.Header.Get("Authorization") != "Bearer t-token" {
+				t.Errorf("Expected the tenant token, got %v", req.Header)
+			}
+			calls = append(calls, req.Method+" "+req.URL.Path+" "+body)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(response))}, nil
+		}),
+	}
+	p := &LarkProvider{}
+	if err := p.Pin(cfg, "oc_123", "om_456"); err != nil {
+		t.Fatalf("Unexpected error pinning: %v", err)
+	}
+	if err := p.Unpin(cfg, "oc_123", "om_456"); err != nil {
+		t.Fatalf("Unexpected error unpinning: %v", err)
+	}
+	want := `POST /open-apis/im/v1/pins {"message_id":"om_456"},DELETE /open-apis/im/v1/pins/om_456 `
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	response = `{"code":230001,"msg":"no permission"}`
+	if err := p.Pin(cfg, "oc_123", "om_456"); err == nil || err.Error() != "lark API error 230001: no permission" {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// pubsubAPIBase is the Pub/Sub REST API root; a variable so tests can point it elsewhere
+var pubsubAPIBase = "https://pubsub.googleapis.com/v1"
+
+// PubSubProvider implements Provider by publishing alert events to a Google Cloud Pub/Sub topic
+type PubSubProvider struct{}
+
+func (p *PubSubProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *PubSubProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "PubSubProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	topic, err := pubsubTopic(cfg, channel)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Attributes let subscribers filter without decoding the payload
+	attributes := map[string]string{
+		"level":       types.LevelName(event.Level),
+		"fingerprint": event.Fingerprint,
+	}
+	if event.Service != "" {
+		attributes["service"] = event.Service
+	}
+	if event.Environment != "" {
+		attributes["environment"] = event.Environment
+	}
+	pubsubMessage := map[string]interface{}{
+		"data":       base64.StdEncoding.EncodeToString(data),
+		"attributes": attributes,
+	}
+	if ordered, _ := cfg.ProviderConfig["pubsub_ordering"].(bool); ordered {
+		pubsubMessage["orderingKey"] = event.Fingerprint
+	}
+	payload := map[string]interface{}{"messages": []interface{}{pubsubMessage}}
+
+	types.DebugLog(cfg, "sendPubSub: publishing to %s, data size: %d bytes", topic, len(data))
+	if _, err := gcpRequest(cfg, opSend, "POST", fmt.Sprintf("%s/%s:publish", pubsubAPIBase, topic), payload); err != nil {
+		types.DebugLog(cfg, "sendPubSub: publish failed: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendPubSub: message published successfully")
+	return nil
+}
+
+// pubsubTopic returns the full topic path from pubsub_topic (or the channel), qualifying
+// short names with gcp_project
+func pubsubTopic(cfg types.Config, channel string) (string, error) {
+	topic, _ := cfg.ProviderConfig["pubsub_topic"].(string)
+	if topic == "" {
+		topic = channel
+	}
+	if topic == "" {
+		return "", fmt.Errorf("pubsub_topic must be set in provider_config or provided as channel")
+	}
+	if strings.HasPrefix(topic, "projects/") {
+		return topic, nil
+	}
+	project, _ := cfg.ProviderConfig["gcp_project"].(string)
+	if project == "" {
+		return "", fmt.Errorf("gcp_project must be set in provider_config for short topic name '%s'", topic)
+	}
+	return fmt.Sprintf("projects/%s/topics/%s", project, topic), nil
+}
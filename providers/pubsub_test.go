@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestPubSubPublishWithServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	var published struct {
+		Messages []struct {
+			Data       string            `json:"data"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"messages"`
+	}
+	var path, auth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.Form.Get("assertion") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"ya29.test","expires_in":3600}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&published)
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	original := pubsubAPIBase
+	pubsubAPIBase = server.URL + "/v1"
+	defer func() { pubsubAPIBase = original }()
+
+	account, _ := json.Marshal(gcpServiceAccount{
+		ClientEmail: "alerts@test-project.iam.gserviceaccount.com",
+		PrivateKey:  privatePEM,
+		TokenURI:    server.URL + "/token",
+	})
+	cfg := types.Config{
+		ServiceName: "billing",
+		Environment: "prod",
+		ProviderConfig: map[string]interface{}{
+			"gcp_credentials_json": string(account),
+			"gcp_project":          "test-project",
+			"pubsub_topic":         "alerts",
+		},
+	}
+	if err := (&PubSubProvider{}).Send(types.ERROR, "boom", nil, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path != "/v1/projects/test-project/topics/alerts:publish" || auth != "Bearer ya29.test" {
+		t.Errorf("Unexpected publish request: %s with %s", path, auth)
+	}
+	if len(published.Messages) != 1 || published.Messages[0].Attributes["level"] != "ERROR" || published.Messages[0].Attributes["service"] != "billing" {
+		t.Fatalf("Unexpected published messages: %+v", published)
+	}
+	data, _ := base64.StdEncoding.DecodeString(published.Messages[0].Data)
+	var event types.AlertEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Message != "boom" {
+		t.Errorf("Expected alert event payload, got %s", data)
+	}
+}
+
+func TestGCPAccessTokenDefaultsTokenURI(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	account, _ := json.Marshal(gcpServiceAccount{
+		ClientEmail: "default-uri@test-project.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	var requested string
+	cfg := types.Config{
+		Cache: cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			requested = req.URL.String()
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"access_token":"ya29.default","expires_in":3600}`))}, nil
+		}),
+		ProviderConfig: map[string]interface{}{"gcp_credentials_json": string(account)},
+	}
+	if token, err := gcpAccessToken(cfg); err != nil || token != "ya29.default" {
+		t.Fatalf("Expected the token, got %q, %v", token, err)
+	}
+	if requested != gcpDefaultTokenURI {
+		t.Errorf("Expected the key without token_uri to use %s, got %q", gcpDefaultTokenURI, requested)
+	}
+}
@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// recordedBodyLimit is how much of each request and response body is recorded
+const recordedBodyLimit = 4 << 10
+
+const redacted = "[REDACTED]"
+
+var (
+	// Credentials in JSON bodies, e.g. "app_secret", "tenant_access_token" or "api_key"
+	jsonSecretPattern = regexp.MustCompile(`("[\w-]*(?i:secret|token|password|api_key|apikey|authorization)[\w-]*"\s*:\s*)"[^"]*"`)
+	// Credentials in form bodies
+	formSecretPattern = regexp.MustCompile(`((?:^|&)[\w-]*(?i:secret|token|password|key)[\w-]*=)[^&]*`)
+	// URLs in error messages, which *url.Error quotes
+	urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"']+`)
+)
+
+// recordingDoer records every exchange to the flight recorder configured on cfg
+type recordingDoer struct {
+	next     types.HTTPDoer
+	recorder *types.FlightRecorder
+	alertID  string
+}
+
+func (d recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	exchange := types.HTTPExchange{
+		Time:           time.Now(),
+		AlertID:        d.alertID,
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			exchange.RequestBody = redactBody(readLimited(body))
+			body.Close()
+		}
+	}
+
+	resp, err := d.next.Do(req)
+	exchange.DurationMs = time.Since(exchange.Time).Milliseconds()
+	if err != nil {
+		exchange.Error = err.Error()
+		d.recorder.Record(exchange)
+		return resp, err
+	}
+	exchange.Status = resp.StatusCode
+	head := readLimited(resp.Body)
+	// Hand the provider the full body, including the part already read
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	exchange.ResponseBody = redactBody(head)
+	d.recorder.Record(exchange)
+	return resp, nil
+}
+
+// readLimited reads up to recordedBodyLimit bytes
+func readLimited(r io.Reader) []byte {
+	data, _ := io.ReadAll(io.LimitReader(r, recordedBodyLimit))
+	return data
+}
+
+// redactURL hides query values and token-like path segments
+func redactURL(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if looksLikeToken(segment) {
+			segments[i] = redacted
+		}
+	}
+	redactedURL := u.Scheme + "://" + u.Host + strings.Join(segments, "/")
+	if u.RawQuery == "" {
+		return redactedURL
+	}
+	keys := make([]string, 0)
+	for key := range u.Query() {
+		keys = append(keys, url.QueryEscape(key)+"="+redacted)
+	}
+	sort.Strings(keys)
+	return redactedURL + "?" + strings.Join(keys, "&")
+}
+
+// RedactURLs returns text with every URL in it redacted like recorded requests, so errors
+// such as *url.Error, whose text includes the full webhook URL, can be shown safely
+func RedactURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return redacted
+		}
+		return redactURL(u)
+	})
+}
+
+// looksLikeToken reports whether a path segment looks like a webhook token, e.g. the
+// last segment of /services/T0/B0/<token> or /hook/<uuid>, rather than a word
+func looksLikeToken(segment string) bool {
+	if len(segment) < 16 {
+		return false
+	}
+	var digit, upper, lower bool
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c != '-' && c != '_':
+			return false
+		}
+	}
+	return digit || (upper && lower)
+}
+
+// redactHeaders returns the request headers with credentials hidden
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		lower := strings.ToLower(key)
+		if lower == "authorization" || lower == "cookie" || strings.Contains(lower, "auth") ||
+			strings.Contains(lower, "token") || strings.Contains(lower, "key") || strings.Contains(lower, "signature") {
+			value = redacted
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// redactBody hides credentials in JSON and form encoded bodies
+func redactBody(body []byte) string {
+	text := jsonSecretPattern.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+	return formSecretPattern.ReplaceAllString(text, "${1}"+redacted)
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestFlightRecorderRedactsExchanges(t *testing.T) {
+	recorder := types.NewFlightRecorder(2)
+	cfg := types.Config{
+		SendMethod:     types.MethodWebhook,
+		MessageID:      "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		FlightRecorder: recorder,
+		ProviderConfig: map[string]interface{}{"token": "https://hooks.slack.invalid/services/T0001/B0001/Xy7Kp2Qw9Lm4Rt6Vb8Nc1Zd3"},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("no_service"))}, nil
+		}),
+	}
+	for i := 0; i < 3; i++ {
+		(&SlackProvider{}).Send(types.ERROR, "boom", nil, cfg)
+	}
+
+	exchanges := recorder.Exchanges()
+	if len(exchanges) != 2 {
+		t.Fatalf("Expected the ring buffer to keep the last 2 exchanges, got %d", len(exchanges))
+	}
+	exchange := exchanges[1]
+	if exchange.URL != "https://hooks.slack.invalid/services/T0001/B0001/[REDACTED]" {
+		t.Errorf("Expected the webhook token to be redacted, got %s", exchange.URL)
+	}
+	if exchange.Status != http.StatusNotFound || exchange.ResponseBody != "no_service" || exchange.AlertID != cfg.MessageID {
+		t.Errorf("Unexpected exchange: %+v", exchange)
+	}
+	if !strings.HasPrefix(exchange.RequestBody, `{"text":"boom`) {
+		t.Errorf("Expected the request body to be recorded, got %s", exchange.RequestBody)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	cases := map[string]string{
+		`{"app_id":"cli_1","app_secret":"s3cret"}`:               `{"app_id":"cli_1","app_secret":"[REDACTED]"}`,
+		`{"code":0,"tenant_access_token":"t-abc","expire":7200}`: `{"code":0,"tenant_access_token":"[REDACTED]","expire":7200}`,
+		`To=%2B15550100&Body=boom&api_key=k1`:                    `To=%2B15550100&Body=boom&api_key=[REDACTED]`,
+	}
+	for body, expected := range cases {
+		if got := redactBody([]byte(body)); got != expected {
+			t.Errorf("redactBody(%s) = %s, want %s", body, got, expected)
+		}
+	}
+	if looksLikeToken("tenant_access_token") || !looksLikeToken("0f6b8c2e-4d1a-4c3b-9e7f-2a5d8b1c6e90") {
+		t.Error("Expected only token-like path segments to be redacted")
+	}
+	headers := redactHeaders(http.Header{"Authorization": {"Bearer xoxb"}, "Content-Type": {"application/json"}})
+	if headers["Authorization"] != "[REDACTED]" || headers["Content-Type"] != "application/json" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+	text := RedactURLs(`Post "https://hooks.slack.com/services/T0001/B0001/abcDEF123ghiJKL456?token=x": dial tcp: timeout`)
+	if text != `Post "https://hooks.slack.com/services/T0001/B0001/[REDACTED]?token=[REDACTED]": dial tcp: timeout` {
+		t.Errorf("Expected the error's URL to be redacted, got %s", text)
+	}
+}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// SDKMessage is an alert rendered by the Slack or Lark provider for the api send method,
+// the same message the webclient method posts
+type SDKMessage struct {
+	Token     string          // Slack bot token or Lark tenant access token
+	ChannelID string          // Slack channel, or Lark chat ID
+	Text      string          // Slack fallback text
+	Blocks    json.RawMessage // Slack Block Kit blocks
+	MsgType   string          // Lark message type, "post" or "interactive"
+	Content   string          // Lark message content, JSON encoded
+}
+
+// SDKClient delivers rendered alerts through a provider's official SDK. Clients are
+// registered by the sdk package.
+type SDKClient interface {
+	PostMessage(ctx context.Context, cfg types.Config, msg SDKMessage) error
+}
+
+// ChannelLister is implemented by SDK clients that look up channel IDs, returning the
+// ID of every channel the bot belongs to keyed by name
+type ChannelLister interface {
+	ListChannels(ctx context.Context, cfg types.Config, token string) (map[string]string, error)
+}
+
+var (
+	sdkClientsMu sync.RWMutex
+	sdkClients   = make(map[string]SDKClient)
+)
+
+// RegisterSDKClient makes client deliver alerts for provider with the api send method
+func RegisterSDKClient(provider string, client SDKClient) {
+	sdkClientsMu.Lock()
+	defer sdkClientsMu.Unlock()
+	sdkClients[provider] = client
+}
+
+// sdkClient returns the SDK client registered for provider
+func sdkClient(provider string) (SDKClient, error) {
+	sdkClientsMu.RLock()
+	defer sdkClientsMu.RUnlock()
+	client, ok := sdkClients[provider]
+	if !ok {
+		return nil, fmt.Errorf("send method %q for %s requires importing github.com/alvianhanif/gocommonlog/sdk", types.MethodSDK, provider)
+	}
+	return client, nil
+}
+
+// ChannelNotFound wraps an SDK error so it matches types.ErrChannelNotFound while
+// keeping its message
+func ChannelNotFound(err error) error {
+	return channelNotFoundError{err}
+}
+
+// HTTPClient returns the client providers send requests with, including the user agent,
+// flight recorder and fault injection, for SDKs to send theirs through
+func HTTPClient(cfg types.Config) types.HTTPDoer {
+	return httpDoer(cfg)
+}
@@ -0,0 +1,209 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Built-in secret reference schemes
+const (
+	secretSchemeAWS   = "aws-sm"
+	secretSchemeGCP   = "gcp-sm"
+	secretSchemeVault = "vault"
+)
+
+// SecretScheme returns the scheme of a secret reference such as "vault://path#key", or ""
+// when value is not a reference to a built-in or configured resolver
+func SecretScheme(cfg types.Config, value string) string {
+	i := strings.Index(value, "://")
+	if i <= 0 {
+		return ""
+	}
+	scheme := value[:i]
+	if _, ok := cfg.SecretResolvers[scheme]; ok {
+		return scheme
+	}
+	switch scheme {
+	case secretSchemeAWS, secretSchemeGCP, secretSchemeVault:
+		return scheme
+	}
+	return ""
+}
+
+// ResolveSecret fetches the secret a reference points to. References have the form
+// scheme://name#key, where the optional key selects a field of a JSON secret:
+//
+//	aws-sm://prod/alerts#slack_token                    AWS Secrets Manager, with aws_* settings
+//	gcp-sm://projects/p/secrets/slack-token             GCP Secret Manager, latest version unless given
+//	vault://secret/data/alerts#slack_token              Vault KV v1 or v2, with vault_addr and vault_token
+//
+// Resolvers in cfg.SecretResolvers receive the full reference.
+func ResolveSecret(cfg types.Config, ref string) (string, error) {
+	scheme := SecretScheme(cfg, ref)
+	if resolver, ok := cfg.SecretResolvers[scheme]; ok {
+		return resolver.ResolveSecret(ref)
+	}
+	name, key := strings.TrimPrefix(ref, scheme+"://"), ""
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		name, key = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid secret reference %q", ref)
+	}
+	types.DebugLog(cfg, "Resolving %s secret %s", scheme, name)
+
+	var value string
+	var err error
+	switch scheme {
+	case secretSchemeAWS:
+		value, err = awsSecret(cfg, name)
+	case secretSchemeGCP:
+		value, err = gcpSecret(cfg, name)
+	case secretSchemeVault:
+		return vaultSecret(cfg, name, key)
+	default:
+		return "", fmt.Errorf("no secret resolver for %q", ref)
+	}
+	if err != nil || key == "" {
+		return value, err
+	}
+	return secretField([]byte(value), key)
+}
+
+// secretField returns one field of a JSON object secret
+func secretField(data []byte, key string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot select %q: %w", key, err)
+	}
+	value, ok := fields[key].(string)
+	if !ok {
+		return "", fmt.Errorf("secret has no string field %q", key)
+	}
+	return value, nil
+}
+
+// awsSecret fetches a secret string with the Secrets Manager GetSecretValue API
+func awsSecret(cfg types.Config, secretID string) (string, error) {
+	creds, region, err := awsConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	body, _ := json.Marshal(map[string]string{"SecretId": secretID})
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, body, creds, region, "secretsmanager", currentTime(cfg))
+
+	resp, err := operationDoer(cfg, opAuth).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data := readResponse(cfg, "awsSecret", resp)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("AWS Secrets Manager response: %d: %s", resp.StatusCode, data)
+	}
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid AWS Secrets Manager response: %w", err)
+	}
+	if result.SecretString == "" {
+		return "", fmt.Errorf("AWS secret %s has no string value", secretID)
+	}
+	return result.SecretString, nil
+}
+
+// gcpSecret accesses a secret version with the Secret Manager API
+func gcpSecret(cfg types.Config, name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	data, err := gcpRequest(cfg, opAuth, "GET", "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid GCP Secret Manager response: %w", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid GCP secret payload: %w", err)
+	}
+	return string(value), nil
+}
+
+// vaultSecret reads a key of a Vault KV secret, using vault_addr and vault_token or the
+// VAULT_ADDR and VAULT_TOKEN environment variables. Without a key the secret must have
+// exactly one field.
+func vaultSecret(cfg types.Config, path, key string) (string, error) {
+	settings := settingsOf(cfg)
+	addr := settings.String("vault_addr", os.Getenv("VAULT_ADDR"))
+	token := settings.String("vault_token", os.Getenv("VAULT_TOKEN"))
+	if addr == "" || token == "" {
+		return "", fmt.Errorf("vault_addr and vault_token must be set in provider_config or environment")
+	}
+	req, err := http.NewRequest("GET", strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := settings.String("vault_namespace", os.Getenv("VAULT_NAMESPACE")); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	resp, err := operationDoer(cfg, opAuth).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data := readResponse(cfg, "vaultSecret", resp)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("vault response: %d", resp.StatusCode)
+	}
+
+	// KV v2 nests the fields under data.data, KV v1 returns them under data
+	var result struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	fields := result.Data
+	if nested, ok := fields["data"]; ok {
+		if _, hasMetadata := fields["metadata"]; hasMetadata {
+			fields = nil
+			json.Unmarshal(nested, &fields)
+		}
+	}
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("vault secret %s has %d fields, select one with #key", path, len(fields))
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+	var value string
+	if err := json.Unmarshal(fields[key], &value); err != nil {
+		return "", fmt.Errorf("vault secret %s has no string field %q", path, key)
+	}
+	return value, nil
+}
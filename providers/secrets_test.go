@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestResolveSecret(t *testing.T) {
+	var requests []*http.Request
+	cfg := types.Config{
+		ProviderConfig: map[string]interface{}{
+			"aws_access_key_id":     "AKIDEXAMPLE",
+			"aws_secret_access_key": "secret",
+			"aws_region":            "us-east-1",
+			"gcp_access_token":      "ya29.token",
+			"vault_addr":            "https://vault.example.com/",
+			"vault_token":           "s.vault",
+			"auth_timeout":          time.Minute,
+		},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req)
+			body := `{}`
+			switch {
+			case req.URL.Host == "secretsmanager.us-east-1.amazonaws.com":
+				body = `{"SecretString":"{\"slack_token\":\"xoxb-aws\"}"}`
+			case req.URL.Host == "secretmanager.googleapis.com":
+				body = `{"payload":{"data":"` + base64.StdEncoding.EncodeToString([]byte("xoxb-gcp")) + `"}}`
+			case req.URL.Path == "/v1/secret/data/alerts":
+				body = `{"data":{"data":{"slack_token":"xoxb-vault","lark_secret":"s"},"metadata":{"version":3}}}`
+			case req.URL.Path == "/v1/kv/alerts":
+				body = `{"data":{"token":"xoxb-kv1"}}`
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}),
+	}
+
+	cases := map[string]string{
+		"aws-sm://prod/alerts#slack_token":        "xoxb-aws",
+		"gcp-sm://projects/p/secrets/slack-token": "xoxb-gcp",
+		"vault://secret/data/alerts#slack_token":  "xoxb-vault",
+		"vault://kv/alerts":                       "xoxb-kv1",
+		"custom://anything":                       "xoxb-custom",
+	}
+	cfg.SecretResolvers = map[string]types.SecretResolver{
+		"custom": types.SecretResolverFunc(func(ref string) (string, error) { return "xoxb-custom", nil }),
+	}
+	for ref, expected := range cases {
+		if value, err := ResolveSecret(cfg, ref); err != nil || value != expected {
+			t.Errorf("%s: expected %q, got %q, %v", ref, expected, value, err)
+		}
+	}
+
+	for _, req := range requests {
+		if _, ok := req.Context().Deadline(); !ok {
+			t.Errorf("Expected the secret fetch from %s to be limited by auth_timeout", req.URL.Host)
+		}
+		switch req.URL.Host {
+		case "secretsmanager.us-east-1.amazonaws.com":
+			if req.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+				t.Errorf("Expected a signed GetSecretValue request, got %v", req.Header)
+			}
+		case "secretmanager.googleapis.com":
+			if req.URL.Path != "/v1/projects/p/secrets/slack-token/versions/latest:access" {
+				t.Errorf("Expected the latest version to be accessed, got %s", req.URL.Path)
+			}
+		default:
+			if req.Header.Get("X-Vault-Token") != "s.vault" {
+				t.Errorf("Expected the vault token, got %v", req.Header)
+			}
+		}
+	}
+
+	if _, err := ResolveSecret(cfg, "vault://secret/data/alerts"); err == nil {
+		t.Error("Expected an error selecting from a secret with several fields")
+	}
+	if SecretScheme(cfg, "https://hooks.slack.com/services/x") != "" || SecretScheme(cfg, "xoxb-plain") != "" {
+		t.Error("Expected URLs and plain tokens not to be secret references")
+	}
+}
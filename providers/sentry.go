@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// SentryProvider implements Provider by submitting alerts as Sentry events
+type SentryProvider struct{}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Message     string            `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+	Exception   *sentryException  `json:"exception,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+type sentryException struct {
+	Values []sentryExceptionValue `json:"values"`
+}
+
+type sentryExceptionValue struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function,omitempty"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+func (p *SentryProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *SentryProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "SentryProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	dsn, _ := cfg.ProviderConfig["sentry_dsn"].(string)
+	if dsn == "" {
+		err := fmt.Errorf("sentry_dsn must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+	storeURL, publicKey, err := parseSentryDSN(dsn)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	event := newSentryEvent(level, message, attachment, cfg, channel)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	types.DebugLog(cfg, "sendSentry: event %s prepared, size: %d bytes", event.EventID, len(data))
+
+	req, err := http.NewRequest("POST", storeURL, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=gocommonlog, sentry_timestamp=%d, sentry_key=%s",
+		currentTime(cfg).Unix(), publicKey))
+
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendSentry: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendSentry: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("sentry store response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendSentry: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendSentry: event sent successfully")
+	return nil
+}
+
+// parseSentryDSN converts https://<key>@<host>/<project> into the store endpoint and public key
+func parseSentryDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid sentry_dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", "", fmt.Errorf("invalid sentry_dsn: missing public key")
+	}
+	path := strings.Trim(u.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	projectID, prefix := path, ""
+	if idx >= 0 {
+		projectID, prefix = path[idx+1:], "/"+path[:idx]
+	}
+	if projectID == "" {
+		return "", "", fmt.Errorf("invalid sentry_dsn: missing project ID")
+	}
+	storeURL := fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, projectID)
+	return storeURL, u.User.Username(), nil
+}
+
+func newSentryEvent(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) sentryEvent {
+	alert := types.NewAlertEvent(level, message, attachment, cfg, channel)
+
+	eventID := make([]byte, 16)
+	rand.Read(eventID)
+
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(eventID),
+		Timestamp:   alert.Timestamp(),
+		Level:       sentryLevel(level),
+		Platform:    "go",
+		Logger:      "gocommonlog",
+		Message:     message,
+		Environment: cfg.Environment,
+		Tags:        map[string]string{},
+		Extra:       alert.Fields,
+	}
+	if cfg.Fingerprinter != nil {
+		// Sentry groups by stack trace and message unless a custom grouping is configured
+		event.Fingerprint = []string{alert.Fingerprint}
+	}
+	if cfg.ServiceName != "" {
+		event.Tags["service"] = cfg.ServiceName
+		event.ServerName = cfg.ServiceName
+	}
+	if cfg.Environment != "" {
+		event.Tags["environment"] = cfg.Environment
+	}
+	if channel != "" {
+		event.Tags["channel"] = channel
+	}
+	if alert.ID != "" {
+		event.Tags["alert_id"] = alert.ID
+	}
+	if alert.CorrelationID != "" {
+		event.Tags["correlation_id"] = alert.CorrelationID
+	}
+	if alert.Trace != "" {
+		event.Exception = &sentryException{Values: []sentryExceptionValue{{
+			Type:       "alert",
+			Value:      message,
+			Stacktrace: &sentryStacktrace{Frames: parseGoTrace(alert.Trace)},
+		}}}
+	}
+	return event
+}
+
+func sentryLevel(level int) string {
+	switch level {
+	case types.ERROR:
+		return "error"
+	case types.WARN:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// parseGoTrace converts a Go panic/debug.Stack() trace into Sentry frames.
+// Go prints the innermost call first; Sentry expects the outermost first.
+func parseGoTrace(trace string) []sentryFrame {
+	var frames []sentryFrame
+	lines := strings.Split(trace, "\n")
+	for i := 0; i < len(lines)-1; i++ {
+		function := strings.TrimSpace(lines[i])
+		location := lines[i+1]
+		if function == "" || !strings.HasPrefix(location, "\t") {
+			continue
+		}
+		location = strings.TrimSpace(location)
+		if idx := strings.LastIndex(location, " +0x"); idx >= 0 {
+			location = location[:idx]
+		}
+		colon := strings.LastIndex(location, ":")
+		if colon < 0 {
+			continue
+		}
+		lineno, err := strconv.Atoi(location[colon+1:])
+		if err != nil {
+			continue
+		}
+		if paren := strings.LastIndex(function, "("); paren > 0 {
+			function = function[:paren]
+		}
+		module := ""
+		if dot := strings.LastIndex(function, "."); dot > 0 {
+			module = function[:dot]
+		}
+		path := location[:colon]
+		frames = append(frames, sentryFrame{
+			Function: function,
+			Module:   module,
+			Filename: path[strings.LastIndex(path, "/")+1:],
+			AbsPath:  path,
+			Lineno:   lineno,
+		})
+		i++
+	}
+	for l, r := 0, len(frames)-1; l < r; l, r = l+1, r-1 {
+		frames[l], frames[r] = frames[r], frames[l]
+	}
+	return frames
+}
@@ -0,0 +1,92 @@
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestParseSentryDSN(t *testing.T) {
+	storeURL, key, err := parseSentryDSN("https://abc123@o1.ingest.sentry.io/42")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if storeURL != "https://o1.ingest.sentry.io/api/42/store/" {
+		t.Errorf("Unexpected store URL: %s", storeURL)
+	}
+	if key != "abc123" {
+		t.Errorf("Expected key abc123, got %s", key)
+	}
+	if _, _, err := parseSentryDSN("https://o1.ingest.sentry.io/42"); err == nil {
+		t.Error("Expected error for DSN without public key")
+	}
+}
+
+func TestParseGoTrace(t *testing.T) {
+	trace := "goroutine 1 [running]:\nmain.handler(0x1)\n\t/app/handler.go:27 +0x2f\nmain.main()\n\t/app/main.go:15 +0x1d\n"
+	frames := parseGoTrace(trace)
+	if len(frames) != 2 {
+		t.Fatalf("Expected 2 frames, got %d", len(frames))
+	}
+	// Outermost frame first
+	if frames[0].Function != "main.main" || frames[0].Lineno != 15 || frames[0].Filename != "main.go" {
+		t.Errorf("Unexpected first frame: %+v", frames[0])
+	}
+	if frames[1].Function != "main.handler" || frames[1].AbsPath != "/app/handler.go" {
+		t.Errorf("Unexpected second frame: %+v", frames[1])
+	}
+}
+
+func TestSentrySendsEvent(t *testing.T) {
+	var body []byte
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		auth = r.Header.Get("X-Sentry-Auth")
+	}))
+	defer server.Close()
+
+	cfg := types.Config{
+		ServiceName: "billing",
+		Environment: "production",
+		ProviderConfig: map[string]interface{}{
+			"sentry_dsn": strings.Replace(server.URL, "http://", "http://pubkey@", 1) + "/7",
+		},
+	}
+	attachment := &types.Attachment{FileName: types.TraceFileName, Content: "main.main()\n\t/app/main.go:15 +0x1d"}
+	if err := (&SentryProvider{}).Send(types.ERROR, "boom", attachment, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var event sentryEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("Expected JSON body, got %v", err)
+	}
+	if !strings.Contains(auth, "sentry_key=pubkey") {
+		t.Errorf("Expected auth header with key, got %s", auth)
+	}
+	if event.Level != "error" || event.Tags["service"] != "billing" || event.Environment != "production" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if event.Exception == nil || len(event.Exception.Values[0].Stacktrace.Frames) != 1 {
+		t.Errorf("Expected exception with one frame, got %+v", event.Exception)
+	}
+	if event.Fingerprint != nil {
+		t.Errorf("Expected Sentry's own grouping without a fingerprint function, got %v", event.Fingerprint)
+	}
+
+	cfg.Fingerprinter = func(alert types.AlertContext) string { return "by-endpoint" }
+	cfg.Fingerprint = "by-endpoint"
+	if err := (&SentryProvider{}).Send(types.ERROR, "boom", attachment, cfg); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	event = sentryEvent{}
+	if err := json.Unmarshal(body, &event); err != nil || len(event.Fingerprint) != 1 || event.Fingerprint[0] != "by-endpoint" {
+		t.Errorf("Expected the configured fingerprint, got %v (%v)", event.Fingerprint, err)
+	}
+}
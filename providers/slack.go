@@ -2,16 +2,223 @@ package providers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/alvianhanif/gocommonlog/types"
 )
 
+// slackAuthTestURL is the Slack token verification endpoint; a variable so tests can point it elsewhere
+var slackAuthTestURL = "https://slack.com/api/auth.test"
+
 // SlackProvider implements Provider for Slack
 type SlackProvider struct{}
 
+// slackMessage is the chat.postMessage and incoming webhook payload
+type slackMessage struct {
+	Channel string       `json:"channel,omitempty"`
+	Text    string       `json:"text"`
+	Blocks  []slackBlock `json:"blocks,omitempty"`
+}
+
+// slackBlock is a Block Kit layout block; only sections, images and actions are used
+type slackBlock struct {
+	Type      string         `json:"type"`
+	Text      *slackText     `json:"text,omitempty"`
+	ImageURL  string         `json:"image_url,omitempty"`
+	SlackFile *slackFile     `json:"slack_file,omitempty"`
+	AltText   string         `json:"alt_text,omitempty"`
+	Elements  []slackElement `json:"elements,omitempty"`
+}
+
+// slackFile references an uploaded file from an image block
+type slackFile struct {
+	ID string `json:"id"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackElement struct {
+	Type     string    `json:"type"`
+	Text     slackText `json:"text"`
+	ActionID string    `json:"action_id"`
+	Value    string    `json:"value,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Style    string    `json:"style,omitempty"`
+}
+
+// slackSectionLimit is the maximum length of a section block's text
+const slackSectionLimit = 3000
+
+// slackSection builds a mrkdwn section block, truncating text to the section limit
+func slackSection(text string) slackBlock {
+	if len(text) > slackSectionLimit {
+		text = strings.ToValidUTF8(text[:slackSectionLimit-3], "") + "..."
+	}
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}
+}
+
+// Slack rejects an actions block with more elements, or a button with longer text
+const (
+	slackActionsLimit    = 25
+	slackButtonTextLimit = 75
+)
+
+// slackButton builds a button, truncating its text to the button text limit
+func slackButton(text, actionID string) slackElement {
+	if runes := []rune(text); len(runes) > slackButtonTextLimit {
+		text = string(runes[:slackButtonTextLimit-3]) + "..."
+	}
+	return slackElement{Type: "button", Text: slackText{Type: "plain_text", Text: text}, ActionID: actionID}
+}
+
+// newSlackMessage builds the payload. Snippets, an image URL and links are rendered as
+// blocks, links as buttons, followed by an Acknowledge button when cfg.AckID is set. Links
+// beyond Slack's limit of 25 buttons are left out, keeping the Acknowledge button. Text
+// stays as the notification fallback.
+func newSlackMessage(channel, text string, cfg types.Config) slackMessage {
+	msg := slackMessage{Channel: channel, Text: text}
+	hasImage := cfg.Image != nil && cfg.Image.URL != ""
+	if cfg.AckID == "" && len(cfg.Links) == 0 && len(cfg.Snippets) == 0 && !hasImage {
+		return msg
+	}
+	msg.Blocks = []slackBlock{slackSection(text)}
+	if hasImage {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "image", ImageURL: cfg.Image.URL, AltText: cfg.Image.Alt()})
+	}
+	for _, snippet := range cfg.Snippets {
+		// mrkdwn has no syntax highlighting and would show the language as the first line
+		snippet.Language = ""
+		msg.Blocks = append(msg.Blocks, slackSection(markdownSnippet(snippet, "*")))
+	}
+
+	links := cfg.Links
+	maxLinks := slackActionsLimit
+	if cfg.AckID != "" {
+		maxLinks--
+	}
+	if len(links) > maxLinks {
+		links = links[:maxLinks]
+	}
+	var buttons []slackElement
+	for i, link := range links {
+		button := slackButton(link.Text, fmt.Sprintf("commonlog_link_%d", i))
+		button.URL = link.URL
+		buttons = append(buttons, button)
+	}
+	if cfg.AckID != "" {
+		button := slackButton(types.Localize(cfg, types.TextAcknowledge), types.AckActionID)
+		button.Value = cfg.AckID
+		button.Style = "primary"
+		buttons = append(buttons, button)
+	}
+	if len(buttons) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "actions", Elements: buttons})
+	}
+	return msg
+}
+
+// addImageFile shows an uploaded file as an image block after the message text
+func (m *slackMessage) addImageFile(fileID, altText string) {
+	image := slackBlock{Type: "image", SlackFile: &slackFile{ID: fileID}, AltText: altText}
+	if len(m.Blocks) == 0 {
+		m.Blocks = []slackBlock{slackSection(m.Text), image}
+		return
+	}
+	m.Blocks = append(m.Blocks[:1], append([]slackBlock{image}, m.Blocks[1:]...)...)
+}
+
+// slackResponse is the envelope returned by every Slack Web API method
+type slackResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	TS      string `json:"ts,omitempty"`      // Set by chat.postMessage
+	Channel string `json:"channel,omitempty"` // Set by chat.postMessage
+}
+
+// slackUploadURLResponse is the files.getUploadURLExternal response
+type slackUploadURLResponse struct {
+	slackResponse
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// slackCompleteUpload is the files.completeUploadExternal request body
+type slackCompleteUpload struct {
+	Files []slackUploadedFile `json:"files"`
+}
+
+type slackUploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// slackAPI calls a Slack Web API method and returns an error unless it reports ok
+func slackAPI(cfg types.Config, token string, req *http.Request, result interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data := readResponse(cfg, "slackAPI", resp)
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("slack API response: %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("invalid slack API response: %w", err)
+	}
+	return nil
+}
+
+// uploadSlackImage uploads image bytes with the external upload flow, without sharing
+// them to a channel, and returns the file ID for an image block
+func uploadSlackImage(cfg types.Config, token string, image *types.Image) (string, error) {
+	form := url.Values{"filename": {image.Name()}, "length": {strconv.Itoa(len(image.Data))}}
+	req, _ := http.NewRequest("POST", "https://slack.com/api/files.getUploadURLExternal", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var upload slackUploadURLResponse
+	if err := slackAPI(cfg, token, req, &upload); err != nil {
+		return "", err
+	}
+	if !upload.OK {
+		return "", fmt.Errorf("slack API error: %s", upload.Error)
+	}
+
+	req, _ = http.NewRequest("POST", upload.UploadURL, bytes.NewReader(image.Data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("slack file upload response: %d", resp.StatusCode)
+	}
+
+	data, _ := json.Marshal(slackCompleteUpload{Files: []slackUploadedFile{{ID: upload.FileID, Title: image.Alt()}}})
+	req, _ = http.NewRequest("POST", "https://slack.com/api/files.completeUploadExternal", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	var complete slackResponse
+	if err := slackAPI(cfg, token, req, &complete); err != nil {
+		return "", err
+	}
+	if !complete.OK {
+		return "", fmt.Errorf("slack API error: %s", complete.Error)
+	}
+	types.DebugLog(cfg, "uploadSlackImage: uploaded %s (%d bytes) as %s", image.Name(), len(image.Data), upload.FileID)
+	return upload.FileID, nil
+}
+
 func (p *SlackProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
 	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
 }
@@ -29,6 +236,9 @@ func (p *SlackProvider) SendToChannel(level int, message string, attachment *typ
 	case types.MethodWebhook:
 		types.DebugLog(cfg, "Using Slack webhook method")
 		return p.sendSlackWebhook(message, attachment, cfgCopy)
+	case types.MethodSDK:
+		types.DebugLog(cfg, "Using Slack SDK method")
+		return p.sendSlackSDK(message, attachment, cfgCopy)
 	default:
 		err := fmt.Errorf("unknown send method for Slack: %s", cfgCopy.SendMethod)
 		types.DebugLog(cfg, "Error: %v", err)
@@ -36,37 +246,82 @@ func (p *SlackProvider) SendToChannel(level int, message string, attachment *typ
 	}
 }
 
-// formatMessage formats the alert message with optional attachment
-func (p *SlackProvider) formatMessage(message string, attachment *types.Attachment, cfg types.Config) string {
-	formatted := ""
-
-	// Add service and environment header
-	if cfg.ServiceName != "" && cfg.Environment != "" {
-		formatted += fmt.Sprintf("*[%s - %s]*\n", cfg.ServiceName, cfg.Environment)
-	} else if cfg.ServiceName != "" {
-		formatted += fmt.Sprintf("*[%s]*\n", cfg.ServiceName)
-	} else if cfg.Environment != "" {
-		formatted += fmt.Sprintf("*[%s]*\n", cfg.Environment)
+// slackToken returns the bot token for the webclient method, preferring a rotated token
+// from slack_refresh, then slack_token, then token
+func slackToken(cfg types.Config) (string, error) {
+	settings := settingsOf(cfg)
+	if refresh, ok := cfg.ProviderConfig["slack_refresh"].(types.SlackRefreshConfig); ok {
+		return getSlackAccessToken(cfg, refresh)
 	}
+	if token := settings.String("slack_token", ""); token != "" {
+		types.DebugLog(cfg, "Using SlackToken (length: %d)", len(token))
+		return token, nil
+	}
+	token, err := settings.RequireString("token", "token for Slack webclient method")
+	if err == nil {
+		types.DebugLog(cfg, "Using Token (length: %d)", len(token))
+	}
+	return token, err
+}
 
-	formatted += message
-
+// formatMessage formats the alert message with optional attachment
+func (p *SlackProvider) formatMessage(message string, attachment *types.Attachment, cfg types.Config) string {
+	var formatted strings.Builder
+	size := len(message) + len(cfg.ServiceName) + len(cfg.Environment) + len(cfg.MessageID) + len(cfg.CorrelationID) + 48
 	if attachment != nil {
-		if attachment.Content != "" {
+		size += len(attachment.FileName) + len(attachment.Content) + len(attachment.URL) + 40
+	}
+	formatted.Grow(size)
+	formatted.WriteString(renderSections(cfg, func(section string) string {
+		switch section {
+		case types.SectionHeader:
+			// Service and environment header, then the timestamp, hostname and call site
+			var header []string
+			if cfg.ServiceName != "" && cfg.Environment != "" {
+				header = append(header, "*["+cfg.ServiceName+" - "+cfg.Environment+"]*")
+			} else if cfg.ServiceName != "" {
+				header = append(header, "*["+cfg.ServiceName+"]*")
+			} else if cfg.Environment != "" {
+				header = append(header, "*["+cfg.Environment+"]*")
+			}
+			if meta := alertMetaLine(cfg); meta != "" {
+				header = append(header, "_"+meta+"_")
+			}
+			return strings.Join(header, "\n")
+		case types.SectionMessage:
+			return message
+		case types.SectionFields:
+			return fieldLines(cfg, "*")
+		case types.SectionTrace:
+			if attachment == nil || attachment.Content == "" {
+				return ""
+			}
 			// Inline content - show as expandable code block
 			filename := attachment.FileName
 			if filename == "" {
-				filename = "Trace Logs"
+				filename = types.Localize(cfg, types.TextTraceLogs)
+			}
+			return "*" + filename + ":*\n```\n" + attachment.Content + "\n```"
+		case types.SectionAttachment:
+			if attachment == nil || attachment.URL == "" {
+				return ""
 			}
-			formatted += fmt.Sprintf("\n\n*%s:*\n```\n%s\n```", filename, attachment.Content)
-		}
-		if attachment.URL != "" {
 			// External URL attachment
-			formatted += fmt.Sprintf("\n\n*Attachment:* %s", attachment.URL)
+			return "*" + types.Localize(cfg, types.TextAttachment) + ":* " + attachment.URL
+		case types.SectionFooter:
+			var footer []string
+			if idLine := alertIDLine(cfg); idLine != "" {
+				footer = append(footer, "_"+idLine+"_")
+			}
+			if line := alertFooter(cfg); line != "" {
+				footer = append(footer, "_"+line+"_")
+			}
+			return strings.Join(footer, "\n")
 		}
-	}
+		return ""
+	}))
 
-	return formatted
+	return formatted.String()
 }
 
 func (p *SlackProvider) sendSlackWebhook(message string, attachment *types.Attachment, cfg types.Config) error {
@@ -74,30 +329,29 @@ func (p *SlackProvider) sendSlackWebhook(message string, attachment *types.Attac
 	formattedMessage := p.formatMessage(message, attachment, cfg)
 
 	// For webhook, the token field contains the webhook URL
-	webhookURL := cfg.ProviderConfig["token"].(string)
-	if webhookURL == "" {
-		err := fmt.Errorf("webhook URL is required for Slack webhook method")
+	webhookURL, err := settingsOf(cfg).RequireString("token", "webhook URL for Slack webhook method")
+	if err != nil {
 		types.DebugLog(cfg, "Error: %v", err)
 		return err
 	}
 	types.DebugLog(cfg, "sendSlackWebhook: using webhook URL (length: %d), channel: %s", len(webhookURL), cfg.Channel)
 
-	payload := map[string]interface{}{
-		"text": formattedMessage,
+	// Webhooks cannot upload files, so images are only shown from their URL
+	if cfg.Image != nil && len(cfg.Image.Data) > 0 && cfg.Image.URL == "" {
+		log.Printf("[WARN] Slack webhook method cannot upload images, dropping %s", cfg.Image.Name())
 	}
+
 	// If channel is specified, include it in the payload
-	if cfg.Channel != "" {
-		payload["channel"] = cfg.Channel
-	}
+	payload := newSlackMessage(cfg.Channel, formattedMessage, cfg)
 
 	data, _ := json.Marshal(payload)
 	types.DebugLog(cfg, "sendSlackWebhook: payload prepared, size: %d bytes", len(data))
 
-	req, _ := http.NewRequest("POST", webhookURL, bytes.NewBuffer(data))
+	req, _ := http.NewRequest("POST", webhookURL, bytes.NewReader(data))
 	req.Header.Set("Content-Type", "application/json")
 
 	types.DebugLog(cfg, "sendSlackWebhook: sending HTTP request to webhook URL")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpDoer(cfg).Do(req)
 	if err != nil {
 		types.DebugLog(cfg, "sendSlackWebhook: HTTP request failed: %v", err)
 		return err
@@ -105,9 +359,7 @@ func (p *SlackProvider) sendSlackWebhook(message string, attachment *types.Attac
 	defer resp.Body.Close()
 
 	// Log response data
-	respData := new(bytes.Buffer)
-	respData.ReadFrom(resp.Body)
-	types.DebugLog(cfg, "sendSlackWebhook: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+	logResponse(cfg, "sendSlackWebhook", resp)
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("slack webhook response: %d", resp.StatusCode)
@@ -123,30 +375,38 @@ func (p *SlackProvider) sendSlackWebClient(message string, attachment *types.Att
 	formattedMessage := p.formatMessage(message, attachment, cfg)
 
 	// Use SlackToken if available, otherwise fall back to Token
-	token := cfg.ProviderConfig["token"].(string)
-	if slackToken, ok := cfg.ProviderConfig["slack_token"].(string); ok && slackToken != "" {
-		token = slackToken
-		types.DebugLog(cfg, "sendSlackWebClient: using SlackToken (length: %d)", len(token))
-	} else {
-		types.DebugLog(cfg, "sendSlackWebClient: using Token (length: %d)", len(token))
+	token, err := slackToken(cfg)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	// Image bytes are uploaded and shown from the uploaded file, falling back to the image URL
+	image := cfg.Image
+	var imageFileID string
+	if image != nil && len(image.Data) > 0 {
+		imageFileID, err = uploadSlackImage(cfg, token, image)
+		if err != nil {
+			log.Printf("[WARN] Failed to upload Slack image %s, falling back to its URL: %v", image.Name(), err)
+		} else {
+			cfg.Image = nil
+		}
 	}
 
 	url := "https://slack.com/api/chat.postMessage"
-	headers := map[string]string{"Authorization": "Bearer " + token, "Content-Type": "application/json; charset=utf-8"}
-	payload := map[string]interface{}{
-		"channel": cfg.Channel,
-		"text":    formattedMessage,
+	payload := newSlackMessage(cfg.Channel, formattedMessage, cfg)
+	if imageFileID != "" {
+		payload.addImageFile(imageFileID, image.Alt())
 	}
 	data, _ := json.Marshal(payload)
 	types.DebugLog(cfg, "sendSlackWebClient: sending to channel: %s, payload size: %d bytes", cfg.Channel, len(data))
 
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	types.DebugLog(cfg, "sendSlackWebClient: sending HTTP request to Slack API")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpDoer(cfg).Do(req)
 	if err != nil {
 		types.DebugLog(cfg, "sendSlackWebClient: HTTP request failed: %v", err)
 		return err
@@ -154,15 +414,145 @@ func (p *SlackProvider) sendSlackWebClient(message string, attachment *types.Att
 	defer resp.Body.Close()
 
 	// Log response data
-	respData := new(bytes.Buffer)
-	respData.ReadFrom(resp.Body)
-	types.DebugLog(cfg, "sendSlackWebClient: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+	respData := readResponse(cfg, "sendSlackWebClient", resp)
 
 	if resp.StatusCode != 200 {
 		err := fmt.Errorf("slack WebClient response: %d", resp.StatusCode)
 		types.DebugLog(cfg, "sendSlackWebClient: error response: %v", err)
 		return err
 	}
+	// The Web API reports failures such as channel_not_found with a 200 status
+	var result slackResponse
+	if err := json.Unmarshal(respData, &result); err != nil {
+		return fmt.Errorf("invalid slack WebClient response: %w", err)
+	}
+	if !result.OK {
+		err := fmt.Errorf("slack API error: %s", result.Error)
+		if result.Error == "channel_not_found" {
+			err = channelNotFoundError{err}
+		}
+		types.DebugLog(cfg, "sendSlackWebClient: error response: %v", err)
+		if refresh, ok := cfg.ProviderConfig["slack_refresh"].(types.SlackRefreshConfig); ok && (result.Error == "token_expired" || result.Error == "invalid_auth") {
+			forgetSlackToken(cfg, refresh)
+		}
+		return err
+	}
+	cfg.Response.SetMessage(result.TS, result.Channel)
 	types.DebugLog(cfg, "sendSlackWebClient: message sent successfully")
 	return nil
 }
+
+// sendSlackSDK posts the message the webclient method would post through the Slack SDK
+// client registered by the sdk package
+func (p *SlackProvider) sendSlackSDK(message string, attachment *types.Attachment, cfg types.Config) error {
+	client, err := sdkClient("slack")
+	if err != nil {
+		return err
+	}
+	token, err := slackToken(cfg)
+	if err != nil {
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	// Image bytes are uploaded and shown from the uploaded file, falling back to the image URL
+	image := cfg.Image
+	var imageFileID string
+	if image != nil && len(image.Data) > 0 {
+		imageFileID, err = uploadSlackImage(cfg, token, image)
+		if err != nil {
+			log.Printf("[WARN] Failed to upload Slack image %s, falling back to its URL: %v", image.Name(), err)
+		} else {
+			cfg.Image = nil
+		}
+	}
+	payload := newSlackMessage(cfg.Channel, p.formatMessage(message, attachment, cfg), cfg)
+	if imageFileID != "" {
+		payload.addImageFile(imageFileID, image.Alt())
+	}
+	var blocks json.RawMessage
+	if len(payload.Blocks) > 0 {
+		blocks, _ = json.Marshal(payload.Blocks)
+	}
+
+	types.DebugLog(cfg, "sendSlackSDK: sending to channel: %s", cfg.Channel)
+	err = client.PostMessage(context.Background(), cfg, SDKMessage{Token: token, ChannelID: cfg.Channel, Text: payload.Text, Blocks: blocks})
+	if err != nil {
+		types.DebugLog(cfg, "sendSlackSDK: error response: %v", err)
+		if refresh, ok := cfg.ProviderConfig["slack_refresh"].(types.SlackRefreshConfig); ok && (strings.Contains(err.Error(), "token_expired") || strings.Contains(err.Error(), "invalid_auth")) {
+			forgetSlackToken(cfg, refresh)
+		}
+		return err
+	}
+	types.DebugLog(cfg, "sendSlackSDK: message sent successfully")
+	return nil
+}
+
+// HealthCheck verifies the bot token with auth.test for the webclient method; webhooks
+// cannot be verified without posting, so only their URL is checked
+func (p *SlackProvider) HealthCheck(ctx context.Context, cfg types.Config) error {
+	if cfg.SendMethod != types.MethodWebClient && cfg.SendMethod != types.MethodSDK {
+		_, err := settingsOf(cfg).RequireString("token", "webhook URL for Slack webhook method")
+		return err
+	}
+	token, err := slackToken(cfg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", slackAuthTestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "SlackProvider.HealthCheck: auth.test request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result slackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid slack auth.test response: %w", err)
+	}
+	types.DebugLog(cfg, "SlackProvider.HealthCheck: auth.test status: %d, ok: %t", resp.StatusCode, result.OK)
+	if !result.OK {
+		return fmt.Errorf("slack auth.test failed: %s", result.Error)
+	}
+	return nil
+}
+
+// slackEmojiNames maps the status emoji commonly passed as characters to their Slack names
+var slackEmojiNames = map[string]string{
+	"👀": "eyes",
+	"✅": "white_check_mark",
+	"❌": "x",
+	"🔥": "fire",
+}
+
+// React adds an emoji reaction to a posted message with reactions.add. The emoji is its
+// Slack name, with or without colons, or one of the characters in slackEmojiNames. A
+// reaction the message already has is not an error.
+func (p *SlackProvider) React(cfg types.Config, channel, messageID, emoji string) error {
+	name := strings.Trim(emoji, ":")
+	if mapped, ok := slackEmojiNames[emoji]; ok {
+		name = mapped
+	}
+	token, err := slackToken(cfg)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]string{"channel": channel, "timestamp": messageID, "name": name})
+	req, _ := http.NewRequest("POST", "https://slack.com/api/reactions.add", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	var result slackResponse
+	if err := slackAPI(cfg, token, req, &result); err != nil {
+		return err
+	}
+	if !result.OK && result.Error != "already_reacted" {
+		return fmt.Errorf("slack API error: %s", result.Error)
+	}
+	types.DebugLog(cfg, "React: added :%s: to message %s in %s", name, messageID, channel)
+	return nil
+}
@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// slackOAuthURL is the Slack token exchange endpoint
+const slackOAuthURL = "https://slack.com/api/oauth.v2.access"
+
+// slackTokenResponse is the oauth.v2.access response for the refresh_token grant
+type slackTokenResponse struct {
+	OK           bool   `json:"ok"`
+	Error        string `json:"error,omitempty"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// slackTokenKey returns the state key for tokens of an app installation, hashing the
+// configured refresh token rather than storing it in the key
+func slackTokenKey(refresh types.SlackRefreshConfig) string {
+	sum := sha256.Sum256([]byte(refresh.RefreshToken))
+	return refresh.ClientID + ":" + hex.EncodeToString(sum[:8])
+}
+
+// forgetSlackToken drops the cached access token after Slack rejects it, so the next send
+// exchanges the refresh token again
+func forgetSlackToken(cfg types.Config, refresh types.SlackRefreshConfig) {
+	NewBucket(cfg, "slack_token").Delete(slackTokenKey(refresh))
+}
+
+// getSlackAccessToken returns a cached access token for a Slack app with token rotation,
+// exchanging the refresh token with oauth.v2.access when none is cached. Slack may return
+// a new refresh token with each exchange; it is cached and used for the next exchange.
+func getSlackAccessToken(cfg types.Config, refresh types.SlackRefreshConfig) (string, error) {
+	if refresh.ClientID == "" || refresh.ClientSecret == "" || refresh.RefreshToken == "" {
+		return "", fmt.Errorf("slack_refresh requires ClientID, ClientSecret and RefreshToken")
+	}
+	key := slackTokenKey(refresh)
+	tokens := NewBucket(cfg, "slack_token")
+	refreshTokens := NewBucket(cfg, "slack_refresh_token")
+	cached, found, err := tokens.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cached Slack token: %w", err)
+	}
+	if found {
+		return cached, nil
+	}
+
+	refreshToken := refresh.RefreshToken
+	if rotated, found, err := refreshTokens.Get(key); err == nil && found {
+		refreshToken = rotated
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {refresh.ClientID},
+		"client_secret": {refresh.ClientSecret},
+		"refresh_token": {refreshToken},
+	}
+	req, err := http.NewRequest("POST", slackOAuthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := operationDoer(cfg, opAuth).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data := readResponse(cfg, "getSlackAccessToken", resp)
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("slack oauth.v2.access response: %d", resp.StatusCode)
+	}
+	var result slackTokenResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("invalid slack oauth.v2.access response: %w", err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("slack token refresh error: %s", result.Error)
+	}
+	types.DebugLog(cfg, "Slack access token refreshed, expires in %ds", result.ExpiresIn)
+
+	// Cache the token until 10 minutes before it expires
+	expireSeconds := result.ExpiresIn - 600
+	if expireSeconds <= 0 {
+		expireSeconds = 60
+	}
+	if err := tokens.Set(key, result.AccessToken, time.Duration(expireSeconds)*time.Second); err != nil {
+		fmt.Printf("[Slack] Warning: failed to cache token: %v\n", err)
+	}
+	if result.RefreshToken != "" && result.RefreshToken != refreshToken {
+		if err := refreshTokens.Set(key, result.RefreshToken, 0); err != nil {
+			fmt.Printf("[Slack] Warning: failed to cache refresh token: %v\n", err)
+		}
+	}
+	return result.AccessToken, nil
+}
@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// stateNoExpiry keeps values stored without a TTL in caches that require one
+const stateNoExpiry = 100 * 365 * 24 * time.Hour
+
+// Bucket is a namespace of durable provider state, such as cached tokens, channel IDs or
+// issue numbers. Values are kept in Redis when redis_host is set, otherwise in
+// Config.Cache or the global cache, and are encrypted when cache_encryption_key is set.
+// Keys are stored as commonlog_{namespace}:{key}.
+type Bucket struct {
+	cfg       types.Config
+	namespace string
+}
+
+// NewBucket returns the bucket of namespace for the settings in cfg
+func NewBucket(cfg types.Config, namespace string) Bucket {
+	return Bucket{cfg: cfg, namespace: namespace}
+}
+
+func (b Bucket) key(key string) string {
+	return "commonlog_" + b.namespace + ":" + key
+}
+
+// Get returns the value of key, or false when it is missing. Only Redis failures are
+// returned as errors.
+func (b Bucket) Get(key string) (string, bool, error) {
+	fullKey := b.key(key)
+	client, err := getRedisClient(b.cfg)
+	if err != nil {
+		value, found := cacheStore(b.cfg).Get(fullKey)
+		if found {
+			types.DebugLog(b.cfg, "%s value retrieved from memory", b.namespace)
+		}
+		return value, found, nil
+	}
+	value, err := client.Get(context.Background(), fullKey).Result()
+	if err == redis.Nil {
+		redisStats.misses.Add(1)
+		return "", false, nil
+	} else if err != nil {
+		forgetRedisClient(client)
+		return "", false, err
+	}
+	value = countRedisLookup(openCacheValue(b.cfg, fullKey, value))
+	return value, value != "", nil
+}
+
+// Set stores value under key for ttl, or until replaced when ttl is 0
+func (b Bucket) Set(key, value string, ttl time.Duration) error {
+	fullKey := b.key(key)
+	client, err := getRedisClient(b.cfg)
+	if err != nil {
+		if ttl <= 0 {
+			ttl = stateNoExpiry
+		}
+		cacheStore(b.cfg).Set(fullKey, value, ttl)
+		types.DebugLog(b.cfg, "%s value cached in memory", b.namespace)
+		return nil
+	}
+	sealed, err := sealCacheValue(b.cfg, value)
+	if err != nil {
+		return err
+	}
+	redisStats.sets.Add(1)
+	if err := client.Set(context.Background(), fullKey, sealed, ttl).Err(); err != nil {
+		forgetRedisClient(client)
+		return err
+	}
+	return nil
+}
+
+// Delete removes the value of key
+func (b Bucket) Delete(key string) error {
+	fullKey := b.key(key)
+	client, err := getRedisClient(b.cfg)
+	if err != nil {
+		cacheStore(b.cfg).Delete(fullKey)
+		return nil
+	}
+	redisStats.deletes.Add(1)
+	if err := client.Del(context.Background(), fullKey).Err(); err != nil {
+		forgetRedisClient(client)
+		return err
+	}
+	return nil
+}
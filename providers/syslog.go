@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// syslogEnterpriseID is the private enterprise number used for structured data IDs
+// (32473 is reserved by IANA for documentation and examples)
+const syslogEnterpriseID = "32473"
+
+// SyslogProvider implements Provider by emitting RFC 5424 messages to a local or remote syslog daemon
+type SyslogProvider struct{}
+
+// syslogSeverities maps alert levels to RFC 5424 severities
+var syslogSeverities = map[int]int{
+	types.INFO:  6, // informational
+	types.WARN:  4, // warning
+	types.ERROR: 3, // error
+}
+
+func (p *SyslogProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *SyslogProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "SyslogProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	network, _ := cfg.ProviderConfig["syslog_network"].(string)
+	address, _ := cfg.ProviderConfig["syslog_address"].(string)
+
+	conn, err := dialSyslog(cfg, network, address)
+	if err != nil {
+		types.DebugLog(cfg, "sendSyslog: failed to connect: %v", err)
+		return err
+	}
+	defer conn.Close()
+
+	line := p.formatMessage(level, message, attachment, cfg, channel)
+	if network == "tcp" || network == "tls" {
+		// Stream transports use octet-counting framing (RFC 6587)
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	types.DebugLog(cfg, "sendSyslog: writing %d bytes over %s", len(line), conn.RemoteAddr())
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte(line)); err != nil {
+		types.DebugLog(cfg, "sendSyslog: write failed: %v", err)
+		return fmt.Errorf("syslog write failed: %w", err)
+	}
+	types.DebugLog(cfg, "sendSyslog: message written successfully")
+	return nil
+}
+
+// syslogDialTimeout bounds connecting to the daemon when dial_timeout is not set
+const syslogDialTimeout = 5 * time.Second
+
+// dialSyslog connects to the configured daemon; with no network set it uses the local socket.
+// Network daemons are dialed with the dial_timeout, dns_server and ip_preference settings,
+// and TLS connections present the tls_client_cert and trust tls_ca_cert, like HTTP providers.
+func dialSyslog(cfg types.Config, network, address string) (net.Conn, error) {
+	switch network {
+	case "":
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if conn, err := net.DialTimeout("unixgram", path, syslogDialTimeout); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, fmt.Errorf("no local syslog socket found, set syslog_network and syslog_address")
+	case "unix", "unixgram":
+		if address == "" {
+			return nil, fmt.Errorf("syslog_address must be set in provider_config")
+		}
+		return net.DialTimeout(network, address, syslogDialTimeout)
+	case "udp", "tcp", "tls":
+		if address == "" {
+			return nil, fmt.Errorf("syslog_address must be set in provider_config")
+		}
+	default:
+		return nil, fmt.Errorf("unknown syslog_network: %s", network)
+	}
+
+	dial, err := DialContext(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: syslogDialTimeout}).DialContext
+	}
+	timeout := syslogDialTimeout
+	if dialTimeout, _ := cfg.ProviderConfig["dial_timeout"].(time.Duration); dialTimeout > 0 {
+		timeout = dialTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if network != "tls" {
+		return dial(ctx, network, address)
+	}
+
+	tlsConfig, err := ClientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	tlsConfig.InsecureSkipVerify, _ = cfg.ProviderConfig["syslog_tls_insecure"].(bool)
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		tlsConfig.ServerName = host
+	}
+	raw, err := dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(raw, tlsConfig)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// formatMessage renders an RFC 5424 message with alert metadata as structured data
+func (p *SyslogProvider) formatMessage(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) string {
+	facility := 1 // user-level messages
+	if _, ok := cfg.ProviderConfig["syslog_facility"]; ok {
+		facility = configInt(cfg.ProviderConfig["syslog_facility"])
+	}
+	severity, ok := syslogSeverities[level]
+	if !ok {
+		severity = 5 // notice
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	appName, _ := cfg.ProviderConfig["syslog_app_name"].(string)
+	if appName == "" {
+		appName = cfg.ServiceName
+	}
+	if appName == "" {
+		appName = "gocommonlog"
+	}
+
+	event := types.NewAlertEvent(level, message, attachment, cfg, channel)
+
+	sd := fmt.Sprintf("[commonlog@%s level=\"%s\" fingerprint=\"%s\"", syslogEnterpriseID, types.LevelName(event.Level), event.Fingerprint)
+	if event.Service != "" {
+		sd += fmt.Sprintf(" service=\"%s\"", syslogParamValue(event.Service))
+	}
+	if event.Environment != "" {
+		sd += fmt.Sprintf(" environment=\"%s\"", syslogParamValue(event.Environment))
+	}
+	if channel != "" {
+		sd += fmt.Sprintf(" channel=\"%s\"", syslogParamValue(channel))
+	}
+	if event.ID != "" {
+		sd += fmt.Sprintf(" id=\"%s\"", event.ID)
+	}
+	if event.CorrelationID != "" {
+		sd += fmt.Sprintf(" correlation_id=\"%s\"", syslogParamValue(event.CorrelationID))
+	}
+	sd += "]"
+	if len(event.Fields) > 0 {
+		keys := make([]string, 0, len(event.Fields))
+		for k := range event.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sd += fmt.Sprintf("[fields@%s", syslogEnterpriseID)
+		for _, k := range keys {
+			sd += fmt.Sprintf(" %s=\"%s\"", syslogParamName(k), syslogParamValue(event.Fields[k]))
+		}
+		sd += "]"
+	}
+
+	msg := message
+	if event.Trace != "" {
+		msg += "\n" + event.Trace
+	}
+	if event.Attachment != nil && event.Attachment.URL != "" {
+		msg += "\nAttachment: " + event.Attachment.URL
+	}
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s \ufeff%s",
+		facility*8+severity,
+		currentTime(cfg).UTC().Format(time.RFC3339Nano),
+		syslogHeaderField(hostname, 255),
+		syslogHeaderField(appName, 48),
+		os.Getpid(),
+		types.LevelName(event.Level),
+		sd,
+		msg,
+	)
+}
+
+// syslogHeaderField strips spaces and truncates header fields to their RFC 5424 limits
+func syslogHeaderField(value string, max int) string {
+	value = strings.Join(strings.Fields(value), "_")
+	if len(value) > max {
+		value = value[:max]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// syslogParamName sanitizes a structured data parameter name
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// syslogParamValue escapes '"', '\' and ']' in structured data parameter values
+func syslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
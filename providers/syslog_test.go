@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestSyslogUDPMessage(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP listener unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	cfg := types.Config{
+		ServiceName: "billing",
+		Environment: "prod",
+		Fields:      map[string]string{"region": "eu\"west]"},
+		ProviderConfig: map[string]interface{}{
+			"syslog_network":  "udp",
+			"syslog_address":  conn.LocalAddr().String(),
+			"syslog_facility": 16, // local0
+		},
+	}
+	if err := (&SyslogProvider{}).SendToChannel(types.ERROR, "db down", nil, cfg, "ops"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Expected syslog message, got %v", err)
+	}
+	line := string(buf[:n])
+
+	// local0 (16) * 8 + error (3) = 131
+	if !strings.HasPrefix(line, "<131>1 ") {
+		t.Errorf("Expected PRI 131 and version 1, got %q", line)
+	}
+	if !strings.Contains(line, " billing ") || !strings.Contains(line, " ERROR [commonlog@32473 level=\"ERROR\"") {
+		t.Errorf("Expected app name and structured data, got %q", line)
+	}
+	if !strings.Contains(line, `[fields@32473 region="eu\"west\]"]`) {
+		t.Errorf("Expected escaped field values, got %q", line)
+	}
+	if !strings.HasSuffix(line, "\ufeffdb down") {
+		t.Errorf("Expected BOM-prefixed message, got %q", line)
+	}
+}
+
+// selfSignedPEM returns a self-signed certificate for cn and 127.0.0.1 and its key, as PEM
+func selfSignedPEM(t *testing.T, cn string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestSyslogTLSUsesTransportSettings(t *testing.T) {
+	serverCert, serverKey := selfSignedPEM(t, "syslog", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := selfSignedPEM(t, "alerts", x509.ExtKeyUsageClientAuth)
+	pair, _ := tls.X509KeyPair([]byte(serverCert), []byte(serverKey))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(clientCert))
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Skipf("TCP listener unavailable: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		received <- conn.(*tls.Conn).ConnectionState().PeerCertificates[0].Subject.CommonName + " " + string(buf[:n])
+	}()
+
+	cfg := types.Config{ProviderConfig: map[string]interface{}{
+		"syslog_network":  "tls",
+		"syslog_address":  listener.Addr().String(),
+		"tls_client_cert": clientCert,
+		"tls_client_key":  clientKey,
+		"tls_ca_cert":     serverCert,
+	}}
+	if err := (&SyslogProvider{}).SendToChannel(types.ERROR, "db down", nil, cfg, "ops"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	select {
+	case line := <-received:
+		if !strings.HasPrefix(line, "alerts ") || !strings.Contains(line, "<11>1 ") {
+			t.Errorf("Expected the client certificate and the message, got %q", line)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the syslog message over TLS")
+	}
+
+	// The dialer settings apply too: no IPv6 address matches the IPv4 listener
+	cfg.ProviderConfig["ip_preference"] = "ipv6_only"
+	if err := (&SyslogProvider{}).SendToChannel(types.ERROR, "db down", nil, cfg, "ops"); err == nil ||
+		!strings.Contains(err.Error(), "ip_preference") {
+		t.Errorf("Expected ip_preference to be applied, got %v", err)
+	}
+}
+
+func TestOrderIPs(t *testing.T) {
+	v4, v6 := net.IPAddr{IP: net.ParseIP("192.0.2.1")}, net.IPAddr{IP: net.ParseIP("2001:db8::1")}
+	if ordered := orderIPs([]net.IPAddr{v4, v6}, "ipv6"); !ordered[0].IP.Equal(v6.IP) || len(ordered) != 2 {
+		t.Errorf("Expected IPv6 first, got %v", ordered)
+	}
+	if ordered := orderIPs([]net.IPAddr{v6, v4}, "ipv4_only"); len(ordered) != 1 || !ordered[0].IP.Equal(v4.IP) {
+		t.Errorf("Expected IPv4 only, got %v", ordered)
+	}
+}
@@ -0,0 +1,257 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ClientTLSConfig returns the TLS settings of the logger's HTTP client and the syslog
+// provider. It presents the tls_client_cert and tls_client_key certificate to servers
+// that request one, and trusts tls_ca_cert in addition to the system roots. Each setting
+// is PEM content or the path of a PEM file; certificate files are reloaded when they
+// change, so rotated certificates are picked up without a restart. It returns nil when
+// no TLS setting is set.
+func ClientTLSConfig(cfg types.Config) (*tls.Config, error) {
+	certSetting, _ := cfg.ProviderConfig["tls_client_cert"].(string)
+	keySetting, _ := cfg.ProviderConfig["tls_client_key"].(string)
+	caSetting, _ := cfg.ProviderConfig["tls_ca_cert"].(string)
+	if certSetting == "" && keySetting == "" && caSetting == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certSetting != "" || keySetting != "" {
+		if certSetting == "" || keySetting == "" {
+			return nil, fmt.Errorf("tls_client_cert and tls_client_key must be set together")
+		}
+		cert := &clientCertificate{certSetting: certSetting, keySetting: keySetting}
+		if _, err := cert.load(); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert.load()
+		}
+	}
+	if caSetting != "" {
+		data, _, err := pemSetting(caSetting)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls_ca_cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("invalid tls_ca_cert: no PEM certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// pemSetting returns the PEM content of a setting holding PEM content or a file path, and
+// the file's modification time when it is a path
+func pemSetting(setting string) ([]byte, time.Time, error) {
+	if strings.HasPrefix(strings.TrimSpace(setting), "-----BEGIN") {
+		return []byte(setting), time.Time{}, nil
+	}
+	info, err := os.Stat(setting)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(setting)
+	return data, info.ModTime(), err
+}
+
+// clientCertificate is the client certificate presented in TLS handshakes, reloaded when
+// its files are modified
+type clientCertificate struct {
+	certSetting, keySetting string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+// load returns the certificate, reloading it when a file changed. A certificate that
+// fails to reload is logged and the previous one kept, so a half-written rotation does
+// not break sends.
+func (c *clientCertificate) load() (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	certPEM, certModified, err := pemSetting(c.certSetting)
+	if err != nil {
+		return c.keep(fmt.Errorf("invalid tls_client_cert: %w", err))
+	}
+	keyPEM, keyModified, err := pemSetting(c.keySetting)
+	if err != nil {
+		return c.keep(fmt.Errorf("invalid tls_client_key: %w", err))
+	}
+	if keyModified.After(certModified) {
+		certModified = keyModified
+	}
+	if c.cert != nil && !certModified.After(c.modified) {
+		return c.cert, nil
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return c.keep(fmt.Errorf("invalid TLS client certificate: %w", err))
+	}
+	if c.cert != nil {
+		log.Printf("[INFO] Reloaded the TLS client certificate")
+	}
+	c.cert, c.modified = &cert, certModified
+	return c.cert, nil
+}
+
+// keep returns the current certificate after a failed reload, or err before the first load
+func (c *clientCertificate) keep(err error) (*tls.Certificate, error) {
+	if c.cert == nil {
+		return nil, err
+	}
+	log.Printf("[WARN] %v; keeping the previous certificate", err)
+	return c.cert, nil
+}
+
+// IP preferences of the ip_preference setting
+const (
+	ipDualStack = "dual"      // Addresses in resolver order, racing IPv4 and IPv6 (the default)
+	ipPrefer6   = "ipv6"      // IPv6 addresses first, then IPv4
+	ipPrefer4   = "ipv4"      // IPv4 addresses first, then IPv6
+	ipOnly6     = "ipv6_only" // IPv6 addresses only
+	ipOnly4     = "ipv4_only" // IPv4 addresses only
+)
+
+// DialContext dials the connections of the logger's HTTP client and the syslog provider
+// with dial_timeout, resolving hosts with the DNS
+// servers in dns_server and ordering their addresses by ip_preference. It returns nil
+// when none of them is set.
+func DialContext(cfg types.Config) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	timeout, _ := cfg.ProviderConfig["dial_timeout"].(time.Duration)
+	preference, _ := cfg.ProviderConfig["ip_preference"].(string)
+	var servers []string
+	switch value := cfg.ProviderConfig["dns_server"].(type) {
+	case string:
+		servers = strings.Split(value, ",")
+	case []string:
+		servers = value
+	}
+	if timeout <= 0 && preference == "" && len(servers) == 0 {
+		return nil, nil
+	}
+
+	if timeout <= 0 {
+		timeout = 30 * time.Second // http.DefaultTransport's
+	}
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	resolver := net.DefaultResolver
+	if len(servers) > 0 {
+		addresses := make([]string, 0, len(servers))
+		for _, server := range servers {
+			server = strings.TrimSpace(server)
+			if server == "" {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(server); err != nil {
+				server = net.JoinHostPort(server, "53")
+			}
+			if host, _, _ := net.SplitHostPort(server); net.ParseIP(host) == nil {
+				return nil, fmt.Errorf("invalid dns_server %q: want an IP address such as 10.0.0.2 or [2001:db8::53]:53", server)
+			}
+			addresses = append(addresses, server)
+		}
+		resolver = pinnedResolver(addresses, dialer)
+		dialer.Resolver = resolver
+	}
+
+	switch preference {
+	case "", ipDualStack:
+		return dialer.DialContext, nil
+	case ipPrefer6, ipPrefer4, ipOnly6, ipOnly4:
+	default:
+		return nil, fmt.Errorf("invalid ip_preference %q: want %s, %s, %s, %s or %s", preference, ipDualStack, ipPrefer6, ipPrefer4, ipOnly6, ipOnly4)
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		ips = orderIPs(ips, preference)
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("no address for %s matches ip_preference %s", host, preference)
+		}
+		// Addresses are tried in order, each for up to the dial timeout
+		var firstErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, firstErr
+	}, nil
+}
+
+// pinnedResolver resolves hosts with the given DNS servers only, trying them in order
+func pinnedResolver(servers []string, dialer *net.Dialer) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var firstErr error
+			for _, server := range servers {
+				conn, err := dialer.DialContext(ctx, network, server)
+				if err == nil {
+					return conn, nil
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			return nil, firstErr
+		},
+	}
+}
+
+// orderIPs returns the addresses of the family preferred by preference first, keeping
+// the resolver's order within each family, and drops the other family for the _only
+// preferences
+func orderIPs(ips []net.IPAddr, preference string) []net.IPAddr {
+	var v4, v6 []net.IPAddr
+	for _, ip := range ips {
+		if ip.IP.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	switch preference {
+	case ipOnly6:
+		return v6
+	case ipOnly4:
+		return v4
+	case ipPrefer4:
+		return append(v4, v6...)
+	default:
+		return append(v6, v4...)
+	}
+}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// twilioAPIBase is the Twilio REST API root; a variable so tests can point it elsewhere
+var twilioAPIBase = "https://api.twilio.com"
+
+// defaultSMSLength keeps alerts within a single SMS segment
+const defaultSMSLength = 160
+
+// TwilioProvider implements Provider by sending ERROR alerts as SMS through Twilio
+type TwilioProvider struct{}
+
+func (p *TwilioProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *TwilioProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "TwilioProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	// SMS is reserved for critical pages
+	if level != types.ERROR {
+		types.DebugLog(cfg, "Twilio only pages ERROR alerts, skipping level: %d", level)
+		return fmt.Errorf("twilio only pages ERROR alerts: %w", types.ErrSkipped)
+	}
+
+	accountSID, _ := cfg.ProviderConfig["twilio_account_sid"].(string)
+	authToken, _ := cfg.ProviderConfig["twilio_auth_token"].(string)
+	from, _ := cfg.ProviderConfig["twilio_from"].(string)
+	if accountSID == "" || authToken == "" || from == "" {
+		err := fmt.Errorf("twilio_account_sid, twilio_auth_token and twilio_from must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	recipients := p.recipients(cfg, channel)
+	if len(recipients) == 0 {
+		err := fmt.Errorf("no Twilio recipients configured for channel '%s'", channel)
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	maxLength := configInt(cfg.ProviderConfig["twilio_max_length"])
+	if maxLength <= 0 {
+		maxLength = defaultSMSLength
+	}
+	body := p.formatMessage(message, cfg, maxLength)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", twilioAPIBase, accountSID)
+	var paged []string
+	for _, to := range recipients {
+		if err := p.sendSMS(cfg, endpoint, accountSID, authToken, from, to, body); err != nil {
+			return twilioPartialError(err, paged)
+		}
+		paged = append(paged, to)
+	}
+	types.DebugLog(cfg, "sendTwilio: SMS sent successfully to %d recipients", len(recipients))
+	return nil
+}
+
+// sendSMS sends body to one recipient
+func (p *TwilioProvider) sendSMS(cfg types.Config, endpoint, accountSID, authToken, from, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", from)
+	form.Set("Body", body)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(accountSID, authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	types.DebugLog(cfg, "sendTwilio: sending SMS (%d chars) to recipient ending in %s", len(body), lastDigits(to))
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendTwilio: HTTP request failed: %v", err)
+		return err
+	}
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	resp.Body.Close()
+	types.DebugLog(cfg, "sendTwilio: response status: %d, body length: %d", resp.StatusCode, respData.Len())
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		err := fmt.Errorf("twilio messages response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendTwilio: error response: %v, body: %s", err, respData.String())
+		return err
+	}
+	return nil
+}
+
+// twilioPartialError notes the recipients paged before err, so a retry or an operator
+// knows who already got the SMS. Numbers are shortened to their last digits.
+func twilioPartialError(err error, paged []string) error {
+	if len(paged) == 0 {
+		return err
+	}
+	endings := make([]string, len(paged))
+	for i, to := range paged {
+		endings[i] = lastDigits(to)
+	}
+	return fmt.Errorf("%w (already paged recipients ending in %s)", err, strings.Join(endings, ", "))
+}
+
+// recipients returns the numbers mapped to the channel, falling back to twilio_to
+func (p *TwilioProvider) recipients(cfg types.Config, channel string) []string {
+	if mapping, ok := cfg.ProviderConfig["twilio_channel_numbers"].(map[string][]string); ok {
+		if numbers, ok := mapping[channel]; ok && len(numbers) > 0 {
+			return numbers
+		}
+	}
+	return configStringSlice(cfg.ProviderConfig["twilio_to"])
+}
+
+// formatMessage renders a plaintext version of the alert, truncated to maxLength characters
+func (p *TwilioProvider) formatMessage(message string, cfg types.Config, maxLength int) string {
+	formatted := ""
+	if cfg.ServiceName != "" && cfg.Environment != "" {
+		formatted = fmt.Sprintf("[%s - %s] ", cfg.ServiceName, cfg.Environment)
+	} else if cfg.ServiceName != "" {
+		formatted = fmt.Sprintf("[%s] ", cfg.ServiceName)
+	} else if cfg.Environment != "" {
+		formatted = fmt.Sprintf("[%s] ", cfg.Environment)
+	}
+	formatted += strings.Join(strings.Fields(message), " ")
+
+	runes := []rune(formatted)
+	if len(runes) > maxLength {
+		if maxLength > 3 {
+			return string(runes[:maxLength-3]) + "..."
+		}
+		return string(runes[:maxLength])
+	}
+	return formatted
+}
+
+// lastDigits returns the last four characters of a phone number for debug logging
+func lastDigits(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
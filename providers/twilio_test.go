@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+func TestTwilioFormatMessageTruncates(t *testing.T) {
+	p := &TwilioProvider{}
+	cfg := types.Config{ServiceName: "billing", Environment: "prod"}
+	body := p.formatMessage("payment\nfailed "+strings.Repeat("x", 200), cfg, 40)
+	if len(body) != 40 || !strings.HasSuffix(body, "...") {
+		t.Errorf("Expected 40 char truncated body, got %q (%d)", body, len(body))
+	}
+	if !strings.HasPrefix(body, "[billing - prod] payment failed") {
+		t.Errorf("Expected plaintext header and collapsed whitespace, got %q", body)
+	}
+}
+
+func TestTwilioSendsPerChannelNumbers(t *testing.T) {
+	var recipients []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if user, pass, ok := r.BasicAuth(); !ok || user != "AC123" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		recipients = append(recipients, r.Form.Get("To"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	original := twilioAPIBase
+	twilioAPIBase = server.URL
+	defer func() { twilioAPIBase = original }()
+
+	cfg := types.Config{
+		ProviderConfig: map[string]interface{}{
+			"twilio_account_sid": "AC123",
+			"twilio_auth_token":  "secret",
+			"twilio_from":        "+15550000000",
+			"twilio_to":          "+15551111111",
+			"twilio_channel_numbers": map[string][]string{
+				"payments": {"+15552222222", "+15553333333"},
+			},
+		},
+	}
+	p := &TwilioProvider{}
+	if err := p.SendToChannel(types.ERROR, "boom", nil, cfg, "payments"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(recipients) != 2 || recipients[0] != "+15552222222" {
+		t.Errorf("Expected channel numbers, got %v", recipients)
+	}
+
+	// WARN alerts are not paged
+	recipients = nil
+	if err := p.SendToChannel(types.WARN, "boom", nil, cfg, "payments"); !errors.Is(err, types.ErrSkipped) || len(recipients) != 0 {
+		t.Errorf("Expected WARN to be skipped, got err %v recipients %v", err, recipients)
+	}
+}
+
+func TestTwilioPartialFailureNamesPagedNumbers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("To") == "+15553333333" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	original := twilioAPIBase
+	twilioAPIBase = server.URL
+	defer func() { twilioAPIBase = original }()
+
+	cfg := types.Config{
+		ProviderConfig: map[string]interface{}{
+			"twilio_account_sid": "AC123",
+			"twilio_auth_token":  "secret",
+			"twilio_from":        "+15550000000",
+			"twilio_to":          []string{"+15551111111", "+15552222222", "+15553333333", "+15554444444"},
+		},
+	}
+	err := (&TwilioProvider{}).SendToChannel(types.ERROR, "boom", nil, cfg, "")
+	if err == nil || err.Error() != "twilio messages response: 400 (already paged recipients ending in 1111, 2222)" {
+		t.Errorf("Expected the error to name the paged recipients, got %v", err)
+	}
+}
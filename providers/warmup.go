@@ -0,0 +1,77 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// larkAPIURL is the host of the Lark Open API, warmed for the webclient and SDK methods
+const larkAPIURL = "https://open.larksuite.com/open-apis/"
+
+// warmConnection opens a keep-alive connection to the host of rawURL with a HEAD request
+// to its root, so the next request to the host skips the DNS lookup and TLS handshake.
+// Any response will do, since only the connection matters.
+func warmConnection(ctx context.Context, cfg types.Config, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return fmt.Errorf("cannot warm up a connection to an invalid URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target.Scheme+"://"+target.Host+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		return err
+	}
+	// The connection is only reused once the body is read and closed
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	types.DebugLog(cfg, "Warmed up connection to %s", target.Host)
+	return nil
+}
+
+// Warmup fetches the access token when token rotation is enabled and opens a connection
+// to the Slack API, or to the webhook's host for the webhook method
+func (p *SlackProvider) Warmup(ctx context.Context, cfg types.Config) error {
+	if cfg.SendMethod == types.MethodWebhook {
+		webhookURL, err := settingsOf(cfg).RequireString("token", "webhook URL for Slack webhook method")
+		if err != nil {
+			return err
+		}
+		return warmConnection(ctx, cfg, webhookURL)
+	}
+	if _, err := slackToken(cfg); err != nil {
+		return err
+	}
+	return warmConnection(ctx, cfg, slackAuthTestURL)
+}
+
+// Warmup fetches and caches the tenant access token and opens a connection to the Lark
+// API, or to the webhook's host for the webhook method
+func (p *LarkProvider) Warmup(ctx context.Context, cfg types.Config) error {
+	if cfg.SendMethod == types.MethodWebhook {
+		if cfg.Token == "" {
+			return fmt.Errorf("webhook URL is required for Lark webhook method")
+		}
+		return warmConnection(ctx, cfg, cfg.Token)
+	}
+	if _, err := larkTenantToken(cfg); err != nil {
+		return err
+	}
+	return warmConnection(ctx, cfg, larkAPIURL)
+}
+
+// Warmup opens a connection to the webhook's host
+func (p *GenericWebhookProvider) Warmup(ctx context.Context, cfg types.Config) error {
+	webhookURL, _ := cfg.ProviderConfig["token"].(string)
+	if webhookURL == "" {
+		return fmt.Errorf("webhook URL is required for generic webhook provider")
+	}
+	return warmConnection(ctx, cfg, webhookURL)
+}
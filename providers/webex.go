@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// webexMessagesURL is the Webex messages endpoint; a variable so tests can point it elsewhere
+var webexMessagesURL = "https://webexapis.com/v1/messages"
+
+// WebexProvider implements Provider for Webex Teams using a bot token
+type WebexProvider struct{}
+
+func (p *WebexProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *WebexProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "WebexProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	// Use webex_token if available, otherwise fall back to token
+	token, _ := cfg.ProviderConfig["token"].(string)
+	if webexToken, ok := cfg.ProviderConfig["webex_token"].(string); ok && webexToken != "" {
+		token = webexToken
+	}
+	if token == "" {
+		err := fmt.Errorf("bot token is required for Webex provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	// The channel is the Webex room ID
+	roomID := channel
+	if roomID == "" {
+		roomID, _ = cfg.ProviderConfig["webex_room_id"].(string)
+	}
+	if roomID == "" {
+		err := fmt.Errorf("room ID is required for Webex provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	markdown := p.formatMessage(message, attachment, cfg)
+
+	var body bytes.Buffer
+	contentType := "application/json"
+	if attachment != nil && attachment.Content != "" {
+		// Inline content is uploaded as a file alongside the message
+		filename := attachment.FileName
+		if filename == "" {
+			filename = "attachment.txt"
+		}
+		writer := multipart.NewWriter(&body)
+		writer.WriteField("roomId", roomID)
+		writer.WriteField("markdown", markdown)
+		part, err := writer.CreateFormFile("files", filename)
+		if err != nil {
+			return err
+		}
+		part.Write([]byte(attachment.Content))
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		contentType = writer.FormDataContentType()
+		types.DebugLog(cfg, "sendWebex: uploading inline attachment %s (%d bytes)", filename, len(attachment.Content))
+	} else {
+		payload := map[string]interface{}{
+			"roomId":   roomID,
+			"markdown": markdown,
+		}
+		if attachment != nil && attachment.URL != "" {
+			payload["files"] = []string{attachment.URL}
+		}
+		data, _ := json.Marshal(payload)
+		body.Write(data)
+	}
+	types.DebugLog(cfg, "sendWebex: sending to room: %s, payload size: %d bytes", roomID, body.Len())
+
+	req, err := http.NewRequest("POST", webexMessagesURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendWebex: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendWebex: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("webex messages response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendWebex: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendWebex: message sent successfully")
+	return nil
+}
+
+// formatMessage formats the alert as Webex markdown; inline attachment content
+// is uploaded as a file rather than rendered
+func (p *WebexProvider) formatMessage(message string, attachment *types.Attachment, cfg types.Config) string {
+	formatted := ""
+
+	// Add service and environment header
+	if cfg.ServiceName != "" && cfg.Environment != "" {
+		formatted += fmt.Sprintf("**[%s - %s]**\n\n", cfg.ServiceName, cfg.Environment)
+	} else if cfg.ServiceName != "" {
+		formatted += fmt.Sprintf("**[%s]**\n\n", cfg.ServiceName)
+	} else if cfg.Environment != "" {
+		formatted += fmt.Sprintf("**[%s]**\n\n", cfg.Environment)
+	}
+	if meta := alertMetaLine(cfg); meta != "" {
+		formatted += "_" + meta + "_\n\n"
+	}
+
+	formatted += message
+
+	if attachment != nil && attachment.Content != "" && attachment.URL != "" {
+		// Only one file can be attached, so link the URL when content is uploaded
+		formatted += fmt.Sprintf("\n\n**%s:** %s", types.Localize(cfg, types.TextAttachment), attachment.URL)
+	}
+	formatted += markdownExtras(cfg)
+	if idLine := alertIDLine(cfg); idLine != "" {
+		formatted += "\n\n_" + idLine + "_"
+	}
+	if footer := alertFooter(cfg); footer != "" {
+		formatted += "\n_" + footer + "_"
+	}
+
+	return formatted
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ZulipProvider implements Provider for Zulip stream messages using a bot API key
+type ZulipProvider struct{}
+
+func (p *ZulipProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *ZulipProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	types.DebugLog(cfg, "ZulipProvider.SendToChannel called with level: %d, channel: %s", level, channel)
+
+	site, _ := cfg.ProviderConfig["zulip_site"].(string)
+	email, _ := cfg.ProviderConfig["zulip_email"].(string)
+	apiKey, _ := cfg.ProviderConfig["zulip_api_key"].(string)
+	if site == "" || email == "" || apiKey == "" {
+		err := fmt.Errorf("zulip_site, zulip_email and zulip_api_key must be set in provider_config")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	// The channel is the stream, optionally suffixed with "/topic"
+	stream, topic := channel, ""
+	if idx := strings.Index(channel, "/"); idx >= 0 {
+		stream, topic = channel[:idx], channel[idx+1:]
+	}
+	if topic == "" {
+		topic, _ = cfg.ProviderConfig["zulip_topic"].(string)
+	}
+	if topic == "" {
+		topic = defaultTopic(cfg)
+	}
+	if stream == "" {
+		err := fmt.Errorf("stream is required for Zulip provider")
+		types.DebugLog(cfg, "Error: %v", err)
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("type", "stream")
+	form.Set("to", stream)
+	form.Set("topic", topic)
+	form.Set("content", markdownMessage(level, message, attachment, cfg))
+
+	req, err := http.NewRequest("POST", strings.TrimRight(site, "/")+"/api/v1/messages", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(email, apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	types.DebugLog(cfg, "sendZulip: sending to stream: %s, topic: %s", stream, topic)
+	resp, err := httpDoer(cfg).Do(req)
+	if err != nil {
+		types.DebugLog(cfg, "sendZulip: HTTP request failed: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Log response data
+	respData := new(bytes.Buffer)
+	respData.ReadFrom(resp.Body)
+	types.DebugLog(cfg, "sendZulip: response status: %d, body length: %d, body: %s", resp.StatusCode, respData.Len(), respData.String())
+
+	if resp.StatusCode != 200 {
+		err := fmt.Errorf("zulip messages response: %d", resp.StatusCode)
+		types.DebugLog(cfg, "sendZulip: error response: %v", err)
+		return err
+	}
+	types.DebugLog(cfg, "sendZulip: message sent successfully")
+	return nil
+}
+
+// defaultTopic names the Zulip topic after the service, falling back to "alerts"
+func defaultTopic(cfg types.Config) string {
+	if cfg.ServiceName != "" {
+		return cfg.ServiceName
+	}
+	return "alerts"
+}
+
+// markdownMessage formats an alert as CommonMark, shared by providers that render standard markdown
+func markdownMessage(level int, message string, attachment *types.Attachment, cfg types.Config) string {
+	formatted := ""
+
+	// Add service and environment header
+	if cfg.ServiceName != "" && cfg.Environment != "" {
+		formatted += fmt.Sprintf("**[%s] %s - %s**\n", types.LevelName(level), cfg.ServiceName, cfg.Environment)
+	} else if cfg.ServiceName != "" {
+		formatted += fmt.Sprintf("**[%s] %s**\n", types.LevelName(level), cfg.ServiceName)
+	} else if cfg.Environment != "" {
+		formatted += fmt.Sprintf("**[%s] %s**\n", types.LevelName(level), cfg.Environment)
+	} else {
+		formatted += fmt.Sprintf("**[%s]**\n", types.LevelName(level))
+	}
+	if meta := alertMetaLine(cfg); meta != "" {
+		formatted += "_" + meta + "_\n"
+	}
+
+	formatted += message
+
+	if attachment != nil {
+		if attachment.Content != "" {
+			// Inline content - show as code block
+			filename := attachment.FileName
+			if filename == "" {
+				filename = types.Localize(cfg, types.TextTraceLogs)
+			}
+			formatted += fmt.Sprintf("\n\n**%s:**\n```\n%s\n```", filename, attachment.Content)
+		}
+		if attachment.URL != "" {
+			// External URL attachment
+			formatted += fmt.Sprintf("\n\n**%s:** %s", types.Localize(cfg, types.TextAttachment), attachment.URL)
+		}
+	}
+	formatted += markdownExtras(cfg)
+	if idLine := alertIDLine(cfg); idLine != "" {
+		formatted += "\n\n_" + idLine + "_"
+	}
+	if footer := alertFooter(cfg); footer != "" {
+		formatted += "\n_" + footer + "_"
+	}
+
+	return formatted
+}
@@ -0,0 +1,44 @@
+// Package asynqalert hooks queuealert into asynq servers. It is a separate module so the
+// gocommonlog module does not depend on asynq.
+package asynqalert
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alvianhanif/gocommonlog/queuealert"
+	"github.com/hibiken/asynq"
+)
+
+// ErrorHandler returns an asynq error handler sending the alerts of a:
+//
+//	srv := asynq.NewServer(redis, asynq.Config{
+//	    ErrorHandler: asynqalert.ErrorHandler(queuealert.New(logger)),
+//	})
+//
+// Tasks failing with asynq.SkipRetry have no retries left.
+func ErrorHandler(a *queuealert.Alerter) asynq.ErrorHandler {
+	return asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+		a.Failed(Job(ctx, task, err), err, "")
+	})
+}
+
+// Job describes task from the context asynq passes to handlers
+func Job(ctx context.Context, task *asynq.Task, err error) queuealert.Job {
+	retry, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	queue, _ := asynq.GetQueueName(ctx)
+	id, _ := asynq.GetTaskID(ctx)
+	job := queuealert.Job{
+		Queue:       queue,
+		Type:        task.Type(),
+		ID:          id,
+		Payload:     task.Payload(),
+		Retry:       retry,
+		RetriesLeft: maxRetry - retry,
+	}
+	if errors.Is(err, asynq.SkipRetry) {
+		job.RetriesLeft = 0
+	}
+	return job
+}
@@ -0,0 +1,47 @@
+package asynqalert
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/queuealert"
+	"github.com/alvianhanif/gocommonlog/types"
+	"github.com/hibiken/asynq"
+)
+
+type captureProvider struct {
+	messages []string
+	fields   []map[string]string
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.messages = append(p.messages, message)
+	p.fields = append(p.fields, cfg.Fields)
+	return nil
+}
+
+func newAlerter(name string) (*queuealert.Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return queuealert.New(logger), capture
+}
+
+func TestErrorHandler(t *testing.T) {
+	alerter, capture := newAlerter("asynq-capture")
+	task := asynq.NewTask("email:welcome", []byte(`{"user":42}`))
+
+	ErrorHandler(alerter).HandleError(context.Background(), task, errors.New("smtp timeout"))
+	if len(capture.messages) != 1 || capture.messages[0] != "Job email:welcome failed permanently: smtp timeout" {
+		t.Fatalf("Unexpected alerts %q", capture.messages)
+	}
+	if capture.fields[0]["job.payload_digest"] != queuealert.PayloadDigest([]byte(`{"user":42}`)) {
+		t.Errorf("Expected the payload digest, got %v", capture.fields[0])
+	}
+}
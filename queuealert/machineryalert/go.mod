@@ -0,0 +1,21 @@
+module github.com/alvianhanif/gocommonlog/queuealert/machineryalert
+
+go 1.20
+
+require (
+	github.com/alvianhanif/gocommonlog v0.0.0
+	github.com/RichardKnop/machinery v1.10.8
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-redis/redis/v8 v8.11.0 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/segmentio/kafka-go v0.4.47 // indirect
+	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
+)
+
+replace github.com/alvianhanif/gocommonlog => ../..
@@ -0,0 +1,90 @@
+// Package machineryalert hooks queuealert into machinery workers. It is a separate module
+// so the gocommonlog module does not depend on machinery.
+//
+// A machinery worker's error handler only receives the error, so tasks are wrapped
+// instead, when they are registered.
+package machineryalert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	"github.com/alvianhanif/gocommonlog/queuealert"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Task wraps the task function fn, registered as name, so its failures send the alerts
+// of a. A panic in fn is returned as an error, and tasks.ErrRetryTaskLater errors are not
+// alerted on. Tasks taking a context.Context as their first argument report their UUID,
+// queue and retries left:
+//
+//	server.RegisterTask("send_email", machineryalert.Task(alerter, "send_email", sendEmail))
+//
+// Task panics when fn is not a function whose last result is an error, as machinery
+// requires.
+func Task(a *queuealert.Alerter, name string, fn interface{}) interface{} {
+	value := reflect.ValueOf(fn)
+	fnType := value.Type()
+	if fnType.Kind() != reflect.Func || fnType.NumOut() == 0 || fnType.Out(fnType.NumOut()-1) != errorType {
+		panic(fmt.Sprintf("machineryalert: task %s must be a function returning an error", name))
+	}
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		err := a.Run(job(name, fnType, args), func() error {
+			if fnType.IsVariadic() {
+				results = value.CallSlice(args)
+			} else {
+				results = value.Call(args)
+			}
+			last := results[len(results)-1]
+			if last.IsNil() {
+				return nil
+			}
+			err := last.Interface().(error)
+			var retryLater tasks.ErrRetryTaskLater
+			if errors.As(err, &retryLater) {
+				// Not a failure: machinery runs the task again later
+				return nil
+			}
+			return err
+		})
+		if results == nil {
+			// fn panicked
+			results = make([]reflect.Value, fnType.NumOut())
+			for i := range results {
+				results[i] = reflect.Zero(fnType.Out(i))
+			}
+			results[len(results)-1] = reflect.ValueOf(&err).Elem()
+		}
+		return results
+	}).Interface()
+}
+
+// job describes the task from the signature machinery puts in its context
+func job(name string, fnType reflect.Type, args []reflect.Value) queuealert.Job {
+	j := queuealert.Job{Type: name}
+	if fnType.NumIn() == 0 || fnType.In(0) != contextType {
+		return j
+	}
+	ctx, _ := args[0].Interface().(context.Context)
+	if ctx == nil {
+		return j
+	}
+	signature := tasks.SignatureFromContext(ctx)
+	if signature == nil {
+		return j
+	}
+	j.Queue, j.ID, j.RetriesLeft = signature.RoutingKey, signature.UUID, signature.RetryCount
+	if payload, err := json.Marshal(signature.Args); err == nil {
+		j.Payload = payload
+	}
+	return j
+}
@@ -0,0 +1,64 @@
+package machineryalert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RichardKnop/machinery/v1/tasks"
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/queuealert"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type captureProvider struct {
+	messages []string
+	fields   []map[string]string
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.messages = append(p.messages, message)
+	p.fields = append(p.fields, cfg.Fields)
+	return nil
+}
+
+func newAlerter(name string) (*queuealert.Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return queuealert.New(logger), capture
+}
+
+func TestTask(t *testing.T) {
+	alerter, capture := newAlerter("machinery-capture")
+	sendEmail := Task(alerter, "send_email", func(to string, fail bool) (string, error) {
+		if fail {
+			return "", errors.New("smtp timeout")
+		}
+		if to == "" {
+			panic("no recipient")
+		}
+		return "sent", nil
+	}).(func(string, bool) (string, error))
+
+	if result, err := sendEmail("ops@example.com", false); result != "sent" || err != nil || len(capture.messages) != 0 {
+		t.Fatalf("Expected the task result without an alert, got %q, %v, %q", result, err, capture.messages)
+	}
+	if _, err := sendEmail("ops@example.com", true); err == nil || len(capture.messages) != 1 || capture.messages[0] != "Job send_email failed permanently: smtp timeout" {
+		t.Errorf("Expected the task error and an alert, got %v and %q", err, capture.messages)
+	}
+	if _, err := sendEmail("", false); err == nil || len(capture.messages) != 2 || !strings.Contains(capture.messages[1], "panic: no recipient") {
+		t.Errorf("Expected the panic as error and an alert, got %v and %q", err, capture.messages)
+	}
+
+	retryLater := Task(alerter, "poll", func() error {
+		return tasks.NewErrRetryTaskLater("not ready", 0)
+	}).(func() error)
+	if err := retryLater(); err == nil || len(capture.messages) != 2 {
+		t.Errorf("Expected the retry-later error without an alert, got %v and %q", err, capture.messages)
+	}
+}
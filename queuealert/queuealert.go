@@ -0,0 +1,125 @@
+// Package queuealert alerts on failed background jobs with their type, payload digest,
+// retry count and error trace. The asynqalert, machineryalert and sqsalert modules hook
+// it into the error handling of asynq, machinery and SQS consumers.
+package queuealert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Job describes the job a failure is about
+type Job struct {
+	Queue       string
+	Type        string // Task type or name
+	ID          string
+	Payload     []byte // Sent only as a digest, as it may hold personal data
+	Retry       int    // Retries made before this attempt, 0 when unknown
+	RetriesLeft int    // Retries the queue will still make; the failure is final at 0
+}
+
+// Option customizes an Alerter
+type Option func(*Alerter)
+
+// WithEveryFailure alerts on every failed attempt instead of only once a job has no
+// retries left
+func WithEveryFailure() Option {
+	return func(a *Alerter) { a.everyFailure = true }
+}
+
+// WithSendOptions sets the options of the alerts, such as their channel
+func WithSendOptions(opts types.SendOptions) Option {
+	return func(a *Alerter) { a.send = opts }
+}
+
+// Alerter sends the alerts of the queue adapters through a Logger
+type Alerter struct {
+	logger       *gocommonlog.Logger
+	everyFailure bool
+	send         types.SendOptions
+}
+
+// New creates an Alerter sending through logger
+func New(logger *gocommonlog.Logger, options ...Option) *Alerter {
+	a := &Alerter{logger: logger}
+	for _, option := range options {
+		option(a)
+	}
+	return a
+}
+
+// Run calls fn, turning a panic into an error, and alerts when it fails. It returns fn's
+// error, for adapters wrapping job handlers.
+func (a *Alerter) Run(job Job, fn func() error) error {
+	trace, err := runRecovered(a.logger, fn)
+	if err != nil {
+		a.Failed(job, err, trace)
+	}
+	return err
+}
+
+// Failed alerts on a failed attempt of job: an ERROR alert once it has no retries left,
+// and a WARN alert for attempts that will be retried with WithEveryFailure. trace is sent
+// as the alert's trace; when empty, errors formatting a stack with %+v, such as those of
+// github.com/pkg/errors, provide it.
+func (a *Alerter) Failed(job Job, err error, trace string) {
+	final := job.RetriesLeft <= 0
+	if !final && !a.everyFailure {
+		types.DebugLog(a.logger.Config(), "queuealert: job %s %s failed with %d retries left: %v", job.Type, job.ID, job.RetriesLeft, err)
+		return
+	}
+	if trace == "" {
+		if detailed := fmt.Sprintf("%+v", err); detailed != err.Error() {
+			trace = detailed
+		}
+	}
+
+	level, message := types.ERROR, fmt.Sprintf("Job %s failed permanently: %v", job.Type, err)
+	if !final {
+		level, message = types.WARN, fmt.Sprintf("Job %s failed, %d retries left: %v", job.Type, job.RetriesLeft, err)
+	}
+	opts := a.send
+	opts.Trace = trace
+	opts.Fields = make(map[string]string, len(a.send.Fields)+6)
+	for key, value := range a.send.Fields {
+		opts.Fields[key] = value
+	}
+	opts.Fields["job.type"] = job.Type
+	opts.Fields["job.retries_left"] = strconv.Itoa(job.RetriesLeft)
+	opts.Fields["job.payload_digest"] = PayloadDigest(job.Payload)
+	if job.Queue != "" {
+		opts.Fields["job.queue"] = job.Queue
+	}
+	if job.ID != "" {
+		opts.Fields["job.id"] = job.ID
+	}
+	if job.Retry > 0 {
+		opts.Fields["job.retry"] = strconv.Itoa(job.Retry)
+	}
+	if _, sendErr := a.logger.SendWithOptions(level, message, opts); sendErr != nil {
+		log.Printf("[ERROR] queuealert: failed to send alert for job %s %s: %v", job.Type, job.ID, sendErr)
+	}
+}
+
+// PayloadDigest identifies a payload without revealing it, as the start of its SHA-256
+// and its size, e.g. "sha256:9f86d081884c7d65 (42 bytes)"
+func PayloadDigest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf("sha256:%s (%d bytes)", hex.EncodeToString(sum[:8]), len(payload))
+}
+
+// runRecovered calls fn, turning a panic into an error and returning its stack
+func runRecovered(logger *gocommonlog.Logger, fn func() error) (trace string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			trace, err = logger.Stack(0), fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return "", fn()
+}
@@ -0,0 +1,107 @@
+package queuealert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type sentAlert struct {
+	level      int
+	message    string
+	attachment *types.Attachment
+	cfg        types.Config
+}
+
+type captureProvider struct {
+	alerts []sentAlert
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.alerts = append(p.alerts, sentAlert{level, message, attachment, cfg})
+	return nil
+}
+
+func newTestAlerter(name string, options ...Option) (*Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return New(logger, options...), capture
+}
+
+// stackError formats a stack with %+v like github.com/pkg/errors
+type stackError struct{}
+
+func (stackError) Error() string { return "smtp timeout" }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	fmt.Fprint(s, e.Error())
+	if s.Flag('+') {
+		fmt.Fprint(s, "\nmain.sendEmail\n\tmain.go:42")
+	}
+}
+
+func TestFailedAlertsOnceRetriesAreExhausted(t *testing.T) {
+	alerter, capture := newTestAlerter("queuealert-capture")
+	job := Job{Queue: "critical", Type: "email:welcome", ID: "task-1", Payload: []byte(`{"user":42}`), Retry: 2, RetriesLeft: 1}
+
+	alerter.Failed(job, stackError{}, "")
+	if len(capture.alerts) != 0 {
+		t.Fatalf("Expected no alert while retries are left, got %+v", capture.alerts)
+	}
+
+	job.Retry, job.RetriesLeft = 3, 0
+	alerter.Failed(job, stackError{}, "")
+	if len(capture.alerts) != 1 {
+		t.Fatalf("Expected an alert for the final failure, got %d", len(capture.alerts))
+	}
+	alert := capture.alerts[0]
+	if alert.level != types.ERROR || alert.message != "Job email:welcome failed permanently: smtp timeout" {
+		t.Errorf("Unexpected alert %d %q", alert.level, alert.message)
+	}
+	fields := alert.cfg.Fields
+	if fields["job.queue"] != "critical" || fields["job.id"] != "task-1" || fields["job.retry"] != "3" || fields["job.retries_left"] != "0" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+	if fields["job.payload_digest"] != PayloadDigest([]byte(`{"user":42}`)) || strings.Contains(fields["job.payload_digest"], "user") {
+		t.Errorf("Expected a payload digest, got %q", fields["job.payload_digest"])
+	}
+	if alert.attachment == nil || !strings.Contains(alert.attachment.Content, "main.go:42") {
+		t.Errorf("Expected the error's stack as trace, got %+v", alert.attachment)
+	}
+}
+
+func TestRunRecoversPanicsAndAlertsEveryFailure(t *testing.T) {
+	alerter, capture := newTestAlerter("queuealert-every", WithEveryFailure())
+	job := Job{Type: "report:build", RetriesLeft: 2}
+
+	err := alerter.Run(job, func() error { panic("nil report") })
+	if err == nil || err.Error() != "panic: nil report" {
+		t.Fatalf("Expected the panic as error, got %v", err)
+	}
+	if len(capture.alerts) != 1 {
+		t.Fatalf("Expected an alert for the retried failure, got %d", len(capture.alerts))
+	}
+	alert := capture.alerts[0]
+	if alert.level != types.WARN || alert.message != "Job report:build failed, 2 retries left: panic: nil report" {
+		t.Errorf("Unexpected alert %d %q", alert.level, alert.message)
+	}
+	if alert.attachment == nil || !strings.HasPrefix(alert.attachment.Content, "github.com/alvianhanif/gocommonlog/queuealert.TestRunRecoversPanicsAndAlertsEveryFailure.func") {
+		t.Errorf("Expected the panic stack as trace, got %+v", alert.attachment)
+	}
+
+	if err := alerter.Run(job, func() error { return nil }); err != nil || len(capture.alerts) != 1 {
+		t.Errorf("Expected no alert for a successful run, got %v and %d alerts", err, len(capture.alerts))
+	}
+	if err := alerter.Run(job, func() error { return errors.New("boom") }); err == nil || len(capture.alerts) != 2 {
+		t.Errorf("Expected the error and an alert, got %v and %d alerts", err, len(capture.alerts))
+	}
+}
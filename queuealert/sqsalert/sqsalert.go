@@ -0,0 +1,62 @@
+// Package sqsalert hooks queuealert into Amazon SQS consumers. It is a separate module so
+// the gocommonlog module does not depend on the AWS SDK.
+package sqsalert
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/alvianhanif/gocommonlog/queuealert"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// TypeAttribute is the message attribute naming the job type; messages without it use
+// the queue name
+const TypeAttribute = "type"
+
+// Handler wraps the message handler fn of queue so its failures and panics send the
+// alerts of a. A panic is returned as an error, leaving the message on the queue.
+// maxReceiveCount is the queue's redrive policy: once a message was received that many
+// times, the failure is final as it moves to the dead-letter queue. Without a redrive
+// policy, pass 0 to alert on every failure. Request the ApproximateReceiveCount
+// attribute when receiving messages so retries are counted:
+//
+//	handle := sqsalert.Handler(alerter, "orders", 5, processOrder)
+//	out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+//	    QueueUrl:              &queueURL,
+//	    AttributeNames:        []types.QueueAttributeName{types.QueueAttributeName(types.MessageSystemAttributeNameApproximateReceiveCount)},
+//	    MessageAttributeNames: []string{sqsalert.TypeAttribute},
+//	})
+//	for _, msg := range out.Messages {
+//	    if handle(ctx, msg) == nil {
+//	        // delete msg
+//	    }
+//	}
+func Handler(a *queuealert.Alerter, queue string, maxReceiveCount int, fn func(ctx context.Context, msg types.Message) error) func(ctx context.Context, msg types.Message) error {
+	return func(ctx context.Context, msg types.Message) error {
+		return a.Run(Job(queue, maxReceiveCount, msg), func() error { return fn(ctx, msg) })
+	}
+}
+
+// Job describes msg received from queue
+func Job(queue string, maxReceiveCount int, msg types.Message) queuealert.Job {
+	job := queuealert.Job{Queue: queue, Type: queue, ID: deref(msg.MessageId), Payload: []byte(deref(msg.Body))}
+	if attribute, ok := msg.MessageAttributes[TypeAttribute]; ok && deref(attribute.StringValue) != "" {
+		job.Type = deref(attribute.StringValue)
+	}
+	received, _ := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	if received > 1 {
+		job.Retry = received - 1
+	}
+	if maxReceiveCount > 0 && received > 0 {
+		job.RetriesLeft = maxReceiveCount - received
+	}
+	return job
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,60 @@
+package sqsalert
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/queuealert"
+	"github.com/alvianhanif/gocommonlog/types"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type captureProvider struct {
+	messages []string
+	fields   []map[string]string
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.messages = append(p.messages, message)
+	p.fields = append(p.fields, cfg.Fields)
+	return nil
+}
+
+func newAlerter(name string) (*queuealert.Alerter, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider(name, func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: name, Channel: "#default"})
+	return queuealert.New(logger), capture
+}
+
+func TestHandler(t *testing.T) {
+	alerter, capture := newAlerter("sqs-capture")
+	handle := Handler(alerter, "orders", 3, func(ctx context.Context, msg sqstypes.Message) error {
+		return errors.New("payment declined")
+	})
+	id, body, orderType := "msg-1", `{"order":7}`, "order:charge"
+	msg := sqstypes.Message{
+		MessageId:         &id,
+		Body:              &body,
+		Attributes:        map[string]string{"ApproximateReceiveCount": "2"},
+		MessageAttributes: map[string]sqstypes.MessageAttributeValue{TypeAttribute: {StringValue: &orderType}},
+	}
+
+	if err := handle(context.Background(), msg); err == nil || len(capture.messages) != 0 {
+		t.Fatalf("Expected the error without an alert while receives are left, got %v and %q", err, capture.messages)
+	}
+	msg.Attributes["ApproximateReceiveCount"] = "3"
+	handle(context.Background(), msg)
+	if len(capture.messages) != 1 || capture.messages[0] != "Job order:charge failed permanently: payment declined" {
+		t.Fatalf("Unexpected alerts %q", capture.messages)
+	}
+	if fields := capture.fields[0]; fields["job.queue"] != "orders" || fields["job.id"] != "msg-1" || fields["job.retry"] != "2" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,129 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// maxPostedMessages bounds the posted messages remembered for React and Pin
+const maxPostedMessages = 10000
+
+// postedMessage is an alert's message as posted by a provider that supports reactions or
+// pins, with the config it was sent with
+type postedMessage struct {
+	provider    types.Provider
+	config      types.Config
+	channel     string
+	messageID   string
+	condition   string // SendOptions.Condition, whose Resolve unpins the message
+	fingerprint string
+	pinned      bool
+	expires     time.Time
+}
+
+// pinnedPost reports whether any of an alert's messages is pinned. Pinned alerts are kept
+// past ack_ttl and maxPostedMessages until unpinned, so Resolve can still unpin them.
+func pinnedPost(posted []postedMessage) bool {
+	for _, message := range posted {
+		if message.pinned {
+			return true
+		}
+	}
+	return false
+}
+
+// rememberPosted records the message a provider posted for an alert, so React and Pin can
+// find it for as long as ack state is kept (ack_ttl), or until unpinned. An alert sent to several channels
+// has a message in each. Messages of providers without reactions or pins, or that did not
+// report a message ID, are not recorded.
+func (l *Logger) rememberPosted(alertID string, provider types.Provider, cfg types.Config, condition string) {
+	_, reactor := provider.(types.Reactor)
+	_, pinner := provider.(types.Pinner)
+	if !reactor && !pinner || cfg.Response == nil || cfg.Response.MessageID == "" {
+		return
+	}
+	now := l.now()
+	posted := postedMessage{
+		provider:    provider,
+		config:      cfg,
+		channel:     cfg.Response.Channel,
+		messageID:   cfg.Response.MessageID,
+		condition:   condition,
+		fingerprint: cfg.Fingerprint,
+		expires:     now.Add(l.ackTTL()),
+	}
+	if posted.channel == "" {
+		posted.channel = cfg.Channel
+	}
+	posted.config.Response = nil
+
+	l.postedMu.Lock()
+	defer l.postedMu.Unlock()
+	if l.posted == nil {
+		l.posted = make(map[string][]postedMessage)
+	}
+	if _, known := l.posted[alertID]; !known && len(l.posted) >= maxPostedMessages {
+		l.evictPosted(now)
+	}
+	l.posted[alertID] = append(l.posted[alertID], posted)
+}
+
+// evictPosted forgets the messages of expired alerts, or of the alert expiring first when
+// none has. Pinned alerts are kept.
+func (l *Logger) evictPosted(now time.Time) {
+	oldest := ""
+	for id, posted := range l.posted {
+		if pinnedPost(posted) {
+			continue
+		}
+		if !now.Before(posted[0].expires) {
+			delete(l.posted, id)
+		} else if oldest == "" || posted[0].expires.Before(l.posted[oldest][0].expires) {
+			oldest = id
+		}
+	}
+	if len(l.posted) >= maxPostedMessages && oldest != "" {
+		delete(l.posted, oldest)
+	}
+}
+
+// postedFor returns the messages posted for an alert, forgetting them once expired unless
+// pinned
+func (l *Logger) postedFor(alertID string) []postedMessage {
+	l.postedMu.Lock()
+	defer l.postedMu.Unlock()
+	posted := l.posted[alertID]
+	if len(posted) > 0 && !pinnedPost(posted) && !l.now().Before(posted[0].expires) {
+		delete(l.posted, alertID)
+		return nil
+	}
+	return append([]postedMessage(nil), posted...)
+}
+
+// React adds an emoji reaction to the message posted for an alert, for example
+// types.ReactionInvestigating when someone starts looking into it and
+// types.ReactionResolved once it is fixed, so automation can show an alert's status in
+// the channel. The emoji is named as in Slack, with or without colons. It returns
+// ErrAlertNotFound when the alert's message was not posted by a provider supporting
+// reactions, such as Slack with the webclient method, or has expired (ack_ttl). An alert
+// sent to several channels gets the reaction on each message.
+func (l *Logger) React(alertID, emoji string) error {
+	found := false
+	for _, posted := range l.postedFor(alertID) {
+		reactor, ok := posted.provider.(types.Reactor)
+		if !ok {
+			continue
+		}
+		found = true
+		if err := reactor.React(posted.config, posted.channel, posted.messageID, emoji); err != nil {
+			return fmt.Errorf("failed to add reaction %s to alert %s: %w", emoji, alertID, err)
+		}
+	}
+	if !found {
+		return ErrAlertNotFound
+	}
+	types.DebugLog(l.config, "Added reaction %s to alert %s", emoji, alertID)
+	return nil
+}
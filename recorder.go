@@ -0,0 +1,53 @@
+package gocommonlog
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// newFlightRecorder returns the configured recorder, creating one keeping the last
+// flight_recorder exchanges, or nil when recording is off
+func newFlightRecorder(cfg types.Config) *types.FlightRecorder {
+	if cfg.FlightRecorder != nil {
+		return cfg.FlightRecorder
+	}
+	if size, _ := cfg.ProviderConfig["flight_recorder"].(int); size > 0 {
+		return types.NewFlightRecorder(size)
+	}
+	return nil
+}
+
+// DebugDump returns the provider HTTP exchanges kept by the flight recorder, oldest
+// first, with credentials redacted. It returns nil unless flight_recorder is set.
+func (l *Logger) DebugDump() []types.HTTPExchange {
+	if l.config.FlightRecorder == nil {
+		return nil
+	}
+	return l.config.FlightRecorder.Exchanges()
+}
+
+// dumpOnFailure writes the flight recorder to flight_recorder_file, as JSON lines, after
+// a failed send
+func (l *Logger) dumpOnFailure(alertID string) {
+	path, _ := l.config.ProviderConfig["flight_recorder_file"].(string)
+	if path == "" || l.config.FlightRecorder == nil {
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Printf("[ERROR] Failed to write flight recorder dump for alert %s: %v", alertID, err)
+		return
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, exchange := range l.config.FlightRecorder.Exchanges() {
+		if err := encoder.Encode(exchange); err != nil {
+			log.Printf("[ERROR] Failed to write flight recorder dump for alert %s: %v", alertID, err)
+			return
+		}
+	}
+	types.DebugLog(l.config, "Wrote flight recorder dump for alert %s to %s", alertID, path)
+}
@@ -0,0 +1,154 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// renderedResponse acknowledges every request made while rendering, in a form the Slack
+// and Lark APIs, including the Lark token endpoint, accept
+const renderedResponse = `{"ok":true,"code":0,"msg":"success","tenant_access_token":"rendered","expire":7200}`
+
+// Render returns what the alert would be sent as, per provider request, without sending
+// it, so tests and preview tools can assert on the exact payload. The alert is scrubbed,
+// routed and formatted like a send, but level policies, mutes and suppression are not
+// applied, nothing is audited, and tokens are rendered as configured rather than resolved
+// from secret references or a TokenStore. Providers that do not send over HTTP, such as
+// Kafka and syslog, cannot be rendered.
+func (l *Logger) Render(level int, message string, opts types.SendOptions) (types.RenderedAlert, error) {
+	message, opts = l.scrubAlert(message, opts)
+	provider, providerName, err := l.alertProvider(opts)
+	if err != nil {
+		return types.RenderedAlert{}, err
+	}
+	if l.configErr != nil {
+		return types.RenderedAlert{}, l.configErr
+	}
+	now := l.now()
+	record := types.AuditRecord{
+		ID:            types.NewULID(now),
+		CorrelationID: opts.CorrelationID,
+		Time:          now,
+		Service:       l.config.ServiceName,
+		Environment:   l.config.Environment,
+		Provider:      providerName,
+	}
+	if opts.ServiceName != "" {
+		record.Service = opts.ServiceName
+	}
+	if opts.Environment != "" {
+		record.Environment = opts.Environment
+	}
+	out := l.prepare(level, message, message, opts, "", nil, record, provider, l.callSite())
+	rendered := types.RenderedAlert{ID: record.ID, Provider: out.alert.Provider, Channel: out.channel, Message: message}
+	switch out.provider.(type) {
+	case *providers.KafkaProvider, *providers.SyslogProvider:
+		return rendered, fmt.Errorf("provider %q does not send over HTTP and cannot be rendered", rendered.Provider)
+	}
+
+	// Tokens fetched while rendering must not reach the logger's cache or Redis, where
+	// real sends would pick them up, and chaos settings would fail requests that are
+	// never sent
+	store := &renderCache{base: out.config.Cache, local: cache.NewInMemoryCacheWithClock(l.now)}
+	if store.base == nil {
+		store.base = cache.GetGlobalCache()
+	}
+	defer store.local.Close()
+	capture := &renderDoer{}
+	cfg := out.config
+	cfg.HTTPClient = capture
+	cfg.Cache = store
+	cfg.ObjectStore = nil
+	cfg.PasteSink = nil
+	cfg.FlightRecorder = nil
+	cfg.ProviderConfig = withoutSettings(cfg.ProviderConfig, func(key string) bool {
+		return key == "chaos" || strings.HasPrefix(key, "redis_")
+	})
+	attachment, cfg := hideTrace(out.provider, out.attachment, cfg)
+	err = out.provider.SendToChannel(level, message, fitAttachment(attachment, cfg), cfg, out.channel)
+	rendered.Requests = capture.requests()
+	return rendered, err
+}
+
+// withoutSettings returns a copy of settings without the keys drop reports, or settings
+// itself when it has none of them
+func withoutSettings(settings map[string]interface{}, drop func(key string) bool) map[string]interface{} {
+	var copied map[string]interface{}
+	for k := range settings {
+		if drop(k) {
+			copied = make(map[string]interface{}, len(settings))
+			break
+		}
+	}
+	if copied == nil {
+		return settings
+	}
+	for k, v := range settings {
+		if !drop(k) {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// renderCache reads through to the logger's cache, so a render uses the tokens and chat
+// IDs cached by earlier sends, but keeps what it caches itself to the render
+type renderCache struct {
+	base  cache.Cache
+	local *cache.InMemoryCache
+}
+
+func (c *renderCache) Get(key string) (string, bool) {
+	if value, ok := c.local.Get(key); ok {
+		return value, true
+	}
+	return c.base.Get(key)
+}
+
+func (c *renderCache) Set(key, value string, duration time.Duration) {
+	c.local.Set(key, value, duration)
+}
+
+func (c *renderCache) Delete(key string) {
+	c.local.Delete(key)
+}
+
+// renderDoer records the requests of a render and acknowledges each one
+type renderDoer struct {
+	mu       sync.Mutex
+	captured []types.RenderedRequest
+}
+
+func (d *renderDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	d.mu.Lock()
+	d.captured = append(d.captured, types.RenderedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body})
+	d.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(renderedResponse)),
+		Request:    req,
+	}, nil
+}
+
+func (d *renderDoer) requests() []types.RenderedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.captured
+}
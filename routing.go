@@ -0,0 +1,117 @@
+package gocommonlog
+
+import (
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// compiledRoute is a routing table entry with its provider and settings prepared once by
+// NewLogger, so matching an alert does not allocate
+type compiledRoute struct {
+	types.Route
+	provider       types.Provider
+	providerName   string
+	providerConfig map[string]interface{}
+	digest         bool // posts digest reports, which are not counted in digests
+}
+
+// compileRoutes creates the provider and merged ProviderConfig of every route
+func compileRoutes(cfg types.Config) []compiledRoute {
+	return compileRouteList(cfg, cfg.Routes)
+}
+
+// compileGroups compiles the members of every broadcast group
+func compileGroups(cfg types.Config) map[string][]compiledRoute {
+	if len(cfg.Groups) == 0 {
+		return nil
+	}
+	groups := make(map[string][]compiledRoute, len(cfg.Groups))
+	for name, members := range cfg.Groups {
+		groups[name] = compileRouteList(cfg, members)
+	}
+	return groups
+}
+
+func compileRouteList(cfg types.Config, list []types.Route) []compiledRoute {
+	routes := make([]compiledRoute, 0, len(list))
+	for _, route := range list {
+		compiled := compiledRoute{Route: route}
+		compiled.providerName, _ = cfg.ProviderConfig["provider"].(string)
+		if route.Provider != "" {
+			compiled.providerName = route.Provider
+		}
+
+		if route.Provider != "" || route.Token != "" || len(route.ProviderConfig) > 0 {
+			compiled.providerConfig = make(map[string]interface{}, len(cfg.ProviderConfig)+len(route.ProviderConfig)+2)
+			for key, value := range cfg.ProviderConfig {
+				compiled.providerConfig[key] = value
+			}
+			for key, value := range route.ProviderConfig {
+				compiled.providerConfig[key] = value
+			}
+			compiled.providerConfig["provider"] = compiled.providerName
+			if route.Token != "" {
+				compiled.providerConfig["token"] = route.Token
+			}
+		}
+		compiled.provider = createProvider(compiled.providerName)
+		types.DebugLog(cfg, "Compiled route '%s' to provider: %s, channel: %s", route.Name, compiled.providerName, route.Channel)
+		routes = append(routes, compiled)
+	}
+	return routes
+}
+
+// useProvider makes routes, group members and self-monitoring alerts without their own
+// provider send with provider, injected by WithProvider
+func (l *Logger) useProvider(provider types.Provider) {
+	for i := range l.routes {
+		if l.routes[i].Route.Provider == "" {
+			l.routes[i].provider = provider
+		}
+	}
+	for _, members := range l.groups {
+		for i := range members {
+			if members[i].Route.Provider == "" {
+				members[i].provider = provider
+			}
+		}
+	}
+	if l.monitor != nil && l.monitor.route.Route.Provider == "" {
+		l.monitor.route.provider = provider
+	}
+	for _, job := range l.digests {
+		if job.route.Route.Provider == "" {
+			job.route.provider = provider
+		}
+	}
+}
+
+// metaRoute reports whether route sends the logger's own alerts, self-monitoring alerts
+// and digest reports, which are not counted against alert budgets
+func (l *Logger) metaRoute(route *compiledRoute) bool {
+	return route != nil && (route.digest || l.monitor != nil && route == &l.monitor.route)
+}
+
+// matchRoute returns the first route matching alert, or nil
+func (l *Logger) matchRoute(alert types.AlertContext) *compiledRoute {
+	for i := range l.routes {
+		if l.routes[i].Matches(alert) {
+			types.DebugLog(l.config, "Alert matched route '%s'", l.routes[i].Name)
+			return &l.routes[i]
+		}
+	}
+	return nil
+}
+
+// apply returns cfg with the route's send method, token and provider settings
+func (r *compiledRoute) apply(cfg types.Config) types.Config {
+	if r.SendMethod != "" {
+		cfg.SendMethod = r.SendMethod
+	}
+	if r.Token != "" {
+		cfg.Token = r.Token
+	}
+	if r.providerConfig != nil {
+		cfg.ProviderConfig = r.providerConfig
+	}
+	return cfg
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxMetadataValue is how much of each request metadata value is added to an alert
+const maxMetadataValue = 128
+
+// DefaultAlertCodes are the status codes the interceptors alert on, with their levels:
+// server-side failures are ERROR alerts and overload or timeouts WARN alerts. Client
+// errors such as NotFound or InvalidArgument are not alerted on.
+var DefaultAlertCodes = map[codes.Code]int{
+	codes.Unknown:           types.ERROR,
+	codes.Internal:          types.ERROR,
+	codes.DataLoss:          types.ERROR,
+	codes.Unimplemented:     types.ERROR,
+	codes.Unavailable:       types.WARN,
+	codes.DeadlineExceeded:  types.WARN,
+	codes.ResourceExhausted: types.WARN,
+}
+
+// sensitiveMetadata marks metadata keys left out of alerts
+var sensitiveMetadata = []string{"authorization", "cookie", "token", "secret", "password", "key"}
+
+// InterceptorOption customizes the alerts of the server interceptors
+type InterceptorOption func(*interceptorOptions)
+
+type interceptorOptions struct {
+	alertCodes   map[codes.Code]int
+	metadataKeys []string
+}
+
+// WithAlertCodes replaces DefaultAlertCodes with the status codes to alert on and their
+// levels
+func WithAlertCodes(alertCodes map[codes.Code]int) InterceptorOption {
+	return func(o *interceptorOptions) { o.alertCodes = alertCodes }
+}
+
+// WithMetadataKeys limits the request metadata added to alerts to keys; by default every
+// key except credentials such as authorization, cookies and tokens is added
+func WithMetadataKeys(keys ...string) InterceptorOption {
+	return func(o *interceptorOptions) { o.metadataKeys = keys }
+}
+
+// UnaryServerInterceptor recovers panics in unary handlers, answering codes.Internal, and
+// sends an alert through logger for panics and for errors with a code in the alert codes
+func UnaryServerInterceptor(logger *gocommonlog.Logger, options ...InterceptorOption) grpc.UnaryServerInterceptor {
+	o := newInterceptorOptions(options)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				o.alertPanic(logger, ctx, info.FullMethod, r)
+				resp, err = nil, status.Error(codes.Internal, "internal error")
+			}
+		}()
+		resp, err = handler(ctx, req)
+		o.alertError(logger, ctx, info.FullMethod, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming handlers
+func StreamServerInterceptor(logger *gocommonlog.Logger, options ...InterceptorOption) grpc.StreamServerInterceptor {
+	o := newInterceptorOptions(options)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				o.alertPanic(logger, ss.Context(), info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		err = handler(srv, ss)
+		o.alertError(logger, ss.Context(), info.FullMethod, err)
+		return err
+	}
+}
+
+func newInterceptorOptions(options []InterceptorOption) interceptorOptions {
+	o := interceptorOptions{alertCodes: DefaultAlertCodes}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+// alertPanic sends an ERROR alert for a recovered panic with its stack as the trace
+func (o interceptorOptions) alertPanic(logger *gocommonlog.Logger, ctx context.Context, method string, recovered interface{}) {
+	fields := o.callFields(ctx, method)
+	fields["grpc.code"] = codes.Internal.String()
+	message := fmt.Sprintf("gRPC %s panicked: %v", method, recovered)
+	o.send(logger, types.ERROR, message, logger.Stack(1), fields)
+}
+
+// alertError sends an alert when err has one of the alert codes
+func (o interceptorOptions) alertError(logger *gocommonlog.Logger, ctx context.Context, method string, err error) {
+	if err == nil {
+		return
+	}
+	st := status.Convert(err)
+	level, ok := o.alertCodes[st.Code()]
+	if !ok {
+		return
+	}
+	fields := o.callFields(ctx, method)
+	fields["grpc.code"] = st.Code().String()
+	message := fmt.Sprintf("gRPC %s failed with %s: %s", method, st.Code(), st.Message())
+	o.send(logger, level, message, "", fields)
+}
+
+func (o interceptorOptions) send(logger *gocommonlog.Logger, level int, message, trace string, fields map[string]string) {
+	if p := fields["grpc.peer"]; p != "" {
+		message += " (peer " + p + ")"
+	}
+	opts := types.SendOptions{Trace: trace, Fields: fields, CorrelationID: fields["grpc.md.x-request-id"]}
+	if _, err := logger.SendWithOptions(level, message, opts); err != nil {
+		log.Printf("[ERROR] Failed to send alert for gRPC %s: %v", fields["grpc.method"], err)
+	}
+}
+
+// callFields returns the method, peer address and trimmed request metadata of a call
+func (o interceptorOptions) callFields(ctx context.Context, method string) map[string]string {
+	fields := map[string]string{"grpc.method": method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["grpc.peer"] = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := o.metadataKeys
+	if keys == nil {
+		for key := range md {
+			if !sensitiveMetadataKey(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+	}
+	for _, key := range keys {
+		values := md.Get(key)
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ",")
+		if len(value) > maxMetadataValue {
+			value = strings.ToValidUTF8(value[:maxMetadataValue-3], "") + "..."
+		}
+		fields["grpc.md."+strings.ToLower(key)] = value
+	}
+	return fields
+}
+
+// sensitiveMetadataKey reports whether key may carry credentials
+func sensitiveMetadataKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range sensitiveMetadata {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
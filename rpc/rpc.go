@@ -0,0 +1,174 @@
+// Package rpc serves the protobuf AlertService (see alertpb/alert.proto) over gRPC,
+// so services written in other languages can submit alerts through one central relay.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/alertpb"
+	"github.com/alvianhanif/gocommonlog/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenMetadataKey carries the relay token when an authorization header is not used
+const tokenMetadataKey = "x-commonlog-token"
+
+// Server implements alertpb.AlertServiceServer by forwarding alerts through a Logger
+type Server struct {
+	alertpb.UnimplementedAlertServiceServer
+	logger *gocommonlog.Logger
+	tokens []string
+}
+
+// NewServer creates an AlertService implementation. Calls must present one of tokens as
+// "authorization: Bearer <token>" or "x-commonlog-token: <token>" metadata; with no tokens
+// authentication is disabled.
+func NewServer(logger *gocommonlog.Logger, tokens ...string) *Server {
+	return &Server{logger: logger, tokens: tokens}
+}
+
+// Send delivers a single alert through the Logger
+func (s *Server) Send(ctx context.Context, req *alertpb.SendAlertRequest) (*alertpb.SendAlertResponse, error) {
+	if !s.authorized(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "invalid or missing token")
+	}
+	level, err := levelFromProto(req.GetLevel())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetMessage() == "" {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+
+	var attachment *types.Attachment
+	if a := req.GetAttachment(); a != nil {
+		attachment = &types.Attachment{URL: a.GetUrl(), FileName: a.GetFileName(), Content: a.GetContent()}
+	}
+	if req.GetProvider() != "" {
+		err = s.logger.CustomSend(req.GetProvider(), level, req.GetMessage(), attachment, req.GetTrace(), req.GetChannel())
+	} else {
+		err = s.logger.SendToChannel(level, req.GetMessage(), attachment, req.GetTrace(), req.GetChannel())
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to relay alert: %v", err)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &alertpb.SendAlertResponse{Fingerprint: s.logger.Fingerprint(level, req.GetMessage(), types.SendOptions{Provider: req.GetProvider()})}, nil
+}
+
+func (s *Server) authorized(ctx context.Context) bool {
+	if len(s.tokens) == 0 {
+		return true
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	presented := ""
+	if values := md.Get(tokenMetadataKey); len(values) > 0 {
+		presented = values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 && strings.HasPrefix(values[0], "Bearer ") {
+		presented = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if presented == "" {
+		return false
+	}
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ListenAndServe serves the AlertService on addr until the listener fails
+func ListenAndServe(addr string, logger *gocommonlog.Logger, tokens ...string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	alertpb.RegisterAlertServiceServer(grpcServer, NewServer(logger, tokens...))
+	log.Printf("[INFO] gocommonlog gRPC relay listening on %s", addr)
+	return grpcServer.Serve(listener)
+}
+
+// Client is a thin wrapper around the generated AlertService client
+type Client struct {
+	conn   *grpc.ClientConn
+	client alertpb.AlertServiceClient
+	token  string
+}
+
+// Dial connects to a relay at target. Without dial options the connection is
+// unencrypted, which is only suitable inside a trusted cluster network.
+func Dial(target, token string, opts ...grpc.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial alert relay: %w", err)
+	}
+	return &Client{conn: conn, client: alertpb.NewAlertServiceClient(conn), token: token}, nil
+}
+
+// Send submits an alert to the relay and returns its fingerprint
+func (c *Client) Send(ctx context.Context, level int, message string, attachment *types.Attachment, trace, channel string) (string, error) {
+	req := &alertpb.SendAlertRequest{
+		Level:   levelToProto(level),
+		Message: message,
+		Channel: channel,
+		Trace:   trace,
+	}
+	if attachment != nil {
+		req.Attachment = &alertpb.Attachment{Url: attachment.URL, FileName: attachment.FileName, Content: attachment.Content}
+	}
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetFingerprint(), nil
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func levelFromProto(level alertpb.Level) (int, error) {
+	switch level {
+	case alertpb.Level_LEVEL_INFO:
+		return types.INFO, nil
+	case alertpb.Level_LEVEL_WARN:
+		return types.WARN, nil
+	case alertpb.Level_LEVEL_ERROR:
+		return types.ERROR, nil
+	default:
+		return 0, fmt.Errorf("unknown alert level: %s", level)
+	}
+}
+
+func levelToProto(level int) alertpb.Level {
+	switch level {
+	case types.INFO:
+		return alertpb.Level_LEVEL_INFO
+	case types.WARN:
+		return alertpb.Level_LEVEL_WARN
+	case types.ERROR:
+		return alertpb.Level_LEVEL_ERROR
+	default:
+		return alertpb.Level_LEVEL_UNSPECIFIED
+	}
+}
@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/alertpb"
+	"github.com/alvianhanif/gocommonlog/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type captureProvider struct {
+	level      int
+	message    string
+	channel    string
+	attachment *types.Attachment
+	cfg        types.Config
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.level, p.message, p.channel, p.attachment, p.cfg = level, message, channel, attachment, cfg
+	return nil
+}
+
+func startRelay(t *testing.T, token string) (*captureProvider, *grpc.ClientConn) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider("rpc-capture", func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: "rpc-capture", Channel: "#default"})
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	alertpb.RegisterAlertServiceServer(grpcServer, NewServer(logger, token))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return capture, conn
+}
+
+func TestClientSendRelaysAlert(t *testing.T) {
+	capture, conn := startRelay(t, "relay-token")
+	client := &Client{conn: conn, client: alertpb.NewAlertServiceClient(conn), token: "relay-token"}
+
+	fingerprint, err := client.Send(context.Background(), types.ERROR, "disk full",
+		&types.Attachment{FileName: "df.txt", Content: "100%"}, "stack", "#ops")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if fingerprint != types.Fingerprint(types.ERROR, "disk full") {
+		t.Errorf("Unexpected fingerprint %q", fingerprint)
+	}
+	if capture.level != types.ERROR || capture.message != "disk full" || capture.channel != "#ops" {
+		t.Errorf("Unexpected relayed alert: %+v", capture)
+	}
+	if capture.attachment == nil || !strings.Contains(capture.attachment.Content, "stack") {
+		t.Errorf("Expected attachment with trace, got %+v", capture.attachment)
+	}
+}
+
+func TestServerRejectsInvalidRequests(t *testing.T) {
+	_, conn := startRelay(t, "relay-token")
+
+	unauthenticated := &Client{conn: conn, client: alertpb.NewAlertServiceClient(conn), token: "wrong"}
+	if _, err := unauthenticated.Send(context.Background(), types.ERROR, "boom", nil, "", ""); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+
+	client := &Client{conn: conn, client: alertpb.NewAlertServiceClient(conn), token: "relay-token"}
+	if _, err := client.Send(context.Background(), 42, "boom", nil, "", ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for unknown level, got %v", err)
+	}
+	if _, err := client.Send(context.Background(), types.WARN, "", nil, "", ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for empty message, got %v", err)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider("rpc-interceptor-capture", func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: "rpc-interceptor-capture", Channel: "#default"})
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-request-id", "req-1",
+		"authorization", "Bearer secret",
+		"user-agent", strings.Repeat("a", 200)))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 5000}})
+
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such order")
+	})
+	if status.Code(err) != codes.NotFound || capture.message != "" {
+		t.Fatalf("Expected NotFound without an alert, got %v and %q", err, capture.message)
+	}
+
+	_, err = interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "db down")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected the handler error, got %v", err)
+	}
+	if capture.level != types.WARN || capture.message != "gRPC /orders.Orders/Create failed with Unavailable: db down (peer 10.0.0.7:5000)" {
+		t.Errorf("Unexpected alert %d %q", capture.level, capture.message)
+	}
+	fields := capture.cfg.Fields
+	if fields["grpc.method"] != "/orders.Orders/Create" || fields["grpc.code"] != "Unavailable" || fields["grpc.peer"] != "10.0.0.7:5000" {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+	if fields["grpc.md.x-request-id"] != "req-1" || capture.cfg.CorrelationID != "req-1" {
+		t.Errorf("Expected request ID field and correlation ID, got %v", capture.cfg)
+	}
+	if _, ok := fields["grpc.md.authorization"]; ok {
+		t.Errorf("Expected authorization metadata to be left out, got %v", fields)
+	}
+	if ua := fields["grpc.md.user-agent"]; len(ua) != maxMetadataValue || !strings.HasSuffix(ua, "...") {
+		t.Errorf("Expected trimmed user agent, got %q", ua)
+	}
+
+	resp, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("nil order")
+	})
+	if resp != nil || status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal after a panic, got %v, %v", resp, err)
+	}
+	if capture.level != types.ERROR || !strings.HasPrefix(capture.message, "gRPC /orders.Orders/Create panicked: nil order") {
+		t.Errorf("Unexpected panic alert %d %q", capture.level, capture.message)
+	}
+	if capture.attachment == nil || !strings.HasPrefix(capture.attachment.Content, "github.com/alvianhanif/gocommonlog/rpc.TestUnaryServerInterceptor.func") {
+		t.Errorf("Expected the panic stack as trace, got %+v", capture.attachment)
+	}
+}
+
+func TestStreamServerInterceptorAlertCodes(t *testing.T) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider("rpc-stream-capture", func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: "rpc-stream-capture", Channel: "#default"})
+	interceptor := StreamServerInterceptor(logger,
+		WithAlertCodes(map[codes.Code]int{codes.PermissionDenied: types.ERROR}),
+		WithMetadataKeys("tenant"))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("tenant", "acme", "x-request-id", "req-2"))
+	info := &grpc.StreamServerInfo{FullMethod: "/orders.Orders/Watch"}
+
+	err := interceptor(nil, &fakeStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return status.Error(codes.Internal, "ignored")
+	})
+	if status.Code(err) != codes.Internal || capture.message != "" {
+		t.Fatalf("Expected Internal without an alert, got %v and %q", err, capture.message)
+	}
+
+	interceptor(nil, &fakeStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return status.Error(codes.PermissionDenied, "not allowed")
+	})
+	if capture.message != "gRPC /orders.Orders/Watch failed with PermissionDenied: not allowed" {
+		t.Errorf("Unexpected alert %q", capture.message)
+	}
+	if capture.cfg.Fields["grpc.md.tenant"] != "acme" || capture.cfg.Fields["grpc.md.x-request-id"] != "" {
+		t.Errorf("Expected only the tenant metadata, got %v", capture.cfg.Fields)
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,60 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// warnSampler decides which WARN alerts are sent when warn_sample_every or
+// warn_sample_rate is set, counting the dropped ones
+type warnSampler struct {
+	mu      sync.Mutex
+	every   int     // send 1 in every alerts
+	rate    float64 // send each alert with this probability
+	seen    int
+	dropped int
+	rand    *rand.Rand
+}
+
+// newWarnSampler returns nil when WARN sampling is not configured
+func newWarnSampler(cfg types.Config) *warnSampler {
+	every, _ := cfg.ProviderConfig["warn_sample_every"].(int)
+	rate, _ := cfg.ProviderConfig["warn_sample_rate"].(float64)
+	if every <= 1 && (rate <= 0 || rate >= 1) {
+		return nil
+	}
+	return &warnSampler{every: every, rate: rate, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// sample reports whether the alert should be sent and, if so, how many alerts were
+// dropped since the last one sent
+func (s *warnSampler) sample() (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var send bool
+	if s.every > 1 {
+		send = s.seen%s.every == 0
+		s.seen++
+	} else {
+		send = s.rand.Float64() < s.rate
+	}
+	if !send {
+		s.dropped++
+		return false, 0
+	}
+	dropped := s.dropped
+	s.dropped = 0
+	return true, dropped
+}
+
+// sampledMessage appends the number of WARN alerts dropped since the last one sent
+func sampledMessage(message string, dropped int) string {
+	if dropped == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n\n(%d more WARN alerts were dropped by sampling since the last one sent)", message, dropped)
+}
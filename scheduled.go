@@ -0,0 +1,162 @@
+package gocommonlog
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ErrScheduleCanceled is returned by ScheduledAlert.Wait when the alert was canceled
+// before it was sent
+var ErrScheduleCanceled = errors.New("gocommonlog: scheduled alert canceled")
+
+// ScheduledAlert is an alert queued by SendAt or SendAfter
+type ScheduledAlert struct {
+	At time.Time // When the alert is due
+
+	logger *Logger
+	timer  types.Timer
+	done   chan struct{}
+	id     string
+	err    error
+	alert  *queuedAlert // the alert of SendAt and SendAfter, spilled when dropped on Close
+}
+
+// SendAt sends the alert at t, or immediately if t is in the past. Pending alerts are
+// dropped by Close, so schedules do not outlive the process, unless async_spill_file is
+// set: they are written to it and scheduled again by the next logger.
+func (l *Logger) SendAt(t time.Time, level int, message string, opts types.SendOptions) (*ScheduledAlert, error) {
+	return l.SendAfter(t.Sub(l.now()), level, message, opts)
+}
+
+// SendAfter sends the alert once d has elapsed, e.g. to re-alert when an incident has
+// not been acknowledged within 15 minutes
+func (l *Logger) SendAfter(d time.Duration, level int, message string, opts types.SendOptions) (*ScheduledAlert, error) {
+	message, opts = l.scrubAlert(message, opts)
+	opts.Fields = l.scrubFields(opts.Fields)
+	return l.sendAfter(d, &queuedAlert{Level: level, Message: message, Options: opts, Source: l.callSite()})
+}
+
+// sendAfter schedules a scrubbed alert with the call site captured by SendAfter, or
+// replayed from the spill file. When it is due, the alert goes through the async queue
+// like a SendAsync alert.
+func (l *Logger) sendAfter(d time.Duration, alert *queuedAlert) (*ScheduledAlert, error) {
+	scheduled, err := l.schedule(d, alert, func(complete func(string, error)) {
+		if err := l.beginSend(); err != nil {
+			complete("", err)
+			return
+		}
+		due := *alert
+		if due.Options.Time.IsZero() {
+			due.Options.Time = l.now()
+		}
+		due.done = complete
+		l.enqueue(due)
+	})
+	if err == nil {
+		types.DebugLog(l.config, "Scheduled alert at %s, level: %d, message length: %d", scheduled.At.Format(time.RFC3339), alert.Level, len(alert.Message))
+		l.emit(types.Event{Type: types.EventScheduled, Level: alert.Level, Detail: scheduled.At.Format(time.RFC3339)})
+	}
+	return scheduled, err
+}
+
+// schedule runs send once d has elapsed, unless canceled first. send calls complete once
+// with the alert ID and send error. alert is the alert send sends, nil for follow-ups,
+// which are not spilled on Close.
+func (l *Logger) schedule(d time.Duration, alert *queuedAlert, send func(complete func(string, error))) (*ScheduledAlert, error) {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed {
+		return nil, ErrLoggerClosed
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	scheduled := &ScheduledAlert{At: l.now().Add(d), logger: l, done: make(chan struct{}), alert: alert}
+	if alert != nil {
+		alert.Due = &scheduled.At
+	}
+	l.scheduleMu.Lock()
+	defer l.scheduleMu.Unlock()
+	if l.scheduled == nil {
+		l.scheduled = make(map[*ScheduledAlert]struct{})
+	}
+	l.scheduled[scheduled] = struct{}{}
+	scheduled.timer = l.afterFunc(d, func() {
+		if !l.unschedule(scheduled) {
+			return
+		}
+		send(func(id string, err error) {
+			scheduled.id, scheduled.err = id, err
+			close(scheduled.done)
+		})
+	})
+	return scheduled, nil
+}
+
+// Cancel stops the alert from being sent, reporting false if it was already sent or canceled
+func (s *ScheduledAlert) Cancel() bool {
+	return s.logger.cancelScheduled(s, ErrScheduleCanceled)
+}
+
+// Wait blocks until the alert has been sent or canceled and returns its alert ID and
+// send error. It returns ErrQueueFull when the async queue had no room for the alert once
+// it was due, even if the async_overflow policy spilled it for later.
+func (s *ScheduledAlert) Wait() (string, error) {
+	<-s.done
+	return s.id, s.err
+}
+
+// Done is closed once the alert has been sent or canceled
+func (s *ScheduledAlert) Done() <-chan struct{} {
+	return s.done
+}
+
+// unschedule removes a pending alert, reporting whether it was still pending
+func (l *Logger) unschedule(s *ScheduledAlert) bool {
+	l.scheduleMu.Lock()
+	defer l.scheduleMu.Unlock()
+	if _, ok := l.scheduled[s]; !ok {
+		return false
+	}
+	delete(l.scheduled, s)
+	return true
+}
+
+// cancelScheduled stops a pending alert and completes it with err
+func (l *Logger) cancelScheduled(s *ScheduledAlert, err error) bool {
+	if !l.unschedule(s) {
+		return false
+	}
+	s.timer.Stop()
+	s.err = err
+	close(s.done)
+	return true
+}
+
+// dropScheduled cancels every pending alert, returning the number of follow-ups dropped
+// and the alerts of SendAt and SendAfter, for Close to spill
+func (l *Logger) dropScheduled() (int, []queuedAlert) {
+	l.scheduleMu.Lock()
+	pending := make([]*ScheduledAlert, 0, len(l.scheduled))
+	for s := range l.scheduled {
+		pending = append(pending, s)
+	}
+	l.scheduleMu.Unlock()
+
+	dropped := 0
+	var alerts []queuedAlert
+	for _, s := range pending {
+		if !l.cancelScheduled(s, ErrLoggerClosed) {
+			continue
+		}
+		if s.alert != nil {
+			alerts = append(alerts, *s.alert)
+		} else {
+			dropped++
+		}
+	}
+	return dropped, alerts
+}
@@ -0,0 +1,155 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// scrubRule replaces the matches of a pattern that pass its check
+type scrubRule struct {
+	name        string
+	pattern     *regexp.Regexp
+	check       func(match string) bool // Optional; matches failing it are kept
+	replacement string
+}
+
+// builtinScrubRules are the personal data patterns selected by scrub_pii
+var builtinScrubRules = []scrubRule{
+	{
+		name:        "email",
+		pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		replacement: "[REDACTED:email]",
+	},
+	{
+		name:        "bearer_token",
+		pattern:     regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		replacement: "Bearer [REDACTED]",
+	},
+	{
+		name:        "credit_card",
+		pattern:     regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		check:       luhnValid,
+		replacement: "[REDACTED:card]",
+	},
+	{
+		name:        "ip",
+		pattern:     regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i:[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7})`),
+		check:       func(match string) bool { return net.ParseIP(match) != nil },
+		replacement: "[REDACTED:ip]",
+	},
+}
+
+// scrubber removes personal data from alerts before they are logged or sent, when
+// scrub_pii or scrub_patterns is set
+type scrubber struct {
+	rules []scrubRule
+}
+
+// newScrubber returns nil when scrubbing is not configured, and an error for an unknown
+// built-in or an invalid pattern
+func newScrubber(cfg types.Config) (*scrubber, error) {
+	var s scrubber
+	switch pii := cfg.ProviderConfig["scrub_pii"].(type) {
+	case bool:
+		if pii {
+			s.rules = append(s.rules, builtinScrubRules...)
+		}
+	case []string:
+		for _, name := range pii {
+			rule, ok := builtinScrubRule(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown scrub_pii pattern %q (expected email, bearer_token, credit_card or ip)", name)
+			}
+			s.rules = append(s.rules, rule)
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("scrub_pii must be a bool or a list of pattern names, got %T", pii)
+	}
+	patterns, _ := cfg.ProviderConfig["scrub_patterns"].([]string)
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrub_patterns pattern %q: %w", pattern, err)
+		}
+		s.rules = append(s.rules, scrubRule{name: pattern, pattern: compiled, replacement: "[REDACTED]"})
+	}
+	if len(s.rules) == 0 {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func builtinScrubRule(name string) (scrubRule, bool) {
+	for _, rule := range builtinScrubRules {
+		if rule.name == name {
+			return rule, true
+		}
+	}
+	return scrubRule{}, false
+}
+
+// scrub replaces the personal data in text
+func (s *scrubber) scrub(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	for _, rule := range s.rules {
+		rule := rule
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.check != nil && !rule.check(match) {
+				return match
+			}
+			return rule.replacement
+		})
+	}
+	return text
+}
+
+// scrubAlert returns the message and options with the personal data removed from the
+// message, trace and attachment content. Fields are scrubbed by alertFields.
+func (l *Logger) scrubAlert(message string, opts types.SendOptions) (string, types.SendOptions) {
+	if l.scrubber == nil {
+		return message, opts
+	}
+	opts.Trace = l.scrubber.scrub(opts.Trace)
+	if opts.Attachment != nil && opts.Attachment.Content != "" {
+		attachment := *opts.Attachment
+		attachment.Content = l.scrubber.scrub(attachment.Content)
+		opts.Attachment = &attachment
+	}
+	return l.scrubber.scrub(message), opts
+}
+
+// scrubFields returns a copy of fields with the personal data removed from the values
+func (l *Logger) scrubFields(fields map[string]string) map[string]string {
+	if l.scrubber == nil || len(fields) == 0 {
+		return fields
+	}
+	scrubbed := make(map[string]string, len(fields))
+	for key, value := range fields {
+		scrubbed[key] = l.scrubber.scrub(value)
+	}
+	return scrubbed
+}
+
+// luhnValid reports whether the digits of a card number candidate pass the Luhn check
+func luhnValid(candidate string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(candidate)
+	sum, double := 0, false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,19 @@
+// Package sdk delivers Slack and Lark alerts through the official slack-go/slack and
+// larksuite/oapi-sdk-go clients for the api send method (types.MethodSDK). Import it for
+// its side effect:
+//
+//	import _ "github.com/alvianhanif/gocommonlog/sdk"
+//
+// The providers render the same messages as for the webclient method and resolve tokens
+// and Lark chat IDs with the same caches; only the API calls go through the SDKs. Their
+// HTTP requests use Config.HTTPClient like every other provider request.
+package sdk
+
+import (
+	"github.com/alvianhanif/gocommonlog/providers"
+)
+
+func init() {
+	providers.RegisterSDKClient("slack", slackClient{})
+	providers.RegisterSDKClient("lark", larkClient{})
+}
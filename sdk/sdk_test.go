@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func jsonResponse(body string) *http.Response {
+	return &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestSlackSDK(t *testing.T) {
+	var posted url.Values
+	cfg := types.Config{
+		SendMethod:     types.MethodSDK,
+		ProviderConfig: map[string]interface{}{"slack_token": "xoxb-test"},
+		Links:          []types.Link{{Text: "Runbook", URL: "https://runbooks.example.com/payments"}},
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasSuffix(req.URL.Path, "/chat.postMessage") {
+				t.Errorf("Unexpected request to %s", req.URL)
+			}
+			req.ParseForm()
+			posted = req.PostForm
+			if req.PostForm.Get("channel") == "#missing" {
+				return jsonResponse(`{"ok":false,"error":"channel_not_found"}`), nil
+			}
+			return jsonResponse(`{"ok":true,"channel":"C1","ts":"1.2"}`), nil
+		}),
+	}
+
+	provider := &providers.SlackProvider{}
+	if err := provider.SendToChannel(types.ERROR, "Payment failed", nil, cfg, "#ops"); err != nil {
+		t.Fatalf("Expected the SDK send to succeed, got %v", err)
+	}
+	if posted.Get("token") != "xoxb-test" || posted.Get("channel") != "#ops" || !strings.Contains(posted.Get("text"), "Payment failed") {
+		t.Errorf("Unexpected chat.postMessage form: %v", posted)
+	}
+	var blocks []map[string]interface{}
+	if err := json.Unmarshal([]byte(posted.Get("blocks")), &blocks); err != nil || len(blocks) != 2 || blocks[1]["type"] != "actions" {
+		t.Errorf("Expected the rendered blocks, got %q", posted.Get("blocks"))
+	}
+
+	err := provider.SendToChannel(types.ERROR, "Payment failed", nil, cfg, "#missing")
+	if !errors.Is(err, types.ErrChannelNotFound) || err.Error() != "slack API error: channel_not_found" {
+		t.Errorf("Expected ErrChannelNotFound, got %v", err)
+	}
+}
+
+func TestLarkSDK(t *testing.T) {
+	var message map[string]interface{}
+	var listed int
+	cfg := types.Config{
+		SendMethod:  types.MethodSDK,
+		Token:       "t-tenant",
+		Environment: "sdk",
+		Cache:       cache.NewInMemoryCache(),
+		HTTPClient: doerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer t-tenant" {
+				t.Errorf("Expected the tenant token, got %q", req.Header.Get("Authorization"))
+			}
+			switch {
+			case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/im/v1/chats"):
+				listed++
+				if req.URL.Query().Get("page_token") == "" {
+					return jsonResponse(`{"code":0,"data":{"items":[{"chat_id":"oc_1","name":"alerts"}],"page_token":"p2","has_more":true}}`), nil
+				}
+				return jsonResponse(`{"code":0,"data":{"items":[{"chat_id":"oc_2","name":"ops"}],"has_more":false}}`), nil
+			case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/im/v1/messages"):
+				if req.URL.Query().Get("receive_id_type") != "chat_id" {
+					t.Errorf("Expected chat_id receive IDs, got %s", req.URL.RawQuery)
+				}
+				json.NewDecoder(req.Body).Decode(&message)
+				return jsonResponse(`{"code":0,"data":{"message_id":"om_1"}}`), nil
+			}
+			t.Errorf("Unexpected request %s %s", req.Method, req.URL)
+			return jsonResponse(`{"code":1,"msg":"unexpected"}`), nil
+		}),
+	}
+
+	provider := &providers.LarkProvider{}
+	if err := provider.SendToChannel(types.ERROR, "Payment failed", nil, cfg, "ops"); err != nil {
+		t.Fatalf("Expected the SDK send to succeed, got %v", err)
+	}
+	if message["receive_id"] != "oc_2" || message["msg_type"] != "post" {
+		t.Errorf("Unexpected message: %v", message)
+	}
+	if content, _ := message["content"].(string); !strings.Contains(content, "Payment failed") {
+		t.Errorf("Expected the rendered post as JSON content, got %v", message["content"])
+	}
+	if listed != 2 {
+		t.Errorf("Expected both pages of chats to be listed, got %d requests", listed)
+	}
+
+	if err := provider.SendToChannel(types.ERROR, "Payment failed", nil, cfg, "alerts"); err != nil || listed != 2 {
+		t.Errorf("Expected the cached chat list to be used, got %v after %d requests", err, listed)
+	}
+}
@@ -0,0 +1,49 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+
+	"github.com/slack-go/slack"
+)
+
+// slackClient posts alerts with chat.postMessage through slack-go
+type slackClient struct{}
+
+func (slackClient) PostMessage(ctx context.Context, cfg types.Config, msg providers.SDKMessage) error {
+	var blocks slack.Blocks
+	if len(msg.Blocks) > 0 {
+		if err := json.Unmarshal(msg.Blocks, &blocks); err != nil {
+			return fmt.Errorf("invalid slack blocks: %w", err)
+		}
+	}
+	api := slack.New(msg.Token, slack.OptionHTTPClient(providers.HTTPClient(cfg)))
+	_, _, err := api.PostMessageContext(ctx, msg.ChannelID,
+		slack.MsgOptionText(msg.Text, false),
+		slack.MsgOptionBlocks(blocks.BlockSet...),
+	)
+	if err != nil {
+		return slackError(err)
+	}
+	types.DebugLog(cfg, "slack SDK: message posted to %s", msg.ChannelID)
+	return nil
+}
+
+// slackError reports Web API errors like the webclient method does, matching
+// types.ErrChannelNotFound for channel_not_found
+func slackError(err error) error {
+	var apiErr slack.SlackErrorResponse
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	err = fmt.Errorf("slack API error: %s", apiErr.Err)
+	if apiErr.Err == "channel_not_found" {
+		return providers.ChannelNotFound(err)
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultSecretTTL is how long a resolved secret is used before it is fetched again
+const defaultSecretTTL = 5 * time.Minute
+
+// secretCache keeps resolved secret references for secret_ttl, so rotated secrets are
+// picked up without fetching them for every alert
+type secretCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]secretEntry
+	fetches map[string]*secretFetch // fetches in progress, by reference
+}
+
+type secretEntry struct {
+	value   string
+	fetched time.Time
+}
+
+// secretFetch is a fetch of a secret reference, awaited by concurrent sends needing it
+type secretFetch struct {
+	done  chan struct{}
+	value string
+	err   error
+}
+
+func newSecretCache(cfg types.Config) *secretCache {
+	ttl, _ := cfg.ProviderConfig["secret_ttl"].(time.Duration)
+	if ttl <= 0 {
+		ttl = defaultSecretTTL
+	}
+	return &secretCache{ttl: ttl, entries: make(map[string]secretEntry), fetches: make(map[string]*secretFetch)}
+}
+
+// resolve returns the value of ref, fetching it when it isn't cached or is older than the
+// TTL. When a refresh fails the last value is kept, so a secrets manager outage doesn't
+// stop alerts whose token hasn't rotated. The cache is not locked during the fetch, so a
+// slow secrets manager only delays the sends needing that secret, and concurrent sends
+// wait for the fetch in progress instead of starting another.
+func (c *secretCache) resolve(cfg types.Config, ref string, now time.Time) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[ref]
+	if ok && now.Sub(entry.fetched) < c.ttl {
+		c.mu.Unlock()
+		return entry.value, nil
+	}
+	fetch, fetching := c.fetches[ref]
+	if !fetching {
+		fetch = &secretFetch{done: make(chan struct{})}
+		c.fetches[ref] = fetch
+	}
+	c.mu.Unlock()
+
+	if fetching {
+		<-fetch.done
+	} else {
+		fetch.value, fetch.err = providers.ResolveSecret(cfg, ref)
+		c.mu.Lock()
+		if fetch.err == nil {
+			c.entries[ref] = secretEntry{value: fetch.value, fetched: now}
+		}
+		delete(c.fetches, ref)
+		c.mu.Unlock()
+		close(fetch.done)
+	}
+	if fetch.err != nil {
+		if ok {
+			log.Printf("[WARN] Failed to refresh secret %s, using the cached value: %v", ref, fetch.err)
+			return entry.value, nil
+		}
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, fetch.err)
+	}
+	return fetch.value, nil
+}
+
+// invalidate drops every cached secret, so the next send fetches them again
+func (c *secretCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]secretEntry)
+}
+
+// authErrors are fragments of provider errors that mean a token was rejected
+var authErrors = []string{"invalid_auth", "token_expired", "token_revoked", "not_authed", "response: 401", "response: 403"}
+
+// authFailed drops the cached secrets when err means a token was rejected, since it may
+// have been rotated
+func (c *secretCache) authFailed(err error) {
+	c.mu.Lock()
+	cached := len(c.entries) > 0
+	c.mu.Unlock()
+	if !cached {
+		return
+	}
+	for _, fragment := range authErrors {
+		if strings.Contains(err.Error(), fragment) {
+			log.Printf("[WARN] Provider rejected the token, fetching secrets again for the next alert")
+			c.invalidate()
+			return
+		}
+	}
+}
+
+// resolveSecrets returns cfg with the secret references in Token and in string and
+// LarkTokenConfig settings replaced by their values. cfg.ProviderConfig is copied before
+// it is changed.
+func (l *Logger) resolveSecrets(cfg types.Config) (types.Config, error) {
+	now := l.now()
+	resolve := func(value string) (string, bool, error) {
+		if providers.SecretScheme(cfg, value) == "" {
+			return value, false, nil
+		}
+		resolved, err := l.secrets.resolve(cfg, value, now)
+		return resolved, true, err
+	}
+
+	token, _, err := resolve(cfg.Token)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Token = token
+
+	var providerConfig map[string]interface{}
+	set := func(key string, value interface{}) {
+		if providerConfig == nil {
+			providerConfig = make(map[string]interface{}, len(cfg.ProviderConfig))
+			for k, v := range cfg.ProviderConfig {
+				providerConfig[k] = v
+			}
+		}
+		providerConfig[key] = value
+	}
+	for key, value := range cfg.ProviderConfig {
+		switch value := value.(type) {
+		case string:
+			resolved, ok, err := resolve(value)
+			if err != nil {
+				return cfg, err
+			}
+			if ok {
+				set(key, resolved)
+			}
+		case types.LarkTokenConfig:
+			appID, idRef, err := resolve(value.AppID)
+			if err != nil {
+				return cfg, err
+			}
+			appSecret, secretRef, err := resolve(value.AppSecret)
+			if err != nil {
+				return cfg, err
+			}
+			if idRef || secretRef {
+				set(key, types.LarkTokenConfig{AppID: appID, AppSecret: appSecret})
+			}
+		case types.SlackRefreshConfig:
+			clientSecret, secretRef, err := resolve(value.ClientSecret)
+			if err != nil {
+				return cfg, err
+			}
+			refreshToken, refreshRef, err := resolve(value.RefreshToken)
+			if err != nil {
+				return cfg, err
+			}
+			if secretRef || refreshRef {
+				value.ClientSecret, value.RefreshToken = clientSecret, refreshToken
+				set(key, value)
+			}
+		}
+	}
+	if providerConfig != nil {
+		cfg.ProviderConfig = providerConfig
+	}
+	return cfg, nil
+}
+
+// RefreshSecrets drops the cached values of secret references, so the next alert fetches
+// them again; call it after rotating a token to use the new one before secret_ttl passes
+func (l *Logger) RefreshSecrets() {
+	l.secrets.invalidate()
+}
+
+// checkCacheEncryptionKey validates cache_encryption_key, unless it is a secret reference
+// resolved when alerts are sent
+func checkCacheEncryptionKey(cfg types.Config) error {
+	value, ok := cfg.ProviderConfig["cache_encryption_key"]
+	if !ok {
+		return nil
+	}
+	key, _ := value.(string)
+	if providers.SecretScheme(cfg, key) != "" {
+		return nil
+	}
+	if _, err := cache.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid cache_encryption_key: %w", err)
+	}
+	return nil
+}
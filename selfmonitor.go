@@ -0,0 +1,195 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Self-monitoring defaults
+const (
+	defaultSelfMonitorWindow    = 5 * time.Minute
+	defaultSelfMonitorMinAlerts = 5
+	selfMonitorBuckets          = 30 // self_monitor_window is counted in this many buckets
+)
+
+// selfMonitor is set with self_monitor_threshold. It measures the share of alerts each
+// provider failed to deliver within self_monitor_window and sends a meta-alert when it
+// reaches the threshold, so broken webhooks and revoked tokens are noticed before users
+// complain. A recovery alert follows once the failure rate falls to half the threshold.
+type selfMonitor struct {
+	mu        sync.Mutex
+	threshold float64
+	minAlerts int
+	window    time.Duration
+	bucket    time.Duration
+	providers map[string]*providerHealth
+	route     compiledRoute // where meta-alerts go
+	last      chan struct{} // closed once the latest meta-alert was sent, so they go out in order
+}
+
+// providerHealth counts the deliveries of one provider in buckets covering the window
+type providerHealth struct {
+	sent, failed [selfMonitorBuckets]int
+	index        int
+	current      time.Time // start of the current bucket
+	failing      bool      // a meta-alert was sent and recovery has not been reported
+	lastErr      string
+}
+
+// newSelfMonitor returns nil when self_monitor_threshold is not set. The meta-alerts go
+// to self_monitor_channel, through self_monitor_provider when set.
+func newSelfMonitor(cfg types.Config) *selfMonitor {
+	threshold, _ := cfg.ProviderConfig["self_monitor_threshold"].(float64)
+	if threshold <= 0 {
+		return nil
+	}
+	if threshold > 1 {
+		log.Printf("[WARN] self_monitor_threshold %v is a failure rate between 0 and 1, using 1", threshold)
+		threshold = 1
+	}
+	window, _ := cfg.ProviderConfig["self_monitor_window"].(time.Duration)
+	if window <= 0 {
+		window = defaultSelfMonitorWindow
+	}
+	minAlerts, _ := cfg.ProviderConfig["self_monitor_min_alerts"].(int)
+	if minAlerts <= 0 {
+		minAlerts = defaultSelfMonitorMinAlerts
+	}
+	bucket := window / selfMonitorBuckets
+	if bucket <= 0 {
+		bucket = 1
+	}
+	route := types.Route{Name: "self-monitor"}
+	route.Channel, _ = cfg.ProviderConfig["self_monitor_channel"].(string)
+	route.Provider, _ = cfg.ProviderConfig["self_monitor_provider"].(string)
+	route.SendMethod, _ = cfg.ProviderConfig["self_monitor_send_method"].(string)
+	route.Token, _ = cfg.ProviderConfig["self_monitor_token"].(string)
+	return &selfMonitor{
+		threshold: threshold,
+		minAlerts: minAlerts,
+		window:    window,
+		bucket:    bucket,
+		providers: make(map[string]*providerHealth),
+		route:     compileRouteList(cfg, []types.Route{route})[0],
+	}
+}
+
+// advance moves the current bucket to now, clearing the buckets that left the window
+func (m *selfMonitor) advance(health *providerHealth, now time.Time) {
+	if health.current.IsZero() {
+		health.current = now
+		return
+	}
+	passed := int64(now.Sub(health.current) / m.bucket)
+	if passed <= 0 {
+		return
+	}
+	for i := int64(0); i < passed && i < selfMonitorBuckets; i++ {
+		health.index = (health.index + 1) % selfMonitorBuckets
+		health.sent[health.index], health.failed[health.index] = 0, 0
+	}
+	health.current = health.current.Add(time.Duration(passed) * m.bucket)
+}
+
+// selfMonitorChange is a change of a provider's health reported by a meta-alert
+type selfMonitorChange int
+
+const (
+	selfMonitorSteady    selfMonitorChange = iota // nothing to report
+	selfMonitorFailing                            // the failure rate reached the threshold
+	selfMonitorRecovered                          // the failure rate fell to half the threshold
+)
+
+// selfMonitorReport is what record found about a provider
+type selfMonitorReport struct {
+	change        selfMonitorChange
+	failed, total int           // deliveries in the window
+	lastErr       string        // latest failure
+	previous      chan struct{} // closed once the previous meta-alert was sent, nil when none was
+	done          chan struct{} // to close once this report's meta-alert was sent
+}
+
+// record counts a delivery of provider and reports whether its health changed, with the
+// failed and total deliveries in the window
+func (m *selfMonitor) record(provider string, err error, now time.Time) selfMonitorReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health := m.providers[provider]
+	if health == nil {
+		health = &providerHealth{}
+		m.providers[provider] = health
+	}
+	m.advance(health, now)
+	if err != nil {
+		health.failed[health.index]++
+		health.lastErr = err.Error()
+	} else {
+		health.sent[health.index]++
+	}
+	failed, total := 0, 0
+	for i := range health.sent {
+		failed += health.failed[i]
+		total += health.sent[i] + health.failed[i]
+	}
+	report := selfMonitorReport{failed: failed, total: total, lastErr: health.lastErr}
+	rate := float64(failed) / float64(total)
+	switch {
+	case !health.failing && total >= m.minAlerts && rate >= m.threshold:
+		health.failing = true
+		report.change = selfMonitorFailing
+	case health.failing && err == nil && rate <= m.threshold/2:
+		health.failing = false
+		report.change = selfMonitorRecovered
+	default:
+		return report
+	}
+	report.previous, report.done = m.last, make(chan struct{})
+	m.last = report.done
+	return report
+}
+
+// monitorDelivery records the outcome of an alert passed to a provider and sends a
+// meta-alert when the provider starts failing or recovers. Meta-alerts are sent in order
+// on their own goroutine, so the alert that tipped the rate is not delayed, and are not
+// counted themselves, so a failing meta-alert cannot trigger another.
+func (l *Logger) monitorDelivery(providerName string, route *compiledRoute, err error) {
+	m := l.monitor
+	if m == nil || route == &m.route {
+		return
+	}
+	report := m.record(providerName, err, l.now())
+	var level int
+	var message string
+	switch report.change {
+	case selfMonitorFailing:
+		log.Printf("[ERROR] Alert delivery through %s is failing: %d of %d alerts failed", providerName, report.failed, report.total)
+		level = types.ERROR
+		message = fmt.Sprintf("Alert delivery through %s is failing: %d of %d alerts failed in the last %s (last error: %s)",
+			providerName, report.failed, report.total, m.window, report.lastErr)
+	case selfMonitorRecovered:
+		log.Printf("[INFO] Alert delivery through %s recovered", providerName)
+		level = types.WARN
+		message = fmt.Sprintf("Alert delivery through %s recovered: %d of %d alerts failed in the last %s",
+			providerName, report.failed, report.total, m.window)
+	default:
+		return
+	}
+	if l.beginSend() != nil {
+		close(report.done)
+		return
+	}
+	go func() {
+		defer l.inflight.Done()
+		defer close(report.done)
+		if report.previous != nil {
+			<-report.previous
+		}
+		if _, err := l.dispatch(level, message, types.SendOptions{}, "", &m.route, ""); err != nil {
+			log.Printf("[ERROR] Failed to send self-monitoring alert: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// slackSignatureMaxAge rejects replayed Slack interaction requests
+const slackSignatureMaxAge = 5 * time.Minute
+
+// AckHandler receives Acknowledge button interactions from Slack (interactivity request
+// URL) and Lark (card request URL) and acknowledges the alert on the logger
+type AckHandler struct {
+	logger *gocommonlog.Logger
+
+	SlackSigningSecret    string // Verifies X-Slack-Signature; Slack requests are rejected when empty
+	LarkVerificationToken string // Verifies the token in Lark callbacks; Lark requests are rejected when empty
+	MaxBodyBytes          int64  // Maximum accepted request body size, defaults to 1 MiB
+	now                   func() time.Time
+}
+
+// NewAckHandler creates an interaction callback handler for logger
+func NewAckHandler(logger *gocommonlog.Logger, slackSigningSecret, larkVerificationToken string) *AckHandler {
+	return &AckHandler{
+		logger:                logger,
+		SlackSigningSecret:    slackSigningSecret,
+		LarkVerificationToken: larkVerificationToken,
+		MaxBodyBytes:          defaultMaxBodyBytes,
+		now:                   time.Now,
+	}
+}
+
+// slackInteraction is the block_actions payload posted by Slack
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// larkCallback is a Lark card action callback or URL verification request
+type larkCallback struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Token     string `json:"token"`
+	OpenID    string `json:"open_id"`
+	Action    struct {
+		Value map[string]string `json:"value"`
+	} `json:"action"`
+}
+
+// ServeHTTP handles a single interaction callback
+func (h *AckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, "error", "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.MaxBodyBytes))
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "failed to read request body")
+		return
+	}
+
+	if r.Header.Get("X-Slack-Signature") != "" {
+		h.serveSlack(w, r, body)
+		return
+	}
+	h.serveLark(w, body)
+}
+
+func (h *AckHandler) serveSlack(w http.ResponseWriter, r *http.Request, body []byte) {
+	if !h.validSlackSignature(r.Header.Get("X-Slack-Request-Timestamp"), r.Header.Get("X-Slack-Signature"), body) {
+		writeResponse(w, http.StatusUnauthorized, "error", "invalid Slack signature")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "invalid form body")
+		return
+	}
+	var interaction slackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "invalid interaction payload: "+err.Error())
+		return
+	}
+	user := interaction.User.Username
+	if user == "" {
+		user = interaction.User.ID
+	}
+	for _, action := range interaction.Actions {
+		if action.ActionID == types.AckActionID {
+			h.acknowledge(w, action.Value, user)
+			return
+		}
+	}
+	writeResponse(w, http.StatusOK, "ignored", "")
+}
+
+// validSlackSignature checks the v0 HMAC-SHA256 request signature and its timestamp
+func (h *AckHandler) validSlackSignature(timestamp, signature string, body []byte) bool {
+	if h.SlackSigningSecret == "" {
+		return false
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := h.now().Sub(time.Unix(seconds, 0))
+	if age > slackSignatureMaxAge || age < -slackSignatureMaxAge {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.SlackSigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (h *AckHandler) serveLark(w http.ResponseWriter, body []byte) {
+	var callback larkCallback
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&callback); err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "invalid callback JSON: "+err.Error())
+		return
+	}
+	if h.LarkVerificationToken == "" || subtle.ConstantTimeCompare([]byte(callback.Token), []byte(h.LarkVerificationToken)) != 1 {
+		writeResponse(w, http.StatusUnauthorized, "error", "invalid Lark verification token")
+		return
+	}
+	if callback.Type == "url_verification" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"challenge": callback.Challenge})
+		return
+	}
+	if callback.Action.Value["action"] != types.AckActionID {
+		writeResponse(w, http.StatusOK, "ignored", "")
+		return
+	}
+	h.acknowledge(w, callback.Action.Value["alert_id"], callback.OpenID)
+}
+
+func (h *AckHandler) acknowledge(w http.ResponseWriter, alertID, user string) {
+	err := h.logger.Acknowledge(alertID, user)
+	if errors.Is(err, gocommonlog.ErrAlertNotFound) {
+		writeResponse(w, http.StatusNotFound, "error", "unknown or expired alert")
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to acknowledge alert %s: %v", alertID, err)
+		writeResponse(w, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+	writeResponse(w, http.StatusOK, "acknowledged", "")
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// GrafanaWebhook is the payload of a Grafana unified alerting webhook contact point
+type GrafanaWebhook struct {
+	Receiver string         `json:"receiver"`
+	Status   string         `json:"status"` // "firing" or "resolved"
+	Alerts   []GrafanaAlert `json:"alerts"`
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+}
+
+// GrafanaAlert is one alert of a Grafana webhook
+type GrafanaAlert struct {
+	Status       string             `json:"status"` // "firing" or "resolved"
+	Labels       map[string]string  `json:"labels"`
+	Annotations  map[string]string  `json:"annotations"`
+	StartsAt     time.Time          `json:"startsAt"`
+	EndsAt       time.Time          `json:"endsAt"`
+	Values       map[string]float64 `json:"values"`
+	GeneratorURL string             `json:"generatorURL"`
+	Fingerprint  string             `json:"fingerprint"`
+	SilenceURL   string             `json:"silenceURL"`
+	DashboardURL string             `json:"dashboardURL"`
+	PanelURL     string             `json:"panelURL"`
+	ImageURL     string             `json:"imageURL"`
+}
+
+// GrafanaHandler receives Grafana unified alerting webhooks and sends each firing alert
+// through a Logger, with its panel image and links to the dashboard, panel, alert rule
+// and silence form
+type GrafanaHandler struct {
+	logger       *gocommonlog.Logger
+	tokens       []string
+	MaxBodyBytes int64 // Maximum accepted request body size, defaults to 1 MiB
+	SendResolved bool  // Send an INFO alert when an alert resolves, subject to the INFO level policy
+}
+
+// NewGrafanaHandler creates a Grafana webhook receiver. Requests must present one of
+// tokens like the relay Handler; configure it as the contact point's authorization
+// credentials. A channel query parameter overrides the channel of every alert.
+func NewGrafanaHandler(logger *gocommonlog.Logger, tokens ...string) *GrafanaHandler {
+	return &GrafanaHandler{logger: logger, tokens: tokens, MaxBodyBytes: defaultMaxBodyBytes}
+}
+
+// ServeHTTP handles POST requests carrying a GrafanaWebhook, answering 202 when every
+// alert was delivered and 502 with the provider errors otherwise
+func (h *GrafanaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, "error", "method not allowed")
+		return
+	}
+	if !authorized(r, h.tokens) {
+		writeResponse(w, http.StatusUnauthorized, "error", "invalid or missing token")
+		return
+	}
+
+	var webhook GrafanaWebhook
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.MaxBodyBytes))
+	if err := decoder.Decode(&webhook); err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "invalid Grafana webhook JSON: "+err.Error())
+		return
+	}
+	if len(webhook.Alerts) == 0 {
+		writeResponse(w, http.StatusBadRequest, "error", "webhook has no alerts")
+		return
+	}
+
+	channel := r.URL.Query().Get("channel")
+	var failures []string
+	for _, alert := range webhook.Alerts {
+		if err := h.forward(alert, channel); err != nil {
+			log.Printf("[ERROR] Failed to forward Grafana alert %s: %v", alert.Labels["alertname"], err)
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		writeResponse(w, http.StatusBadGateway, "error", strings.Join(failures, "; "))
+		return
+	}
+	writeResponse(w, http.StatusAccepted, "sent", "")
+}
+
+// forward sends one Grafana alert, or clears its condition for flap detection when it
+// resolved
+func (h *GrafanaHandler) forward(alert GrafanaAlert, channel string) error {
+	condition := "grafana:" + alert.Fingerprint
+	level := grafanaLevel(alert.Labels["severity"])
+	message := grafanaMessage(alert)
+	if alert.Status == "resolved" {
+		h.logger.Resolve(condition)
+		if !h.SendResolved {
+			return nil
+		}
+		level, message = types.INFO, "Resolved: "+message
+	}
+
+	opts := types.SendOptions{
+		Channel:     channel,
+		Time:        alert.StartsAt,
+		ServiceName: alert.Labels["service"],
+		Environment: alert.Labels["environment"],
+		Links:       grafanaLinks(alert),
+		Condition:   condition,
+	}
+	if alert.Status == "resolved" {
+		opts.Time, opts.Condition = alert.EndsAt, ""
+	}
+	if alert.ImageURL != "" {
+		opts.Image = &types.Image{URL: alert.ImageURL, AltText: alert.Labels["alertname"]}
+	}
+	_, err := h.logger.SendWithOptions(level, message, opts)
+	return err
+}
+
+// grafanaLevel maps the severity label to an alert level, defaulting to ERROR
+func grafanaLevel(severity string) int {
+	switch strings.ToLower(severity) {
+	case "warning", "warn", "medium":
+		return types.WARN
+	case "info", "low":
+		return types.INFO
+	default:
+		return types.ERROR
+	}
+}
+
+// grafanaMessage renders the alert name and summary, its description and the query values
+func grafanaMessage(alert GrafanaAlert) string {
+	message := alert.Labels["alertname"]
+	if summary := alert.Annotations["summary"]; summary != "" {
+		if message != "" {
+			message += ": "
+		}
+		message += summary
+	}
+	if description := alert.Annotations["description"]; description != "" {
+		message += "\n" + description
+	}
+	if len(alert.Values) > 0 {
+		names := make([]string, 0, len(alert.Values))
+		for name := range alert.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = fmt.Sprintf("%s=%g", name, alert.Values[name])
+		}
+		message += "\nValues: " + strings.Join(values, ", ")
+	}
+	return message
+}
+
+// grafanaLinks returns the runbook, dashboard, panel, alert rule and silence links the
+// alert has
+func grafanaLinks(alert GrafanaAlert) []types.Link {
+	var links []types.Link
+	for _, link := range []types.Link{
+		{Text: "Runbook", URL: alert.Annotations["runbook_url"]},
+		{Text: "Dashboard", URL: alert.DashboardURL},
+		{Text: "Panel", URL: alert.PanelURL},
+		{Text: "Alert rule", URL: alert.GeneratorURL},
+		{Text: "Silence", URL: alert.SilenceURL},
+	} {
+		if link.URL != "" {
+			links = append(links, link)
+		}
+	}
+	return links
+}
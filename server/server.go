@@ -0,0 +1,166 @@
+// Package server exposes a Logger over HTTP, turning gocommonlog into a
+// self-hostable alert relay for services written in other languages.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultMaxBodyBytes limits the size of an incoming alert document
+const defaultMaxBodyBytes = 1 << 20
+
+// healthCheckTimeout bounds the dependency checks run for /healthz
+const healthCheckTimeout = 5 * time.Second
+
+// Alert is the JSON document accepted by the relay endpoint
+type Alert struct {
+	Level       string            `json:"level"`                 // "info", "warn" or "error"
+	Message     string            `json:"message"`               // Alert text
+	Channel     string            `json:"channel,omitempty"`     // Optional channel override
+	Provider    string            `json:"provider,omitempty"`    // Optional provider override
+	Trace       string            `json:"trace,omitempty"`       // Optional trace log
+	Attachment  *types.Attachment `json:"attachment,omitempty"`  // Optional attachment
+	Service     string            `json:"service,omitempty"`     // Optional service name override
+	Environment string            `json:"environment,omitempty"` // Optional environment override
+}
+
+// response is returned for every request
+type response struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler accepts alerts over HTTP and forwards them through a Logger
+type Handler struct {
+	logger       *gocommonlog.Logger
+	tokens       []string
+	MaxBodyBytes int64 // Maximum accepted request body size, defaults to 1 MiB
+}
+
+// NewHandler creates a relay handler. Requests must present one of tokens as
+// "Authorization: Bearer <token>" or "X-Commonlog-Token: <token>"; with no tokens
+// authentication is disabled.
+func NewHandler(logger *gocommonlog.Logger, tokens ...string) *Handler {
+	return &Handler{logger: logger, tokens: tokens, MaxBodyBytes: defaultMaxBodyBytes}
+}
+
+// ServeHTTP handles POST requests carrying a single Alert
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeResponse(w, http.StatusMethodNotAllowed, "error", "method not allowed")
+		return
+	}
+	if !authorized(r, h.tokens) {
+		writeResponse(w, http.StatusUnauthorized, "error", "invalid or missing token")
+		return
+	}
+
+	var alert Alert
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.MaxBodyBytes))
+	if err := decoder.Decode(&alert); err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", "invalid alert JSON: "+err.Error())
+		return
+	}
+	level, err := types.ParseLevel(alert.Level)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "error", err.Error())
+		return
+	}
+	if alert.Message == "" {
+		writeResponse(w, http.StatusBadRequest, "error", "message is required")
+		return
+	}
+
+	_, err = h.logger.SendWithOptions(level, alert.Message, types.SendOptions{
+		Attachment:  alert.Attachment,
+		Trace:       alert.Trace,
+		Channel:     alert.Channel,
+		Provider:    alert.Provider,
+		ServiceName: alert.Service,
+		Environment: alert.Environment,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to relay alert: %v", err)
+		writeResponse(w, http.StatusBadGateway, "error", err.Error())
+		return
+	}
+	writeResponse(w, http.StatusAccepted, "sent", "")
+}
+
+// authorized reports whether r presents one of tokens, always true with no tokens
+func authorized(r *http.Request, tokens []string) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	presented := r.Header.Get("X-Commonlog-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		presented = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if presented == "" {
+		return false
+	}
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeResponse(w http.ResponseWriter, status int, state, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response{Status: state, Error: message})
+}
+
+// HealthHandler reports logger.HealthCheck as JSON, answering 503 when any component is unhealthy
+func HealthHandler(logger *gocommonlog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+		health := logger.HealthCheck(ctx)
+		code := http.StatusOK
+		if !health.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(health)
+	})
+}
+
+// StatsHandler reports logger.Stats as JSON. Requests must present one of tokens like
+// relay requests, since the stats name providers and their last errors.
+func StatsHandler(logger *gocommonlog.Logger, tokens ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, tokens) {
+			writeResponse(w, http.StatusUnauthorized, "error", "invalid or missing token")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logger.Stats())
+	})
+}
+
+// ListenAndServe serves the relay on addr at /alerts and the Grafana receiver at /grafana,
+// with a /healthz endpoint backed by HealthHandler and a /stats endpoint backed by
+// StatsHandler, which requires the tokens like the relay
+func ListenAndServe(addr string, logger *gocommonlog.Logger, tokens ...string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/alerts", NewHandler(logger, tokens...))
+	mux.Handle("/grafana", NewGrafanaHandler(logger, tokens...))
+	mux.Handle("/healthz", HealthHandler(logger))
+	mux.Handle("/stats", StatsHandler(logger, tokens...))
+	log.Printf("[INFO] gocommonlog relay listening on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
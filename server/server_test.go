@@ -0,0 +1,245 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type captureProvider struct {
+	level      int
+	message    string
+	channel    string
+	attachment *types.Attachment
+	cfg        types.Config
+	count      int
+}
+
+func (p *captureProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *captureProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.level, p.message, p.channel, p.attachment, p.cfg = level, message, channel, attachment, cfg
+	p.count++
+	return nil
+}
+
+func newTestHandler(t *testing.T) (*Handler, *captureProvider) {
+	capture := &captureProvider{}
+	gocommonlog.RegisterProvider("server-capture", func() types.Provider { return capture })
+	logger := gocommonlog.NewLogger(types.Config{Provider: "server-capture", Channel: "#default"})
+	return NewHandler(logger, "relay-token"), capture
+}
+
+func TestHandlerRelaysAlert(t *testing.T) {
+	handler, capture := newTestHandler(t)
+	body := `{"level":"error","message":"disk full","channel":"#ops","trace":"stack","attachment":{"file_name":"df.txt","content":"100%"}}`
+	req := httptest.NewRequest("POST", "/alerts", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer relay-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if capture.level != types.ERROR || capture.message != "disk full" || capture.channel != "#ops" {
+		t.Errorf("Unexpected relayed alert: %+v", capture)
+	}
+	if capture.attachment == nil || !strings.Contains(capture.attachment.Content, "stack") {
+		t.Errorf("Expected attachment with trace, got %+v", capture.attachment)
+	}
+}
+
+func TestHandlerRejectsInvalidRequests(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	cases := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{"missing token", "POST", "", `{"level":"error","message":"x"}`, http.StatusUnauthorized},
+		{"wrong token", "POST", "nope", `{"level":"error","message":"x"}`, http.StatusUnauthorized},
+		{"wrong method", "GET", "relay-token", "", http.StatusMethodNotAllowed},
+		{"bad json", "POST", "relay-token", `{`, http.StatusBadRequest},
+		{"bad level", "POST", "relay-token", `{"level":"fatal","message":"x"}`, http.StatusBadRequest},
+		{"empty message", "POST", "relay-token", `{"level":"warn"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/alerts", strings.NewReader(tc.body))
+		if tc.token != "" {
+			req.Header.Set("X-Commonlog-Token", tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, rec.Code)
+		}
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	rec := httptest.NewRecorder()
+	HealthHandler(handler.logger).ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"healthy":true`) {
+		t.Errorf("Expected healthy 200 response, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func newAckLogger(t *testing.T) *gocommonlog.Logger {
+	gocommonlog.RegisterProvider("server-ack", func() types.Provider { return &captureProvider{} })
+	return gocommonlog.NewLogger(types.Config{
+		Provider:       "server-ack",
+		Channel:        "#ops",
+		Cache:          cache.NewInMemoryCache(),
+		ProviderConfig: map[string]interface{}{"ack_enabled": true},
+	})
+}
+
+func TestAckHandlerSlack(t *testing.T) {
+	logger := newAckLogger(t)
+	alertID, _ := logger.SendWithOptions(types.ERROR, "disk full", types.SendOptions{})
+	handler := NewAckHandler(logger, "signing-secret", "")
+
+	payload := `{"type":"block_actions","user":{"id":"U1","username":"alice"},"actions":[{"action_id":"commonlog_ack","value":"` + alertID + `"}]}`
+	body := url.Values{"payload": {payload}}.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("signing-secret"))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	req := httptest.NewRequest("POST", "/ack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if acked, by, _ := logger.AckStatus(alertID); !acked || by != "alice" {
+		t.Errorf("Expected alert acknowledged by alice, got %t %q", acked, by)
+	}
+
+	req = httptest.NewRequest("POST", "/ack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0=forged")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a forged signature, got %d", rec.Code)
+	}
+}
+
+func TestAckHandlerLark(t *testing.T) {
+	logger := newAckLogger(t)
+	alertID, _ := logger.SendWithOptions(types.ERROR, "disk full", types.SendOptions{})
+	handler := NewAckHandler(logger, "", "verify-token")
+
+	cases := []struct {
+		body string
+		code int
+	}{
+		{`{"type":"url_verification","challenge":"abc","token":"verify-token"}`, http.StatusOK},
+		{`{"token":"wrong","open_id":"ou_1","action":{"value":{"action":"commonlog_ack","alert_id":"` + alertID + `"}}}`, http.StatusUnauthorized},
+		{`{"token":"verify-token","open_id":"ou_1","action":{"value":{"action":"commonlog_ack","alert_id":"unknown"}}}`, http.StatusNotFound},
+		{`{"token":"verify-token","open_id":"ou_1","action":{"value":{"action":"commonlog_ack","alert_id":"` + alertID + `"}}}`, http.StatusOK},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/ack", strings.NewReader(c.body)))
+		if rec.Code != c.code {
+			t.Errorf("Expected %d for %s, got %d: %s", c.code, c.body, rec.Code, rec.Body.String())
+		}
+	}
+	if acked, by, _ := logger.AckStatus(alertID); !acked || by != "ou_1" {
+		t.Errorf("Expected alert acknowledged by ou_1, got %t %q", acked, by)
+	}
+}
+
+func TestGrafanaHandler(t *testing.T) {
+	capture := &captureProvider{}
+	logger := gocommonlog.NewLogger(types.Config{Channel: "#default"}, gocommonlog.WithProvider(capture))
+	handler := NewGrafanaHandler(logger, "grafana-token")
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/grafana?channel=%23monitoring", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer grafana-token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	firing := `{"receiver":"chat","status":"firing","alerts":[{"status":"firing",
+		"labels":{"alertname":"HighLatency","severity":"warning","service":"checkout"},
+		"annotations":{"summary":"p99 latency above 2s","runbook_url":"https://runbooks.example.com/latency"},
+		"startsAt":"2024-05-01T12:00:00Z","values":{"B":2.4},"fingerprint":"abc123",
+		"generatorURL":"https://grafana.example.com/alerting/grafana/uid/view",
+		"panelURL":"https://grafana.example.com/d/checkout?viewPanel=2",
+		"imageURL":"https://grafana.example.com/public/img/attachments/abc.png"}]}`
+	if rec := post(firing); rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if capture.level != types.WARN || capture.message != "HighLatency: p99 latency above 2s\nValues: B=2.4" || capture.channel != "#monitoring" {
+		t.Errorf("Unexpected forwarded alert: %d %q to %s", capture.level, capture.message, capture.channel)
+	}
+	if capture.cfg.Image == nil || capture.cfg.Image.URL != "https://grafana.example.com/public/img/attachments/abc.png" || capture.cfg.ServiceName != "checkout" {
+		t.Errorf("Expected the panel image and service label, got %+v", capture.cfg)
+	}
+	if len(capture.cfg.Links) != 3 || capture.cfg.Links[0].Text != "Runbook" || capture.cfg.Links[1].Text != "Panel" {
+		t.Errorf("Unexpected links: %+v", capture.cfg.Links)
+	}
+
+	resolved := strings.Replace(firing, `"status":"firing",
+		"labels"`, `"status":"resolved",
+		"labels"`, 1)
+	post(resolved)
+	if capture.count != 1 {
+		t.Errorf("Expected resolved alerts to be skipped by default, got %d sends", capture.count)
+	}
+
+	if rec := post(`{"status":"firing","alerts":[]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a webhook without alerts, got %d", rec.Code)
+	}
+	req := httptest.NewRequest("POST", "/grafana", strings.NewReader(firing))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the token, got %d", rec.Code)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	handler.logger.Send(types.ERROR, "disk full", nil, "")
+	rec := httptest.NewRecorder()
+	StatsHandler(handler.logger).ServeHTTP(rec, httptest.NewRequest("GET", "/stats", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sent":1`) {
+		t.Errorf("Expected stats with one sent alert, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	StatsHandler(handler.logger, "s3cret").ServeHTTP(rec, httptest.NewRequest("GET", "/stats", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the token, got %d", rec.Code)
+	}
+	req := httptest.NewRequest("GET", "/stats", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	StatsHandler(handler.logger, "s3cret").ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected stats with the token, got %d", rec.Code)
+	}
+}
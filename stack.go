@@ -0,0 +1,133 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// maxStackDepth bounds the frames captured by Stack
+const maxStackDepth = 128
+
+// libraryDir is the directory of the library's sources, whose frames are not call sites
+var libraryDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file) + "/"
+}()
+
+// Stack returns the calling goroutine's stack, formatted like debug.Stack, as the trace
+// of an alert. skip omits that many callers, 0 starting with the caller of Stack. In a
+// deferred function recovering a panic, the stack starts at the panic instead.
+//
+// The stack_* settings filter the frames so chat messages show the relevant application
+// frames: runtime frames are always dropped, stack_skip_packages drops the frames of
+// functions starting with any of its prefixes, such as "github.com/gin-gonic/",
+// stack_skip_vendor drops the frames of dependencies and the standard library,
+// stack_trim_prefixes trims file path prefixes such as the build directory, and
+// stack_max_frames caps the number of frames.
+func (l *Logger) Stack(skip int) string {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+
+	var all []runtime.Frame
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			// Frames above the panic are the recovering code
+			all = all[:0]
+		}
+		all = append(all, frame)
+		if !more {
+			break
+		}
+	}
+
+	skipPackages, _ := l.config.ProviderConfig["stack_skip_packages"].([]string)
+	skipVendor, _ := l.config.ProviderConfig["stack_skip_vendor"].(bool)
+	trimPrefixes, _ := l.config.ProviderConfig["stack_trim_prefixes"].([]string)
+	maxFrames, _ := l.config.ProviderConfig["stack_max_frames"].(int)
+
+	var b strings.Builder
+	written, omitted := 0, 0
+	for _, frame := range all {
+		if skipFrame(frame, skipPackages, skipVendor) {
+			continue
+		}
+		if maxFrames > 0 && written == maxFrames {
+			omitted++
+			continue
+		}
+		fmt.Fprintf(&b, "%s(...)\n\t%s:%d\n", frame.Function, trimFile(frame.File, trimPrefixes), frame.Line)
+		written++
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, "... %d more frames\n", omitted)
+	}
+	return b.String()
+}
+
+// skipFrame reports whether frame is left out of a stack
+func skipFrame(frame runtime.Frame, skipPackages []string, skipVendor bool) bool {
+	if frame.Function == "" || strings.HasPrefix(frame.Function, "runtime.") || strings.HasPrefix(frame.Function, "runtime/debug.") {
+		return true
+	}
+	for _, prefix := range skipPackages {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return true
+		}
+	}
+	if skipVendor {
+		// Module cache paths, or module@version paths in -trimpath builds
+		file := frame.File
+		return strings.Contains(file, "/vendor/") || strings.Contains(file, "/pkg/mod/") || strings.Contains(file, "@v") ||
+			strings.HasPrefix(file, runtime.GOROOT()+"/src/")
+	}
+	return false
+}
+
+// trimFile shortens a file path: module cache paths to their module path, and the first
+// matching prefix of trimPrefixes removed
+func trimFile(file string, trimPrefixes []string) string {
+	if i := strings.Index(file, "/pkg/mod/"); i >= 0 {
+		return file[i+len("/pkg/mod/"):]
+	}
+	for _, prefix := range trimPrefixes {
+		if strings.HasPrefix(file, prefix) {
+			return strings.TrimPrefix(file[len(prefix):], "/")
+		}
+	}
+	return file
+}
+
+// callSite returns the file:line and function of the first caller outside the library
+// when include_source is set, skipping source_skip more frames for the application's own
+// alert helpers, or "" otherwise
+func (l *Logger) callSite() string {
+	if include, _ := l.config.ProviderConfig["include_source"].(bool); !include {
+		return ""
+	}
+	skip, _ := l.config.ProviderConfig["source_skip"].(int)
+	trimPrefixes, _ := l.config.ProviderConfig["stack_trim_prefixes"].([]string)
+
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.File, libraryDir) && !strings.HasSuffix(frame.File, "_test.go")
+		if !internal && !skipFrame(frame, nil, false) {
+			if skip == 0 {
+				function := frame.Function
+				if slash := strings.LastIndex(function, "/"); slash >= 0 {
+					function = function[slash+1:]
+				}
+				return fmt.Sprintf("%s:%d %s", trimFile(frame.File, trimPrefixes), frame.Line, function)
+			}
+			skip--
+		}
+		if !more {
+			return ""
+		}
+	}
+}
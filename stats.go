@@ -0,0 +1,113 @@
+package gocommonlog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// ProviderStats counts the alerts passed to one provider
+type ProviderStats struct {
+	Sent                int64     `json:"sent"`
+	Failed              int64     `json:"failed"`
+	ConsecutiveFailures int64     `json:"consecutive_failures"` // Failures since the last alert the provider delivered
+	LastError           string    `json:"last_error,omitempty"` // URLs are redacted, since webhook URLs carry tokens
+	LastFailure         time.Time `json:"last_failure"`         // Zero when no alert failed
+	LastSuccess         time.Time `json:"last_success"`         // Zero when no alert was delivered
+}
+
+// Stats is a snapshot of the alert pipeline returned by Logger.Stats. The counters cover
+// every alert since the logger was created, including scheduled alerts, follow-ups and
+// alerts sent by SendAsync workers.
+type Stats struct {
+	Sent          int64                    `json:"sent"`
+	Failed        int64                    `json:"failed"`
+	Suppressed    int64                    `json:"suppressed"` // Sampled, dropped, flapping, muted, storm and skipped alerts
+	Logged        int64                    `json:"logged"`     // Alerts written to the local log only
+	Outcomes      map[string]int64         `json:"outcomes"`   // Alerts by audit outcome, such as sent or sampled
+	Providers     map[string]ProviderStats `json:"providers"`  // Sent and failed alerts by provider name
+	Queue         QueueStats               `json:"queue"`
+	Storm         StormStats               `json:"storm"`
+	Flap          FlapStats                `json:"flap"`
+	Cache         CacheStats               `json:"cache"`
+	MemoryHitRate float64                  `json:"memory_hit_rate"` // Share of memory cache lookups that were hits
+	RedisHitRate  float64                  `json:"redis_hit_rate"`  // Share of Redis cache lookups that were hits
+}
+
+// pipelineCounters counts the outcome of every alert handled by the logger
+type pipelineCounters struct {
+	mu        sync.Mutex
+	outcomes  map[string]int64
+	providers map[string]*ProviderStats
+}
+
+// record counts an alert by its outcome, and by provider when it was sent or failed
+func (c *pipelineCounters) record(record types.AuditRecord, err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.outcomes == nil {
+		c.outcomes = make(map[string]int64)
+		c.providers = make(map[string]*ProviderStats)
+	}
+	c.outcomes[record.Outcome]++
+	if record.Outcome != types.AuditSent && record.Outcome != types.AuditFailed {
+		return
+	}
+	provider := c.providers[record.Provider]
+	if provider == nil {
+		provider = &ProviderStats{}
+		c.providers[record.Provider] = provider
+	}
+	if record.Outcome == types.AuditSent {
+		provider.Sent++
+		provider.ConsecutiveFailures = 0
+		provider.LastSuccess = now
+		return
+	}
+	provider.Failed++
+	provider.ConsecutiveFailures++
+	provider.LastFailure = now
+	if err != nil {
+		provider.LastError = providers.RedactURLs(err.Error())
+	}
+}
+
+// Stats returns the counts of sent, failed and suppressed alerts, the state of each
+// provider, the SendAsync queue, the alert storm safety valve, flap detection and the
+// caches, for simple dashboards without a metrics system. server.StatsHandler serves it
+// as JSON. The logger has no circuit breaker or token-bucket rate limiter, so there is no
+// breaker state or rate-limit tokens to report; the storm valve rate is the closest.
+func (l *Logger) Stats() Stats {
+	stats := Stats{
+		Outcomes:  make(map[string]int64),
+		Providers: make(map[string]ProviderStats),
+		Queue:     l.QueueStats(),
+		Storm:     l.StormStats(),
+		Flap:      l.FlapStats(),
+		Cache:     l.CacheStats(),
+	}
+	c := &l.counters
+	c.mu.Lock()
+	for outcome, count := range c.outcomes {
+		stats.Outcomes[outcome] = count
+		switch outcome {
+		case types.AuditSent:
+			stats.Sent = count
+		case types.AuditFailed:
+			stats.Failed = count
+		case types.AuditLogged:
+			stats.Logged = count
+		default:
+			stats.Suppressed += count
+		}
+	}
+	for name, provider := range c.providers {
+		stats.Providers[name] = *provider
+	}
+	c.mu.Unlock()
+	stats.MemoryHitRate = stats.Cache.Memory.HitRate()
+	stats.RedisHitRate = stats.Cache.Redis.HitRate()
+	return stats
+}
@@ -0,0 +1,174 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// Alert storm defaults
+const (
+	defaultStormWindow = time.Hour
+	stormBuckets       = 60 // storm_window is counted in this many buckets
+)
+
+// stormValve is the safety valve set with storm_threshold. When more than
+// storm_threshold alerts are sent within storm_window, across all channels, a single
+// notice is sent and further alerts are suppressed until the rate of alerts falls to
+// half the threshold, so the logger cannot flood channels or exhaust provider quotas.
+// Up to storm_error_reserve ERROR alerts within the window are still delivered during a
+// storm, so a burst of WARN and INFO alerts cannot hide them.
+type stormValve struct {
+	mu         sync.Mutex
+	threshold  int
+	reserve    int // ERROR alerts delivered during storms within the window
+	window     time.Duration
+	bucket     time.Duration
+	counts     [stormBuckets]int // alerts per bucket, including suppressed ones
+	reserved   [stormBuckets]int // ERROR alerts delivered from the reserve per bucket
+	index      int               // index of the current bucket
+	current    time.Time         // start of the current bucket
+	storming   bool
+	since      time.Time // start of the current storm
+	suppressed int       // alerts suppressed during the current storm
+	storms     int
+	total      int64 // alerts suppressed since the logger was created
+}
+
+// stormDecision is the outcome of an alert for the safety valve
+type stormDecision int
+
+const (
+	stormDeliver  stormDecision = iota // deliver the alert
+	stormNotice                        // the storm started, send the notice instead
+	stormSuppress                      // a storm is in progress, suppress the alert
+)
+
+// newStormValve returns nil when storm_threshold is not set
+func newStormValve(cfg types.Config) *stormValve {
+	threshold, _ := cfg.ProviderConfig["storm_threshold"].(int)
+	if threshold <= 0 {
+		return nil
+	}
+	window, _ := cfg.ProviderConfig["storm_window"].(time.Duration)
+	if window <= 0 {
+		window = defaultStormWindow
+	}
+	bucket := window / stormBuckets
+	if bucket <= 0 {
+		bucket = 1
+	}
+	reserve, set := cfg.ProviderConfig["storm_error_reserve"].(int)
+	if !set {
+		reserve = threshold / 10
+	}
+	if reserve < 0 {
+		reserve = 0
+	}
+	return &stormValve{threshold: threshold, reserve: reserve, window: window, bucket: bucket}
+}
+
+// advance moves the current bucket to now, clearing the buckets that left the window
+func (v *stormValve) advance(now time.Time) {
+	if v.current.IsZero() {
+		v.current = now
+		return
+	}
+	passed := int64(now.Sub(v.current) / v.bucket)
+	if passed <= 0 {
+		return
+	}
+	for i := int64(0); i < passed && i < stormBuckets; i++ {
+		v.index = (v.index + 1) % stormBuckets
+		v.counts[v.index] = 0
+		v.reserved[v.index] = 0
+	}
+	v.current = v.current.Add(time.Duration(passed) * v.bucket)
+}
+
+// rate returns the number of alerts within the window
+func (v *stormValve) rate() int {
+	return sumBuckets(v.counts)
+}
+
+// sumBuckets returns the count within the window
+func sumBuckets(counts [stormBuckets]int) int {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total
+}
+
+// fire records an alert of level at now and decides whether it is delivered. When a
+// storm ends, the number of alerts suppressed during it is returned.
+func (v *stormValve) fire(now time.Time, level int) (stormDecision, int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.advance(now)
+	v.counts[v.index]++
+	rate := v.rate()
+	if !v.storming {
+		if rate <= v.threshold {
+			return stormDeliver, 0
+		}
+		v.storming, v.since = true, now
+		v.storms++
+		return stormNotice, 0
+	}
+	if rate > v.threshold/2 {
+		if level == types.ERROR && sumBuckets(v.reserved) < v.reserve {
+			v.reserved[v.index]++
+			return stormDeliver, 0
+		}
+		v.suppressed++
+		v.total++
+		return stormSuppress, 0
+	}
+	suppressed := v.suppressed
+	v.storming, v.suppressed = false, 0
+	return stormDeliver, suppressed
+}
+
+// stormNoticeMessage replaces the alert that started the storm
+func (v *stormValve) stormNoticeMessage(message string) string {
+	return fmt.Sprintf("Alert storm detected: more than %d alerts in %s; alerts are suppressed until the rate drops to %d.\n\nLatest alert: %s",
+		v.threshold, v.window, v.threshold/2, message)
+}
+
+// stormEndedMessage appends the number of alerts suppressed during the storm
+func stormEndedMessage(message string, suppressed int) string {
+	if suppressed == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s\n\n(%d alerts were suppressed during the alert storm)", message, suppressed)
+}
+
+// StormStats reports the alert storm safety valve set with storm_threshold
+type StormStats struct {
+	Storming   bool      `json:"storming"`   // Alerts are being suppressed
+	Since      time.Time `json:"since"`      // Start of the current storm, zero when none
+	Rate       int       `json:"rate"`       // Alerts within storm_window, including suppressed ones
+	Storms     int       `json:"storms"`     // Storms since the logger was created
+	Suppressed int64     `json:"suppressed"` // Alerts suppressed since the logger was created
+}
+
+// StormStats returns the state and counters of the alert storm safety valve, for
+// example to export the number of suppressed alerts as a metric. It is zero when
+// storm_threshold is not set.
+func (l *Logger) StormStats() StormStats {
+	if l.storm == nil {
+		return StormStats{}
+	}
+	v := l.storm
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.advance(l.now())
+	stats := StormStats{Storming: v.storming, Rate: v.rate(), Storms: v.storms, Suppressed: v.total}
+	if v.storming {
+		stats.Since = v.since
+	}
+	return stats
+}
@@ -0,0 +1,84 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// alertTemplate is a registered message template with its severity preset
+type alertTemplate struct {
+	level int
+	tmpl  *template.Template
+}
+
+// Built-in templates. Deployments are WARN so they reach chat, since INFO alerts are
+// only logged locally by default.
+var builtinTemplates = map[string]struct {
+	level int
+	text  string
+}{
+	"deployment": {types.WARN, `Deployed {{.version}}{{with index . "service"}} of {{.}}{{end}}{{with index . "environment"}} to {{.}}{{end}}` +
+		`{{with index . "author"}}
+Deployed by: {{.}}{{end}}{{with index . "changes"}}
+Changes: {{.}}{{end}}{{with index . "url"}}
+Details: {{.}}{{end}}`},
+	"job_failure": {types.ERROR, `Job {{.job}} failed{{with index . "duration"}} after {{.}}{{end}}
+Error: {{.error}}{{with index . "run_id"}}
+Run: {{.}}{{end}}{{with index . "url"}}
+Logs: {{.}}{{end}}`},
+	"sla_breach": {types.ERROR, `SLA breach: {{.sla}} is {{.actual}} against a target of {{.target}}` +
+		`{{with index . "window"}} over {{.}}{{end}}{{with index . "dashboard"}}
+Dashboard: {{.}}{{end}}`},
+	"data_quality": {types.WARN, `Data quality check {{.check}} failed on {{.dataset}}` +
+		`{{with index . "failed_rows"}}: {{.}} rows failed{{end}}{{with index . "details"}}
+Details: {{.}}{{end}}`},
+}
+
+var (
+	templateRegistry   = map[string]alertTemplate{}
+	templateRegistryMu sync.RWMutex
+)
+
+func init() {
+	for name, builtin := range builtinTemplates {
+		if err := RegisterTemplate(name, builtin.level, builtin.text); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTemplate makes a text/template available to SendTemplate under name, with the
+// alert level it is sent at. Referencing a key missing from the data is an error, so use
+// {{with index . "key"}} for optional values. Registering an existing name replaces it.
+func RegisterTemplate(name string, level int, text string) error {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid alert template %s: %w", name, err)
+	}
+	templateRegistryMu.Lock()
+	defer templateRegistryMu.Unlock()
+	templateRegistry[name] = alertTemplate{level: level, tmpl: tmpl}
+	return nil
+}
+
+// SendTemplate renders the named template with data and sends it at the template's level,
+// returning the alert ID. Providers format the rendered text like any other message.
+func (l *Logger) SendTemplate(name string, data map[string]interface{}) (string, error) {
+	templateRegistryMu.RLock()
+	registered, ok := templateRegistry[name]
+	templateRegistryMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown alert template: %s", name)
+	}
+
+	var message strings.Builder
+	if err := registered.tmpl.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("failed to render alert template %s: %w", name, err)
+	}
+	types.DebugLog(l.config, "Rendered template %s, level: %d, message length: %d", name, registered.level, message.Len())
+	return l.SendWithOptions(registered.level, message.String(), types.SendOptions{})
+}
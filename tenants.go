@@ -0,0 +1,79 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// tenantTokenSettings are the token settings replaced by a tenant's token, so the
+// logger's own credentials are never used for a tenant's alert
+var tenantTokenSettings = []string{"slack_token", "slack_refresh", "lark_token"}
+
+// tenantConfig returns cfg with the token of tenant from cfg.TokenStore for the provider,
+// or cfg unchanged when tenant is empty
+func tenantConfig(cfg types.Config, tenant, providerName string) (types.Config, error) {
+	if tenant == "" {
+		return cfg, nil
+	}
+	if cfg.TokenStore == nil {
+		return cfg, fmt.Errorf("alert for tenant %s requires a TokenStore", tenant)
+	}
+	token, err := cfg.TokenStore.Get(tenant, providerName)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get %s token for tenant %s: %w", providerName, tenant, err)
+	}
+	if token == "" {
+		return cfg, fmt.Errorf("no %s token for tenant %s", providerName, tenant)
+	}
+	types.DebugLog(cfg, "Using %s token of tenant %s (length: %d)", providerName, tenant, len(token))
+
+	providerConfig := make(map[string]interface{}, len(cfg.ProviderConfig))
+	for key, value := range cfg.ProviderConfig {
+		providerConfig[key] = value
+	}
+	for _, key := range tenantTokenSettings {
+		delete(providerConfig, key)
+	}
+	providerConfig["token"] = token
+	cfg.ProviderConfig = providerConfig
+	cfg.Token = token
+	cfg.Tenant = tenant
+	return cfg, nil
+}
+
+// cacheTokenStore is a TokenStore kept in a cache.Cache
+type cacheTokenStore struct {
+	cache cache.Cache
+}
+
+// NewTokenStore returns a TokenStore that keeps tokens in c, or the global cache when c
+// is nil, under commonlog_tenant_token:{provider}:{tenant}. Use a shared cache such as
+// Redis so every instance sees the same tokens.
+func NewTokenStore(c cache.Cache) types.TokenStore {
+	return cacheTokenStore{cache: c}
+}
+
+func (s cacheTokenStore) store() cache.Cache {
+	if s.cache != nil {
+		return s.cache
+	}
+	return cache.GetGlobalCache()
+}
+
+// Get returns the token of tenant for provider
+func (s cacheTokenStore) Get(tenant, provider string) (string, error) {
+	token, ok := s.store().Get("commonlog_tenant_token:" + provider + ":" + tenant)
+	if !ok {
+		return "", fmt.Errorf("no %s token stored for tenant %s", provider, tenant)
+	}
+	return token, nil
+}
+
+// Set stores the token of tenant for provider until replaced
+func (s cacheTokenStore) Set(tenant, provider, token string) error {
+	s.store().Set("commonlog_tenant_token:"+provider+":"+tenant, token, 100*365*24*time.Hour)
+	return nil
+}
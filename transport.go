@@ -0,0 +1,99 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"github.com/alvianhanif/gocommonlog/providers"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// transportSettings are the settings that need the logger's own HTTP client
+var transportSettings = []string{"proxy_url", "tls_client_cert", "tls_client_key", "tls_ca_cert", "dns_server", "ip_preference", "dial_timeout"}
+
+// newHTTPClient returns the client providers send with when the transport settings need
+// one of their own, or nil to keep Config.HTTPClient, which defaults to
+// http.DefaultClient and so honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+func newHTTPClient(cfg types.Config) (*http.Client, error) {
+	var set []string
+	for _, key := range transportSettings {
+		if _, ok := cfg.ProviderConfig[key]; ok {
+			set = append(set, key)
+		}
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	if cfg.HTTPClient != nil {
+		log.Printf("[WARN] %s ignored because Config.HTTPClient is set; configure that client instead", strings.Join(set, ", "))
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if _, ok := cfg.ProviderConfig["proxy_url"]; ok {
+		proxy, err := proxyFunc(cfg)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+	dial, err := providers.DialContext(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if dial != nil {
+		transport.DialContext = dial
+	}
+	tlsConfig, err := providers.ClientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// proxyFunc sends requests through proxy_url, authenticating with proxy_username and
+// proxy_password when set, except to the hosts in no_proxy, which defaults to NO_PROXY
+func proxyFunc(cfg types.Config) (func(*http.Request) (*url.URL, error), error) {
+	raw, _ := cfg.ProviderConfig["proxy_url"].(string)
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Host == "" {
+		// The URL may hold credentials, so it is not included
+		return nil, fmt.Errorf("invalid proxy_url: want a URL such as http://proxy.example.com:3128")
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy_url scheme %q: want http, https or socks5", proxyURL.Scheme)
+	}
+	if username, _ := cfg.ProviderConfig["proxy_username"].(string); username != "" {
+		password, _ := cfg.ProviderConfig["proxy_password"].(string)
+		proxyURL.User = url.UserPassword(username, password)
+	}
+
+	var noProxy string
+	switch hosts := cfg.ProviderConfig["no_proxy"].(type) {
+	case string:
+		noProxy = hosts
+	case []string:
+		noProxy = strings.Join(hosts, ",")
+	default:
+		noProxy = os.Getenv("NO_PROXY")
+		if noProxy == "" {
+			noProxy = os.Getenv("no_proxy")
+		}
+	}
+	proxyConfig := httpproxy.Config{HTTPProxy: proxyURL.String(), HTTPSProxy: proxyURL.String(), NoProxy: noProxy}
+	proxy := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timer is a call scheduled with TimerClock.AfterFunc. *time.Timer implements it.
+type Timer interface {
+	Stop() bool // Cancels the call, reporting false if it already ran or was stopped
+}
+
+// TimerClock is a Clock that also schedules calls, so tests can fire scheduled alerts,
+// ack reminders, escalations and the end of mutes by moving time. The Logger schedules
+// with system timers when Config.Clock does not implement it.
+type TimerClock interface {
+	Clock
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// ManualClock is a TimerClock for tests whose time only moves when Advance or Set is
+// called. Calls scheduled with AfterFunc run in order, on the goroutine moving the time,
+// once it reaches them.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	at    time.Time
+	f     func()
+}
+
+// NewManualClock returns a ManualClock set to now
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the clock's time
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the time forward by d, running the calls that become due
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set moves the time to t, running the calls that become due, including those they
+// schedule in turn. Each call sees the time it was due at.
+func (c *ManualClock) Set(t time.Time) {
+	for {
+		timer := c.nextDue(t)
+		if timer == nil {
+			return
+		}
+		timer.f()
+	}
+}
+
+// nextDue removes and returns the earliest call due by t, moving the time to it, or moves
+// the time to t when none is
+func (c *ManualClock) nextDue(t time.Time) *manualTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) == 0 || c.timers[0].at.After(t) {
+		c.now = t
+		return nil
+	}
+	timer := c.timers[0]
+	c.timers = c.timers[1:]
+	if timer.at.After(c.now) {
+		c.now = timer.at
+	}
+	return timer
+}
+
+// AfterFunc schedules f to run once the time has moved d past now. Like time.AfterFunc,
+// f runs on its own goroutine right away when d is not positive.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &manualTimer{clock: c, at: c.now.Add(d), f: f}
+	if d <= 0 {
+		go f()
+		return timer
+	}
+	i := sort.Search(len(c.timers), func(i int) bool { return c.timers[i].at.After(timer.at) })
+	c.timers = append(c.timers, nil)
+	copy(c.timers[i+1:], c.timers[i:])
+	c.timers[i] = timer
+	return timer
+}
+
+// Pending returns the number of scheduled calls that have not run
+func (c *ManualClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Stop implements Timer
+func (t *manualTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
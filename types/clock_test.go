@@ -0,0 +1,43 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManualClockRunsDueCallsInOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	var calls []string
+	clock.AfterFunc(2*time.Minute, func() { calls = append(calls, "2m") })
+	clock.AfterFunc(time.Minute, func() {
+		calls = append(calls, "1m")
+		// Due within the same Advance, so it runs too
+		clock.AfterFunc(30*time.Second, func() { calls = append(calls, "1m30s") })
+	})
+	stopped := clock.AfterFunc(90*time.Second, func() { calls = append(calls, "stopped") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Errorf("Expected Stop to report true once")
+	}
+
+	clock.Advance(time.Minute + 30*time.Second)
+	if strings.Join(calls, ",") != "1m,1m30s" || clock.Pending() != 1 {
+		t.Errorf("Expected the due calls only, got %v with %d pending", calls, clock.Pending())
+	}
+	if !clock.Now().Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Unexpected time %v", clock.Now())
+	}
+	clock.Set(start.Add(time.Hour))
+	if strings.Join(calls, ",") != "1m,1m30s,2m" || clock.Pending() != 0 {
+		t.Errorf("Expected every call to run, got %v", calls)
+	}
+
+	done := make(chan struct{})
+	clock.AfterFunc(0, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Expected a call due now to run right away")
+	}
+}
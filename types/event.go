@@ -0,0 +1,165 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AlertEvent is the canonical structured form of an alert. Providers emitting structured
+// documents (generic webhook, Kafka, Elasticsearch, Pub/Sub, CloudWatch Logs, syslog,
+// GitHub and Sentry) build it with NewAlertEvent, so they all share the JSON schema of
+// MarshalJSON.
+type AlertEvent struct {
+	ID            string            // Unique alert ID, empty for alerts not sent through a Logger
+	CorrelationID string            // Caller-provided correlation ID
+	Time          time.Time         // When the alerted event occurred
+	Level         int               // Alert level
+	Service       string            // Service the alert is about
+	Environment   string            // Environment the alert is about
+	Channel       string            // Channel the alert is sent to
+	Message       string            // Alert message
+	Fingerprint   string            // Grouping key, see FingerprintFunc
+	Fields        map[string]string // Extra key/value fields from Config.Fields and SendOptions.Fields
+	Trace         string            // Trace log, split out of the attachment
+	Attachment    *Attachment       // Attachment without the trace, nil when there is none
+	Links         []Link            // Named links
+	Snippets      []Snippet         // Code snippets
+	ImageURL      string            // URL of the image shown with the alert
+	Source        string            // Call site of the alert, see Config.Source
+}
+
+// alertEventJSON is the wire form of AlertEvent
+type alertEventJSON struct {
+	ID            string            `json:"id,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Level         string            `json:"level"`
+	Message       string            `json:"message"`
+	Service       string            `json:"service,omitempty"`
+	Environment   string            `json:"environment,omitempty"`
+	Channel       string            `json:"channel,omitempty"`
+	Timestamp     string            `json:"timestamp"`
+	Fingerprint   string            `json:"fingerprint"`
+	Fields        map[string]string `json:"fields,omitempty"`
+	Trace         string            `json:"trace,omitempty"`
+	Attachment    *Attachment       `json:"attachment,omitempty"`
+	Links         []Link            `json:"links,omitempty"`
+	Snippets      []Snippet         `json:"snippets,omitempty"`
+	ImageURL      string            `json:"image_url,omitempty"`
+	Source        string            `json:"source,omitempty"`
+}
+
+// NewAlertEvent builds the event for an alert sent to channel, splitting a merged trace
+// back out of the attachment content. The fingerprint is cfg.Fingerprint, or the default
+// one for alerts not sent through a Logger.
+func NewAlertEvent(level int, message string, attachment *Attachment, cfg Config, channel string) AlertEvent {
+	event := AlertEvent{
+		ID:            cfg.MessageID,
+		CorrelationID: cfg.CorrelationID,
+		Time:          cfg.EventTime,
+		Level:         level,
+		Service:       cfg.ServiceName,
+		Environment:   cfg.Environment,
+		Channel:       channel,
+		Message:       message,
+		Fingerprint:   cfg.Fingerprint,
+		Fields:        cfg.Fields,
+		Links:         cfg.Links,
+		Snippets:      cfg.Snippets,
+		Source:        cfg.Source,
+	}
+	if event.Fingerprint == "" {
+		event.Fingerprint = Fingerprint(level, message)
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+		if cfg.Clock != nil {
+			event.Time = cfg.Clock.Now()
+		}
+	}
+	if cfg.Image != nil {
+		event.ImageURL = cfg.Image.URL
+	}
+	if attachment == nil {
+		return event
+	}
+
+	content := attachment.Content
+	if attachment.FileName == TraceFileName {
+		event.Trace, content = content, ""
+	} else if idx := strings.Index(content, TraceSeparator); idx >= 0 {
+		event.Trace = content[idx+len(TraceSeparator):]
+		content = content[:idx]
+	}
+	if content != "" || attachment.URL != "" {
+		event.Attachment = &Attachment{
+			URL:      attachment.URL,
+			FileName: attachment.FileName,
+			Content:  content,
+		}
+	}
+	return event
+}
+
+// Timestamp returns the event time in UTC as RFC 3339, as it appears in the JSON
+func (e AlertEvent) Timestamp() string {
+	return e.Time.UTC().Format(time.RFC3339)
+}
+
+// MarshalJSON encodes the event with the level as its name and the time as an RFC 3339
+// timestamp in UTC
+func (e AlertEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(alertEventJSON{
+		ID:            e.ID,
+		CorrelationID: e.CorrelationID,
+		Level:         LevelName(e.Level),
+		Message:       e.Message,
+		Service:       e.Service,
+		Environment:   e.Environment,
+		Channel:       e.Channel,
+		Timestamp:     e.Timestamp(),
+		Fingerprint:   e.Fingerprint,
+		Fields:        e.Fields,
+		Trace:         e.Trace,
+		Attachment:    e.Attachment,
+		Links:         e.Links,
+		Snippets:      e.Snippets,
+		ImageURL:      e.ImageURL,
+		Source:        e.Source,
+	})
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON, for consumers of the sinks
+func (e *AlertEvent) UnmarshalJSON(data []byte) error {
+	var wire alertEventJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	level, err := ParseLevel(wire.Level)
+	if err != nil {
+		return err
+	}
+	eventTime, err := time.Parse(time.RFC3339, wire.Timestamp)
+	if err != nil {
+		return err
+	}
+	*e = AlertEvent{
+		ID:            wire.ID,
+		CorrelationID: wire.CorrelationID,
+		Time:          eventTime,
+		Level:         level,
+		Service:       wire.Service,
+		Environment:   wire.Environment,
+		Channel:       wire.Channel,
+		Message:       wire.Message,
+		Fingerprint:   wire.Fingerprint,
+		Fields:        wire.Fields,
+		Trace:         wire.Trace,
+		Attachment:    wire.Attachment,
+		Links:         wire.Links,
+		Snippets:      wire.Snippets,
+		ImageURL:      wire.ImageURL,
+		Source:        wire.Source,
+	}
+	return nil
+}
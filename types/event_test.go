@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAlertEventJSON(t *testing.T) {
+	cfg := Config{
+		ServiceName: "billing",
+		Environment: "prod",
+		MessageID:   "01HX",
+		EventTime:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("WIB", 7*3600)),
+		Fields:      map[string]string{"region": "eu-west-1"},
+	}
+	attachment := &Attachment{FileName: "payload.json", Content: "{}" + TraceSeparator + "panic: boom"}
+	event := NewAlertEvent(ERROR, "Payment failed", attachment, cfg, "#ops")
+	if event.Trace != "panic: boom" || event.Attachment.Content != "{}" {
+		t.Errorf("Expected the trace split from the attachment, got %q and %+v", event.Trace, event.Attachment)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Expected the event to encode, got %v", err)
+	}
+	var wire map[string]interface{}
+	json.Unmarshal(data, &wire)
+	if wire["level"] != "ERROR" || wire["timestamp"] != "2024-01-01T20:04:05Z" || wire["channel"] != "#ops" || wire["fingerprint"] != Fingerprint(ERROR, "Payment failed") {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var decoded AlertEvent
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected the event to decode, got %v", err)
+	}
+	if !decoded.Time.Equal(event.Time) {
+		t.Errorf("Expected time %v, got %v", event.Time, decoded.Time)
+	}
+	decoded.Time = event.Time
+	if !reflect.DeepEqual(decoded, event) {
+		t.Errorf("Expected the event to round-trip, got %+v want %+v", decoded, event)
+	}
+}
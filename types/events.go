@@ -0,0 +1,41 @@
+package types
+
+import "time"
+
+// Lifecycle events emitted by the Logger to Config.OnEvent and its subscribers. There
+// are no retry, circuit breaker or dead-letter events, since the Logger does not retry
+// sends or short-circuit providers; alerts written to async_spill_file are EventSpilled.
+const (
+	EventEnqueued       = "enqueued"        // SendAsync queued an alert
+	EventDropped        = "dropped"         // A queued alert was dropped to make room
+	EventRejected       = "rejected"        // SendAsync failed with ErrQueueFull
+	EventSpilled        = "spilled"         // An alert was written to async_spill_file, to be sent later; Detail is the file
+	EventScheduled      = "scheduled"       // An alert was scheduled by SendAt, SendAfter or an ack follow-up; Detail is when it is due
+	EventSent           = "sent"            // An alert was delivered to its provider
+	EventFailed         = "failed"          // The provider failed to deliver an alert
+	EventSuppressed     = "suppressed"      // An alert was not sent; Detail is the audit outcome, such as sampled or storm
+	EventStormStart     = "storm_start"     // The alert storm safety valve started suppressing alerts
+	EventStormEnd       = "storm_end"       // The alert storm ended; Detail is the number of suppressed alerts
+	EventFlapping       = "flapping"        // A condition started flapping; Detail is the condition
+	EventBudgetExceeded = "budget_exceeded" // A service sent more alerts than its alert budget; Detail is the service
+	EventMuted          = "muted"           // Mute was called; Detail is the reason
+	EventUnmuted        = "unmuted"         // Unmute was called or a mute with muted alerts ended; Detail is the number muted
+	EventConfigError    = "config_error"    // NewLogger found a configuration problem, reported to Config.OnEvent only; Err is the problem
+)
+
+// Event is a lifecycle event of the Logger, for applications to log or react to the
+// health of their alerting. Fields that do not apply to the event are empty.
+type Event struct {
+	Type     string    // One of the Event types
+	Time     time.Time // When the event happened, by Config.Clock
+	AlertID  string    // Alert the event is about, when it has been assigned one
+	Level    int       // Level of the alert
+	Provider string    // Provider the alert was passed to
+	Channel  string    // Channel the alert was sent to
+	Detail   string    // Event-specific detail, described with each type
+	Err      error     // Why the alert failed
+}
+
+// EventFunc is called for each lifecycle event on the goroutine that caused it, often in
+// the middle of a send, so it must not block
+type EventFunc func(event Event)
@@ -0,0 +1,52 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// FingerprintFunc returns the grouping key of an alert, used by sinks to group and
+// deduplicate alerts, e.g. to group by error type, endpoint or customer instead of the
+// message text. FingerprintOf turns the parts of a key into a fingerprint.
+type FingerprintFunc func(alert AlertContext) string
+
+var (
+	uuidPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	hexPattern    = regexp.MustCompile(`\b(0x)?[0-9a-fA-F]{8,}\b`)
+	numberPattern = regexp.MustCompile(`\b\d+(\.\d+)*`)
+)
+
+// NormalizeMessage replaces the variable parts of a message, UUIDs, hexadecimal IDs of 8
+// or more digits and numbers, with placeholders, so alerts differing only in IDs or
+// counts share a fingerprint
+func NormalizeMessage(message string) string {
+	message = uuidPattern.ReplaceAllString(message, "<uuid>")
+	message = hexPattern.ReplaceAllStringFunc(message, func(id string) string {
+		// Words like "deadbeef" have no digit, and numbers are replaced below
+		digits := strings.TrimPrefix(id, "0x")
+		if !strings.ContainsAny(digits, "0123456789") || strings.Trim(digits, "0123456789") == "" {
+			return id
+		}
+		return "<hex>"
+	})
+	return numberPattern.ReplaceAllString(message, "<n>")
+}
+
+// Fingerprint returns the default grouping key of an alert: its level and normalized
+// message
+func Fingerprint(level int, message string) string {
+	return FingerprintOf(LevelName(level), NormalizeMessage(message))
+}
+
+// DefaultFingerprint is the FingerprintFunc used when Config.Fingerprinter is not set
+func DefaultFingerprint(alert AlertContext) string {
+	return Fingerprint(alert.Level, alert.Message)
+}
+
+// FingerprintOf returns a fingerprint identifying the given parts
+func FingerprintOf(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(sum[:8])
+}
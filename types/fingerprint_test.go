@@ -0,0 +1,22 @@
+package types
+
+import "testing"
+
+func TestNormalizeMessage(t *testing.T) {
+	for message, expected := range map[string]string{
+		"Order 1234 failed after 3.5s":                                  "Order <n> failed after <n>s",
+		"User 0b9e7c4a-6f1d-4c57-9a3e-2d8f1b6c7e90 not found":           "User <uuid> not found",
+		"Object 5f3c9a7e1b2d4f60 missing at 0x7ffe12ab":                 "Object <hex> missing at <hex>",
+		"Cache deadbeef lookup for tenant acme-eu2 on http2 connection": "Cache deadbeef lookup for tenant acme-eu2 on http2 connection",
+	} {
+		if normalized := NormalizeMessage(message); normalized != expected {
+			t.Errorf("NormalizeMessage(%q) = %q, want %q", message, normalized, expected)
+		}
+	}
+	if Fingerprint(ERROR, "Order 1234 failed") != Fingerprint(ERROR, "Order 5678 failed") {
+		t.Error("Expected messages differing in numbers to share a fingerprint")
+	}
+	if Fingerprint(ERROR, "Order 1234 failed") == Fingerprint(WARN, "Order 1234 failed") {
+		t.Error("Expected the level to be part of the fingerprint")
+	}
+}
@@ -0,0 +1,73 @@
+package types
+
+// Fixed strings rendered by providers around an alert, used as translation keys
+const (
+	TextAlert         = "Alert"
+	TextAttachment    = "Attachment"
+	TextTraceLogs     = "Trace Logs"
+	TextAlertID       = "Alert ID"
+	TextCorrelationID = "Correlation ID"
+	TextAcknowledge   = "Acknowledge"
+	TextImage         = "Image"
+
+	TextNotAcknowledged = "Not acknowledged"                       // Prefix of ack reminders
+	TextEscalation      = "Escalation (not acknowledged after %s)" // Prefix of escalations, formatted with the step delay
+)
+
+// Translator localizes the fixed strings providers render around an alert. Returning ""
+// keeps the English text.
+type Translator interface {
+	Translate(locale, text string) string
+}
+
+// Translations is a Translator backed by a locale -> English text -> translation table
+type Translations map[string]map[string]string
+
+// Translate looks text up in the locale's table
+func (t Translations) Translate(locale, text string) string {
+	return t[locale][text]
+}
+
+// DefaultTranslations is used when Config.Locale is set without a Translator
+var DefaultTranslations = Translations{
+	"zh_cn": {
+		TextAlert:         "告警",
+		TextAttachment:    "附件",
+		TextTraceLogs:     "跟踪日志",
+		TextAlertID:       "告警 ID",
+		TextCorrelationID: "关联 ID",
+		TextAcknowledge:   "确认",
+		TextImage:         "图片",
+
+		TextNotAcknowledged: "未确认",
+		TextEscalation:      "升级（%s 后仍未确认）",
+	},
+	"ja_jp": {
+		TextAlert:         "アラート",
+		TextAttachment:    "添付ファイル",
+		TextTraceLogs:     "トレースログ",
+		TextAlertID:       "アラート ID",
+		TextCorrelationID: "相関 ID",
+		TextAcknowledge:   "確認",
+		TextImage:         "画像",
+
+		TextNotAcknowledged: "未確認",
+		TextEscalation:      "エスカレーション（%s 経過後も未確認）",
+	},
+}
+
+// Localize translates text into cfg.Locale, returning text unchanged when no locale is
+// set or no translation exists
+func Localize(cfg Config, text string) string {
+	if cfg.Locale == "" {
+		return text
+	}
+	var translator Translator = DefaultTranslations
+	if cfg.Translator != nil {
+		translator = cfg.Translator
+	}
+	if translated := translator.Translate(cfg.Locale, text); translated != "" {
+		return translated
+	}
+	return text
+}
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockfordAlphabet is the Crockford base32 alphabet used by ULIDs
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID for t: a 48-bit millisecond timestamp followed by 80 random
+// bits, encoded as 26 Crockford base32 characters so IDs sort by creation time
+func NewULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	rand.Read(id[6:])
+	return encodeULID(id)
+}
+
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var dst [26]byte
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(dst[:])
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewULID(t *testing.T) {
+	at := time.Unix(0, 1469918176385*int64(time.Millisecond))
+	id := NewULID(at)
+	if len(id) != 26 || !strings.HasPrefix(id, "01ARYZ6S41") {
+		t.Errorf("Expected 26 character ULID with timestamp prefix 01ARYZ6S41, got %s", id)
+	}
+	if other := NewULID(at); other == id {
+		t.Errorf("Expected random component to differ, got %s twice", id)
+	}
+	if later := NewULID(at.Add(time.Millisecond)); later <= id {
+		t.Errorf("Expected later ULID %s to sort after %s", later, id)
+	}
+
+	var max [16]byte
+	for i := range max {
+		max[i] = 0xff
+	}
+	if got := encodeULID(max); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Errorf("Expected maximum ULID, got %s", got)
+	}
+}
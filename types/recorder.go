@@ -0,0 +1,60 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// HTTPExchange is one provider HTTP request and its response, with credentials redacted
+type HTTPExchange struct {
+	Time           time.Time         `json:"time"`
+	AlertID        string            `json:"alert_id,omitempty"`
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	RequestHeaders map[string]string `json:"request_headers,omitempty"`
+	RequestBody    string            `json:"request_body,omitempty"`
+	Status         int               `json:"status,omitempty"`
+	ResponseBody   string            `json:"response_body,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	DurationMs     int64             `json:"duration_ms"`
+}
+
+// FlightRecorder keeps the last provider HTTP exchanges in a ring buffer, so delivery
+// issues can be diagnosed after the fact without full debug logging
+type FlightRecorder struct {
+	mu        sync.Mutex
+	exchanges []HTTPExchange
+	next      int
+	full      bool
+}
+
+// NewFlightRecorder returns a recorder keeping the last size exchanges
+func NewFlightRecorder(size int) *FlightRecorder {
+	if size < 1 {
+		size = 1
+	}
+	return &FlightRecorder{exchanges: make([]HTTPExchange, size)}
+}
+
+// Record adds an exchange, replacing the oldest one when the buffer is full
+func (r *FlightRecorder) Record(exchange HTTPExchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges[r.next] = exchange
+	r.next = (r.next + 1) % len(r.exchanges)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Exchanges returns the recorded exchanges, oldest first
+func (r *FlightRecorder) Exchanges() []HTTPExchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]HTTPExchange(nil), r.exchanges[:r.next]...)
+	}
+	exchanges := make([]HTTPExchange, 0, len(r.exchanges))
+	exchanges = append(exchanges, r.exchanges[r.next:]...)
+	return append(exchanges, r.exchanges[:r.next]...)
+}
@@ -0,0 +1,30 @@
+package types
+
+import "net/http"
+
+// RenderedRequest is an HTTP request a provider would make to send an alert
+type RenderedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// RenderedAlert is what a provider would send for an alert, as captured by Logger.Render
+// without any network calls
+type RenderedAlert struct {
+	ID       string            // Alert ID the alert was rendered with, a new one for each render
+	Provider string            // Provider the alert would be sent with, after routing
+	Channel  string            // Channel the alert would be sent to
+	Message  string            // Message passed to the provider, after scrubbing
+	Requests []RenderedRequest // Requests the provider made, in order
+}
+
+// Payload returns the body of the last request, which posts the alert itself for
+// providers that first fetch a token or upload an image, or "" when no request was made
+func (r RenderedAlert) Payload() string {
+	if len(r.Requests) == 0 {
+		return ""
+	}
+	return string(r.Requests[len(r.Requests)-1].Body)
+}
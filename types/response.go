@@ -0,0 +1,69 @@
+package types
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProviderResponse is what the provider reported when sending an alert, for workflows
+// built on the posted message such as threading replies or updating it. Providers fill
+// it in through Config.Response during the send.
+type ProviderResponse struct {
+	StatusCode int               // HTTP status of the provider's last response, 0 for providers not using HTTP
+	MessageID  string            // ID of the posted message, e.g. the Slack ts or the Lark message_id
+	Channel    string            // ID of the channel posted to, e.g. Slack's C0123ABC or Lark's oc_ chat ID
+	RateLimit  map[string]string // Rate-limit headers of the last response, such as Retry-After and X-RateLimit-Remaining
+	RetryAfter time.Duration     // Retry-After of the last response, when it was given in seconds
+	Data       map[string]string // Other provider-specific values
+}
+
+// SetMessage records the ID of the posted message and its channel; r may be nil
+func (r *ProviderResponse) SetMessage(id, channel string) {
+	if r == nil {
+		return
+	}
+	r.MessageID = id
+	if channel != "" {
+		r.Channel = channel
+	}
+}
+
+// Set records a provider-specific value; r may be nil
+func (r *ProviderResponse) Set(key, value string) {
+	if r == nil || value == "" {
+		return
+	}
+	if r.Data == nil {
+		r.Data = make(map[string]string)
+	}
+	r.Data[key] = value
+}
+
+// RecordHTTP records the status and rate-limit headers of an HTTP response; r may be nil
+func (r *ProviderResponse) RecordHTTP(resp *http.Response) {
+	if r == nil || resp == nil {
+		return
+	}
+	r.StatusCode = resp.StatusCode
+	r.RateLimit, r.RetryAfter = nil, 0
+	for name, values := range resp.Header {
+		lower := strings.ToLower(name)
+		if lower != "retry-after" && !strings.HasPrefix(lower, "x-ratelimit") && !strings.HasPrefix(lower, "ratelimit") {
+			continue
+		}
+		if r.RateLimit == nil {
+			r.RateLimit = make(map[string]string)
+		}
+		r.RateLimit[name] = strings.Join(values, ", ")
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		r.RetryAfter = time.Duration(seconds) * time.Second
+	}
+}
+
+// AfterSendFunc is called after each alert the Logger passed to a provider, whether it
+// was delivered or failed, with delivery.Response holding what the provider reported. It
+// runs on the sending goroutine, so it should return quickly.
+type AfterSendFunc func(alert AlertContext, delivery Delivery)
@@ -0,0 +1,42 @@
+package types
+
+// Sections of a rendered alert, listed in Config.Sections or SendOptions.Sections to set
+// their order and which are shown
+const (
+	SectionHeader     = "header"     // Service and environment, with the timestamp, hostname and call site line
+	SectionMessage    = "message"    // The alert message
+	SectionFields     = "fields"     // Config.Fields and SendOptions.Fields as key: value lines; not shown by default
+	SectionTrace      = "trace"      // Inline attachment content, such as the trace log
+	SectionAttachment = "attachment" // Link to the attachment
+	SectionFooter     = "footer"     // Alert and correlation IDs, with the include_footer line
+)
+
+// DefaultSections is the layout used when Config.Sections is empty
+var DefaultSections = []string{SectionHeader, SectionMessage, SectionTrace, SectionAttachment, SectionFooter}
+
+// KnownSection reports whether name is one of the Section constants
+func KnownSection(name string) bool {
+	switch name {
+	case SectionHeader, SectionMessage, SectionFields, SectionTrace, SectionAttachment, SectionFooter:
+		return true
+	}
+	return false
+}
+
+// SectionsOf returns the sections cfg renders, in order
+func SectionsOf(cfg Config) []string {
+	if len(cfg.Sections) > 0 {
+		return cfg.Sections
+	}
+	return DefaultSections
+}
+
+// ShowsSection reports whether cfg renders section
+func ShowsSection(cfg Config, section string) bool {
+	for _, name := range SectionsOf(cfg) {
+		if name == section {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,17 @@
 package types
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/alvianhanif/gocommonlog/cache"
 )
 
 // AlertLevel defines the severity of the alert
@@ -14,6 +23,59 @@ const (
 	ERROR
 )
 
+// LevelName returns the human readable name of an alert level
+func LevelName(level int) string {
+	switch level {
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// LevelPolicy controls what happens to alerts of a level
+type LevelPolicy string
+
+// Level policies for Config.LevelPolicies
+const (
+	PolicyLocalOnly LevelPolicy = "local_only" // Written to the local log only, the default for INFO
+	PolicySendOnly  LevelPolicy = "send_only"  // Sent to the provider only, the default for WARN and ERROR
+	PolicyBoth      LevelPolicy = "both"       // Written to the local log and sent
+	PolicyDrop      LevelPolicy = "drop"       // Discarded
+)
+
+// DefaultLevelPolicy returns the policy used for level when Config.LevelPolicies has none
+func DefaultLevelPolicy(level int) LevelPolicy {
+	if level == INFO {
+		return PolicyLocalOnly
+	}
+	return PolicySendOnly
+}
+
+// ParseLevel converts a level name ("info", "warn"/"warning", "error") to its alert level
+func ParseLevel(name string) (int, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "INFO":
+		return INFO, nil
+	case "WARN", "WARNING":
+		return WARN, nil
+	case "ERROR":
+		return ERROR, nil
+	default:
+		return 0, fmt.Errorf("unknown alert level: %s", name)
+	}
+}
+
+// Trace attachment conventions shared by the logger and providers
+const (
+	TraceFileName  = "trace.log"
+	TraceSeparator = "\n\n--- Trace Log ---\n"
+)
+
 // DebugLogger provides centralized debug logging
 var DebugLogger = log.New(os.Stdout, "[COMMONLOG DEBUG] ", log.LstdFlags|log.Lshortfile)
 
@@ -24,10 +86,14 @@ func DebugLog(cfg Config, format string, args ...interface{}) {
 	}
 }
 
+// AckActionID identifies the Acknowledge button in Slack and Lark interaction callbacks
+const AckActionID = "commonlog_ack"
+
 // SendMethod defines supported sending methods
 const (
 	MethodWebClient = "webclient"
 	MethodWebhook   = "webhook"
+	MethodSDK       = "api" // Official Slack and Lark SDKs; import github.com/alvianhanif/gocommonlog/sdk
 )
 
 // ChannelResolver defines an interface for resolving channels based on alert levels
@@ -48,6 +114,61 @@ func (r *DefaultChannelResolver) ResolveChannel(level int) string {
 	return r.DefaultChannel
 }
 
+// AlertContext describes an alert being routed, for resolvers that consider more than severity
+type AlertContext struct {
+	Level         int
+	Message       string
+	Service       string
+	Environment   string
+	Fields        map[string]string // The logger's structured fields; read only
+	CorrelationID string
+	Provider      string
+}
+
+// ContextChannelResolver resolves a channel from the full alert context. Resolvers set as
+// Config.ChannelResolver that also implement it are called with ResolveChannelContext.
+type ContextChannelResolver interface {
+	ResolveChannelContext(alert AlertContext) string
+}
+
+// ContextResolverFunc adapts a routing function to both resolver interfaces, so it can be
+// set as Config.ChannelResolver
+type ContextResolverFunc func(alert AlertContext) string
+
+// ResolveChannelContext calls f(alert)
+func (f ContextResolverFunc) ResolveChannelContext(alert AlertContext) string {
+	return f(alert)
+}
+
+// ResolveChannel calls f with only the level set
+func (f ContextResolverFunc) ResolveChannel(level int) string {
+	return f(AlertContext{Level: level})
+}
+
+// levelResolver adapts a level-only ChannelResolver to ContextChannelResolver
+type levelResolver struct {
+	resolver ChannelResolver
+}
+
+func (r levelResolver) ResolveChannelContext(alert AlertContext) string {
+	return r.resolver.ResolveChannel(alert.Level)
+}
+
+// AsContextResolver returns resolver as a ContextChannelResolver, wrapping level-only
+// resolvers so they keep working unchanged
+func AsContextResolver(resolver ChannelResolver) ContextChannelResolver {
+	if contextResolver, ok := resolver.(ContextChannelResolver); ok {
+		return contextResolver
+	}
+	return levelResolver{resolver: resolver}
+}
+
+// OnCallResolver returns whoever is on call at a given time, as a channel or user the
+// provider can deliver to (a Slack user ID, a Lark chat, a phone number, ...)
+type OnCallResolver interface {
+	OnCall(at time.Time) (string, error)
+}
+
 // Config holds configuration for the library
 type Config struct {
 	Provider        string                    // "slack" or "lark"
@@ -61,23 +182,408 @@ type Config struct {
 	Environment     string                    // Environment (dev, staging, production)
 	ProviderConfig  map[string]interface{}    // Provider-specific configuration
 	Debug           bool                      // Enable debug logging for all processes
+	Fields          map[string]string         // Extra key/value fields attached to structured payloads
+	HTTPClient      HTTPDoer                  // Optional HTTP client used by providers, defaults to http.DefaultClient
+	Clock           Clock                     // Optional time source, defaults to the system clock
+	Cache           cache.Cache               // Optional token and lookup cache, defaults to the global cache
+	MessageID       string                    // Unique alert ID, set per send by the Logger
+	CorrelationID   string                    // Caller-provided correlation ID, set per send by the Logger
+	EventTime       time.Time                 // When the alerted event occurred, set per send by the Logger
+	AuditSink       AuditSink                 // Optional sink recording the metadata of every alert sent
+	AckID           string                    // Alert ID acknowledged by the rendered Acknowledge button, set per send by the Logger when ack_enabled
+	Escalation      map[string]EscalationPolicy // Escalation policies for ERROR alerts by service name, "*" for any service
+	Routes          []Route                   // Routing table; the first matching route picks the channel, provider and send method
+	Groups          map[string][]Route        // Broadcast groups by name for SendToGroup; members only receive the alerts matching their criteria
+	Digests         []Digest                  // Scheduled summary reports of the alerts sent
+	Locale          string                    // Locale of the strings rendered around alerts, e.g. "en_us" or "zh_cn"; empty keeps English
+	Translator      Translator                // Optional translations for Locale, defaults to DefaultTranslations
+	Actions         []Link                    // Buttons added to every ERROR alert, such as Runbook, Dashboard or Silence; URLs may use {alert_id}, {fingerprint} and other placeholders
+	Links           []Link                    // Named links rendered with the alert, set per send by the Logger
+	Snippets        []Snippet                 // Code snippets rendered with the alert, set per send by the Logger
+	Image           *Image                    // Image shown inline with the alert, set per send by the Logger
+	Sections        []string                  // Order and inclusion of the rendered sections, defaults to DefaultSections; set per send from SendOptions.Sections
+	LevelPolicies   map[int]LevelPolicy       // Per-level local log and send policy, see DefaultLevelPolicy
+	MirrorWriter    io.Writer                 // Optional writer receiving a single-line record of every alert and its outcome
+	Maintenance     []MaintenanceWindow       // Windows during which alerts are muted
+	FlightRecorder  *FlightRecorder           // Optional recorder of provider HTTP exchanges, created by NewLogger when flight_recorder is set
+	TokenStore      TokenStore                // Optional per-tenant tokens, consulted for alerts sent with SendOptions.Tenant
+	SecretResolvers map[string]SecretResolver // Resolvers of secret references by scheme, added to or replacing aws-sm, gcp-sm and vault
+	Tenant          string                    // Tenant whose token is used, set per send by the Logger
+	ObjectStore     ObjectStore               // Optional store for attachments too large to send inline, linked with a signed URL
+	PasteSink       PasteSink                 // Optional sink for attachments too large to send inline, shown as their first lines and a link
+	Source          string                    // Call site of the alert as "file:line function", set per send by the Logger when include_source is set
+	Fingerprinter   FingerprintFunc           // Optional grouping key of alerts, defaults to DefaultFingerprint
+	Fingerprint     string                    // Grouping key of the alert, set per send by the Logger
+	OnOverflow      OverflowFunc              // Optional callback for alerts the SendAsync queue had no room for
+	AfterSend       AfterSendFunc             // Optional callback after each alert passed to a provider, with the provider's response
+	OnEvent         EventFunc                 // Optional callback for lifecycle events, such as alerts queued, failed or suppressed
+	Response        *ProviderResponse         // Filled in by the provider during a send, set per send by the Logger
+}
+
+// SecretResolver fetches the value of a secret reference such as
+// "vault://secret/data/alerts#slack_token", for tokens kept in a secrets manager
+type SecretResolver interface {
+	ResolveSecret(ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver
+type SecretResolverFunc func(ref string) (string, error)
+
+// ResolveSecret calls f(ref)
+func (f SecretResolverFunc) ResolveSecret(ref string) (string, error) {
+	return f(ref)
+}
+
+// TokenStore holds provider tokens per tenant, so one Logger can send alerts on behalf of
+// many workspaces. A token replaces the token setting for the send: a bot token, tenant
+// access token or webhook URL, depending on the provider and send method.
+type TokenStore interface {
+	Get(tenant, provider string) (string, error)
+	Set(tenant, provider, token string) error
+}
+
+// FaultInjection simulates provider failures for testing how failed deliveries are
+// handled, set under the chaos setting. Each rate is the probability, from 0 to 1, of
+// failing a provider HTTP request that way.
+type FaultInjection struct {
+	Timeout         float64       // Fail with a simulated timeout error
+	TooManyRequests float64       // Respond 429 Too Many Requests
+	ServerError     float64       // Respond 503 Service Unavailable
+	ConnectionError float64       // Fail with a simulated connection refused error
+	Latency         time.Duration // Delay every request that is not failed, limited by its timeout setting
+}
+
+// MaintenanceWindow mutes alerts from Start until End
+type MaintenanceWindow struct {
+	Start  time.Time
+	End    time.Time
+	Reason string // Included in the summary of muted alerts
+}
+
+// SendOptions holds the optional parts of an alert for Logger.SendWithOptions
+type SendOptions struct {
+	Attachment    *Attachment       // Optional attachment
+	Trace         string            // Optional trace log
+	Channel       string            // Overrides the default channel/resolver
+	Provider      string            // Overrides the logger's provider
+	CorrelationID string            // Ties the alert to a request trace or audit log
+	Time          time.Time         // When the event occurred, defaults to the time of the send
+	ServiceName   string            // Overrides the logger's service name for this alert
+	Environment   string            // Overrides the logger's environment for this alert
+	Links         []Link            // Named links, rendered as buttons where the provider supports them
+	Snippets      []Snippet         // Code snippets, rendered as code blocks
+	Image         *Image            // Image shown inline, such as a chart screenshot
+	Condition     string            // Identifies the alerting condition for flap detection, see Logger.Resolve
+	Tenant        string            // Sends with this tenant's token from Config.TokenStore
+	Parallel      bool              // SendToChannels sends to every channel concurrently
+	Fields        map[string]string // Extra key/value fields added to Config.Fields for this alert
+	Sections      []string          // Overrides Config.Sections for this alert
+}
+
+// Link is a named link such as a dashboard or log query, rendered as a button in Slack
+// and Lark and as a markdown link elsewhere
+type Link struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Snippet is a code snippet rendered as a code block, highlighted for Language where the
+// provider supports it
+type Snippet struct {
+	Title    string `json:"title,omitempty"`    // Optional heading shown above the code
+	Language string `json:"language,omitempty"` // Optional language, e.g. "sql" or "go"
+	Code     string `json:"code"`
+}
+
+// Route is one entry of the routing table. Empty criteria match any alert; Channel,
+// Provider, SendMethod and Token default to the logger's when empty.
+type Route struct {
+	Name        string                   // Used in debug logs
+	Levels      []int                    // Alert levels matched
+	Service     string                   // Service name matched
+	Environment string                   // Environment matched
+	Fields      map[string]string        // Fields that must all be equal
+	Match       func(AlertContext) bool  // Optional custom predicate, checked after the other criteria
+
+	Channel        string                 // Destination channel, defaults to the channel resolver
+	Provider       string                 // Provider used for this route
+	SendMethod     string                 // Send method used for this route
+	Token          string                 // Token or webhook URL used for this route
+	ProviderConfig map[string]interface{} // Settings merged over the logger's ProviderConfig
+}
+
+// Matches reports whether the route applies to alert
+func (r Route) Matches(alert AlertContext) bool {
+	if len(r.Levels) > 0 {
+		found := false
+		for _, level := range r.Levels {
+			if level == alert.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Service != "" && r.Service != alert.Service {
+		return false
+	}
+	if r.Environment != "" && r.Environment != alert.Environment {
+		return false
+	}
+	for key, value := range r.Fields {
+		if alert.Fields[key] != value {
+			return false
+		}
+	}
+	return r.Match == nil || r.Match(alert)
+}
+
+// EscalationPolicy is a chain of steps re-sending an ERROR alert that has not been
+// acknowledged. Without ack_enabled alerts are never acknowledged, so every step runs.
+type EscalationPolicy struct {
+	Steps []EscalationStep
 }
 
+// EscalationStep re-sends the alert After the original was sent
+type EscalationStep struct {
+	After    time.Duration // Delay from the original alert
+	Channel  string        // Escalation channel, defaults to the original channel
+	Provider string        // Paging provider, defaults to the logger's provider
+}
+
+// Digest is a summary report of the alerts sent since the previous report, posted on a
+// cron schedule: counts by level and service, the most frequent alerts and the noisiest
+// hours. Without a Channel, each channel that received alerts gets a report of its own.
+type Digest struct {
+	Name       string // Report title, defaults to "Alert digest"
+	Schedule   string // Cron expression, such as "0 9 * * *" (daily at 9:00) or "@weekly"
+	TimeZone   string // IANA time zone of Schedule and the report's hours, defaults to the local time zone
+	Channel    string // Channel receiving a single report of every channel
+	Provider   string // Provider of Channel, defaults to the logger's provider
+	SendMethod string // Send method of Channel
+	Token      string // Token or webhook URL of Channel
+	Top        int    // Most frequent alerts listed, defaults to 5
+}
+
+// HTTPDoer is the subset of *http.Client used by providers, so tests can replace the network
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Clock supplies the current time, so tests of TTLs and timestamps can be deterministic
+type Clock interface {
+	Now() time.Time
+}
+
+// Audit outcomes recorded for each alert
+const (
+	AuditSent     = "sent"     // Delivered to the provider
+	AuditFailed   = "failed"   // The provider returned an error
+	AuditLogged   = "logged"   // Written to the local log only, by the INFO default or PolicyLocalOnly
+	AuditSampled  = "sampled"  // WARN alert dropped by sampling
+	AuditDropped  = "dropped"  // Discarded by PolicyDrop
+	AuditFlapping = "flapping" // Suppressed because its condition is flapping
+	AuditMuted    = "muted"    // Suppressed by a mute or maintenance window, recorded with audit_muted
+	AuditStorm    = "storm"    // Suppressed by the alert storm safety valve
+	AuditSkipped  = "skipped"  // Not delivered by a provider that ignores its level, see ErrSkipped
+)
+
+// AuditRecord is the metadata of one alert, written to the audit sink for compliance
+// review. It never contains the message text or attachment.
+type AuditRecord struct {
+	ID            string    `json:"id"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Time          time.Time `json:"time"`
+	Level         string    `json:"level"`
+	Service       string    `json:"service,omitempty"`
+	Environment   string    `json:"environment,omitempty"`
+	Channel       string    `json:"channel,omitempty"`
+	Provider      string    `json:"provider"`
+	Tenant        string    `json:"tenant,omitempty"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+	LatencyMs     int64     `json:"latency_ms"`
+}
+
+// Delivery is the outcome of an alert sent to one destination
+type Delivery struct {
+	ID       string            // Unique alert ID
+	Channel  string            // Channel the alert was sent to, empty when it was not sent
+	Provider string            // Provider the alert was sent with
+	Status   string            // One of the Audit outcomes, such as AuditSent, AuditFailed or AuditSampled
+	Attempts int               // Provider calls made, 0 when the alert was suppressed or not sent
+	Latency  time.Duration     // Time taken by the send
+	Err      error             // Why the send failed
+	Response *ProviderResponse // What the provider reported, nil when the alert was not passed to one
+}
+
+// SendResult describes every delivery made by a send, one per destination
+type SendResult struct {
+	Deliveries []Delivery
+}
+
+// Delivered reports whether every destination accepted the alert. Alerts suppressed by a
+// level policy, mute, flap detection or sampling are not delivered.
+func (r SendResult) Delivered() bool {
+	for _, delivery := range r.Deliveries {
+		if delivery.Status != AuditSent {
+			return false
+		}
+	}
+	return len(r.Deliveries) > 0
+}
+
+// IDs returns the alert ID of every delivery, in order
+func (r SendResult) IDs() []string {
+	ids := make([]string, len(r.Deliveries))
+	for i, delivery := range r.Deliveries {
+		ids[i] = delivery.ID
+	}
+	return ids
+}
+
+// AuditSink records alert metadata, separately from debug logging
+type AuditSink interface {
+	RecordAlert(record AuditRecord) error
+}
+
+// AuditFunc adapts a callback to an AuditSink
+type AuditFunc func(record AuditRecord) error
+
+// RecordAlert calls f(record)
+func (f AuditFunc) RecordAlert(record AuditRecord) error {
+	return f(record)
+}
+
+// Actions reported by OverflowEvent
+const (
+	OverflowDropped  = "dropped"  // A queued alert was dropped to make room
+	OverflowRejected = "rejected" // SendAsync failed with ErrQueueFull
+	OverflowSpilled  = "spilled"  // The alert was written to async_spill_file, to be sent later
+)
+
+// OverflowEvent describes an alert the SendAsync queue had no room for
+type OverflowEvent struct {
+	Action  string // One of the Overflow actions
+	Level   int    // Level of the alert
+	Message string // Message of the alert, before scrubbing
+	Queued  int    // Alerts in the queue
+}
+
+// OverflowFunc is called for each alert the SendAsync queue had no room for. It is called
+// by SendAsync and must not block.
+type OverflowFunc func(event OverflowEvent)
+
 // LarkTokenConfig holds Lark app credentials
 type LarkTokenConfig struct {
 	AppID     string
 	AppSecret string
 }
 
+// SlackRefreshConfig holds the Slack app credentials and refresh token used to obtain
+// access tokens when token rotation is enabled
+type SlackRefreshConfig struct {
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
 // Attachment represents a file attachment
 type Attachment struct {
-	URL      string // Public URL for external files
-	FileName string // Optional file name
-	Content  string // Inline content for text attachments
+	URL      string `json:"url,omitempty"`       // Public URL for external files
+	FileName string `json:"file_name,omitempty"` // Optional file name
+	Content  string `json:"content,omitempty"`   // Inline content for text attachments
+}
+
+// ObjectStore keeps attachment content too large for a provider to carry inline. Upload
+// stores data under key and returns a signed URL the alert links to instead.
+type ObjectStore interface {
+	Upload(ctx context.Context, key string, data []byte, contentType string) (string, error)
+}
+
+// PasteSink keeps the full content of attachments too large for a provider to carry
+// inline, like a pastebin. Paste stores content under key and returns a URL the alert
+// links to after a preview of the content's first lines.
+type PasteSink interface {
+	Paste(ctx context.Context, key, content string) (string, error)
+}
+
+// Image is an image shown inline with an alert, such as a chart screenshot. Data is
+// uploaded where the provider supports it; otherwise URL, which must be reachable by the
+// chat service, is shown.
+type Image struct {
+	URL      string // Public image URL
+	Data     []byte // Image bytes, uploaded by the Slack and Lark webclient methods
+	FileName string // Optional file name, defaults to image.png
+	AltText  string // Optional description, defaults to the file name
+}
+
+// Name returns the image's file name, defaulting to image.png
+func (i *Image) Name() string {
+	if i.FileName != "" {
+		return i.FileName
+	}
+	return "image.png"
+}
+
+// Alt returns the image's alt text, defaulting to its file name
+func (i *Image) Alt() string {
+	if i.AltText != "" {
+		return i.AltText
+	}
+	return i.Name()
 }
 
+// ErrChannelNotFound is wrapped by provider errors when the channel does not exist or the
+// bot has not joined it, a configuration error that retrying will not fix
+var ErrChannelNotFound = errors.New("channel not found")
+
+// ErrSkipped is returned, possibly wrapped, by providers that do not deliver an alert by
+// design, such as twilio and github for alerts below ERROR. The logger records the alert
+// as skipped rather than sent or failed, and the send does not return an error.
+var ErrSkipped = errors.New("alert skipped by the provider")
+
 // Provider interface for alert providers
 type Provider interface {
 	Send(level int, message string, attachment *Attachment, cfg Config) error
 	SendToChannel(level int, message string, attachment *Attachment, cfg Config, channel string) error
 }
+
+// HealthChecker is implemented by providers that can verify their credentials and
+// reachability without sending an alert
+type HealthChecker interface {
+	HealthCheck(ctx context.Context, cfg Config) error
+}
+
+// ChannelPrefetcher is implemented by providers that resolve channel names to IDs, so the
+// lookups can be cached before the first alert
+type ChannelPrefetcher interface {
+	PrefetchChannels(ctx context.Context, cfg Config, channels []string) error
+}
+
+// Warmer is implemented by providers that can get ready for their first alert ahead of
+// time, fetching tokens and opening connections
+type Warmer interface {
+	Warmup(ctx context.Context, cfg Config) error
+}
+
+// Reactor is implemented by providers that can add an emoji reaction to a message they
+// posted, identified by the channel and message ID recorded in its ProviderResponse
+type Reactor interface {
+	React(cfg Config, channel, messageID, emoji string) error
+}
+
+// Pinner is implemented by providers that can pin a message they posted to the top of
+// its channel, and remove the pin
+type Pinner interface {
+	Pin(cfg Config, channel, messageID string) error
+	Unpin(cfg Config, channel, messageID string) error
+}
+
+// Reactions marking the status of an alert, for Logger.React
+const (
+	ReactionInvestigating = "eyes"             // 👀 someone is looking into the alert
+	ReactionResolved      = "white_check_mark" // ✅ the problem was fixed
+)
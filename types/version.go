@@ -0,0 +1,47 @@
+package types
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// modulePath is looked up in the build info to find the library version
+const modulePath = "github.com/alvianhanif/gocommonlog"
+
+var (
+	versionOnce sync.Once
+	version     = "(devel)"
+)
+
+// Version returns the gocommonlog module version recorded in the binary's build info,
+// e.g. "v1.4.0", or "(devel)" when built from a local checkout
+func Version() string {
+	versionOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			version = info.Main.Version
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path != modulePath {
+				continue
+			}
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				version = dep.Version
+			}
+			return
+		}
+	})
+	return version
+}
+
+// UserAgent is sent with every provider HTTP request that does not set its own
+func UserAgent() string {
+	return "gocommonlog/" + Version()
+}
@@ -0,0 +1,58 @@
+package gocommonlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// validateProviders checks that the logger, its routes and its broadcast group members
+// name registered providers and known send methods, so typos such as "larkk" are reported
+// instead of silently sending with Slack. The logger's own provider is not checked when
+// injected is set, since WithProvider replaces it.
+func validateProviders(cfg types.Config, injected bool) error {
+	var problems []string
+	checkProvider := func(label string, name string) {
+		if name != "" && !providerRegistered(name) {
+			problems = append(problems, fmt.Sprintf("%sunknown provider %q (registered: %s)", label, name, strings.Join(registeredProviders(), ", ")))
+		}
+	}
+	checkMethod := func(label string, method string) {
+		if method != "" && method != types.MethodWebClient && method != types.MethodWebhook && method != types.MethodSDK {
+			problems = append(problems, fmt.Sprintf("%sunknown send method %q (expected %q, %q or %q)", label, method, types.MethodWebClient, types.MethodWebhook, types.MethodSDK))
+		}
+	}
+
+	if !injected {
+		switch name := cfg.ProviderConfig["provider"].(type) {
+		case string:
+			checkProvider("", name)
+		default:
+			problems = append(problems, fmt.Sprintf("provider must be a string, got %T", name))
+		}
+	}
+	checkMethod("", cfg.SendMethod)
+	for _, route := range cfg.Routes {
+		label := fmt.Sprintf("route '%s': ", route.Name)
+		checkProvider(label, route.Provider)
+		checkMethod(label, route.SendMethod)
+	}
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for i, member := range cfg.Groups[name] {
+			label := fmt.Sprintf("group '%s' member %d: ", name, i+1)
+			checkProvider(label, member.Provider)
+			checkMethod(label, member.SendMethod)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid provider configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
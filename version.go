@@ -0,0 +1,9 @@
+package gocommonlog
+
+import "github.com/alvianhanif/gocommonlog/types"
+
+// Version returns the library version, e.g. "v1.4.0", or "(devel)" when built from a
+// local checkout. It is also sent as the User-Agent and shown in the optional footer.
+func Version() string {
+	return types.Version()
+}
@@ -0,0 +1,240 @@
+// Package watcher tails log files, or reads a stream such as stdin, and sends an alert
+// through a Logger for every line matching a rule, so applications that only write log
+// files get chat alerts without code changes.
+package watcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+// defaultPollInterval is how often tailed files are checked for new lines
+const defaultPollInterval = time.Second
+
+// Rule matches log lines and describes the alert they raise
+type Rule struct {
+	Name       string // Identifies the rule in logs, defaults to the pattern
+	Pattern    string // Regular expression matched against each line
+	Level      string // "info", "warn" or "error", defaults to "error"
+	Message    string // Optional alert text, with $1 or ${name} expanding capture groups; defaults to the source and line
+	TraceLines int    // Following lines sent as the alert's trace, such as a stack trace
+}
+
+// compiledRule is a Rule with its pattern compiled and level parsed
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+	level   int
+}
+
+// Watcher sends alerts for log lines matching its rules. The first matching rule wins.
+type Watcher struct {
+	logger       *gocommonlog.Logger
+	rules        []compiledRule
+	PollInterval time.Duration // How often tailed files are checked for new lines, defaults to 1s
+	FromStart    bool          // Tail files from the beginning instead of only new lines
+}
+
+// New creates a watcher sending through logger, failing on an invalid pattern or level
+func New(logger *gocommonlog.Logger, rules ...Rule) (*Watcher, error) {
+	if len(rules) == 0 {
+		return nil, errors.New("at least one rule is required")
+	}
+	w := &Watcher{logger: logger, PollInterval: defaultPollInterval}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = rule.Pattern
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern: %w", i+1, err)
+		}
+		level := types.ERROR
+		if rule.Level != "" {
+			if level, err = types.ParseLevel(rule.Level); err != nil {
+				return nil, fmt.Errorf("rule %d: %w", i+1, err)
+			}
+		}
+		w.rules = append(w.rules, compiledRule{Rule: rule, pattern: pattern, level: level})
+	}
+	return w, nil
+}
+
+// Watch reads lines from r, such as os.Stdin, until it ends or ctx is done, naming source
+// in the alerts
+func (w *Watcher) Watch(ctx context.Context, r io.Reader, source string) error {
+	m := &matcher{watcher: w, source: source}
+	defer m.flush()
+	lines := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64<<10), 1<<20)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		errs <- scanner.Err()
+	}()
+	for {
+		// A trace being collected is complete once the source goes quiet
+		var idle <-chan time.Time
+		if m.pending != nil {
+			idle = time.After(w.PollInterval)
+		}
+		select {
+		case line := <-lines:
+			m.line(line)
+		case <-idle:
+			m.flush()
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Tail follows the file at path until ctx is done, like tail -F: it starts at the end
+// unless FromStart is set, and reopens the file from the beginning when it is rotated or
+// truncated
+func (w *Watcher) Tail(ctx context.Context, path string) error {
+	m := &matcher{watcher: w, source: path}
+	defer m.flush()
+	file, info, err := openTail(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+	offset := info.Size()
+	if w.FromStart {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	var partial string
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	for {
+		read := false
+		for {
+			chunk, err := reader.ReadString('\n')
+			offset += int64(len(chunk))
+			if err != nil {
+				partial += chunk
+				break
+			}
+			m.line(strings.TrimRight(partial+chunk, "\r\n"))
+			partial, read = "", true
+		}
+		if !read {
+			// A trace being collected is complete once the file goes quiet
+			m.flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			// Rotated away and not recreated yet
+			continue
+		}
+		if os.SameFile(info, current) && current.Size() >= offset {
+			continue
+		}
+		types.DebugLog(w.logger.Config(), "watcher: %s was rotated or truncated, reopening", path)
+		reopened, reopenedInfo, err := openTail(path)
+		if err != nil {
+			log.Printf("[WARN] watcher: failed to reopen %s: %v", path, err)
+			continue
+		}
+		file.Close()
+		file, info, offset, partial = reopened, reopenedInfo, 0, ""
+		reader.Reset(file)
+	}
+}
+
+// openTail opens path for tailing and returns its file info
+func openTail(path string) (*os.File, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// matcher applies the rules to the lines of one source, collecting the trace lines that
+// follow a match before sending its alert
+type matcher struct {
+	watcher *Watcher
+	source  string
+	pending *compiledRule
+	message string
+	trace   []string
+}
+
+// line matches one line against the rules, or adds it to the trace being collected
+func (m *matcher) line(line string) {
+	if m.pending != nil {
+		m.trace = append(m.trace, line)
+		if len(m.trace) >= m.pending.TraceLines {
+			m.flush()
+		}
+		return
+	}
+	for i := range m.watcher.rules {
+		rule := &m.watcher.rules[i]
+		match := rule.pattern.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		message := m.source + ": " + line
+		if rule.Message != "" {
+			message = string(rule.pattern.ExpandString(nil, rule.Message, line, match))
+		}
+		m.pending, m.message = rule, message
+		if rule.TraceLines <= 0 {
+			m.flush()
+		}
+		return
+	}
+}
+
+// flush sends the pending alert, if any
+func (m *matcher) flush() {
+	if m.pending == nil {
+		return
+	}
+	rule, message, trace := m.pending, m.message, strings.Join(m.trace, "\n")
+	m.pending, m.message, m.trace = nil, "", nil
+	types.DebugLog(m.watcher.logger.Config(), "watcher: rule %s matched in %s", rule.Name, m.source)
+	if _, err := m.watcher.logger.SendWithOptions(rule.level, message, types.SendOptions{Trace: trace}); err != nil {
+		log.Printf("[ERROR] watcher: failed to send alert for rule %s in %s: %v", rule.Name, m.source, err)
+	}
+}
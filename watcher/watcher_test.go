@@ -0,0 +1,138 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gocommonlog "github.com/alvianhanif/gocommonlog"
+	"github.com/alvianhanif/gocommonlog/types"
+)
+
+type alert struct {
+	level   int
+	message string
+	trace   string
+}
+
+type recordingProvider struct {
+	mu     sync.Mutex
+	alerts []alert
+}
+
+func (p *recordingProvider) Send(level int, message string, attachment *types.Attachment, cfg types.Config) error {
+	return p.SendToChannel(level, message, attachment, cfg, cfg.Channel)
+}
+
+func (p *recordingProvider) SendToChannel(level int, message string, attachment *types.Attachment, cfg types.Config, channel string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	trace := ""
+	if attachment != nil {
+		trace = attachment.Content
+	}
+	p.alerts = append(p.alerts, alert{level, message, trace})
+	return nil
+}
+
+func (p *recordingProvider) sent() []alert {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]alert(nil), p.alerts...)
+}
+
+func newWatcher(t *testing.T, rules ...Rule) (*Watcher, *recordingProvider) {
+	recorder := &recordingProvider{}
+	logger := gocommonlog.NewLogger(types.Config{Channel: "#ops"}, gocommonlog.WithProvider(recorder))
+	w, err := New(logger, rules...)
+	if err != nil {
+		t.Fatalf("Expected valid rules, got %v", err)
+	}
+	w.PollInterval = 10 * time.Millisecond
+	return w, recorder
+}
+
+func TestWatchMatchesRules(t *testing.T) {
+	w, recorder := newWatcher(t,
+		Rule{Name: "panic", Pattern: `^panic: (.*)`, Message: "Panic: $1", TraceLines: 2},
+		Rule{Pattern: `(?i)\bwarn(ing)?\b`, Level: "warn"},
+	)
+	input := strings.Join([]string{
+		"INFO started",
+		"WARNING disk 91% full",
+		"panic: nil map",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"INFO restarted",
+	}, "\n")
+	if err := w.Watch(context.Background(), strings.NewReader(input), "app.log"); err != nil {
+		t.Fatalf("Expected the stream to be read, got %v", err)
+	}
+
+	sent := recorder.sent()
+	if len(sent) != 2 {
+		t.Fatalf("Expected two alerts, got %+v", sent)
+	}
+	if sent[0].level != types.WARN || sent[0].message != "app.log: WARNING disk 91% full" {
+		t.Errorf("Unexpected warning alert: %+v", sent[0])
+	}
+	if sent[1].level != types.ERROR || sent[1].message != "Panic: nil map" || sent[1].trace != "goroutine 1 [running]:\nmain.main()" {
+		t.Errorf("Unexpected panic alert: %+v", sent[1])
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	if _, err := New(nil, Rule{Pattern: "("}); err == nil || !strings.Contains(err.Error(), "rule 1: invalid pattern") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+	if _, err := New(nil, Rule{Pattern: "x", Level: "fatal"}); err == nil {
+		t.Error("Expected an invalid level to be rejected")
+	}
+}
+
+func TestTailFollowsRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte("ERROR before the watcher started\n"), 0600)
+	w, recorder := newWatcher(t, Rule{Pattern: `ERROR`})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Tail(ctx, path) }()
+	waitFor := func(count int) []alert {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if sent := recorder.sent(); len(sent) >= count {
+				return sent
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return recorder.sent()
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	appendLine := func(line string) {
+		file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		file.WriteString(line)
+		file.Close()
+	}
+	appendLine("ERROR payment failed\n")
+	if sent := waitFor(1); len(sent) != 1 || sent[0].message != path+": ERROR payment failed" {
+		t.Fatalf("Expected only the new line to alert, got %+v", sent)
+	}
+
+	// Rotate: move the file away and start a new one
+	os.Rename(path, path+".1")
+	os.WriteFile(path, []byte("ERROR after rotation\n"), 0600)
+	if sent := waitFor(2); len(sent) != 2 || sent[1].message != path+": ERROR after rotation" {
+		t.Errorf("Expected the rotated file to be followed, got %+v", sent)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected Tail to stop with the context, got %v", err)
+	}
+}